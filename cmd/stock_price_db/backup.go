@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/db"
+)
+
+// バックアップファイル名に付ける日時のフォーマット（UTC、ファイル名の順に並べると古い順になる）
+const backupTimeFormat = "20060102T150405.000Z"
+
+// バックアップファイルの拡張子
+const backupFileExtension = ".db"
+
+// runBackup は backup コマンドを実行し、データベースを日時付きのバックアップファイルに書き出して
+// 整合性を検査します。保持数を超えた古いバックアップは削除します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - args: backup に続くコマンドライン引数
+//   - stdout: 結果の出力先
+//
+// 戻り値:
+//   - エラー（引数が不正な場合、バックアップや整合性の検査に失敗した場合）
+func runBackup(ctx context.Context, args []string, stdout io.Writer) error {
+	// コマンドライン引数を定義
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	dbPath := flags.String("db", "sqlite_data/stock_price.db", "Path to the SQLite database file")
+	backupDir := flags.String("dir", "sqlite_data/backups", "Directory to write the backup files to")
+	keep := flags.Int("keep", 7, "Number of backups of the database to keep in the directory (0 to keep all)")
+	timeout := addTimeoutFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+	if *keep < 0 {
+		return fmt.Errorf("-keep must not be negative: %d", *keep)
+	}
+	ctx, cancel := withTimeout(ctx, *timeout)
+	defer cancel()
+
+	// 日時付きのバックアップファイルに書き出して整合性を検査
+	if err := os.MkdirAll(*backupDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	backupPath := filepath.Join(*backupDir, backupFileName(*dbPath, time.Now()))
+	if err := db.BackupDatabase(ctx, *dbPath, backupPath); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(stdout, "Backed up %s to %s (integrity check ok)\n", *dbPath, backupPath); err != nil {
+		return err
+	}
+
+	// 保持数を超えた古いバックアップを削除
+	removed, err := pruneBackups(*backupDir, *dbPath, *keep)
+	for _, path := range removed {
+		if _, err := fmt.Fprintf(stdout, "Removed old backup %s\n", path); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// runRestore は restore コマンドを実行し、バックアップファイルの内容でデータベースを置き換えて
+// 整合性を検査します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - args: restore に続くコマンドライン引数（フラグに続けてバックアップファイルのパスを指定する）
+//   - stdout: 結果の出力先
+//
+// 戻り値:
+//   - エラー（引数が不正な場合、バックアップが壊れている場合や復元に失敗した場合）
+func runRestore(ctx context.Context, args []string, stdout io.Writer) error {
+	// コマンドライン引数を定義
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	dbPath := flags.String("db", "sqlite_data/stock_price.db", "Path to the SQLite database file")
+	timeout := addTimeoutFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("restore requires exactly one backup file")
+	}
+	backupPath := flags.Arg(0)
+	ctx, cancel := withTimeout(ctx, *timeout)
+	defer cancel()
+
+	if err := db.RestoreDatabase(ctx, backupPath, *dbPath); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(stdout, "Restored %s from %s (integrity check ok)\n", *dbPath, backupPath)
+	return err
+}
+
+// backupFileName はデータベースファイル名と日時からバックアップファイル名を作成します。
+// 例: stock_price.db → stock_price-20250204T093000.000Z.db
+//
+// 引数:
+//   - dbPath: バックアップするSQLiteデータベースファイルのパス
+//   - now: バックアップの日時
+//
+// 戻り値:
+//   - バックアップファイル名
+func backupFileName(dbPath string, now time.Time) string {
+	return backupFilePrefix(dbPath) + now.UTC().Format(backupTimeFormat) + backupFileExtension
+}
+
+// backupFilePrefix はデータベースファイルのバックアップファイル名に共通する接頭辞を返します。
+//
+// 引数:
+//   - dbPath: SQLiteデータベースファイルのパス
+//
+// 戻り値:
+//   - バックアップファイル名の接頭辞
+func backupFilePrefix(dbPath string) string {
+	base := filepath.Base(dbPath)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "-"
+}
+
+// pruneBackups はディレクトリ内のデータベースのバックアップを新しい順に keep 個残し、
+// それより古いバックアップを削除します。
+// ファイル名の日時を解釈できないファイルはバックアップとみなさず削除しません。
+//
+// 引数:
+//   - backupDir: バックアップファイルのディレクトリ
+//   - dbPath: バックアップしたSQLiteデータベースファイルのパス
+//   - keep: 残すバックアップの数（0の場合は全て残す）
+//
+// 戻り値:
+//   - 削除したバックアップファイルのパスの配列（古い順）
+//   - エラー（ディレクトリを読めない場合や削除に失敗した場合）
+func pruneBackups(backupDir string, dbPath string, keep int) ([]string, error) {
+	if keep == 0 {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	// 日時付きのファイル名のみを対象にする（ファイル名の順は日時の順と一致する）
+	prefix := backupFilePrefix(dbPath)
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, backupFileExtension) {
+			continue
+		}
+		timestamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), backupFileExtension)
+		if _, err := time.Parse(backupTimeFormat, timestamp); err != nil {
+			continue
+		}
+		backups = append(backups, name)
+	}
+	slices.Sort(backups)
+
+	var removed []string
+	for _, name := range backups[:max(len(backups)-keep, 0)] {
+		path := filepath.Join(backupDir, name)
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+func TestRunBackupAndRestore(t *testing.T) {
+	// Arrange - 古いバックアップが2つあるディレクトリと1行のデータベースを用意
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "stock_price.db")
+	backupDir := filepath.Join(dir, "backups")
+	ctx := context.Background()
+	repository := testutil.NewSQLiteRepository(t, dbPath)
+	testPrices := []models.DailyStockPrice{
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+	}
+	if err := repository.InitializeDailyStockPriceTable(ctx, testPrices); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	if err := os.MkdirAll(backupDir, 0o755); err != nil {
+		t.Fatalf("Failed to create backup directory: %v", err)
+	}
+	for _, name := range []string{
+		backupFileName(dbPath, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)),
+		backupFileName(dbPath, time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC)),
+		"stock_price-notes.db",
+	} {
+		if err := os.WriteFile(filepath.Join(backupDir, name), nil, 0o644); err != nil {
+			t.Fatalf("Failed to write old backup: %v", err)
+		}
+	}
+
+	// Act - 最新の2つを残してバックアップし、データを変更してから復元する
+	var backup, restore bytes.Buffer
+	backupErr := runBackup(ctx, []string{"-db", dbPath, "-dir", backupDir, "-keep", "2"}, &backup)
+	entries, _ := os.ReadDir(backupDir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if err := repository.InitializeDailyStockPriceTable(ctx, nil); err != nil {
+		t.Fatalf("Failed to clear table: %v", err)
+	}
+	newestBackup := filepath.Join(backupDir, names[1])
+	restoreErr := runRestore(ctx, []string{"-db", dbPath, newestBackup}, &restore)
+	emptyErr := runRestore(ctx, []string{"-db", dbPath, filepath.Join(backupDir, "stock_price-notes.db")}, &bytes.Buffer{})
+
+	// Assert
+	if backupErr != nil || restoreErr != nil {
+		t.Fatalf("Expected no error, but got: %v, %v", backupErr, restoreErr)
+	}
+	if !strings.Contains(backup.String(), "integrity check ok") || !strings.Contains(backup.String(), "Removed old backup "+filepath.Join(backupDir, "stock_price-20250201T000000.000Z.db")) {
+		t.Errorf("Unexpected backup output:\n%s", backup.String())
+	}
+	if len(names) != 3 || !slices.Contains(names, "stock_price-notes.db") || !slices.Contains(names, "stock_price-20250202T000000.000Z.db") {
+		t.Errorf("Expected the two newest backups and the unrelated file to be kept, but got %v", names)
+	}
+	if !strings.Contains(restore.String(), "Restored "+dbPath+" from "+newestBackup) {
+		t.Errorf("Unexpected restore output:\n%s", restore.String())
+	}
+	if emptyErr == nil {
+		t.Error("Expected an error when restoring from an empty file, but got nil")
+	}
+	prices, _ := repository.GetDailyStockPrices(ctx)
+	if len(prices) != 1 || prices[0].StockPrice.Price != models.NewPrice(2873, 0) {
+		t.Errorf("Expected the backed up prices to be restored, but got %+v", prices)
+	}
+}
+
+func TestRunBackup_InvalidArguments(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"-db", filepath.Join(dir, "missing.db"), "-dir", dir},
+		{"-db", filepath.Join(dir, "missing.db"), "-keep", "-1"},
+	} {
+		// Act
+		err := runBackup(context.Background(), args, &bytes.Buffer{})
+
+		// Assert
+		if err == nil {
+			t.Errorf("Expected an error for %v, but got nil", args)
+		}
+	}
+	if err := runRestore(context.Background(), []string{"-db", filepath.Join(dir, "stock_price.db")}, &bytes.Buffer{}); err == nil {
+		t.Error("Expected an error when no backup file is given, but got nil")
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/db"
+)
+
+// runDoctor は doctor コマンドを実行し、データベースの検査で見つかった問題を重大度の高い順に書き込みます。
+// 修復されずに残った重大度 error の問題がある場合はエラーを返します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - args: doctor に続くコマンドライン引数
+//   - stdout: 結果の出力先
+//
+// 戻り値:
+//   - エラー（引数が不正な場合、検査に失敗した場合や重大度 error の問題が残っている場合）
+func runDoctor(ctx context.Context, args []string, stdout io.Writer) error {
+	// コマンドライン引数を定義
+	defaults := db.DefaultDoctorOptions()
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	dbPath := flags.String("db", "sqlite_data/stock_price.db", "Path to the SQLite database file")
+	holidaysPath := flags.String("holidays", "", "File of exchange holidays besides Dec 31 to Jan 3 (one YYYY-MM-DD per line, # starts a comment)")
+	maxGap := flags.Int("max-gap", defaults.MaxGapTradingDays, "Report gaps of more than this many missing trading days within a stock (0 to skip the check)")
+	minDuplicate := flags.Int("min-duplicate", defaults.MinDuplicateSeriesLength, "Report stocks whose prices all match an earlier stock when they have at least this many days (0 to skip the check)")
+	fix := flags.Bool("fix", false, "Repair the problems that can be fixed safely (register missing stocks, convert dates stored in other formats and move rows with unreadable dates to daily_stock_price_quarantine)")
+	timeout := addTimeoutFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+	ctx, cancel := withTimeout(ctx, *timeout)
+	defer cancel()
+
+	// 休場日を読み込む
+	var holidays []time.Time
+	if *holidaysPath != "" {
+		var err error
+		holidays, err = readHolidays(*holidaysPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	findings, err := db.DiagnoseDatabase(ctx, *dbPath, db.DoctorOptions{
+		Calendar:                 models.NewTradingCalendar(holidays),
+		MaxGapTradingDays:        *maxGap,
+		MinDuplicateSeriesLength: *minDuplicate,
+		Fix:                      *fix,
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeDatabaseFindings(stdout, findings); err != nil {
+		return err
+	}
+
+	remainingErrors := 0
+	for _, finding := range findings {
+		if finding.Severity == models.SeverityError && !finding.Fixed {
+			remainingErrors++
+		}
+	}
+	if remainingErrors > 0 {
+		return fmt.Errorf("%s has %d unresolved errors", *dbPath, remainingErrors)
+	}
+	return nil
+}
+
+// writeDatabaseFindings はデータベースの検査で見つかった問題を表形式で書き込み、重大度ごとの件数を書き込みます。
+//
+// 引数:
+//   - w: 書き込み先
+//   - findings: 重大度の高い順に並んだ問題の配列
+//
+// 戻り値:
+//   - エラー（書き込みに失敗した場合）
+func writeDatabaseFindings(w io.Writer, findings []models.DatabaseFinding) error {
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(w, "No problems found")
+		return err
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Severity\tCheck\tStock\tDate\tStatus\tMessage")
+	counts := make(map[models.FindingSeverity]int)
+	fixable, fixed := 0, 0
+	for _, finding := range findings {
+		status := ""
+		switch {
+		case finding.Fixed:
+			status = "fixed"
+			fixed++
+		case finding.Fixable:
+			status = "fixable"
+			fixable++
+		}
+		counts[finding.Severity]++
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			finding.Severity, finding.Check, finding.StockID, finding.PriceDate, status, finding.Message)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	summary := fmt.Sprintf("%d errors, %d warnings, %d info", counts[models.SeverityError], counts[models.SeverityWarning], counts[models.SeverityInfo])
+	if fixed > 0 {
+		summary += fmt.Sprintf("; fixed %d", fixed)
+	}
+	if fixable > 0 {
+		summary += fmt.Sprintf("; %d can be fixed with -fix", fixable)
+	}
+	_, err := fmt.Fprintln(w, summary)
+	return err
+}
+
+// readHolidays は休場日のファイルを読み込みます。
+// 1行に1つのYYYY-MM-DD形式の日付を書き、日付に続く空白以降と # で始まる行は無視します。
+//
+// 引数:
+//   - path: 休場日のファイルのパス
+//
+// 戻り値:
+//   - 休場日の配列
+//   - エラー（ファイルを読めない場合や日付を解釈できない場合）
+func readHolidays(path string) ([]time.Time, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open holidays file: %w", err)
+	}
+	defer file.Close()
+
+	var holidays []time.Time
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		holiday, err := time.Parse(time.DateOnly, fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday %q at line %d of %s", fields[0], lineNumber, path)
+		}
+		holidays = append(holidays, holiday)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read holidays file: %w", err)
+	}
+	return holidays, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+func TestRunDoctor(t *testing.T) {
+	// Arrange - 休場日のファイルに記載した日と土曜日の株価を持つデータベース
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "stock_price.db")
+	holidaysPath := filepath.Join(dir, "holidays.txt")
+	ctx := context.Background()
+	repository := testutil.NewSQLiteRepository(t, dbPath)
+	testPrices := []models.DailyStockPrice{
+		{PriceDate: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+		{PriceDate: time.Date(2025, 2, 11, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2880, 0)}},
+		{PriceDate: time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903, 0)}},
+	}
+	if err := repository.InitializeDailyStockPriceTable(ctx, testPrices); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	if err := os.WriteFile(holidaysPath, []byte("# 2025年の祝日\n2025-02-11 建国記念の日\n\n2025-02-24\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write holidays: %v", err)
+	}
+
+	// Act
+	var output bytes.Buffer
+	err := runDoctor(ctx, []string{"-db", dbPath, "-holidays", holidaysPath}, &output)
+
+	// Assert - 警告のみの場合はエラーにしない
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	for _, expected := range []string{
+		"warning   holiday  7203   2025-02-11",
+		"warning   weekend  7203   2025-02-15",
+		"0 errors, 2 warnings, 0 info\n",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, output.String())
+		}
+	}
+}
+
+func TestRunDoctor_InvalidArguments(t *testing.T) {
+	dir := t.TempDir()
+	invalidHolidays := filepath.Join(dir, "holidays.txt")
+	if err := os.WriteFile(invalidHolidays, []byte("2025/2/11\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write holidays: %v", err)
+	}
+	for _, args := range [][]string{
+		{"-db", filepath.Join(dir, "missing.db")},
+		{"-db", filepath.Join(dir, "missing.db"), "-max-gap", "-1"},
+		{"-holidays", invalidHolidays},
+		{"extra"},
+	} {
+		// Act
+		err := runDoctor(context.Background(), args, &bytes.Buffer{})
+
+		// Assert
+		if err == nil {
+			t.Errorf("Expected an error for %v, but got nil", args)
+		}
+	}
+}
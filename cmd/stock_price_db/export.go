@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/db"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/exporter"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/file"
+)
+
+// runExport は export コマンドを実行し、条件に一致する日次株価情報を (銘柄コード, 日付) の順に書き出します。
+// -o を指定した場合は書き出した件数を stdout に書き込みます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - args: export に続くコマンドライン引数
+//   - stdout: 結果の出力先（-o を指定しない場合は書き出すデータの出力先）
+//
+// 戻り値:
+//   - エラー（引数が不正な場合、データベース操作に失敗した場合や書き出しに失敗した場合）
+func runExport(ctx context.Context, args []string, stdout io.Writer) error {
+	// コマンドライン引数を定義
+	defaults := exporter.DefaultOptions()
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	dbPath := flags.String("db", "sqlite_data/stock_price.db", "Path to the SQLite database file")
+	format := flags.String("format", string(defaults.Format), "Output format: tsv (read by stock_price_importer -tsv), csv, ndjson or sql (CREATE TABLE and INSERT statements)")
+	outputPath := flags.String("o", "", "File to write instead of stdout")
+	stockIDs := flags.String("stock", "", "Comma-separated stock IDs to export (default all stocks)")
+	fromDate := flags.String("from", "", "First date (YYYY-MM-DD, inclusive) to export")
+	toDate := flags.String("to", "", "Last date (YYYY-MM-DD, inclusive) to export")
+	header := flags.Bool("header", defaults.Header, "Write a stock_id, date, price header line (tsv and csv)")
+	dateFormat := flags.String("date-format", defaults.DateLayout, "Date layout for tsv and csv: a Go time layout the importer can read back, or slash, iso, compact or kanji")
+	tableName := flags.String("table", defaults.SQLTableName, "Table created and filled by the sql format")
+	timeout := addTimeoutFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+
+	options, err := exportOptions(*format, *header, *dateFormat, *tableName)
+	if err != nil {
+		return err
+	}
+	filter, err := exportFilter(*stockIDs, *fromDate, *toDate)
+	if err != nil {
+		return err
+	}
+	// 存在しないパスを指定した場合に空のデータベースを作成しないようにする
+	if _, err := os.Stat(*dbPath); err != nil {
+		return fmt.Errorf("database file not found: %s", *dbPath)
+	}
+	ctx, cancel := withTimeout(ctx, *timeout)
+	defer cancel()
+
+	// データベースを開く
+	repository, err := db.NewSQLiteStockPriceRepository(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer repository.Close()
+	dailyPrices := exporter.FilterDailyStockPrices(repository.IterateDailyStockPrices(ctx, models.DailyStockPriceCursor{}), filter)
+
+	if *outputPath == "" {
+		_, err := exporter.WriteDailyStockPrices(stdout, dailyPrices, options)
+		return err
+	}
+	count, err := writeExportFile(*outputPath, dailyPrices, options)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "Exported %d daily stock prices to %s\n", count, *outputPath)
+	return err
+}
+
+// exportOptions はコマンドライン引数から出力設定を作成します。
+//
+// 引数:
+//   - format: 出力形式の名前
+//   - header: ヘッダー行を出力するかどうか
+//   - dateFormat: 日付のレイアウトまたはその別名
+//   - tableName: SQLダンプで作成するテーブル名
+//
+// 戻り値:
+//   - 出力設定
+//   - エラー（出力形式や日付のレイアウトが不正な場合）
+func exportOptions(format string, header bool, dateFormat string, tableName string) (exporter.Options, error) {
+	options := exporter.DefaultOptions()
+	var err error
+	if options.Format, err = exporter.ParseFormat(format); err != nil {
+		return exporter.Options{}, err
+	}
+	layouts, err := file.ParseDateLayouts(dateFormat)
+	if err != nil {
+		return exporter.Options{}, err
+	}
+	if len(layouts) != 1 || layouts[0] == file.WarekiLayout {
+		return exporter.Options{}, fmt.Errorf("-date-format must be a single Gregorian date layout: %s", dateFormat)
+	}
+	options.Header = header
+	options.DateLayout = layouts[0]
+	options.SQLTableName = tableName
+	return options, nil
+}
+
+// exportFilter はコマンドライン引数から出力する日次株価情報の条件を作成します。
+//
+// 引数:
+//   - stockIDs: カンマ区切りの銘柄コード（空の場合は全ての銘柄）
+//   - fromDate: 日付の始点（YYYY-MM-DD形式、空の場合は制限しない）
+//   - toDate: 日付の終点（YYYY-MM-DD形式、空の場合は制限しない）
+//
+// 戻り値:
+//   - 出力する日次株価情報の条件
+//   - エラー（日付が不正な場合や始点が終点より後の場合）
+func exportFilter(stockIDs string, fromDate string, toDate string) (exporter.Filter, error) {
+	var filter exporter.Filter
+	for _, stockID := range strings.Split(stockIDs, ",") {
+		if stockID = strings.TrimSpace(stockID); stockID != "" {
+			filter.StockIDs = append(filter.StockIDs, stockID)
+		}
+	}
+	var err error
+	if fromDate != "" {
+		if filter.From, err = time.Parse(time.DateOnly, fromDate); err != nil {
+			return exporter.Filter{}, fmt.Errorf("invalid -from date %q: %w", fromDate, err)
+		}
+	}
+	if toDate != "" {
+		if filter.To, err = time.Parse(time.DateOnly, toDate); err != nil {
+			return exporter.Filter{}, fmt.Errorf("invalid -to date %q: %w", toDate, err)
+		}
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.From.After(filter.To) {
+		return exporter.Filter{}, fmt.Errorf("-from %s is after -to %s", fromDate, toDate)
+	}
+	return filter, nil
+}
+
+// writeExportFile は日次株価情報をファイルに書き出します。
+// 書き出しに失敗した場合は途中まで書き込んだファイルを削除します。
+//
+// 引数:
+//   - path: 書き出すファイルのパス
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - options: 出力設定
+//
+// 戻り値:
+//   - 書き出した日次株価情報の件数
+//   - エラー（ファイルの作成や書き出しに失敗した場合）
+func writeExportFile(path string, dailyPrices iter.Seq2[models.DailyStockPrice, error], options exporter.Options) (int, error) {
+	output, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create output file: %w", err)
+	}
+	count, err := exporter.WriteDailyStockPrices(output, dailyPrices, options)
+	if closeErr := output.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close output file: %w", closeErr)
+	}
+	if err != nil {
+		return 0, errors.Join(err, os.Remove(path))
+	}
+	return count, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+func TestRunExport(t *testing.T) {
+	// Arrange - 2銘柄の株価を持つデータベース
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "stock_price.db")
+	ctx := context.Background()
+	repository := testutil.NewSQLiteRepository(t, dbPath)
+	testPrices := []models.DailyStockPrice{
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+		{PriceDate: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(29635, 1)}},
+		{PriceDate: time.Date(2025, 2, 6, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903, 0)}},
+		{PriceDate: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8000, 0)}},
+	}
+	if err := repository.InitializeDailyStockPriceTable(ctx, testPrices); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "default tsv",
+			args:     []string{"-db", dbPath},
+			expected: "7203\t2025-02-04\t2873\n7203\t2025-02-05\t2963.5\n7203\t2025-02-06\t2903\n9984\t2025-02-05\t8000\n",
+		},
+		{
+			name:     "filtered csv with header and slash dates",
+			args:     []string{"-db", dbPath, "-format", "csv", "-header", "-date-format", "slash", "-stock", "7203", "-from", "2025-02-05"},
+			expected: "stock_id,date,price\n7203,2025/2/5,2963.5\n7203,2025/2/6,2903\n",
+		},
+		{
+			name:     "ndjson up to a date",
+			args:     []string{"-db", dbPath, "-format", "ndjson", "-stock", "9984, 7203", "-to", "2025-02-04"},
+			expected: "{\"stock_id\":\"7203\",\"date\":\"2025-02-04\",\"price\":2873}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			var output bytes.Buffer
+			err := runExport(ctx, tt.args, &output)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if output.String() != tt.expected {
+				t.Errorf("Expected %q, but got %q", tt.expected, output.String())
+			}
+		})
+	}
+}
+
+func TestRunExport_OutputFile(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "stock_price.db")
+	outputPath := filepath.Join(dir, "export.sql")
+	ctx := context.Background()
+	repository := testutil.NewSQLiteRepository(t, dbPath)
+	testPrices := []models.DailyStockPrice{
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+	}
+	if err := repository.InitializeDailyStockPriceTable(ctx, testPrices); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	// Act
+	var output bytes.Buffer
+	err := runExport(ctx, []string{"-db", dbPath, "-format", "sql", "-table", "prices", "-o", outputPath}, &output)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if expected := "Exported 1 daily stock prices to " + outputPath + "\n"; output.String() != expected {
+		t.Errorf("Expected %q, but got %q", expected, output.String())
+	}
+	dump, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if !bytes.Contains(dump, []byte("INSERT INTO \"prices\" (stock_id, price_date, price) VALUES ('7203', '2025-02-04', 2873);\n")) {
+		t.Errorf("Expected an INSERT statement in the dump, but got:\n%s", dump)
+	}
+}
+
+func TestRunExport_InvalidArguments(t *testing.T) {
+	dir := t.TempDir()
+	missingDB := filepath.Join(dir, "missing.db")
+	for _, args := range [][]string{
+		{"-db", missingDB},
+		{"-db", missingDB, "-format", "xlsx"},
+		{"-db", missingDB, "-date-format", "wareki"},
+		{"-db", missingDB, "-date-format", "iso,slash"},
+		{"-db", missingDB, "-from", "2025/2/4"},
+		{"-db", missingDB, "-from", "2025-02-05", "-to", "2025-02-04"},
+		{"extra"},
+	} {
+		// Act
+		err := runExport(context.Background(), args, &bytes.Buffer{})
+
+		// Assert
+		if err == nil {
+			t.Errorf("Expected an error for %v, but got nil", args)
+		}
+	}
+	if _, err := os.Stat(missingDB); err == nil {
+		t.Error("Expected the missing database not to be created")
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/db"
+)
+
+// SHA-256 の表示桁数
+const shortSHA256Length = 12
+
+// runImportRuns は import-runs コマンドを実行し、取り込み履歴を表形式で書き込みます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - args: import-runs に続くコマンドライン引数
+//   - stdout: 結果の出力先
+//
+// 戻り値:
+//   - エラー（引数が不正な場合やデータベース操作に失敗した場合）
+func runImportRuns(ctx context.Context, args []string, stdout io.Writer) error {
+	// コマンドライン引数を定義
+	flags := flag.NewFlagSet("import-runs", flag.ContinueOnError)
+	dbPath := flags.String("db", "sqlite_data/stock_price.db", "Path to the SQLite database file")
+	timeout := addTimeoutFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, *timeout)
+	defer cancel()
+
+	// データベースを開く
+	repository, err := db.NewSQLiteStockPriceRepository(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer repository.Close()
+
+	runs, err := repository.GetImportRuns(ctx)
+	if err != nil {
+		return err
+	}
+	return writeImportRuns(stdout, runs)
+}
+
+// writeImportRuns は取り込み履歴を表形式で書き込みます。
+//
+// 引数:
+//   - w: 書き込み先
+//   - runs: 取り込み履歴の配列
+//
+// 戻り値:
+//   - エラー（書き込みに失敗した場合）
+func writeImportRuns(w io.Writer, runs []models.ImportRun) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Run\tStatus\tStarted at\tFinished at\tInserted\tUpdated\tUnchanged\tReplaced\tSHA-256\tSource\tOptions")
+	for _, run := range runs {
+		finishedAt := ""
+		if !run.FinishedAt.IsZero() {
+			finishedAt = run.FinishedAt.Local().Format(appliedAtFormat)
+		}
+		fmt.Fprintf(table, "%d\t%s\t%s\t%s\t%d\t%d\t%d\t%d\t%s\t%s\t%s\n",
+			run.ID, run.Status, run.StartedAt.Local().Format(appliedAtFormat), finishedAt,
+			run.Result.Inserted, run.Result.Updated, run.Result.Unchanged, run.Replaced,
+			run.SourceSHA256[:min(len(run.SourceSHA256), shortSHA256Length)], run.SourcePath, run.Options)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%d import runs\n", len(runs))
+	return err
+}
+
+// runUndoImport は undo-import コマンドを実行し、取り込みで書き込まれた行を削除して
+// 取り込みが上書きまたは削除した行を復元します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - args: undo-import に続くコマンドライン引数（フラグに続けて取り込みIDを指定する）
+//   - stdout: 結果の出力先
+//
+// 戻り値:
+//   - エラー（引数が不正な場合、取り消せない場合やデータベース操作に失敗した場合）
+func runUndoImport(ctx context.Context, args []string, stdout io.Writer) error {
+	// コマンドライン引数を定義
+	flags := flag.NewFlagSet("undo-import", flag.ContinueOnError)
+	dbPath := flags.String("db", "sqlite_data/stock_price.db", "Path to the SQLite database file")
+	timeout := addTimeoutFlag(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("undo-import requires exactly one import run ID")
+	}
+	runID, err := strconv.ParseInt(flags.Arg(0), 10, 64)
+	if err != nil || runID <= 0 {
+		return fmt.Errorf("invalid import run ID %q", flags.Arg(0))
+	}
+	ctx, cancel := withTimeout(ctx, *timeout)
+	defer cancel()
+
+	// データベースを開く
+	repository, err := db.NewSQLiteStockPriceRepository(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer repository.Close()
+
+	result, err := repository.UndoImportRun(ctx, runID)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(stdout, "Undid import run %d: removed %d rows, restored %d rows\n", runID, result.Removed, result.Restored)
+	return err
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+func TestRunUndoImport(t *testing.T) {
+	// Arrange - 1回分の取り込みを記録したデータベースを作成
+	dbPath := filepath.Join(t.TempDir(), "stock_price.db")
+	ctx := context.Background()
+	repository := testutil.NewSQLiteRepository(t, dbPath)
+	runID, err := repository.StartImportRun(ctx, models.ImportRun{SourcePath: "prices.tsv", Options: "-mode=upsert"})
+	if err != nil {
+		t.Fatalf("Failed to start import run: %v", err)
+	}
+	dailyPrices := func(yield func(models.DailyStockPrice, error) bool) {
+		yield(models.DailyStockPrice{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}}, nil)
+	}
+	result, err := repository.UpsertDailyStockPricesFromSeq(ctx, runID, dailyPrices, 10, models.ConflictPolicyOverwrite)
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if err := repository.FinishImportRun(ctx, models.ImportRun{ID: runID, Status: models.ImportRunCompleted, Result: result}); err != nil {
+		t.Fatalf("Failed to finish import run: %v", err)
+	}
+	repository.Close()
+
+	// Act
+	var undo, list bytes.Buffer
+	undoErr := runUndoImport(ctx, []string{"-db", dbPath, "1"}, &undo)
+	listErr := runImportRuns(ctx, []string{"-db", dbPath}, &list)
+
+	// Assert
+	if undoErr != nil || listErr != nil {
+		t.Fatalf("Expected no error, but got: %v, %v", undoErr, listErr)
+	}
+	if !strings.Contains(undo.String(), "Undid import run 1: removed 1 rows, restored 0 rows") {
+		t.Errorf("Unexpected output:\n%s", undo.String())
+	}
+	if !strings.Contains(list.String(), "undone") || !strings.Contains(list.String(), "prices.tsv") {
+		t.Errorf("Expected the undone run to be listed, but got:\n%s", list.String())
+	}
+}
+
+func TestRunUndoImport_InvalidRunID(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "stock_price.db")
+	for _, args := range [][]string{{"-db", dbPath}, {"-db", dbPath, "abc"}, {"-db", dbPath, "1"}} {
+		// Act
+		err := runUndoImport(context.Background(), args, &bytes.Buffer{})
+
+		// Assert
+		if err == nil {
+			t.Errorf("Expected an error for %v, but got nil", args)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// サブコマンドを実行する関数（ctx は Ctrl-C（SIGINT）でキャンセルされる）
+type subcommand func(ctx context.Context, args []string, stdout io.Writer) error
+
+// サブコマンドの一覧
+var subcommands = map[string]subcommand{
+	"migrate":     runMigrate,
+	"import-runs": runImportRuns,
+	"undo-import": runUndoImport,
+	"backup":      runBackup,
+	"restore":     runRestore,
+	"doctor":      runDoctor,
+	"export":      runExport,
+}
+
+// 使い方
+const usage = `Usage: stock_price_db <command> [arguments]
+
+Commands:
+  migrate status [-db path] [-timeout d]   Show applied and pending schema migrations
+  migrate up [-db path] [-timeout d]       Apply pending schema migrations
+  import-runs [-db path] [-timeout d]      List recorded importer runs
+  undo-import [-db path] [-timeout d] ID   Remove the rows written by an importer run and restore the rows it replaced or removed
+  backup [-db path] [-dir dir] [-keep n] [-timeout d]
+                                           Write a timestamped, integrity-checked backup and remove the oldest beyond -keep
+  restore [-db path] [-timeout d] FILE     Replace the database contents with an integrity-checked backup
+  doctor [-db path] [-holidays file] [-max-gap n] [-min-duplicate n] [-fix] [-timeout d]
+                                           Check integrity, foreign keys and daily stock price rules, ranked by severity
+  export [-db path] [-format f] [-o file] [-stock ids] [-from date] [-to date] [-header] [-date-format layout] [-table name] [-timeout d]
+                                           Write daily stock prices as tsv (read back by stock_price_importer), csv, ndjson or a sql dump
+`
+
+func main() {
+	// ログの設定
+	log.SetPrefix("StockPriceDB: ")
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	run, ok := subcommands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	// Ctrl-C（SIGINT）で実行中の操作を中断できるようにする
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := run(ctx, os.Args[2:], os.Stdout)
+	stop()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// addTimeoutFlag はサブコマンドのフラグに -timeout を追加します。
+//
+// 引数:
+//   - flags: サブコマンドのフラグ
+//
+// 戻り値:
+//   - -timeout の値を格納する変数
+func addTimeoutFlag(flags *flag.FlagSet) *time.Duration {
+	return flags.Duration("timeout", 0, "Abort the command when it runs longer than this duration, e.g. 30s or 10m (0 for no limit)")
+}
+
+// withTimeout は timeout が正の場合にその時間が経過するとキャンセルされるコンテキストを作成します。
+//
+// 引数:
+//   - ctx: 親のコンテキスト
+//   - timeout: 実行時間の上限（0以下の場合は上限なし）
+//
+// 戻り値:
+//   - コンテキスト
+//   - コンテキストを解放する関数（使い終わったら呼び出す）
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
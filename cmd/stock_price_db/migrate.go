@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/db"
+)
+
+// migrate のサブコマンド
+const (
+	migrateStatus = "status"
+	migrateUp     = "up"
+)
+
+// 適用日時の表示フォーマット
+const appliedAtFormat = time.DateTime
+
+// runMigrate は migrate コマンドを実行します。
+//   - migrate status: マイグレーションの適用状況を表示する
+//   - migrate up: 未適用のマイグレーションを適用する
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - args: migrate に続くコマンドライン引数
+//   - stdout: 結果の出力先
+//
+// 戻り値:
+//   - エラー（引数が不正な場合やデータベース操作に失敗した場合）
+func runMigrate(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("migrate requires %s or %s", migrateStatus, migrateUp)
+	}
+	action := args[0]
+
+	// コマンドライン引数を定義
+	flags := flag.NewFlagSet("migrate "+action, flag.ContinueOnError)
+	dbPath := flags.String("db", "sqlite_data/stock_price.db", "Path to the SQLite database file")
+	timeout := addTimeoutFlag(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	ctx, cancel := withTimeout(ctx, *timeout)
+	defer cancel()
+
+	// データベースを開く
+	migrator, err := db.NewMigrator(ctx, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch action {
+	case migrateStatus:
+		return printMigrationStatus(ctx, stdout, migrator)
+	case migrateUp:
+		appliedMigrations, err := migrator.Up(ctx)
+		for _, migration := range appliedMigrations {
+			fmt.Fprintf(stdout, "Applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(appliedMigrations) == 0 {
+			fmt.Fprintf(stdout, "Database is up to date (version %d)\n", migrator.LatestVersion())
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q (expected %s or %s)", action, migrateStatus, migrateUp)
+	}
+}
+
+// printMigrationStatus はマイグレーションの適用状況を表形式で書き込みます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - w: 書き込み先
+//   - migrator: 適用状況を取得する Migrator
+//
+// 戻り値:
+//   - エラー（データベース操作や書き込みに失敗した場合）
+func printMigrationStatus(ctx context.Context, w io.Writer, migrator *db.Migrator) error {
+	statuses, version, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Version\tName\tStatus\tApplied at")
+	for _, status := range statuses {
+		state := "pending"
+		switch {
+		case !status.Known:
+			state = "unknown"
+		case status.Applied:
+			state = "applied"
+		}
+		appliedAt := ""
+		if !status.AppliedAt.IsZero() {
+			appliedAt = status.AppliedAt.Local().Format(appliedAtFormat)
+		}
+		fmt.Fprintf(table, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "Database schema version %d (this binary supports %d)\n", version, migrator.LatestVersion())
+	return err
+}
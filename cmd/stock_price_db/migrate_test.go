@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunMigrate(t *testing.T) {
+	// Arrange
+	dbPath := filepath.Join(t.TempDir(), "stock_price.db")
+
+	// Act - 未適用の状態を表示
+	var before bytes.Buffer
+	err := runMigrate(context.Background(), []string{migrateStatus, "-db", dbPath}, &before)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !strings.Contains(before.String(), "create_daily_stock_price") || !strings.Contains(before.String(), "pending") {
+		t.Errorf("Expected pending migrations, but got:\n%s", before.String())
+	}
+
+	// Act - マイグレーションを適用
+	var up bytes.Buffer
+	err = runMigrate(context.Background(), []string{migrateUp, "-db", dbPath}, &up)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !strings.Contains(up.String(), "Applied 0001_create_daily_stock_price") {
+		t.Errorf("Expected applied migrations, but got:\n%s", up.String())
+	}
+
+	// Act - 適用後の状態を表示
+	var after bytes.Buffer
+	err = runMigrate(context.Background(), []string{migrateStatus, "-db", dbPath}, &after)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if strings.Contains(after.String(), "pending") {
+		t.Errorf("Expected no pending migrations, but got:\n%s", after.String())
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		t.Errorf("Expected database file to exist: %v", err)
+	}
+}
+
+func TestRunMigrate_UnknownAction(t *testing.T) {
+	// Act
+	err := runMigrate(context.Background(), []string{"down", "-db", filepath.Join(t.TempDir(), "stock_price.db")}, &bytes.Buffer{})
+
+	// Assert
+	if err == nil {
+		t.Error("Expected an error for an unknown action, but got nil")
+	}
+}
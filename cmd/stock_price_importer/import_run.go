@@ -0,0 +1,106 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"hash"
+	"iter"
+	"slices"
+	"strings"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/file"
+)
+
+// 取り込みの設定に記録しないフラグ（入力ファイルとデータベースは取り込み履歴に別に記録する）
+var unrecordedImportFlags = []string{"tsv", "db", "mode", "on-conflict"}
+
+// 入力ファイルの内容の SHA-256 を、取り込みで読み込むデータから計算する構造体
+// ファイルは並列に読み込まれるため、ファイルごとにハッシュを計算する
+type inputDigest struct {
+	// 入力ファイルごとの内容を書き込むハッシュ（入力ファイルのパスの一覧と同じ順）
+	hashes []hash.Hash
+	// 入力ファイルごとに最後まで読み込んだかどうか（各要素は1つのファイルを読み込むゴルーチンのみが書き込む）
+	completed []bool
+}
+
+// newInputDigest は入力ファイルの内容の SHA-256 の計算を開始します。
+// inputSource.Digest に設定すると、取り込みで読み込んだデータをそのままハッシュに書き込むため、
+// 入力ファイルを別に読み直しません。取り込みが終わってから Sum を呼び出してください。
+//
+// 引数:
+//   - fileCount: 入力ファイルの数
+//
+// 戻り値:
+//   - SHA-256 を計算する構造体
+func newInputDigest(fileCount int) *inputDigest {
+	digest := &inputDigest{hashes: make([]hash.Hash, fileCount), completed: make([]bool, fileCount)}
+	for i := range digest.hashes {
+		digest.hashes[i] = sha256.New()
+	}
+	return digest
+}
+
+// trackMembers は入力ファイルのメンバーを返すイテレータを、最後までエラーなく返し終えた場合に
+// そのファイルを読み込み済みとして記録するイテレータに変換します。
+//
+// 引数:
+//   - fileIndex: 入力ファイルの番号
+//   - members: メンバーの読み込みに使用したデータをハッシュに書き込むイテレータ
+//
+// 戻り値:
+//   - メンバーとエラーの組を返すイテレータ
+func (d *inputDigest) trackMembers(fileIndex int, members iter.Seq2[file.InputMember, error]) iter.Seq2[file.InputMember, error] {
+	return func(yield func(file.InputMember, error) bool) {
+		for member, err := range members {
+			if !yield(member, err) || err != nil {
+				return
+			}
+		}
+		d.completed[fileIndex] = true
+	}
+}
+
+// Sum は入力ファイルの内容の SHA-256 を16進数の文字列で返します。
+// 入力ファイルが1つの場合はその内容の SHA-256、複数の場合はファイルごとの SHA-256 を
+// 順に連結した値の SHA-256 です。
+// 読み込みが失敗・中断されて最後まで読み込んでいないファイルがある場合は空文字列を返します。
+//
+// 戻り値:
+//   - SHA-256（16進数の文字列、全てのファイルを読み込んでいない場合は空文字列）
+func (d *inputDigest) Sum() string {
+	if slices.Contains(d.completed, false) {
+		return ""
+	}
+	if len(d.hashes) == 1 {
+		return hex.EncodeToString(d.hashes[0].Sum(nil))
+	}
+	combined := sha256.New()
+	for _, fileHash := range d.hashes {
+		combined.Write(fileHash.Sum(nil))
+	}
+	return hex.EncodeToString(combined.Sum(nil))
+}
+
+// importRunOptions は取り込み履歴に記録する取り込みの設定を返します。
+// 取り込みモードと衝突時の扱いに続けて、コマンドラインで指定したフラグを名前順に並べます。
+//
+// 引数:
+//   - importMode: 取り込みモード
+//   - conflictPolicy: append, upsert の場合に同じ銘柄コード・日付の行が存在する場合の扱い
+//
+// 戻り値:
+//   - 取り込みの設定（例: "-mode=upsert -on-conflict=overwrite -encoding=shift_jis"）
+func importRunOptions(importMode string, conflictPolicy models.ConflictPolicy) string {
+	options := []string{"-mode=" + importMode}
+	if importMode != importModeReplace {
+		options = append(options, "-on-conflict="+string(conflictPolicy))
+	}
+	flag.Visit(func(f *flag.Flag) {
+		if !slices.Contains(unrecordedImportFlags, f.Name) {
+			options = append(options, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+	return strings.Join(options, " ")
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/file"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+func TestInputDigest(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	var paths []string
+	combined := sha256.New()
+	for i := range 3 {
+		path := filepath.Join(dir, fmt.Sprintf("%d.tsv", i))
+		content := fmt.Sprintf("%d\t2025/2/4\t100\n%d\t2025/2/5\t101\n", 1000+i, 1000+i)
+		testutil.WriteFile(t, path, content)
+		paths = append(paths, path)
+		fileSum := sha256.Sum256([]byte(content))
+		combined.Write(fileSum[:])
+	}
+	newSource := func(digest *inputDigest) inputSource {
+		return inputSource{Format: formatAuto, TextEncoding: file.EncodingUTF8, TSVOptions: file.DefaultTSVOptions(), Digest: digest}
+	}
+
+	// Act - 全ての行を読み込む場合と、読み込めないファイルがある場合
+	completeDigest := newInputDigest(len(paths))
+	for _, err := range streamDailyStockPricesConcurrently(newSource(completeDigest), paths, 2, make([]fileSummary, len(paths))) {
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+	failedPaths := append(paths[:2:2], filepath.Join(dir, "missing.tsv"))
+	failedDigest := newInputDigest(len(failedPaths))
+	for range streamDailyStockPricesConcurrently(newSource(failedDigest), failedPaths, 2, make([]fileSummary, len(failedPaths))) {
+	}
+
+	// Assert - 最後まで読み込んだ場合のみファイルごとのハッシュから計算した値を返す
+	if sum := completeDigest.Sum(); sum != hex.EncodeToString(combined.Sum(nil)) {
+		t.Errorf("Expected the digest of the file digests, but got %q", sum)
+	}
+	if sum := failedDigest.Sum(); sum != "" {
+		t.Errorf("Expected no digest when a file is not read to the end, but got %q", sum)
+	}
+}
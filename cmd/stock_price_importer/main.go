@@ -1,20 +1,97 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"io"
+	"iter"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
 	"github.com/toriwasa/sqlite-playground/internal/infrastructures/db"
 	"github.com/toriwasa/sqlite-playground/internal/infrastructures/file"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/memory"
 )
 
+// 入力ファイル形式
+const (
+	formatAuto = "auto"
+	formatTSV  = "tsv"
+	formatCSV  = "csv"
+)
+
+// 取り込みモード
+const (
+	// テーブルを作り直して入力ファイルの内容で置き換える
+	importModeReplace = "replace"
+	// 既存の行を残して追加する（衝突した場合はデフォルトでエラー）
+	importModeAppend = "append"
+	// 既存の行を残して追加する（衝突した場合はデフォルトで上書き）
+	importModeUpsert = "upsert"
+)
+
+// 1トランザクションで書き込む行数のデフォルト値
+const defaultChunkSize = 10000
+
+// 検証結果の出力形式
+const reportFormatJSON = "json"
+
+// JSONファイルとして扱う拡張子
+const jsonExtension = ".json"
+
+// 検証結果の要約に表示する問題の最大件数
+const maxSummaryIssues = 20
+
+// CSVファイルとして扱う拡張子
+const csvExtension = ".csv"
+
+// 標準入力から読み込むことを示す入力ファイルのパス
+const stdinPath = "-"
+
+// ログやエラーに表示する標準入力の名前
+const stdinName = "stdin"
+
+// タブ区切りを指定するための区切り文字の別名
+var tabDelimiterAliases = []string{"\\t", "tab"}
+
 func main() {
 	// コマンドライン引数を定義
-	tsvPath := flag.String("tsv", "internal/data/sample_daily_stock_price.tsv", "Path to the TSV file")
-	dbPath := flag.String("db", "sqlite_data/stock_price.db", "Path to the SQLite database file")
+	tsvPath := flag.String("tsv", "internal/data/sample_daily_stock_price.tsv", "Path to the TSV or CSV file, a directory, a glob pattern or - for stdin (gzip, zip and tar archives are extracted)")
+	dbPath := flag.String("db", "sqlite_data/stock_price.db", "Path to the SQLite database file, or :memory: for a dry run that keeps nothing on disk")
+	stocksPath := flag.String("stocks", "", "Path to a stock master TSV file (stock ID, name, market, sector, currency) imported before the prices; only the stock master is imported unless -tsv is also given")
+	format := flag.String("format", formatAuto, "Input file format: auto, tsv or csv (auto selects by the extension of each file)")
+	delimiter := flag.String("delimiter", ",", "Field delimiter for CSV input (use \"\\t\" or \"tab\" for tabs)")
+	stockIDColumn := flag.Int("stock-id-col", 0, "Column number (0-based) of the stock ID in CSV input")
+	dateColumn := flag.Int("date-col", 1, "Column number (0-based) of the date in CSV input")
+	priceColumn := flag.Int("price-col", 2, "Column number (0-based) of the price in CSV input")
+	openColumn := flag.Int("open-col", file.NoColumn, "Column number (0-based) of the open price in CSV input (-1 if absent, used with -ohlcv)")
+	highColumn := flag.Int("high-col", file.NoColumn, "Column number (0-based) of the high price in CSV input (-1 if absent, used with -ohlcv)")
+	lowColumn := flag.Int("low-col", file.NoColumn, "Column number (0-based) of the low price in CSV input (-1 if absent, used with -ohlcv)")
+	volumeColumn := flag.Int("volume-col", file.NoColumn, "Column number (0-based) of the volume in CSV input (-1 if absent, used with -ohlcv)")
+	skipHeader := flag.Bool("header", false, "Skip the first line of CSV input as a header even if its column names are not recognized")
+	detectHeader := flag.Bool("detect-header", true, "Detect a header row by column names and map the columns by name (overrides the -*-col flags)")
+	columnAliasSpec := flag.String("column-aliases", "", "Additional header names, e.g. \"stock_id=ticker;price=adj_close,調整後終値\"")
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of files parsed concurrently (rows are still written in file order)")
+	mode := flag.String("mode", importModeReplace, "Import mode: replace (recreate the table), append or upsert (keep existing rows)")
+	onConflict := flag.String("on-conflict", "", "How append and upsert treat existing rows with the same stock ID and date: overwrite, keep or fail (default fail for append, overwrite for upsert)")
+	chunkSize := flag.Int("chunk-size", defaultChunkSize, "Number of rows written per transaction; replace mode stages the rows and swaps them in with one final transaction")
+	lenient := flag.Bool("lenient", false, "Skip invalid rows and report them instead of aborting the import")
+	maxRejects := flag.Int("max-rejects", file.UnlimitedRejectedRows, "Fail the import when more than this many rows are rejected in lenient mode (-1 for unlimited)")
+	reportPath := flag.String("report", "", "Write the rejected rows of a lenient import to this file")
+	reportFormat := flag.String("report-format", formatAuto, "Format of the -report file: auto, json or tsv (auto selects by file extension)")
+	dateFormats := flag.String("date-formats", "", "Comma-separated date layouts tried in order (aliases: slash, iso, compact, kanji, wareki; empty for all of them)")
+	encodingName := flag.String("encoding", string(file.EncodingAuto), "Input text encoding: auto, utf-8, shift_jis (cp932), euc-jp, utf-16le or utf-16be")
+	ohlcv := flag.Bool("ohlcv", false, "Import open, high, low, close and volume (close-only files are also accepted)")
+	timeout := flag.Duration("timeout", 0, "Abort the import when it runs longer than this duration, e.g. 30s or 10m (0 for no limit)")
+	busyTimeout := flag.Duration("busy-timeout", db.DefaultSQLiteOptions().BusyTimeout, "How long to wait for another process to release a lock on the SQLite database before retrying")
 	verbose := flag.Bool("v", false, "Enable verbose output")
 	flag.Parse()
 
@@ -26,41 +103,746 @@ func main() {
 		log.SetFlags(0)
 	}
 
-	// TSVファイルの存在確認
-	if _, err := os.Stat(*tsvPath); os.IsNotExist(err) {
-		log.Fatalf("TSV file not found: %s", *tsvPath)
+	// Ctrl-C（SIGINT）と -timeout で取り込みを中断できるようにする
+	ctx, cancel := newCommandContext(*timeout)
+	defer cancel()
+
+	// 銘柄マスタのみを取り込むかどうかを判定
+	importPrices := *stocksPath == "" || isFlagSet("tsv")
+
+	// 入力ファイルの一覧を取得
+	var inputPaths []string
+	var err error
+	if importPrices {
+		inputPaths, err = resolveInputPaths(*tsvPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *workers <= 0 {
+		log.Fatalf("Invalid workers: %d", *workers)
+	}
+	if *maxRejects < file.UnlimitedRejectedRows {
+		log.Fatalf("Invalid -max-rejects: %d", *maxRejects)
+	}
+
+	// 入力ファイル形式を確認（auto の場合はファイルごとに拡張子から決定）
+	if _, err := resolveInputFormat(*tsvPath, *format); err != nil {
+		log.Fatalf("Invalid format: %v", err)
+	}
+
+	// 文字エンコーディングを決定
+	textEncoding, err := file.ParseTextEncoding(*encodingName)
+	if err != nil {
+		log.Fatalf("Invalid encoding: %v", err)
+	}
+
+	// 日付の解析方法を決定
+	dateParser := file.DefaultDateParser()
+	if *dateFormats != "" {
+		dateLayouts, err := file.ParseDateLayouts(*dateFormats)
+		if err != nil {
+			log.Fatalf("Invalid date formats: %v", err)
+		}
+		if dateParser, err = file.NewDateParser(dateLayouts); err != nil {
+			log.Fatalf("Invalid date formats: %v", err)
+		}
+	}
+
+	// ヘッダー行の判定に使用する列名の別名を決定
+	var columnAliases file.ColumnAliases
+	if *detectHeader {
+		columnAliases, err = file.DefaultColumnAliases().Extend(*columnAliasSpec)
+		if err != nil {
+			log.Fatalf("Invalid column aliases: %v", err)
+		}
+	}
+
+	// CSVファイルの読み込み設定を作成
+	csvDelimiter, err := parseDelimiter(*delimiter)
+	if err != nil {
+		log.Fatalf("Invalid delimiter: %v", err)
+	}
+	csvOptions := file.CSVOptions{
+		Delimiter:     csvDelimiter,
+		StockIDColumn: *stockIDColumn,
+		DateColumn:    *dateColumn,
+		PriceColumn:   *priceColumn,
+		OpenColumn:    *openColumn,
+		HighColumn:    *highColumn,
+		LowColumn:     *lowColumn,
+		VolumeColumn:  *volumeColumn,
+		SkipHeader:    *skipHeader,
+		DateParser:    dateParser,
+		ColumnAliases: columnAliases,
+	}
+	source := inputSource{
+		Stdin:        os.Stdin,
+		Format:       strings.ToLower(*format),
+		TextEncoding: textEncoding,
+		TSVOptions:   file.TSVOptions{DateParser: dateParser, ColumnAliases: columnAliases},
+		CSVOptions:   csvOptions,
 	}
 
-	// TSVファイルを読み込む
-	log.Printf("Reading TSV file: %s", *tsvPath)
-	dailyPrices, err := file.ReadDailyStockPriceFromTSV(*tsvPath)
+	// 取り込みモードを確認
+	importMode, conflictPolicy, err := resolveImportMode(*mode, *onConflict)
 	if err != nil {
-		log.Fatalf("Failed to read TSV file: %v", err)
+		log.Fatalf("Invalid import mode: %v", err)
+	}
+	if *ohlcv && importMode != importModeReplace {
+		log.Fatalf("-mode %s is not supported together with -ohlcv", importMode)
+	}
+
+	// 寛容モードの設定を確認
+	if *ohlcv && *lenient {
+		log.Fatalf("-lenient is not supported together with -ohlcv")
+	}
+	resolvedReportFormat, err := resolveReportFormat(*reportPath, *reportFormat)
+	if err != nil {
+		log.Fatalf("Invalid report format: %v", err)
+	}
+	var validationReport *file.ValidationReport
+	if *lenient {
+		validationReport = &file.ValidationReport{}
 	}
-	log.Printf("Read %d daily stock prices", len(dailyPrices))
 
 	// データベースディレクトリを作成
 	dbDir := filepath.Dir(*dbPath)
-	if dbDir != "." {
+	if dbDir != "." && *dbPath != memory.DatabasePath {
 		if err := os.MkdirAll(dbDir, 0755); err != nil {
 			log.Fatalf("Failed to create database directory: %v", err)
 		}
 	}
 
-	// SQLiteデータベースを初期化
-	log.Printf("Initializing SQLite database: %s", *dbPath)
-	if err := db.InitializeDailyStockPriceTable(*dbPath, dailyPrices); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+	// データベースを開く
+	repository, err := openRepository(ctx, *dbPath, *busyTimeout)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer repository.Close()
+	if *dbPath == memory.DatabasePath {
+		log.Printf("Using in-memory database: imported data is discarded on exit")
+	} else {
+		log.Printf("Opened SQLite database: %s", *dbPath)
+	}
+
+	// 銘柄マスタを取り込む
+	if *stocksPath != "" {
+		stockResult, err := importStocks(ctx, repository, *stocksPath)
+		if err != nil {
+			exitOnInterruptedImport(ctx, repository, "the stock master import was rolled back")
+			log.Fatal(err)
+		}
+		fmt.Printf("Imported stock master from %s: inserted %d, updated %d, unchanged %d\n", *stocksPath, stockResult.Inserted, stockResult.Updated, stockResult.Unchanged)
+		if !importPrices {
+			return
+		}
+	}
+
+	// 取り込みの開始を取り込み履歴に記録
+	digest := newInputDigest(len(inputPaths))
+	source.Digest = digest
+	importRun := models.ImportRun{
+		SourcePath: *tsvPath,
+		Options:    importRunOptions(importMode, conflictPolicy),
+	}
+	importRun.ID, err = repository.StartImportRun(ctx, importRun)
+	if err != nil {
+		log.Fatalf("Failed to record import run: %v", err)
+	}
+
+	// 入力ファイルを読み込んでSQLiteデータベースを初期化
+	var importResult models.UpsertResult
+	if *ohlcv {
+		importResult.Inserted, err = importDailyStockBars(ctx, source, inputPaths, repository, importRun.ID)
+	} else {
+		summaries := make([]fileSummary, len(inputPaths))
+		importResult, err = importDailyStockPrices(ctx, source, inputPaths, *workers, repository, importRun.ID, importMode, conflictPolicy, *chunkSize, validationReport, *maxRejects, summaries)
+		printFileSummaries(os.Stdout, summaries)
+	}
+
+	// 取り込みの終了を記録（中断された場合もコミットされた行を取り消せるよう記録する）
+	// 失敗・中断した場合は入力を最後まで取り込んでいないため SHA-256 を記録しない
+	importRun.Result = importResult
+	importRun.Status = models.ImportRunCompleted
+	if err != nil {
+		importRun.Status = models.ImportRunFailed
+	} else {
+		importRun.SourceSHA256 = digest.Sum()
+	}
+	if finishErr := repository.FinishImportRun(context.WithoutCancel(ctx), importRun); finishErr != nil {
+		log.Printf("Failed to record import run %d: %v", importRun.ID, finishErr)
+	}
+
+	// 寛容モードの場合はスキップした行を報告
+	if validationReport != nil {
+		printValidationSummary(*validationReport)
+		if *reportPath != "" {
+			if reportErr := writeValidationReport(*reportPath, resolvedReportFormat, *validationReport); reportErr != nil {
+				log.Fatalf("Failed to write report: %v", reportErr)
+			}
+			log.Printf("Wrote validation report: %s", *reportPath)
+		}
+	}
+	if err != nil {
+		exitOnInterruptedImport(ctx, repository, interruptedImportOutcome(importMode, importResult.Total(), *dbPath, importRun.ID))
+		log.Fatal(err)
 	}
 	log.Printf("Database initialized successfully")
+	if importMode != importModeReplace {
+		fmt.Printf("Inserted %d, updated %d, unchanged %d\n", importResult.Inserted, importResult.Updated, importResult.Unchanged)
+	}
 
-	// 確認のためにデータベースからデータを取得
-	retrievedPrices, err := db.GetDailyStockPrices(*dbPath)
+	// 確認のためにデータベースの件数を取得
+	retrievedCount, err := repository.CountDailyStockPrices(ctx)
 	if err != nil {
 		log.Fatalf("Failed to retrieve data from database: %v", err)
 	}
-	log.Printf("Retrieved %d daily stock prices from database", len(retrievedPrices))
+	log.Printf("Retrieved %d daily stock prices from database", retrievedCount)
 
 	// 成功メッセージを表示
-	fmt.Printf("Successfully imported %d daily stock prices into %s\n", len(dailyPrices), *dbPath)
+	fmt.Printf("Successfully imported %d daily stock prices into %s\n", importResult.Total(), *dbPath)
+	fmt.Printf("Recorded as import run %d (undo with: stock_price_db undo-import -db %s %d)\n", importRun.ID, *dbPath, importRun.ID)
+}
+
+// 入力ファイルの読み込み設定を示す構造体
+type inputSource struct {
+	// 入力ファイルのパスが stdinPath の場合に読み込む Reader
+	Stdin io.Reader
+	// 入力ファイル形式（auto, tsv または csv）
+	Format string
+	// 入力ファイルの文字エンコーディング
+	TextEncoding file.TextEncoding
+	// TSVファイルの読み込み設定
+	TSVOptions file.TSVOptions
+	// CSVファイルの読み込み設定
+	CSVOptions file.CSVOptions
+	// 読み込んだ入力ファイルの内容の SHA-256 を計算する構造体（nil の場合は計算しない）
+	Digest *inputDigest
+}
+
+// openMember は入力ファイル（またはアーカイブ内のファイル）を UTF-8 に変換して読み込む Reader を返します。
+// 入力ファイル形式が auto の場合はファイル名の拡張子から決定します。
+//
+// 引数:
+//   - member: 入力ファイル（またはアーカイブ内のファイル）
+//
+// 戻り値:
+//   - UTF-8 に変換されたデータを返す Reader
+//   - 入力ファイル形式（tsv または csv）
+//   - エラー（文字エンコーディングの判定に失敗した場合）
+func (s inputSource) openMember(member file.InputMember) (io.Reader, string, error) {
+	inputFormat, err := resolveInputFormat(member.Name, s.Format)
+	if err != nil {
+		return nil, "", err
+	}
+
+	decodedReader, usedEncoding, err := file.NewDecodingReader(member.Reader, s.TextEncoding)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", member.Name, err)
+	}
+	log.Printf("Reading %s file as %s: %s", strings.ToUpper(inputFormat), usedEncoding, member.Name)
+
+	return decodedReader, inputFormat, nil
+}
+
+// streamDailyStockPrices は入力ファイルから日次株価情報を1行ずつ読み込むイテレータを返します。
+// アーカイブの場合は各ファイルを格納順に読み込み、ファイルごとに読み込んだ件数をログに出力します。
+// アーカイブ内のファイルで発生したエラーにはファイル名を付けます。
+//
+// 引数:
+//   - fileIndex: 入力ファイルの番号
+//   - path: 入力ファイルのパス（圧縮・アーカイブされたファイルも可）
+//
+// 戻り値:
+//   - 日次株価情報とエラーの組を返すイテレータ
+func (s inputSource) streamDailyStockPrices(fileIndex int, path string) iter.Seq2[models.DailyStockPrice, error] {
+	return func(yield func(models.DailyStockPrice, error) bool) {
+		memberCount := 0
+		for member, err := range s.openInputMembers(fileIndex, path) {
+			if err != nil {
+				yield(models.DailyStockPrice{}, fmt.Errorf("failed to open input file %s: %w", path, err))
+				return
+			}
+			memberCount++
+
+			// ファイルを開く
+			reader, inputFormat, err := s.openMember(member)
+			if err != nil {
+				yield(models.DailyStockPrice{}, err)
+				return
+			}
+
+			// ファイルを読み込むイテレータを作成
+			var dailyPrices iter.Seq2[models.DailyStockPrice, error]
+			if inputFormat == formatCSV {
+				dailyPrices = file.StreamDailyStockPriceFromCSVReader(reader, s.CSVOptions)
+			} else {
+				dailyPrices = file.StreamDailyStockPriceFromTSVReader(reader, s.TSVOptions)
+			}
+			if member.IsArchiveMember {
+				dailyPrices = file.WithSource(dailyPrices, member.Name)
+			}
+
+			// 読み込んだ件数を数えながら返す
+			readCount := 0
+			for dailyPrice, err := range dailyPrices {
+				if err == nil {
+					readCount++
+				}
+				if !yield(dailyPrice, err) {
+					return
+				}
+			}
+			log.Printf("Read %d daily stock prices from %s", readCount, member.Name)
+		}
+
+		if memberCount == 0 {
+			yield(models.DailyStockPrice{}, fmt.Errorf("no files to import in %s", path))
+		}
+	}
+}
+
+// importDailyStockPrices は複数の入力ファイルを並列に読み込み、1つの書き込み処理で
+// chunkSize 件ごとにコミットしながらSQLiteデータベースのdaily_stock_priceテーブルに取り込みます。
+// 全てのファイル（アーカイブの場合は全てのメンバー）をまとめて1つのテーブルに取り込みます。
+// importModeReplace の場合は全件を読み込んでから1つのトランザクションでテーブルを置き換え、
+// 失敗した場合はテーブルを変更しません。それ以外の場合は既存の行を残して conflictPolicy に従って追加します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト（キャンセルされると実行中のチャンクをロールバックする）
+//   - source: 入力ファイルの読み込み設定
+//   - paths: 入力ファイルのパスの一覧
+//   - workers: 並列に読み込むファイル数の上限
+//   - repository: 日次株価情報を書き込むリポジトリ
+//   - runID: 書き込みを記録する取り込みID
+//   - importMode: 取り込みモード（replace, append または upsert）
+//   - conflictPolicy: append, upsert の場合に同じ銘柄コード・日付の行が存在する場合の扱い
+//   - chunkSize: 1トランザクションで書き込む件数
+//   - validationReport: 寛容モードで不正な行を記録する構造体（nil の場合は最初の不正な行で中断する）
+//   - maxRejectedRows: 寛容モードで許容する不正な行数の上限
+//   - summaries: ファイルごとの取り込み結果を記録するスライス（paths と同じ長さ）
+//
+// 戻り値:
+//   - 取り込み結果（replace の場合は全て Inserted として数える）
+//   - エラー（読み込みやデータベース操作に失敗した場合）
+func importDailyStockPrices(ctx context.Context, source inputSource, paths []string, workers int, repository models.StockPriceRepository, runID int64, importMode string, conflictPolicy models.ConflictPolicy, chunkSize int, validationReport *file.ValidationReport, maxRejectedRows int, summaries []fileSummary) (models.UpsertResult, error) {
+	// 入力ファイルを並列に読み込むイテレータを作成
+	dailyPrices := streamDailyStockPricesConcurrently(source, paths, workers, summaries)
+
+	// 寛容モードの場合は不正な行をスキップ
+	if validationReport != nil {
+		dailyPrices = file.SkipInvalidRows(dailyPrices, validationReport, maxRejectedRows)
+	}
+
+	// 既存の行を残す場合は読み込みながら追加
+	if importMode != importModeReplace {
+		log.Printf("Importing into database (%s, on conflict %s)", importMode, conflictPolicy)
+		result, err := repository.UpsertDailyStockPricesFromSeq(ctx, runID, dailyPrices, chunkSize, conflictPolicy)
+		if err != nil {
+			return result, fmt.Errorf("failed to import after %d committed rows: %w", result.Total(), err)
+		}
+		log.Printf("Read %d daily stock prices", result.Total())
+		return result, nil
+	}
+
+	// 読み込みながらSQLiteデータベースを初期化
+	log.Printf("Initializing database")
+	importedCount, err := repository.InitializeDailyStockPriceTableFromSeq(ctx, runID, dailyPrices, chunkSize)
+	if err != nil {
+		return models.UpsertResult{}, fmt.Errorf("failed to import, the existing daily stock prices were kept: %w", err)
+	}
+	log.Printf("Read %d daily stock prices", importedCount)
+	return models.UpsertResult{Inserted: importedCount}, nil
+}
+
+// importDailyStockBars は入力ファイルから日次四本値を順番に読み込み、
+// SQLiteデータベースのdaily_stock_priceテーブルを初期化します。
+// 全てのファイル（アーカイブの場合は全てのメンバー）をまとめて1つのテーブルに取り込みます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト（キャンセルされるとトランザクションをロールバックする）
+//   - source: 入力ファイルの読み込み設定
+//   - paths: 入力ファイルのパスの一覧
+//   - repository: 日次四本値を書き込むリポジトリ
+//   - runID: 書き込みを記録する取り込みID
+//
+// 戻り値:
+//   - 取り込んだ日次四本値の件数
+//   - エラー（読み込みやデータベース操作に失敗した場合）
+func importDailyStockBars(ctx context.Context, source inputSource, paths []string, repository models.StockPriceRepository, runID int64) (int, error) {
+	// 結果を格納するスライス
+	var dailyBars []models.DailyStockBar
+
+	// 入力ファイルを読み込む
+	for member, err := range source.inputMembers(paths) {
+		if err != nil {
+			return 0, fmt.Errorf("failed to open input file: %w", err)
+		}
+		reader, inputFormat, err := source.openMember(member)
+		if err != nil {
+			return 0, err
+		}
+
+		var memberBars []models.DailyStockBar
+		if inputFormat == formatCSV {
+			memberBars, err = file.ReadDailyStockBarFromCSVReader(reader, source.CSVOptions)
+		} else {
+			memberBars, err = file.ReadDailyStockBarFromTSVReader(reader, source.TSVOptions)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s file %s: %w", strings.ToUpper(inputFormat), member.Name, err)
+		}
+		log.Printf("Read %d daily stock bars from %s", len(memberBars), member.Name)
+		dailyBars = append(dailyBars, memberBars...)
+	}
+	if len(dailyBars) == 0 {
+		log.Printf("No daily stock bars found")
+	}
+
+	// SQLiteデータベースを初期化
+	log.Printf("Initializing database")
+	if err := repository.InitializeDailyStockBarTable(ctx, runID, dailyBars); err != nil {
+		return 0, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return len(dailyBars), nil
+}
+
+// importStocks はTSVファイルから銘柄マスタを読み込み、リポジトリに登録します。
+// 登録済みの銘柄は新しい値で上書きします。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - repository: 銘柄マスタを書き込むリポジトリ
+//   - stocksPath: 銘柄マスタのTSVファイルのパス
+//
+// 戻り値:
+//   - 登録結果
+//   - エラー（読み込みやデータベース操作に失敗した場合）
+func importStocks(ctx context.Context, repository models.StockPriceRepository, stocksPath string) (models.UpsertResult, error) {
+	stocks, err := file.ReadStocksFromTSV(stocksPath)
+	if err != nil {
+		return models.UpsertResult{}, fmt.Errorf("failed to read stock master %s: %w", stocksPath, err)
+	}
+	log.Printf("Read %d stocks from %s", len(stocks), stocksPath)
+
+	result, err := repository.UpsertStocks(ctx, stocks)
+	if err != nil {
+		return models.UpsertResult{}, fmt.Errorf("failed to import stock master: %w", err)
+	}
+	return result, nil
+}
+
+// isFlagSet はコマンドライン引数でフラグが指定されたかどうかを返します。
+//
+// 引数:
+//   - name: フラグ名
+//
+// 戻り値:
+//   - 指定された場合は true
+func isFlagSet(name string) bool {
+	found := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+	return found
+}
+
+// newCommandContext は Ctrl-C（SIGINT）を受け取るとキャンセルされ、
+// timeout が正の場合はその時間が経過するとキャンセルされるコンテキストを作成します。
+//
+// 引数:
+//   - timeout: 実行時間の上限（0以下の場合は上限なし）
+//
+// 戻り値:
+//   - コンテキスト
+//   - コンテキストを解放する関数（使い終わったら呼び出す）
+func newCommandContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// exitOnInterruptedImport は Ctrl-C や -timeout で取り込みが中断された場合に、
+// 実行中のトランザクションのロールバックを待ってから、データベースに残った内容を出力して終了します。
+// 中断されていない場合は何もしません。
+//
+// 引数:
+//   - ctx: 取り込みに使用したコンテキスト
+//   - repository: 取り込み先のリポジトリ
+//   - outcome: 中断後にデータベースに残った内容の説明
+func exitOnInterruptedImport(ctx context.Context, repository models.StockPriceRepository, outcome string) {
+	if ctx.Err() == nil {
+		return
+	}
+
+	// Close は実行中の操作の終了を待つため、ロールバックが完了してから終了する
+	if err := repository.Close(); err != nil {
+		log.Printf("Failed to close database: %v", err)
+	}
+	log.Fatalf("Import interrupted (%v): %s", ctx.Err(), outcome)
+}
+
+// interruptedImportOutcome は日次株価情報の取り込みが中断された場合に、データベースに残った内容の説明を返します。
+// importModeReplace の場合は置き換え全体がロールバックされるため、既存の日次株価情報が残ります。
+// それ以外の場合はチャンクごとにコミットするため、コミット済みの行を取り消すコマンドを示します。
+//
+// 引数:
+//   - importMode: 取り込み方法
+//   - committedCount: 中断前にコミットされた行数
+//   - dbPath: SQLiteデータベースファイルのパス
+//   - runID: 取り込みID
+//
+// 戻り値:
+//   - データベースに残った内容の説明
+func interruptedImportOutcome(importMode string, committedCount int, dbPath string, runID int64) string {
+	if importMode == importModeReplace {
+		return "the whole replace was rolled back, the existing daily stock prices were kept"
+	}
+	return fmt.Sprintf("the transaction in progress was rolled back, %d rows committed before it were kept (undo with: stock_price_db undo-import -db %s %d)", committedCount, dbPath, runID)
+}
+
+// openRepository はデータベースを開き、日次株価情報のリポジトリを返します。
+// データベースファイルがなければ作成し、未適用のマイグレーションを適用します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - dbPath: SQLiteデータベースファイルのパス（memory.DatabasePath の場合はメモリ上のリポジトリを使用）
+//   - busyTimeout: 他のプロセスがロックを保持している場合にロックの解放を待つ時間
+//
+// 戻り値:
+//   - リポジトリ（使い終わったら Close を呼び出す）
+//   - エラー（データベースを開けない場合やマイグレーションに失敗した場合）
+func openRepository(ctx context.Context, dbPath string, busyTimeout time.Duration) (models.StockPriceRepository, error) {
+	if dbPath == memory.DatabasePath {
+		return memory.NewInMemoryStockPriceRepository(), nil
+	}
+	options := db.DefaultSQLiteOptions()
+	options.BusyTimeout = busyTimeout
+	options.Migrate = true
+	return db.NewSQLiteStockPriceRepositoryWithOptions(ctx, dbPath, options)
+}
+
+// openInputMembers は入力ファイルのメンバーを返すイテレータを返します。
+// パスが stdinPath の場合は標準入力から読み込みます。
+// Digest を設定している場合は、メンバーの読み込みに使用したデータをそのファイルのハッシュに書き込みます。
+//
+// 引数:
+//   - fileIndex: 入力ファイルの番号
+//   - path: 入力ファイルのパス
+//
+// 戻り値:
+//   - メンバーとエラーの組を返すイテレータ
+func (s inputSource) openInputMembers(fileIndex int, path string) iter.Seq2[file.InputMember, error] {
+	if s.Digest == nil {
+		if path == stdinPath {
+			return file.ReadInputMembers(s.Stdin, stdinName)
+		}
+		return file.OpenInputMembers(path)
+	}
+
+	fileHash := s.Digest.hashes[fileIndex]
+	if path == stdinPath {
+		return s.Digest.trackMembers(fileIndex, file.ReadInputMembersWithDigest(s.Stdin, stdinName, fileHash))
+	}
+	return s.Digest.trackMembers(fileIndex, file.OpenInputMembersWithDigest(path, fileHash))
+}
+
+// inputMembers は複数の入力ファイルのメンバーを順番に返すイテレータを返します。
+//
+// 引数:
+//   - paths: 入力ファイルのパスの一覧
+//
+// 戻り値:
+//   - メンバーとエラーの組を返すイテレータ
+func (s inputSource) inputMembers(paths []string) iter.Seq2[file.InputMember, error] {
+	return func(yield func(file.InputMember, error) bool) {
+		for fileIndex, path := range paths {
+			for member, err := range s.openInputMembers(fileIndex, path) {
+				if !yield(member, err) || err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// printValidationSummary は寛容モードでスキップした行の要約をログに出力します。
+// 問題の種類ごとの件数と、先頭から maxSummaryIssues 件までの問題を出力します。
+//
+// 引数:
+//   - report: 取り込み結果
+func printValidationSummary(report file.ValidationReport) {
+	log.Printf("Validation summary: %d rows accepted, %d rows rejected", report.AcceptedRows, report.RejectedRows)
+	if report.RejectedRows == 0 {
+		return
+	}
+
+	// 問題の種類ごとの件数を集計
+	countByKind := make(map[string]int)
+	var kinds []string
+	for _, issue := range report.Issues {
+		if countByKind[issue.Kind] == 0 {
+			kinds = append(kinds, issue.Kind)
+		}
+		countByKind[issue.Kind]++
+	}
+	for _, kind := range kinds {
+		log.Printf("  %s: %d rows", kind, countByKind[kind])
+	}
+
+	// 先頭の問題を出力
+	for i, issue := range report.Issues {
+		if i >= maxSummaryIssues {
+			log.Printf("  ... %d more (use -report to write all problems)", len(report.Issues)-maxSummaryIssues)
+			break
+		}
+		log.Printf("  line %d: %s", issue.LineNumber, issue.Message)
+	}
+}
+
+// writeValidationReport は取り込み結果を指定された形式でファイルに書き込みます。
+//
+// 引数:
+//   - reportPath: 書き込むファイルのパス
+//   - reportFormat: 出力形式（json または tsv）
+//   - report: 取り込み結果
+//
+// 戻り値:
+//   - エラー（書き込みに失敗した場合）
+func writeValidationReport(reportPath string, reportFormat string, report file.ValidationReport) error {
+	reportFile, err := os.Create(reportPath)
+	if err != nil {
+		return err
+	}
+
+	if reportFormat == reportFormatJSON {
+		err = file.WriteValidationReportJSON(reportFile, report)
+	} else {
+		err = file.WriteValidationReportTSV(reportFile, report)
+	}
+	if err != nil {
+		reportFile.Close()
+		return err
+	}
+	return reportFile.Close()
+}
+
+// resolveImportMode は -mode フラグと -on-conflict フラグの値から
+// 取り込みモードと衝突時の扱いを決定します。
+// -on-conflict が空の場合、append は fail、upsert は overwrite とします。
+//
+// 引数:
+//   - mode: -mode フラグの値
+//   - onConflict: -on-conflict フラグの値
+//
+// 戻り値:
+//   - 取り込みモード（replace, append または upsert）
+//   - 衝突時の扱い（replace の場合は空）
+//   - エラー（フラグの値が不正な場合）
+func resolveImportMode(mode string, onConflict string) (string, models.ConflictPolicy, error) {
+	var defaultPolicy models.ConflictPolicy
+	switch importMode := strings.ToLower(mode); importMode {
+	case importModeReplace:
+		if onConflict != "" {
+			return "", "", fmt.Errorf("-on-conflict is not supported with -mode %s", importModeReplace)
+		}
+		return importModeReplace, "", nil
+	case importModeAppend:
+		defaultPolicy = models.ConflictPolicyFail
+	case importModeUpsert:
+		defaultPolicy = models.ConflictPolicyOverwrite
+	default:
+		return "", "", fmt.Errorf("unknown mode %q (expected %s, %s or %s)", mode, importModeReplace, importModeAppend, importModeUpsert)
+	}
+
+	if onConflict == "" {
+		return strings.ToLower(mode), defaultPolicy, nil
+	}
+	conflictPolicy, err := models.ParseConflictPolicy(strings.ToLower(onConflict))
+	if err != nil {
+		return "", "", err
+	}
+	return strings.ToLower(mode), conflictPolicy, nil
+}
+
+// resolveReportFormat は -report-format フラグの値と出力ファイルの拡張子から
+// 検証結果の出力形式を決定します。
+//
+// 引数:
+//   - reportPath: 出力ファイルのパス
+//   - reportFormat: -report-format フラグの値
+//
+// 戻り値:
+//   - 出力形式（json または tsv）
+//   - エラー（フラグの値が不正な場合）
+func resolveReportFormat(reportPath string, reportFormat string) (string, error) {
+	switch strings.ToLower(reportFormat) {
+	case reportFormatJSON:
+		return reportFormatJSON, nil
+	case formatTSV:
+		return formatTSV, nil
+	case formatAuto:
+		if strings.EqualFold(filepath.Ext(reportPath), jsonExtension) {
+			return reportFormatJSON, nil
+		}
+		return formatTSV, nil
+	default:
+		return "", fmt.Errorf("unknown report format %q (expected %s, %s or %s)", reportFormat, formatAuto, reportFormatJSON, formatTSV)
+	}
+}
+
+// resolveInputFormat は -format フラグの値と入力ファイルの拡張子から
+// 入力ファイル形式を決定します。
+//
+// 引数:
+//   - inputPath: 入力ファイルのパス
+//   - format: -format フラグの値
+//
+// 戻り値:
+//   - 入力ファイル形式（tsv または csv）
+//   - エラー（フラグの値が不正な場合）
+func resolveInputFormat(inputPath string, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case formatTSV:
+		return formatTSV, nil
+	case formatCSV:
+		return formatCSV, nil
+	case formatAuto:
+		if strings.EqualFold(filepath.Ext(inputPath), csvExtension) {
+			return formatCSV, nil
+		}
+		return formatTSV, nil
+	default:
+		return "", fmt.Errorf("unknown format %q (expected %s, %s or %s)", format, formatAuto, formatTSV, formatCSV)
+	}
+}
+
+// parseDelimiter は -delimiter フラグの値を区切り文字に変換します。
+//
+// 引数:
+//   - delimiter: -delimiter フラグの値
+//
+// 戻り値:
+//   - 区切り文字
+//   - エラー（1文字で表せない場合）
+func parseDelimiter(delimiter string) (rune, error) {
+	for _, alias := range tabDelimiterAliases {
+		if strings.EqualFold(delimiter, alias) {
+			return '\t', nil
+		}
+	}
+	if utf8.RuneCountInString(delimiter) != 1 {
+		return 0, fmt.Errorf("delimiter must be a single character: %q", delimiter)
+	}
+	delimiterRune, _ := utf8.DecodeRuneInString(delimiter)
+	return delimiterRune, nil
 }
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/file"
+)
+
+// 読み込み中のファイル1つあたりに確保する行のバッファ数
+const rowBufferSizePerFile = 1024
+
+// ディレクトリを指定した場合に取り込む拡張子
+var importableExtensions = []string{".tsv", ".csv", ".txt", ".gz", ".tgz", ".tar", ".zip"}
+
+// ファイルごとの取り込み結果を示す構造体
+type fileSummary struct {
+	// 入力ファイルのパス
+	Path string
+	// 読み込めた行数
+	ReadRows int
+	// 不正な行としてスキップした行数
+	RejectedRows int
+	// 読み込みにかかった時間
+	Elapsed time.Duration
+	// 読み込みが完了したかどうか（途中で取り込みが中断された場合は false）
+	Finished bool
+}
+
+// 並列読み込みでワーカーから書き込み処理へ送る1行分のデータ
+type parsedRow struct {
+	// 読み込み元ファイルの番号
+	fileIndex int
+	// 日次株価情報
+	dailyPrice models.DailyStockPrice
+	// 読み込み時のエラー
+	err error
+	// ファイルの読み込み完了を示す場合は true（dailyPrice と err は使用しない）
+	finished bool
+	// ファイルの読み込みにかかった時間（finished が true の場合のみ使用）
+	elapsed time.Duration
+}
+
+// 並列読み込みでワーカーに配る1ファイル分の読み込み
+type fileJob struct {
+	// 読み込むファイルの番号
+	fileIndex int
+	// 読み込んだ行の送信先（ワーカーが読み込みの終了時に閉じる）
+	rows chan parsedRow
+}
+
+// resolveInputPaths は -tsv フラグの値を取り込むファイルのパスの一覧に変換します。
+//   - stdinPath の場合: 標準入力
+//   - ディレクトリの場合: 配下の取り込み可能な拡張子のファイル（サブディレクトリを含む）
+//   - グロブパターン（*, ?, [ を含む）の場合: パターンに一致するファイル
+//   - それ以外の場合: 指定されたファイル
+//
+// パスは名前順に並べます。
+//
+// 引数:
+//   - inputPath: -tsv フラグの値
+//
+// 戻り値:
+//   - ファイルのパスの一覧
+//   - エラー（ファイルが存在しない場合やパターンが不正な場合）
+func resolveInputPaths(inputPath string) ([]string, error) {
+	if inputPath == stdinPath {
+		return []string{stdinPath}, nil
+	}
+
+	// グロブパターンの場合は一致するファイルを返す
+	if strings.ContainsAny(inputPath, "*?[") {
+		matches, err := filepath.Glob(inputPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", inputPath, err)
+		}
+		var paths []string
+		for _, match := range matches {
+			if fileInfo, err := os.Stat(match); err == nil && fileInfo.Mode().IsRegular() {
+				paths = append(paths, match)
+			}
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no input files match %s", inputPath)
+		}
+		return paths, nil
+	}
+
+	fileInfo, err := os.Stat(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("input file not found: %s", inputPath)
+	}
+	if !fileInfo.IsDir() {
+		return []string{inputPath}, nil
+	}
+
+	// ディレクトリの場合は配下のファイルを返す
+	var paths []string
+	err = filepath.WalkDir(inputPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") &&
+			slices.Contains(importableExtensions, strings.ToLower(filepath.Ext(path))) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list input directory: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no input files found in %s", inputPath)
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
+
+// streamDailyStockPricesConcurrently は複数の入力ファイルを最大 workers 個のワーカーで並列に読み込み、
+// 読み込んだ日次株価情報を1つのイテレータにまとめて返します。
+// 行はワーカーの完了順によらず paths の順（ファイル内では行の順）に返すため、
+// 複数のファイルに同じ銘柄コード・日付の行がある場合も、実行のたびに同じ行が後から書き込まれます。
+// 先行して読み込むファイルの行はファイルごとのバッファに溜め、バッファが一杯になるとそのワーカーは待機します。
+// イテレータは1つのゴルーチンから呼び出されるため、書き込み処理は1つに限られます。
+// ファイルごとの読み込み結果は summaries に記録します（イテレーションの終了後に参照してください）。
+// 呼び出し元がイテレーションを中断した場合は、全てのワーカーの終了を待ってから戻ります。
+//
+// 引数:
+//   - source: 入力ファイルの読み込み設定
+//   - paths: 入力ファイルのパスの一覧
+//   - workers: 並列に読み込むファイル数の上限
+//   - summaries: ファイルごとの読み込み結果を記録するスライス（paths と同じ長さ）
+//
+// 戻り値:
+//   - 日次株価情報とエラーの組を返すイテレータ
+func streamDailyStockPricesConcurrently(source inputSource, paths []string, workers int, summaries []fileSummary) iter.Seq2[models.DailyStockPrice, error] {
+	return func(yield func(models.DailyStockPrice, error) bool) {
+		workers = max(min(workers, len(paths)), 1)
+		fileJobs := make(chan fileJob)
+		// 読み込みを開始したファイルの行のチャネルを paths の順に並べる
+		orderedRows := make(chan chan parsedRow, workers)
+		done := make(chan struct{})
+
+		// 読み込むファイルをワーカーに配り、そのファイルの行のチャネルを順に書き込み処理へ渡す
+		// ワーカーに渡してからチャネルを並べるため、書き込み処理が待つファイルは必ず読み込み中か読み込み済みになる
+		go func() {
+			defer close(orderedRows)
+			defer close(fileJobs)
+			for fileIndex := range paths {
+				job := fileJob{fileIndex: fileIndex, rows: make(chan parsedRow, rowBufferSizePerFile)}
+				select {
+				case fileJobs <- job:
+				case <-done:
+					return
+				}
+				select {
+				case orderedRows <- job.rows:
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		// ワーカーを起動
+		var waitGroup sync.WaitGroup
+		for range workers {
+			waitGroup.Add(1)
+			go func() {
+				defer waitGroup.Done()
+				for job := range fileJobs {
+					completed := parseFileToChannel(source, paths[job.fileIndex], job.fileIndex, job.rows, done)
+					close(job.rows)
+					if !completed {
+						return
+					}
+				}
+			}()
+		}
+
+		// 中断した場合はワーカーを停止し、全てのワーカーが終了するまで待つ
+		defer func() {
+			close(done)
+			for rows := range orderedRows {
+				for range rows {
+				}
+			}
+			waitGroup.Wait()
+		}()
+
+		// ファイルの順に、ワーカーから受け取った行を返す
+		for rows := range orderedRows {
+			for row := range rows {
+				summary := &summaries[row.fileIndex]
+				summary.Path = paths[row.fileIndex]
+				switch {
+				case row.finished:
+					summary.Elapsed = row.elapsed
+					summary.Finished = true
+					continue
+				case row.err == nil:
+					summary.ReadRows++
+				default:
+					if _, isRowError := file.NewValidationIssue(row.err); isRowError {
+						summary.RejectedRows++
+					}
+				}
+				if !yield(row.dailyPrice, row.err) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// parseFileToChannel は1つの入力ファイルを読み込み、各行と読み込みの完了を rows に送ります。
+//
+// 引数:
+//   - source: 入力ファイルの読み込み設定
+//   - path: 入力ファイルのパス
+//   - fileIndex: 入力ファイルの番号
+//   - rows: 行の送信先
+//   - done: 読み込みの中断を通知するチャネル
+//
+// 戻り値:
+//   - 中断された場合は false
+func parseFileToChannel(source inputSource, path string, fileIndex int, rows chan<- parsedRow, done <-chan struct{}) bool {
+	startTime := time.Now()
+	for dailyPrice, err := range source.streamDailyStockPrices(fileIndex, path) {
+		select {
+		case rows <- parsedRow{fileIndex: fileIndex, dailyPrice: dailyPrice, err: err}:
+		case <-done:
+			return false
+		}
+	}
+
+	select {
+	case rows <- parsedRow{fileIndex: fileIndex, finished: true, elapsed: time.Since(startTime)}:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// printFileSummaries はファイルごとの取り込み結果を表形式で書き込みます。
+// 読み込みを開始しなかったファイルは表示しません。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - summaries: ファイルごとの取り込み結果
+func printFileSummaries(writer io.Writer, summaries []fileSummary) {
+	tabWriter := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tabWriter, "File\tRead\tRejected\tElapsed")
+	for _, summary := range summaries {
+		if summary.Path == "" {
+			continue
+		}
+		elapsed := "-"
+		if summary.Finished {
+			elapsed = summary.Elapsed.Round(time.Microsecond).String()
+		}
+		fmt.Fprintf(tabWriter, "%s\t%d\t%d\t%s\n", summary.Path, summary.ReadRows, summary.RejectedRows, elapsed)
+	}
+	tabWriter.Flush()
+}
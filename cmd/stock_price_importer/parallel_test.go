@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/file"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+func TestResolveInputPaths(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	for _, name := range []string{"b.tsv", "a.csv", "notes.md", ".hidden.tsv", "sub/c.tsv.gz"} {
+		testutil.WriteFile(t, filepath.Join(dir, name), "")
+	}
+	expectedDirPaths := []string{
+		filepath.Join(dir, "a.csv"),
+		filepath.Join(dir, "b.tsv"),
+		filepath.Join(dir, "sub", "c.tsv.gz"),
+	}
+	expectedGlobPaths := []string{filepath.Join(dir, "b.tsv")}
+
+	// Act
+	dirPaths, dirErr := resolveInputPaths(dir)
+	globPaths, globErr := resolveInputPaths(filepath.Join(dir, "[b-z]*.tsv"))
+	_, missingErr := resolveInputPaths(filepath.Join(dir, "missing.tsv"))
+
+	// Assert
+	if dirErr != nil || globErr != nil {
+		t.Fatalf("Expected no error, but got: %v, %v", dirErr, globErr)
+	}
+	if !reflect.DeepEqual(dirPaths, expectedDirPaths) {
+		t.Errorf("Expected %v, but got %v", expectedDirPaths, dirPaths)
+	}
+	if !reflect.DeepEqual(globPaths, expectedGlobPaths) {
+		t.Errorf("Expected %v, but got %v", expectedGlobPaths, globPaths)
+	}
+	if missingErr == nil {
+		t.Error("Expected an error for a missing file, but got nil")
+	}
+}
+
+func TestStreamDailyStockPricesConcurrently(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	var paths []string
+	for i := range 5 {
+		path := filepath.Join(dir, fmt.Sprintf("%d.tsv", i))
+		testutil.WriteFile(t, path, fmt.Sprintf("%d\t2025/2/4\t100\n%d\t2025/2/5\tN/A\n%d\t2025/2/6\t102\n", i, i, i))
+		paths = append(paths, path)
+	}
+	source := inputSource{
+		Format:       formatAuto,
+		TextEncoding: file.EncodingUTF8,
+		TSVOptions:   file.DefaultTSVOptions(),
+		CSVOptions:   file.DefaultCSVOptions(),
+	}
+	summaries := make([]fileSummary, len(paths))
+
+	// Act
+	readCount, errorCount := 0, 0
+	for _, err := range streamDailyStockPricesConcurrently(source, paths, 2, summaries) {
+		if err != nil {
+			errorCount++
+			continue
+		}
+		readCount++
+	}
+
+	// Assert
+	if readCount != 10 || errorCount != 5 {
+		t.Errorf("Expected 10 rows and 5 errors, but got %d rows and %d errors", readCount, errorCount)
+	}
+	for i, summary := range summaries {
+		if summary.Path != paths[i] || summary.ReadRows != 2 || summary.RejectedRows != 1 || !summary.Finished {
+			t.Errorf("Unexpected summary for %s: %+v", paths[i], summary)
+		}
+	}
+}
+
+func TestStreamDailyStockPricesConcurrently_KeepsFileOrder(t *testing.T) {
+	// Arrange
+	// 先頭のファイルをバッファより大きくし、後ろのファイルの読み込みが先に終わるようにする
+	dir := t.TempDir()
+	var paths []string
+	var expected []string
+	for i := range 4 {
+		rowCount := 3
+		if i == 0 {
+			rowCount = rowBufferSizePerFile * 2
+		}
+		var content strings.Builder
+		for day := range rowCount {
+			date := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, day)
+			fmt.Fprintf(&content, "%d\t%s\t%d\n", 1000+i, date.Format("2006/1/2"), 100+day)
+			expected = append(expected, fmt.Sprintf("%d %s", 1000+i, date.Format(time.DateOnly)))
+		}
+		path := filepath.Join(dir, fmt.Sprintf("%d.tsv", i))
+		testutil.WriteFile(t, path, content.String())
+		paths = append(paths, path)
+	}
+	source := inputSource{Format: formatAuto, TextEncoding: file.EncodingUTF8, TSVOptions: file.DefaultTSVOptions()}
+
+	// Act
+	var keys []string
+	for dailyPrice, err := range streamDailyStockPricesConcurrently(source, paths, 4, make([]fileSummary, len(paths))) {
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		keys = append(keys, dailyPrice.StockPrice.StockID+" "+dailyPrice.PriceDate.Format(time.DateOnly))
+	}
+
+	// Assert
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Expected rows in file order (%d rows), but got %d rows in a different order", len(expected), len(keys))
+	}
+}
+
+func TestStreamDailyStockPricesConcurrently_StopEarly(t *testing.T) {
+	// Arrange
+	dir := t.TempDir()
+	var paths []string
+	for i := range 4 {
+		path := filepath.Join(dir, fmt.Sprintf("%d.tsv", i))
+		testutil.WriteFile(t, path, fmt.Sprintf("%d\t2025/2/4\t100\n%d\t2025/2/5\t101\n", i, i))
+		paths = append(paths, path)
+	}
+	source := inputSource{Format: formatAuto, TextEncoding: file.EncodingUTF8, TSVOptions: file.DefaultTSVOptions()}
+
+	// Act
+	readCount := 0
+	for range streamDailyStockPricesConcurrently(source, paths, 2, make([]fileSummary, len(paths))) {
+		readCount++
+		break
+	}
+
+	// Assert
+	if readCount != 1 {
+		t.Errorf("Expected iteration to stop after 1 row, but got %d", readCount)
+	}
+}
+
+func TestStreamDailyStockPricesConcurrently_Stdin(t *testing.T) {
+	// Arrange
+	source := inputSource{
+		Stdin:        strings.NewReader("stock_id\tdate\tprice\n7203\t2025-02-04\t2873\n7203\t2025-02-05\t2903.5\n"),
+		Format:       formatAuto,
+		TextEncoding: file.EncodingAuto,
+		TSVOptions:   file.DefaultTSVOptions(),
+	}
+	paths, err := resolveInputPaths(stdinPath)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Act
+	var prices []models.Price
+	for dailyPrice, err := range streamDailyStockPricesConcurrently(source, paths, 1, make([]fileSummary, len(paths))) {
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		prices = append(prices, dailyPrice.StockPrice.Price)
+	}
+
+	// Assert
+	if !reflect.DeepEqual(prices, []models.Price{models.NewPrice(2873, 0), models.NewPrice(29035, 1)}) {
+		t.Errorf("Expected prices read from stdin, but got %v", prices)
+	}
+}
@@ -1,34 +1,307 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
 
+	"github.com/toriwasa/sqlite-playground/internal/controller"
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
 	"github.com/toriwasa/sqlite-playground/internal/infrastructures/db"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/file"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/memory"
 )
 
+// -load で読み込む際に1回でコミットする件数
+const loadChunkSize = 1000
+
 func main() {
 	// コマンドライン引数を定義
-	dbPath := flag.String("db", "sqlite_data/stock_price.db", "Path to the SQLite database file")
+	dbPath := flag.String("db", "sqlite_data/stock_price.db", "Path to the SQLite database file, or :memory: to query data loaded with -load without touching disk")
+	loadPath := flag.String("load", "", "TSV file of daily stock prices to load before querying (only with -db :memory:)")
+	outputFormat := flag.String("format", outputFormatTable, "Output format: table, tsv, csv or json (one object per line); tsv and csv can be piped into stock_price_importer")
+	showStatistics := flag.Bool("stats", false, "Print statistics of a single stock (-stock, -from, -to) instead of listing daily stock prices")
+	stockID := flag.String("stock", "", "Stock ID to compute statistics for (used with -stats), or comma-separated stock IDs to list")
+	fromDate := flag.String("from", "", "First date (YYYY-MM-DD, inclusive) of the statistics period or of the listed prices")
+	toDate := flag.String("to", "", "Last date (YYYY-MM-DD, inclusive) of the statistics period or of the listed prices")
+	asOf := flag.String("as-of", "", "Use the prices stored at this RFC 3339 time, e.g. 2025-03-01T09:00:00+09:00, to reproduce an earlier report or listing")
+	minPrice := flag.String("min-price", "", "List only daily stock prices at or above this price")
+	maxPrice := flag.String("max-price", "", "List only daily stock prices at or below this price")
+	sortKey := flag.String("sort", string(models.SortByStock), "Order of the listed prices: stock (stock ID, date), date (date, stock ID) or price (price, stock ID, date)")
+	descending := flag.Bool("desc", false, "List in descending order")
+	limit := flag.Int("limit", 0, "Maximum number of daily stock prices (or stocks with -distinct-stocks) to list; 0 lists all of them")
+	offset := flag.Int("offset", 0, "Number of matching daily stock prices (or stocks with -distinct-stocks) to skip before listing")
+	distinctStocks := flag.Bool("distinct-stocks", false, "List the stocks that have matching daily stock prices instead of the prices")
+	afterCursor := flag.String("after", "", "List daily stock prices after this STOCK_ID:YYYY-MM-DD cursor (exclusive); the next cursor is logged when -limit cuts the list short")
+	timeout := flag.Duration("timeout", 0, "Abort queries that run longer than this duration, e.g. 30s or 10m (0 for no limit)")
+	busyTimeout := flag.Duration("busy-timeout", db.DefaultSQLiteOptions().BusyTimeout, "How long to wait for another process to release a lock on the SQLite database before retrying")
 	flag.Parse()
 
-	// データベースからデータを取得
-	dailyPrices, err := db.GetDailyStockPrices(*dbPath)
+	// ログはエラー出力に書き込み、標準出力は結果のみにする
+	log.SetPrefix("StockPriceViewer: ")
+	log.SetFlags(0)
+
+	// -load はディスク上のデータベースを変更しないようメモリ上のデータベースでのみ使用できる
+	if *loadPath != "" && *dbPath != memory.DatabasePath {
+		log.Fatalf("-load is only supported together with -db %s", memory.DatabasePath)
+	}
+
+	// Ctrl-C（SIGINT）と -timeout でクエリを中断できるようにする
+	ctx, cancel := newCommandContext(*timeout)
+	defer cancel()
+
+	// データベースを開く
+	repository, err := openRepository(ctx, *dbPath, *busyTimeout)
 	if err != nil {
-		log.Fatalf("Failed to retrieve data from database: %v", err)
+		log.Fatalf("Failed to open database: %v", err)
+	}
+	defer repository.Close()
+
+	// メモリ上のデータベースにTSVファイルを読み込む
+	if *loadPath != "" {
+		loadedCount, err := repository.InitializeDailyStockPriceTableFromSeq(ctx, models.NoImportRun, file.StreamDailyStockPriceFromTSV(*loadPath), loadChunkSize)
+		if err != nil {
+			log.Fatalf("Failed to load %s: %v", *loadPath, err)
+		}
+		log.Printf("Loaded %d daily stock prices from %s into the in-memory database", loadedCount, *loadPath)
+	}
+
+	writer := bufio.NewWriter(os.Stdout)
+
+	// 統計情報を表示
+	if *showStatistics {
+		if err := printStatistics(ctx, writer, repository, *stockID, *fromDate, *toDate, *asOf); err != nil {
+			log.Fatal(err)
+		}
+		if err := writer.Flush(); err != nil {
+			log.Fatalf("Failed to write output: %v", err)
+		}
+		return
+	}
+
+	// 検索条件を指定した場合は条件に一致する日次株価情報を取得する
+	if usesQueryFlags() {
+		if *afterCursor != "" {
+			log.Fatal("-after cannot be combined with query flags; use -offset instead")
+		}
+		query, err := parseDailyStockPriceQuery(queryFlags{
+			stockIDs:       *stockID,
+			fromDate:       *fromDate,
+			toDate:         *toDate,
+			minPrice:       *minPrice,
+			maxPrice:       *maxPrice,
+			asOf:           *asOf,
+			sortKey:        *sortKey,
+			descending:     *descending,
+			limit:          *limit,
+			offset:         *offset,
+			distinctStocks: *distinctStocks,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := printQueryResult(ctx, writer, repository, query, *outputFormat); err != nil {
+			log.Fatal(err)
+		}
+		if err := writer.Flush(); err != nil {
+			log.Fatalf("Failed to write output: %v", err)
+		}
+		return
+	}
+
+	// 取得を開始するカーソルを解析
+	after, err := models.ParseDailyStockPriceCursor(*afterCursor)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *limit < 0 {
+		log.Fatalf("Invalid -limit: %d", *limit)
+	}
+
+	stocks, err := loadStocks(ctx, repository)
+	if err != nil {
+		log.Fatalf("Failed to retrieve stock master from database: %v", err)
+	}
+
+	// データベースからデータを取得
+	// -limit を指定しない場合は全件をメモリ上に保持せずに1件ずつ書き込む
+	dailyPrices := repository.IterateDailyStockPrices(ctx, after)
+	var page models.DailyStockPricePage
+	if *limit > 0 {
+		page, err = repository.GetDailyStockPricesPage(ctx, after, *limit)
+		if err != nil {
+			log.Fatalf("Failed to retrieve data from database: %v", err)
+		}
+		dailyPrices = models.DailyStockPriceSeq(page.Prices)
 	}
 
 	// 結果を表示
-	fmt.Printf("Found %d daily stock prices in database:\n\n", len(dailyPrices))
-	fmt.Println("StockID\tDate\t\tPrice")
-	fmt.Println("-------\t----------\t-------")
+	if err := writeDailyStockPrices(writer, dailyPrices, stocks, *outputFormat); err != nil {
+		log.Fatalf("Failed to write output: %v", err)
+	}
+	if err := writer.Flush(); err != nil {
+		log.Fatalf("Failed to write output: %v", err)
+	}
+
+	// 続きがある場合は次のページのカーソルを表示
+	if page.HasNext() {
+		log.Printf("More daily stock prices are available; continue with -after %s", page.Next)
+	}
+}
+
+// openRepository はデータベースを開き、日次株価情報のリポジトリを返します。
+// 参照のみのため、データベースファイルの作成やマイグレーションは行いません。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - dbPath: SQLiteデータベースファイルのパス（memory.DatabasePath の場合はメモリ上のリポジトリを使用）
+//   - busyTimeout: 他のプロセスがロックを保持している場合にロックの解放を待つ時間
+//
+// 戻り値:
+//   - リポジトリ（使い終わったら Close を呼び出す）
+//   - エラー（データベースファイルが存在しない場合、開けない場合やスキーマが最新でない場合）
+func openRepository(ctx context.Context, dbPath string, busyTimeout time.Duration) (models.StockPriceRepository, error) {
+	if dbPath == memory.DatabasePath {
+		return memory.NewInMemoryStockPriceRepository(), nil
+	}
+	options := db.DefaultSQLiteOptions()
+	options.BusyTimeout = busyTimeout
+	return db.NewSQLiteStockPriceRepositoryWithOptions(ctx, dbPath, options)
+}
 
-	for _, price := range dailyPrices {
-		fmt.Printf("%s\t%s\t%.2f\n",
-			price.StockPrice.StockID,
-			price.PriceDate.Format("2006-01-02"),
-			price.StockPrice.Price,
-		)
+// newCommandContext は Ctrl-C（SIGINT）を受け取るとキャンセルされ、
+// timeout が正の場合はその時間が経過するとキャンセルされるコンテキストを作成します。
+//
+// 引数:
+//   - timeout: 実行時間の上限（0以下の場合は上限なし）
+//
+// 戻り値:
+//   - コンテキスト
+//   - コンテキストを解放する関数（使い終わったら呼び出す）
+func newCommandContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// loadStocks はリポジトリから全ての銘柄マスタを取得し、銘柄コードをキーとするマップにします。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - repository: 銘柄マスタを取得するリポジトリ
+//
+// 戻り値:
+//   - 銘柄コードをキーとする銘柄マスタ
+//   - エラー（データ取得に失敗した場合）
+func loadStocks(ctx context.Context, repository models.StockPriceRepository) (map[string]models.Stock, error) {
+	stocks, err := repository.GetStocks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stocksByID := make(map[string]models.Stock, len(stocks))
+	for _, stock := range stocks {
+		stocksByID[stock.StockID] = stock
+	}
+	return stocksByID, nil
+}
+
+// printStatistics は指定された銘柄と日付範囲の日次株価統計情報を書き込みます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - writer: 書き込み先
+//   - repository: 日次株価情報を取得するリポジトリ
+//   - stockID: 銘柄コード
+//   - fromDate: 日付範囲の始点（YYYY-MM-DD形式、この日付を含む）
+//   - toDate: 日付範囲の終点（YYYY-MM-DD形式、この日付を含む）
+//   - asOf: 日次株価情報の版の日時（RFC 3339形式、空の場合は現在の値）
+//
+// 戻り値:
+//   - エラー（引数が不正な場合や統計情報の取得・書き込みに失敗した場合）
+func printStatistics(ctx context.Context, writer io.Writer, repository models.StockPriceRepository, stockID string, fromDate string, toDate string, asOf string) error {
+	if stockID == "" || fromDate == "" || toDate == "" {
+		return fmt.Errorf("-stats requires -stock, -from and -to")
+	}
+	startDate, err := time.Parse(outputDateFormat, fromDate)
+	if err != nil {
+		return fmt.Errorf("invalid -from date %q: %w", fromDate, err)
+	}
+	endDate, err := time.Parse(outputDateFormat, toDate)
+	if err != nil {
+		return fmt.Errorf("invalid -to date %q: %w", toDate, err)
+	}
+	var asOfTime time.Time
+	if asOf != "" {
+		if asOfTime, err = time.Parse(time.RFC3339, asOf); err != nil {
+			return fmt.Errorf("invalid -as-of time %q: %w", asOf, err)
+		}
+	}
+
+	statistics, err := controller.GetStockPriceStatisticsByDateRange(ctx, repository, stockID, startDate, endDate, asOfTime)
+	if err != nil {
+		return err
+	}
+	if err := writeDailyStockPriceStatistics(writer, statistics); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// usesQueryFlags は日次株価情報の一覧を絞り込むコマンドライン引数が指定されたかどうかを返します。
+//
+// 戻り値:
+//   - queryFlagNames のいずれかが指定された場合は true
+func usesQueryFlags() bool {
+	used := false
+	flag.Visit(func(f *flag.Flag) {
+		if slices.Contains(queryFlagNames, f.Name) {
+			used = true
+		}
+	})
+	return used
+}
+
+// printQueryResult は検索条件に一致する日次株価情報、または銘柄の一覧を書き込みます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - writer: 書き込み先
+//   - repository: 日次株価情報を取得するリポジトリ
+//   - query: 検索条件
+//   - outputFormat: 出力形式
+//
+// 戻り値:
+//   - エラー（データの取得や書き込みに失敗した場合）
+func printQueryResult(ctx context.Context, writer io.Writer, repository models.StockPriceRepository, query models.DailyStockPriceQuery, outputFormat string) error {
+	stocks, err := loadStocks(ctx, repository)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve stock master from database: %w", err)
+	}
+	result, err := repository.QueryDailyStockPrices(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve data from database: %w", err)
+	}
+
+	if query.DistinctStocks {
+		err = writeStocks(writer, result.StockIDs, stocks, outputFormat)
+	} else {
+		err = writeDailyStockPrices(writer, models.DailyStockPriceSeq(result.Prices), stocks, outputFormat)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
 	}
+	return nil
 }
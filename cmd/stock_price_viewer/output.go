@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 出力形式
+const (
+	outputFormatTable = "table"
+	outputFormatTSV   = "tsv"
+	outputFormatCSV   = "csv"
+	outputFormatJSON  = "json"
+)
+
+// 出力する日付のフォーマット
+const outputDateFormat = "2006-01-02"
+
+// 機械可読な出力形式のヘッダー行（stock_price_importer のヘッダー行として認識される列名）
+var outputHeader = []string{"stock_id", "date", "price"}
+
+// 機械可読な出力形式で銘柄を一覧にする場合のヘッダー行
+var outputStockHeader = []string{"stock_id", "name", "market"}
+
+// JSON形式で出力する1行分の銘柄
+type stockJSON struct {
+	StockID string `json:"stock_id"`
+	Name    string `json:"name,omitempty"`
+	Market  string `json:"market,omitempty"`
+}
+
+// JSON形式で出力する1行分の日次株価情報
+type dailyStockPriceJSON struct {
+	StockID string       `json:"stock_id"`
+	Name    string       `json:"name,omitempty"`
+	Market  string       `json:"market,omitempty"`
+	Date    string       `json:"date"`
+	Price   models.Price `json:"price"`
+}
+
+// writeDailyStockPrices はイテレータから読み込んだ日次株価情報を指定された形式で1件ずつ書き込みます。
+//   - table: 人が読むための表形式
+//   - tsv, csv: ヘッダー行付きの区切り形式（stock_price_importer でそのまま取り込める）
+//   - json: 1行に1件のJSON（NDJSON）
+//
+// table と json では銘柄マスタに登録された銘柄名と上場市場も出力します。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - stocks: 銘柄コードをキーとする銘柄マスタ
+//   - outputFormat: 出力形式
+//
+// 戻り値:
+//   - エラー（出力形式が不正な場合やイテレータがエラーを返した場合、書き込みに失敗した場合）
+func writeDailyStockPrices(writer io.Writer, dailyPrices iter.Seq2[models.DailyStockPrice, error], stocks map[string]models.Stock, outputFormat string) error {
+	switch strings.ToLower(outputFormat) {
+	case outputFormatTable:
+		return writeDailyStockPricesTable(writer, dailyPrices, stocks)
+	case outputFormatTSV:
+		return writeDailyStockPricesDelimited(writer, dailyPrices, '\t')
+	case outputFormatCSV:
+		return writeDailyStockPricesDelimited(writer, dailyPrices, ',')
+	case outputFormatJSON:
+		return writeDailyStockPricesJSON(writer, dailyPrices, stocks)
+	default:
+		return fmt.Errorf("unknown output format %q (expected %s, %s, %s or %s)", outputFormat, outputFormatTable, outputFormatTSV, outputFormatCSV, outputFormatJSON)
+	}
+}
+
+// writeDailyStockPricesTable は日次株価情報を人が読むための表形式で書き込みます。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - stocks: 銘柄コードをキーとする銘柄マスタ
+//
+// 戻り値:
+//   - エラー（イテレータがエラーを返した場合や書き込みに失敗した場合）
+func writeDailyStockPricesTable(writer io.Writer, dailyPrices iter.Seq2[models.DailyStockPrice, error], stocks map[string]models.Stock) error {
+	if _, err := fmt.Fprint(writer, "Daily stock prices in database:\n\n"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(writer, "StockID\tName\tMarket\tDate\t\tPrice"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(writer, "-------\t----\t------\t----------\t-------"); err != nil {
+		return err
+	}
+
+	count := 0
+	for price, err := range dailyPrices {
+		if err != nil {
+			return err
+		}
+		stock := stocks[price.StockPrice.StockID]
+		_, err = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n",
+			price.StockPrice.StockID,
+			stock.Name,
+			stock.Market,
+			price.PriceDate.Format(outputDateFormat),
+			price.StockPrice.Price.StringFixed(2),
+		)
+		if err != nil {
+			return err
+		}
+		count++
+	}
+
+	_, err := fmt.Fprintf(writer, "\nFound %d daily stock prices\n", count)
+	return err
+}
+
+// writeDailyStockPricesDelimited は日次株価情報をヘッダー行付きの区切り形式で書き込みます。
+// 株価は丸めずに、元の値を復元できる最短の表記で出力します。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - delimiter: 区切り文字
+//
+// 戻り値:
+//   - エラー（イテレータがエラーを返した場合や書き込みに失敗した場合）
+func writeDailyStockPricesDelimited(writer io.Writer, dailyPrices iter.Seq2[models.DailyStockPrice, error], delimiter rune) error {
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Comma = delimiter
+
+	if err := csvWriter.Write(outputHeader); err != nil {
+		return err
+	}
+	for price, err := range dailyPrices {
+		if err != nil {
+			return err
+		}
+		record := []string{
+			price.StockPrice.StockID,
+			price.PriceDate.Format(outputDateFormat),
+			price.StockPrice.Price.String(),
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// writeDailyStockPricesJSON は日次株価情報を1行に1件のJSON（NDJSON）で書き込みます。
+// 銘柄名と上場市場は銘柄マスタに登録されている場合のみ出力します。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - stocks: 銘柄コードをキーとする銘柄マスタ
+//
+// 戻り値:
+//   - エラー（イテレータがエラーを返した場合や書き込みに失敗した場合）
+func writeDailyStockPricesJSON(writer io.Writer, dailyPrices iter.Seq2[models.DailyStockPrice, error], stocks map[string]models.Stock) error {
+	encoder := json.NewEncoder(writer)
+	for price, err := range dailyPrices {
+		if err != nil {
+			return err
+		}
+		stock := stocks[price.StockPrice.StockID]
+		record := dailyStockPriceJSON{
+			StockID: price.StockPrice.StockID,
+			Name:    stock.Name,
+			Market:  stock.Market,
+			Date:    price.PriceDate.Format(outputDateFormat),
+			Price:   price.StockPrice.Price,
+		}
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeStocks は銘柄コードの一覧を銘柄マスタの銘柄名と上場市場と共に指定された形式で書き込みます。
+// 出力形式は writeDailyStockPrices と同じです。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - stockIDs: 銘柄コードの配列
+//   - stocks: 銘柄コードをキーとする銘柄マスタ
+//   - outputFormat: 出力形式
+//
+// 戻り値:
+//   - エラー（出力形式が不正な場合や書き込みに失敗した場合）
+func writeStocks(writer io.Writer, stockIDs []string, stocks map[string]models.Stock, outputFormat string) error {
+	switch strings.ToLower(outputFormat) {
+	case outputFormatTable:
+		if _, err := fmt.Fprint(writer, "Stocks with daily stock prices in database:\n\nStockID\tName\tMarket\n-------\t----\t------\n"); err != nil {
+			return err
+		}
+		for _, stockID := range stockIDs {
+			stock := stocks[stockID]
+			if _, err := fmt.Fprintf(writer, "%s\t%s\t%s\n", stockID, stock.Name, stock.Market); err != nil {
+				return err
+			}
+		}
+		_, err := fmt.Fprintf(writer, "\nFound %d stocks\n", len(stockIDs))
+		return err
+	case outputFormatTSV, outputFormatCSV:
+		csvWriter := csv.NewWriter(writer)
+		if strings.ToLower(outputFormat) == outputFormatTSV {
+			csvWriter.Comma = '\t'
+		}
+		if err := csvWriter.Write(outputStockHeader); err != nil {
+			return err
+		}
+		for _, stockID := range stockIDs {
+			stock := stocks[stockID]
+			if err := csvWriter.Write([]string{stockID, stock.Name, stock.Market}); err != nil {
+				return err
+			}
+		}
+		csvWriter.Flush()
+		return csvWriter.Error()
+	case outputFormatJSON:
+		encoder := json.NewEncoder(writer)
+		for _, stockID := range stockIDs {
+			stock := stocks[stockID]
+			if err := encoder.Encode(stockJSON{StockID: stockID, Name: stock.Name, Market: stock.Market}); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q (expected %s, %s, %s or %s)", outputFormat, outputFormatTable, outputFormatTSV, outputFormatCSV, outputFormatJSON)
+	}
+}
+
+// writeDailyStockPriceStatistics は日次株価統計情報を銘柄マスタの情報と共に人が読むための形式で書き込みます。
+// 過去の版で計算した統計情報は、その日時も書き込みます。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - statistics: 日次株価統計情報
+//
+// 戻り値:
+//   - エラー（書き込みに失敗した場合）
+func writeDailyStockPriceStatistics(writer io.Writer, statistics models.DailyStockPriceStatistics) error {
+	_, err := fmt.Fprintf(writer,
+		"Stock:\t\t%s\nMarket:\t\t%s\nSector:\t\t%s\nCurrency:\t%s\nPeriod:\t\t%s to %s\nAverage:\t%.2f\nMax:\t\t%.2f\nMin:\t\t%.2f\nStdDev:\t\t%.2f\n",
+		statistics.Stock.Label(),
+		statistics.Stock.Market,
+		statistics.Stock.Sector,
+		statistics.Stock.Currency,
+		statistics.StartDate.Format(outputDateFormat),
+		statistics.EndDate.Format(outputDateFormat),
+		statistics.Average,
+		statistics.Max,
+		statistics.Min,
+		statistics.StandardDeviation,
+	)
+	if err != nil || statistics.AsOf.IsZero() {
+		return err
+	}
+	_, err = fmt.Fprintf(writer, "As of:\t\t%s\n", statistics.AsOf.Format(time.RFC3339))
+	return err
+}
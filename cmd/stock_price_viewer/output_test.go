@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+func TestWriteDailyStockPrices(t *testing.T) {
+	// Arrange
+	dailyPrices := []models.DailyStockPrice{
+		{
+			PriceDate:  time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
+			StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)},
+		},
+		{
+			PriceDate:  time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC),
+			StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903125, 3)},
+		},
+	}
+	stocks := map[string]models.Stock{
+		"7203": {StockID: "7203", Name: "トヨタ自動車", Market: "TSE Prime", Currency: "JPY"},
+	}
+	testCases := map[string]string{
+		outputFormatTSV: "stock_id\tdate\tprice\n7203\t2025-02-04\t2873\n7203\t2025-02-05\t2903.125\n",
+		outputFormatCSV: "stock_id,date,price\n7203,2025-02-04,2873\n7203,2025-02-05,2903.125\n",
+		outputFormatJSON: "{\"stock_id\":\"7203\",\"name\":\"トヨタ自動車\",\"market\":\"TSE Prime\",\"date\":\"2025-02-04\",\"price\":2873}\n" +
+			"{\"stock_id\":\"7203\",\"name\":\"トヨタ自動車\",\"market\":\"TSE Prime\",\"date\":\"2025-02-05\",\"price\":2903.125}\n",
+	}
+
+	for outputFormat, expected := range testCases {
+		t.Run(outputFormat, func(t *testing.T) {
+			// Act
+			var buffer bytes.Buffer
+			err := writeDailyStockPrices(&buffer, models.DailyStockPriceSeq(dailyPrices), stocks, outputFormat)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if buffer.String() != expected {
+				t.Errorf("Output mismatch.\nExpected: %q\nGot: %q", expected, buffer.String())
+			}
+		})
+	}
+}
+
+func TestWriteDailyStockPrices_UnknownFormat(t *testing.T) {
+	// Act
+	err := writeDailyStockPrices(&bytes.Buffer{}, nil, nil, "xml")
+
+	// Assert
+	if err == nil {
+		t.Error("Expected an error for an unknown format, but got nil")
+	}
+}
+
+func TestWriteDailyStockPrices_Table(t *testing.T) {
+	// Arrange
+	dailyPrices := []models.DailyStockPrice{
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8000005, 3)}},
+	}
+	stocks := map[string]models.Stock{
+		"7203": {StockID: "7203", Name: "トヨタ自動車", Market: "TSE Prime"},
+	}
+	expected := "Daily stock prices in database:\n\n" +
+		"StockID\tName\tMarket\tDate\t\tPrice\n" +
+		"-------\t----\t------\t----------\t-------\n" +
+		"7203\tトヨタ自動車\tTSE Prime\t2025-02-04\t2873.00\n" +
+		"9984\t\t\t2025-02-04\t8000.01\n" +
+		"\nFound 2 daily stock prices\n"
+
+	// Act
+	var buffer bytes.Buffer
+	err := writeDailyStockPrices(&buffer, models.DailyStockPriceSeq(dailyPrices), stocks, outputFormatTable)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if buffer.String() != expected {
+		t.Errorf("Output mismatch.\nExpected: %q\nGot: %q", expected, buffer.String())
+	}
+}
+
+func TestWriteDailyStockPrices_IteratorError(t *testing.T) {
+	// Arrange
+	iteratorErr := errors.New("query failed")
+	dailyPrices := func(yield func(models.DailyStockPrice, error) bool) {
+		yield(models.DailyStockPrice{}, iteratorErr)
+	}
+
+	for _, outputFormat := range []string{outputFormatTable, outputFormatTSV, outputFormatJSON} {
+		t.Run(outputFormat, func(t *testing.T) {
+			// Act
+			err := writeDailyStockPrices(&bytes.Buffer{}, dailyPrices, nil, outputFormat)
+
+			// Assert
+			if !errors.Is(err, iteratorErr) {
+				t.Errorf("Expected the iterator error, but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestWriteStocks(t *testing.T) {
+	// Arrange - 銘柄マスタに銘柄名のない銘柄を含む
+	stockIDs := []string{"7203", "9984"}
+	stocks := map[string]models.Stock{
+		"7203": {StockID: "7203", Name: "トヨタ自動車", Market: "TSE Prime"},
+	}
+	testCases := map[string]string{
+		outputFormatTSV:  "stock_id\tname\tmarket\n7203\tトヨタ自動車\tTSE Prime\n9984\t\t\n",
+		outputFormatCSV:  "stock_id,name,market\n7203,トヨタ自動車,TSE Prime\n9984,,\n",
+		outputFormatJSON: "{\"stock_id\":\"7203\",\"name\":\"トヨタ自動車\",\"market\":\"TSE Prime\"}\n{\"stock_id\":\"9984\"}\n",
+	}
+
+	for outputFormat, expected := range testCases {
+		t.Run(outputFormat, func(t *testing.T) {
+			// Act
+			var buffer bytes.Buffer
+			err := writeStocks(&buffer, stockIDs, stocks, outputFormat)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if buffer.String() != expected {
+				t.Errorf("Output mismatch.\nExpected: %q\nGot: %q", expected, buffer.String())
+			}
+		})
+	}
+}
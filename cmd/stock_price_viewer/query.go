@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 指定すると日次株価情報の一覧を検索条件で絞り込むコマンドライン引数の名前（-limit は検索条件がなくても使用できる）
+var queryFlagNames = []string{"stock", "from", "to", "min-price", "max-price", "as-of", "sort", "desc", "offset", "distinct-stocks"}
+
+// 日次株価情報の一覧を絞り込むコマンドライン引数の値
+type queryFlags struct {
+	// カンマ区切りの銘柄コード
+	stockIDs string
+	// 日付の始点（YYYY-MM-DD形式）
+	fromDate string
+	// 日付の終点（YYYY-MM-DD形式）
+	toDate string
+	// 株価の下限
+	minPrice string
+	// 株価の上限
+	maxPrice string
+	// 版の日時（RFC 3339形式）
+	asOf string
+	// 並び順のキー
+	sortKey string
+	// 並び順を逆にするかどうか
+	descending bool
+	// 最大件数
+	limit int
+	// 読み飛ばす件数
+	offset int
+	// 銘柄コードのみを一覧にするかどうか
+	distinctStocks bool
+}
+
+// parseDailyStockPriceQuery はコマンドライン引数の値を日次株価情報の検索条件に変換します。
+// 空の値は条件を指定しないことを示します。
+//
+// 引数:
+//   - flags: コマンドライン引数の値
+//
+// 戻り値:
+//   - 検索条件
+//   - エラー（値の形式が不正な場合や検索条件が矛盾している場合）
+func parseDailyStockPriceQuery(flags queryFlags) (models.DailyStockPriceQuery, error) {
+	query := models.DailyStockPriceQuery{
+		Descending:     flags.descending,
+		Limit:          flags.limit,
+		Offset:         flags.offset,
+		DistinctStocks: flags.distinctStocks,
+	}
+	for _, stockID := range strings.Split(flags.stockIDs, ",") {
+		if stockID = strings.TrimSpace(stockID); stockID != "" {
+			query.StockIDs = append(query.StockIDs, stockID)
+		}
+	}
+
+	var err error
+	if flags.fromDate != "" {
+		if query.From, err = time.Parse(outputDateFormat, flags.fromDate); err != nil {
+			return models.DailyStockPriceQuery{}, fmt.Errorf("invalid -from date %q: %w", flags.fromDate, err)
+		}
+	}
+	if flags.toDate != "" {
+		if query.To, err = time.Parse(outputDateFormat, flags.toDate); err != nil {
+			return models.DailyStockPriceQuery{}, fmt.Errorf("invalid -to date %q: %w", flags.toDate, err)
+		}
+	}
+	if flags.asOf != "" {
+		if query.AsOf, err = time.Parse(time.RFC3339, flags.asOf); err != nil {
+			return models.DailyStockPriceQuery{}, fmt.Errorf("invalid -as-of time %q: %w", flags.asOf, err)
+		}
+	}
+	if query.MinPrice, err = parseOptionalPrice("-min-price", flags.minPrice); err != nil {
+		return models.DailyStockPriceQuery{}, err
+	}
+	if query.MaxPrice, err = parseOptionalPrice("-max-price", flags.maxPrice); err != nil {
+		return models.DailyStockPriceQuery{}, err
+	}
+	if query.SortBy, err = models.ParseDailyStockPriceSortKey(flags.sortKey); err != nil {
+		return models.DailyStockPriceQuery{}, fmt.Errorf("invalid -sort: %w", err)
+	}
+
+	if err := query.Validate(); err != nil {
+		return models.DailyStockPriceQuery{}, err
+	}
+	return query, nil
+}
+
+// parseOptionalPrice は株価の範囲を指定するコマンドライン引数の値を変換します。
+//
+// 引数:
+//   - flagName: エラーメッセージに含めるコマンドライン引数の名前
+//   - value: 株価の文字列
+//
+// 戻り値:
+//   - 株価（空文字列の場合は nil）
+//   - エラー（株価の形式が不正な場合）
+func parseOptionalPrice(flagName string, value string) (*models.Price, error) {
+	if value == "" {
+		return nil, nil
+	}
+	price, err := models.ParsePrice(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", flagName, value, err)
+	}
+	return &price, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+func TestParseDailyStockPriceQuery(t *testing.T) {
+	// Arrange
+	flags := queryFlags{
+		stockIDs:   "7203, 9984,",
+		fromDate:   "2025-02-03",
+		maxPrice:   "2903.50",
+		asOf:       "2025-03-01T09:00:00+09:00",
+		sortKey:    "PRICE",
+		descending: true,
+		limit:      10,
+		offset:     20,
+	}
+
+	// Act
+	query, err := parseDailyStockPriceQuery(flags)
+
+	// Assert - 指定しなかった条件は制限しない
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	maxPrice := models.NewPrice(29035, 1)
+	expected := models.DailyStockPriceQuery{
+		StockIDs:   []string{"7203", "9984"},
+		From:       time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC),
+		MaxPrice:   &maxPrice,
+		AsOf:       time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		SortBy:     models.SortByPrice,
+		Descending: true,
+		Limit:      10,
+		Offset:     20,
+	}
+	if !query.AsOf.Equal(expected.AsOf) {
+		t.Errorf("Expected as-of %v, but got %v", expected.AsOf, query.AsOf)
+	}
+	query.AsOf = expected.AsOf
+	if !reflect.DeepEqual(query, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, query)
+	}
+}
+
+func TestParseDailyStockPriceQuery_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags queryFlags
+	}{
+		{name: "invalid date", flags: queryFlags{fromDate: "2025/02/03"}},
+		{name: "invalid price", flags: queryFlags{minPrice: "1e3"}},
+		{name: "invalid as-of", flags: queryFlags{asOf: "2025-03-01"}},
+		{name: "unknown sort key", flags: queryFlags{sortKey: "volume"}},
+		{name: "reversed price range", flags: queryFlags{minPrice: "3000", maxPrice: "2000"}},
+		{name: "negative offset", flags: queryFlags{offset: -1}},
+		{name: "distinct stocks sorted by date", flags: queryFlags{sortKey: "date", distinctStocks: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := parseDailyStockPriceQuery(tt.flags)
+
+			// Assert
+			if err == nil {
+				t.Error("Expected an error, but got nil")
+			}
+		})
+	}
+}
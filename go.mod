@@ -4,9 +4,13 @@ go 1.23
 
 toolchain go1.23.2
 
+require (
+	github.com/glebarez/go-sqlite v1.22.0
+	golang.org/x/text v0.21.0
+)
+
 require (
 	github.com/dustin/go-humanize v1.0.1 // indirect
-	github.com/glebarez/go-sqlite v1.22.0 // indirect
 	github.com/google/uuid v1.5.0 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
@@ -1,37 +1,44 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/toriwasa/sqlite-playground/internal/domain/models"
-	"github.com/toriwasa/sqlite-playground/internal/infrastructures/db"
 	"github.com/toriwasa/sqlite-playground/internal/usecase"
 )
 
 // GetStockPriceStatisticsByDateRange は指定された銘柄コードと日付範囲に一致する
-// 日次株価情報の統計を計算します。
+// 日次株価情報の統計を計算し、銘柄マスタの情報を付加します。
+// asOf を指定すると、その日時に保存されていた日次株価情報で計算し、過去に出力した統計を再現できます。
 //
 // 引数:
-//   - dbPath: SQLiteデータベースファイルのパス
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - repository: 日次株価情報を取得するリポジトリ
 //   - stockID: 取得する銘柄コード
 //   - startDate: 取得する日付の始点（この日付を含む）
 //   - endDate: 取得する日付の終点（この日付を含む）
+//   - asOf: 取得する日次株価情報の版の日時（ゼロ値の場合は現在の値）
 //
 // 戻り値:
 //   - 日次株価統計情報
 //   - エラー（データ取得や計算に失敗した場合）
-func GetStockPriceStatisticsByDateRange(dbPath string, stockID string, startDate time.Time, endDate time.Time) (models.DailyStockPriceStatistics, error) {
-	// インフラストラクチャ層から日次株価情報を取得
-	dailyPrices, err := db.GetDailyStockPricesByDateRange(dbPath, stockID, startDate, endDate)
+func GetStockPriceStatisticsByDateRange(ctx context.Context, repository models.StockPriceRepository, stockID string, startDate time.Time, endDate time.Time, asOf time.Time) (models.DailyStockPriceStatistics, error) {
+	// リポジトリから日次株価情報を取得
+	dailyPrices, err := repository.GetDailyStockPricesByDateRange(ctx, stockID, startDate, endDate, asOf)
 	if err != nil {
 		return models.DailyStockPriceStatistics{}, fmt.Errorf("failed to get daily stock prices: %w", err)
 	}
 
 	// データが存在しない場合のエラー処理
 	if len(dailyPrices) == 0 {
-		return models.DailyStockPriceStatistics{}, fmt.Errorf("no stock prices found for stock ID %s between %s and %s",
-			stockID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+		asOfDescription := ""
+		if !asOf.IsZero() {
+			asOfDescription = " as of " + asOf.Format(time.RFC3339)
+		}
+		return models.DailyStockPriceStatistics{}, fmt.Errorf("no stock prices found for stock ID %s between %s and %s%s",
+			stockID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), asOfDescription)
 	}
 
 	// ユースケース層で統計情報を計算
@@ -40,5 +47,16 @@ func GetStockPriceStatisticsByDateRange(dbPath string, stockID string, startDate
 		return models.DailyStockPriceStatistics{}, fmt.Errorf("failed to calculate statistics: %w", err)
 	}
 
+	// 銘柄マスタの情報を付加（登録されていない場合は銘柄コードのみ）
+	stock, found, err := repository.GetStock(ctx, stockID)
+	if err != nil {
+		return models.DailyStockPriceStatistics{}, fmt.Errorf("failed to get stock: %w", err)
+	}
+	if !found {
+		stock = models.Stock{StockID: stockID}
+	}
+	statistics.Stock = stock
+	statistics.AsOf = asOf
+
 	return statistics, nil
 }
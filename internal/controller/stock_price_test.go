@@ -1,17 +1,15 @@
 package controller
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/toriwasa/sqlite-playground/internal/domain/models"
-	"github.com/toriwasa/sqlite-playground/internal/infrastructures/db"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/memory"
 )
 
 func TestGetStockPriceStatisticsByDateRange(t *testing.T) {
-	// テスト用のデータベースパス
-	dbPath := "../infrastructures/db/test_stock_price_controller.db"
-
 	// テスト用の日付
 	startDate := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)
@@ -23,45 +21,51 @@ func TestGetStockPriceStatisticsByDateRange(t *testing.T) {
 			PriceDate: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   2800,
+				Price:   models.NewPrice(2800, 0),
 			},
 		},
 		{
 			PriceDate: time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   2850,
+				Price:   models.NewPrice(2850, 0),
 			},
 		},
 		{
 			PriceDate: time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   2900,
+				Price:   models.NewPrice(2900, 0),
 			},
 		},
 		{
 			PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   2950,
+				Price:   models.NewPrice(2950, 0),
 			},
 		},
 		{
 			PriceDate: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   3000,
+				Price:   models.NewPrice(3000, 0),
 			},
 		},
 	}
 
 	// テスト用のデータベースを初期化
-	err := setupTestDatabase(dbPath, testPrices)
+	repository, err := setupTestDatabase(testPrices)
 	if err != nil {
 		t.Fatalf("Failed to setup test database: %v", err)
 	}
-	defer cleanupTestDatabase(dbPath)
+	defer repository.Close()
+
+	// テスト用の銘柄マスタを登録
+	testStock := models.Stock{StockID: stockID, Name: "トヨタ自動車", Market: "TSE Prime", Sector: "輸送用機器", Currency: "JPY"}
+	if _, err := repository.UpsertStocks(context.Background(), []models.Stock{testStock}); err != nil {
+		t.Fatalf("Failed to register stock: %v", err)
+	}
 
 	// テストケース
 	testCases := []struct {
@@ -111,7 +115,7 @@ func TestGetStockPriceStatisticsByDateRange(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Act
-			stats, err := GetStockPriceStatisticsByDateRange(dbPath, tc.stockID, tc.startDate, tc.endDate)
+			stats, err := GetStockPriceStatisticsByDateRange(context.Background(), repository, tc.stockID, tc.startDate, tc.endDate, time.Time{})
 
 			// Assert
 			if tc.wantErr {
@@ -134,6 +138,11 @@ func TestGetStockPriceStatisticsByDateRange(t *testing.T) {
 				t.Errorf("Expected stock ID %s, but got %s", tc.stockID, stats.StockID)
 			}
 
+			// 銘柄マスタの情報の検証
+			if stats.Stock != testStock {
+				t.Errorf("Expected stock %+v, but got %+v", testStock, stats.Stock)
+			}
+
 			// 日付範囲の検証
 			if stats.StartDate.Before(tc.startDate) {
 				t.Errorf("Start date %v is before requested start date %v", stats.StartDate, tc.startDate)
@@ -156,16 +165,61 @@ func TestGetStockPriceStatisticsByDateRange(t *testing.T) {
 	}
 }
 
-// テスト用のデータベースをセットアップする関数
-func setupTestDatabase(dbPath string, prices []models.DailyStockPrice) error {
-	// インフラストラクチャ層の関数を使用してテーブルを初期化
-	return db.InitializeDailyStockPriceTable(dbPath, prices)
+func TestGetStockPriceStatisticsByDateRange_AsOf(t *testing.T) {
+	// Arrange - 株価を訂正する前の日時を記録する
+	date := func(day int) time.Time { return time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC) }
+	repository, err := setupTestDatabase([]models.DailyStockPrice{
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2800, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(3000, 0)}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to setup test database: %v", err)
+	}
+	defer repository.Close()
+	time.Sleep(5 * time.Millisecond)
+	beforeCorrection := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	correction := func(yield func(models.DailyStockPrice, error) bool) {
+		yield(models.DailyStockPrice{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2900, 0)}}, nil)
+	}
+	if _, err := repository.UpsertDailyStockPricesFromSeq(context.Background(), models.NoImportRun, correction, 1, models.ConflictPolicyOverwrite); err != nil {
+		t.Fatalf("Failed to correct price: %v", err)
+	}
+
+	// Act
+	current, currentErr := GetStockPriceStatisticsByDateRange(context.Background(), repository, "7203", date(1), date(28), time.Time{})
+	past, pastErr := GetStockPriceStatisticsByDateRange(context.Background(), repository, "7203", date(1), date(28), beforeCorrection)
+	_, emptyErr := GetStockPriceStatisticsByDateRange(context.Background(), repository, "7203", date(1), date(28), beforeCorrection.Add(-time.Hour))
+
+	// Assert - 訂正前の日時を指定すると訂正前の株価で計算される
+	if currentErr != nil || pastErr != nil {
+		t.Fatalf("Expected no error, but got: %v, %v", currentErr, pastErr)
+	}
+	if current.Average != 2850 || !current.AsOf.IsZero() {
+		t.Errorf("Expected the current average 2850, but got %+v", current)
+	}
+	if past.Average != 2900 || !past.AsOf.Equal(beforeCorrection) {
+		t.Errorf("Expected the average 2900 as of %v, but got %+v", beforeCorrection, past)
+	}
+	if emptyErr == nil {
+		t.Error("Expected an error before the prices were recorded, but got nil")
+	}
 }
 
-// テスト用のデータベースをクリーンアップする関数
-func cleanupTestDatabase(dbPath string) {
-	// ファイルを削除
-	// Note: 実際のテストでは以下の行のコメントを外して有効にする
-	// 今回はテスト後にファイルを残しておくためコメントアウトしている
-	// os.Remove(dbPath)
+// テスト用のデータベースをセットアップする関数
+// ファイルを作成しないよう、メモリ上のリポジトリを使用する
+func setupTestDatabase(prices []models.DailyStockPrice) (*memory.InMemoryStockPriceRepository, error) {
+	repository := memory.NewInMemoryStockPriceRepository()
+	dailyPriceSeq := func(yield func(models.DailyStockPrice, error) bool) {
+		for _, price := range prices {
+			if !yield(price, nil) {
+				return
+			}
+		}
+	}
+	if _, err := repository.InitializeDailyStockPriceTableFromSeq(context.Background(), models.NoImportRun, dailyPriceSeq, max(len(prices), 1)); err != nil {
+		repository.Close()
+		return nil, err
+	}
+	return repository, nil
 }
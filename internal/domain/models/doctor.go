@@ -0,0 +1,84 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+)
+
+// データベースの検査で見つかった問題の重大度（値が大きいほど重大）
+type FindingSeverity int
+
+const (
+	// 確認を勧める（データは読み込めるが、取り込みの誤りの可能性がある）
+	SeverityInfo FindingSeverity = iota
+	// 取引所の営業日と矛盾するなど、データが誤っている可能性が高い
+	SeverityWarning
+	// データベースの破損や、読み込めない・あり得ない値
+	SeverityError
+)
+
+// String は重大度の名前を返します。
+//
+// 戻り値:
+//   - 重大度の名前（error, warning, info）
+func (s FindingSeverity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
+
+// データベースの検査項目
+type DoctorCheck string
+
+const (
+	// PRAGMA integrity_check
+	CheckIntegrity DoctorCheck = "integrity"
+	// PRAGMA foreign_key_check
+	CheckForeignKey DoctorCheck = "foreign-key"
+	// 0以下の株価
+	CheckNonPositivePrice DoctorCheck = "non-positive-price"
+	// YYYY-MM-DD形式として解釈できない日付
+	CheckInvalidDate DoctorCheck = "invalid-date"
+	// 土曜日・日曜日の株価
+	CheckWeekend DoctorCheck = "weekend"
+	// 取引所の休場日の株価
+	CheckHoliday DoctorCheck = "holiday"
+	// 営業日が長く欠けている期間
+	CheckGap DoctorCheck = "gap"
+	// 他の銘柄と同じ株価が続く系列
+	CheckDuplicateSeries DoctorCheck = "duplicate-series"
+)
+
+// データベースの検査で見つかった問題を示す構造体
+type DatabaseFinding struct {
+	// 重大度
+	Severity FindingSeverity
+	// 検査項目
+	Check DoctorCheck
+	// 問題のある銘柄コード（銘柄によらない問題の場合は空文字列）
+	StockID string
+	// 問題のある日付（データベースに保存されている文字列、日付によらない問題の場合は空文字列）
+	PriceDate string
+	// 問題の説明
+	Message string
+	// 安全に修復できるかどうか
+	Fixable bool
+	// 修復したかどうか
+	Fixed bool
+}
+
+// SortDatabaseFindings は問題を重大度の高い順に並べ替えます。
+// 重大度が同じ問題は元の順（検査した順）を保ちます。
+//
+// 引数:
+//   - findings: 並べ替える問題の配列（直接並べ替える）
+func SortDatabaseFindings(findings []DatabaseFinding) {
+	slices.SortStableFunc(findings, func(a, b DatabaseFinding) int {
+		return cmp.Compare(b.Severity, a.Severity)
+	})
+}
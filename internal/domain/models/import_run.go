@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// 日次株価情報を書き込む際に取り込み履歴に記録しないことを示す取り込みID
+const NoImportRun int64 = 0
+
+// 取り込みの状態
+type ImportRunStatus string
+
+const (
+	// 取り込み中（取り込みを実行したプロセスが強制終了した場合もこの状態のまま残る）
+	ImportRunRunning ImportRunStatus = "running"
+	// 全ての行を取り込んだ
+	ImportRunCompleted ImportRunStatus = "completed"
+	// エラーや中断で終了した（それまでにコミットされた行は取り込みに紐付いたまま残る）
+	ImportRunFailed ImportRunStatus = "failed"
+	// 取り消し済み
+	ImportRunUndone ImportRunStatus = "undone"
+)
+
+// 1回の取り込みの履歴を示す構造体
+type ImportRun struct {
+	// 取り込みID（1から始まる連番）
+	ID int64
+	// 入力ファイルのパス（コマンドラインで指定したもの）
+	SourcePath string
+	// 入力ファイルの内容の SHA-256（16進数の文字列、入力を最後まで読み込まなかった場合は空文字列）
+	SourceSHA256 string
+	// 取り込みの設定（コマンドラインで指定したオプション）
+	Options string
+	// 取り込みの状態
+	Status ImportRunStatus
+	// 開始日時
+	StartedAt time.Time
+	// 終了日時（取り込み中の場合はゼロ値）
+	FinishedAt time.Time
+	// 取り消した日時（取り消していない場合はゼロ値）
+	UndoneAt time.Time
+	// コミットされた行の取り込み結果
+	Result UpsertResult
+	// 上書きまたは削除した既存の行数（取り消すと復元される）
+	Replaced int
+}
+
+// 取り込みを取り消した結果を示す構造体
+type UndoImportResult struct {
+	// 取り込みで書き込まれ、削除した行数
+	Removed int
+	// 取り込みで上書きまたは削除され、復元した行数
+	Restored int
+}
+
+// ImportRunUndoConflictError は取り消す取り込みが書き込んだ行を、後の取り込みが変更している場合のエラー
+// 後の取り込みを先に取り消す必要がある
+type ImportRunUndoConflictError struct {
+	// 取り消そうとした取り込みID
+	RunID int64
+	// 行を変更した後の取り込みID（取り込み履歴に記録せずに書き込まれた場合は NoImportRun）
+	LaterRunID int64
+	// 変更された行の銘柄コード
+	StockID string
+	// 変更された行の日付
+	PriceDate time.Time
+}
+
+func (e *ImportRunUndoConflictError) Error() string {
+	changedBy := fmt.Sprintf("later import run %d; undo that run first", e.LaterRunID)
+	if e.LaterRunID == NoImportRun {
+		changedBy = "a write that was not recorded as an import run"
+	}
+	return fmt.Sprintf("cannot undo import run %d: %s on %s was changed by %s",
+		e.RunID, e.StockID, e.PriceDate.Format(time.DateOnly), changedBy)
+}
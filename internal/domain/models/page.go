@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// カーソル文字列で銘柄コードと日付を区切る文字
+const cursorSeparator = ":"
+
+// 日次株価情報を (銘柄コード, 日付) の順に読み進めるためのカーソル
+// カーソルより後ろ（カーソル自身は含まない）の行から取得を再開する
+// ゼロ値は先頭から取得することを示す
+type DailyStockPriceCursor struct {
+	// 最後に取得した行の銘柄コード
+	StockID string
+	// 最後に取得した行の日付
+	PriceDate time.Time
+}
+
+// IsZero はカーソルが先頭を示すかどうかを返します。
+//
+// 戻り値:
+//   - 先頭を示す場合は true
+func (c DailyStockPriceCursor) IsZero() bool {
+	return c.StockID == "" && c.PriceDate.IsZero()
+}
+
+// String はカーソルを "銘柄コード:YYYY-MM-DD" 形式の文字列に変換します。
+// ParseDailyStockPriceCursor で元のカーソルに戻せます。
+//
+// 戻り値:
+//   - カーソル文字列（先頭を示す場合は空文字列）
+func (c DailyStockPriceCursor) String() string {
+	if c.IsZero() {
+		return ""
+	}
+	return c.StockID + cursorSeparator + c.PriceDate.Format(time.DateOnly)
+}
+
+// ParseDailyStockPriceCursor は "銘柄コード:YYYY-MM-DD" 形式の文字列をカーソルに変換します。
+// 空文字列は先頭を示すカーソルに変換します。
+//
+// 引数:
+//   - value: カーソル文字列
+//
+// 戻り値:
+//   - カーソル
+//   - エラー（形式が不正な場合）
+func ParseDailyStockPriceCursor(value string) (DailyStockPriceCursor, error) {
+	if value == "" {
+		return DailyStockPriceCursor{}, nil
+	}
+
+	// 銘柄コードに区切り文字が含まれていても日付を切り出せるよう最後の区切り文字で分割
+	index := strings.LastIndex(value, cursorSeparator)
+	if index <= 0 {
+		return DailyStockPriceCursor{}, fmt.Errorf("invalid cursor %q (expected STOCK_ID%sYYYY-MM-DD)", value, cursorSeparator)
+	}
+	priceDate, err := time.Parse(time.DateOnly, value[index+1:])
+	if err != nil {
+		return DailyStockPriceCursor{}, fmt.Errorf("invalid cursor %q: %w", value, err)
+	}
+
+	return DailyStockPriceCursor{StockID: value[:index], PriceDate: priceDate}, nil
+}
+
+// 日次株価情報を1ページ分取得した結果を示す構造体
+type DailyStockPricePage struct {
+	// (銘柄コード, 日付) の順に並んだ日次株価情報
+	Prices []DailyStockPrice
+	// 次のページを取得するためのカーソル（最後のページの場合はゼロ値）
+	Next DailyStockPriceCursor
+}
+
+// HasNext は次のページがあるかどうかを返します。
+//
+// 戻り値:
+//   - 次のページがある場合は true
+func (p DailyStockPricePage) HasNext() bool {
+	return !p.Next.IsZero()
+}
@@ -0,0 +1,253 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// 価格の小数点以下の最大桁数
+const MaxPriceScale = 6
+
+// 10のべき乗（添え字が指数）
+var pow10 = [MaxPriceScale + 1]int64{1, 10, 100, 1_000, 10_000, 100_000, 1_000_000}
+
+// 固定小数点の価格を示す構造体
+// 値は ticks × 10^-scale で、末尾の0を取り除いた最小の桁数で保持するため、== で値を比較できる
+// ゼロ値は0を表す
+type Price struct {
+	// 10^-scale 単位で表した価格
+	ticks int64
+	// 小数点以下の桁数
+	scale int
+}
+
+// NewPrice は 10^-scale 単位で表した整数から価格を作成します。
+// 例: NewPrice(29035, 1) は 2903.5
+//
+// 引数:
+//   - ticks: 10^-scale 単位で表した価格
+//   - scale: 小数点以下の桁数（0以上 MaxPriceScale 以下、範囲外の場合は panic する）
+//
+// 戻り値:
+//   - 価格
+func NewPrice(ticks int64, scale int) Price {
+	if scale < 0 || scale > MaxPriceScale {
+		panic(fmt.Sprintf("models.NewPrice: scale %d out of range [0, %d]", scale, MaxPriceScale))
+	}
+	for scale > 0 && ticks%10 == 0 {
+		ticks /= 10
+		scale--
+	}
+	return Price{ticks: ticks, scale: scale}
+}
+
+// ParsePrice は10進数の文字列（例: "2903.5", "-12", ".25"）を価格に変換します。
+// 指数表記は受け付けず、末尾の0を除いて MaxPriceScale 桁より細かい端数は丸めずにエラーにします。
+//
+// 引数:
+//   - s: 価格の文字列
+//
+// 戻り値:
+//   - 価格
+//   - エラー（文字列が10進数でない場合、桁数が多すぎる場合や範囲外の場合）
+func ParsePrice(s string) (Price, error) {
+	digits := s
+	negative := false
+	if digits != "" && (digits[0] == '+' || digits[0] == '-') {
+		negative = digits[0] == '-'
+		digits = digits[1:]
+	}
+	integerPart, fractionPart, _ := strings.Cut(digits, ".")
+	if integerPart == "" && fractionPart == "" || !isDecimalDigits(integerPart) || !isDecimalDigits(fractionPart) {
+		return Price{}, fmt.Errorf("invalid price %q", s)
+	}
+
+	// 末尾の0は値に影響しないため取り除いてから桁数を確認
+	fractionPart = strings.TrimRight(fractionPart, "0")
+	if len(fractionPart) > MaxPriceScale {
+		return Price{}, fmt.Errorf("price %q has more than %d decimal places", s, MaxPriceScale)
+	}
+	ticks, err := strconv.ParseInt("0"+integerPart+fractionPart, 10, 64)
+	if err != nil {
+		return Price{}, fmt.Errorf("price %q is out of range", s)
+	}
+	if negative {
+		ticks = -ticks
+	}
+	return NewPrice(ticks, len(fractionPart)), nil
+}
+
+// isDecimalDigits は文字列が0から9の数字のみで構成されているかどうかを判定します（空文字列は true）。
+//
+// 引数:
+//   - s: 判定する文字列
+//
+// 戻り値:
+//   - 数字のみの場合は true
+func isDecimalDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Ticks は 10^-Scale() 単位で表した価格を返します。
+//
+// 戻り値:
+//   - 10^-Scale() 単位で表した価格
+func (p Price) Ticks() int64 {
+	return p.ticks
+}
+
+// Scale は価格を表すのに必要な小数点以下の桁数を返します。
+//
+// 戻り値:
+//   - 小数点以下の桁数
+func (p Price) Scale() int {
+	return p.scale
+}
+
+// TicksAt は 10^-scale 単位で表した価格を返します。
+//
+// 引数:
+//   - scale: 小数点以下の桁数（Scale() 以上 MaxPriceScale 以下）
+//
+// 戻り値:
+//   - 10^-scale 単位で表した価格
+//   - エラー（scale が Scale() より小さく端数を失う場合や、範囲外になる場合）
+func (p Price) TicksAt(scale int) (int64, error) {
+	if scale < p.scale || scale > MaxPriceScale {
+		return 0, fmt.Errorf("price %s cannot be represented with %d decimal places", p, scale)
+	}
+	factor := pow10[scale-p.scale]
+	if p.ticks > math.MaxInt64/factor || p.ticks < math.MinInt64/factor {
+		return 0, fmt.Errorf("price %s is out of range with %d decimal places", p, scale)
+	}
+	return p.ticks * factor, nil
+}
+
+// Float64 は価格に最も近い float64 を返します（統計値の計算用）。
+//
+// 戻り値:
+//   - 価格の近似値
+func (p Price) Float64() float64 {
+	return float64(p.ticks) / float64(pow10[p.scale])
+}
+
+// Cmp は2つの価格を比較します。
+//
+// 引数:
+//   - other: 比較する価格
+//
+// 戻り値:
+//   - p が小さい場合は -1、等しい場合は 0、大きい場合は 1
+func (p Price) Cmp(other Price) int {
+	// 桁を揃えると int64 の範囲を超える場合があるため多倍長整数で比較する
+	scale := max(p.scale, other.scale)
+	a := new(big.Int).Mul(big.NewInt(p.ticks), big.NewInt(pow10[scale-p.scale]))
+	b := new(big.Int).Mul(big.NewInt(other.ticks), big.NewInt(pow10[scale-other.scale]))
+	return a.Cmp(b)
+}
+
+// String は価格を末尾に0のない10進数の文字列で返します（例: "2903.5", "2873"）。
+// ParsePrice で同じ価格に戻すことができます。
+//
+// 戻り値:
+//   - 価格の文字列
+func (p Price) String() string {
+	return formatTicks(p.ticks, p.scale)
+}
+
+// StringFixed は価格を小数点以下 decimals 桁の文字列で返します。
+// 桁数が足りない場合は0で埋め、端数は四捨五入（0から遠い方に丸める）します。
+//
+// 引数:
+//   - decimals: 小数点以下の桁数（負の場合は0として扱う）
+//
+// 戻り値:
+//   - 価格の文字列（例: decimals が2の場合の "2903.50"）
+func (p Price) StringFixed(decimals int) string {
+	decimals = max(decimals, 0)
+	if decimals >= p.scale {
+		text := p.String()
+		if decimals > 0 && p.scale == 0 {
+			text += "."
+		}
+		return text + strings.Repeat("0", decimals-p.scale)
+	}
+
+	divisor := pow10[p.scale-decimals]
+	ticks, remainder := p.ticks/divisor, p.ticks%divisor
+	if remainder >= divisor-remainder {
+		ticks++
+	} else if -remainder >= divisor+remainder {
+		ticks--
+	}
+	return formatTicks(ticks, decimals)
+}
+
+// formatTicks は 10^-scale 単位の整数を小数点以下 scale 桁の10進数の文字列に変換します。
+//
+// 引数:
+//   - ticks: 10^-scale 単位で表した値
+//   - scale: 小数点以下の桁数
+//
+// 戻り値:
+//   - 10進数の文字列
+func formatTicks(ticks int64, scale int) string {
+	digits := strconv.FormatUint(absUint64(ticks), 10)
+	if scale > 0 {
+		if len(digits) <= scale {
+			digits = strings.Repeat("0", scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	}
+	if ticks < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// absUint64 は整数の絶対値を返します（math.MinInt64 も表せるように符号なし整数で返す）。
+//
+// 引数:
+//   - n: 整数
+//
+// 戻り値:
+//   - 絶対値
+func absUint64(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+// MarshalJSON は価格を丸めずにJSONの数値として書き出します。
+//
+// 戻り値:
+//   - JSONの数値
+//   - エラー（常にnil）
+func (p Price) MarshalJSON() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalJSON はJSONの数値を価格として読み込みます。
+//
+// 引数:
+//   - data: JSONの数値
+//
+// 戻り値:
+//   - エラー（ParsePrice で変換できない場合）
+func (p *Price) UnmarshalJSON(data []byte) error {
+	price, err := ParsePrice(string(data))
+	if err != nil {
+		return err
+	}
+	*p = price
+	return nil
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 日次株価情報の検索結果の並び順のキー
+type DailyStockPriceSortKey string
+
+const (
+	// (銘柄コード, 日付) の順（主キーの順）
+	SortByStock DailyStockPriceSortKey = "stock"
+	// (日付, 銘柄コード) の順
+	SortByDate DailyStockPriceSortKey = "date"
+	// (株価, 銘柄コード, 日付) の順（株価は銘柄の桁数によらず値で比較する）
+	SortByPrice DailyStockPriceSortKey = "price"
+)
+
+// 日次株価情報の検索条件
+// ゼロ値は全ての日次株価情報を (銘柄コード, 日付) の順に取得することを示す
+type DailyStockPriceQuery struct {
+	// 取得する銘柄コード（空の場合は全ての銘柄）
+	StockIDs []string
+	// 日付の始点（この日付を含む、ゼロ値の場合は制限しない）
+	From time.Time
+	// 日付の終点（この日付を含む、ゼロ値の場合は制限しない）
+	To time.Time
+	// 株価の下限（この値を含む、nil の場合は制限しない）
+	MinPrice *Price
+	// 株価の上限（この値を含む、nil の場合は制限しない）
+	MaxPrice *Price
+	// 取得する版の日時（ゼロ値の場合は現在の値）
+	AsOf time.Time
+	// 並び順のキー（空の場合は SortByStock）
+	SortBy DailyStockPriceSortKey
+	// 並び順を逆にするかどうか（同じ値の行の順も逆になる）
+	Descending bool
+	// 取得する最大件数（0の場合は制限しない）
+	Limit int
+	// 並べた結果の先頭から読み飛ばす件数
+	Offset int
+	// 日次株価情報の代わりに、条件に一致する行を持つ銘柄コードを重複なく取得するかどうか
+	// 銘柄コードの順にのみ並べられ、Limit と Offset は銘柄の数に適用される
+	DistinctStocks bool
+}
+
+// 日次株価情報の検索結果
+type DailyStockPriceQueryResult struct {
+	// 条件に一致する日次株価情報（DistinctStocks の場合は nil）
+	Prices []DailyStockPrice
+	// 条件に一致する行を持つ銘柄コード（DistinctStocks の場合のみ）
+	StockIDs []string
+}
+
+// ParseDailyStockPriceSortKey は文字列を DailyStockPriceSortKey に変換します。
+// 空文字列は SortByStock に変換します。
+//
+// 引数:
+//   - value: 並び順のキーの文字列（stock, date または price）
+//
+// 戻り値:
+//   - 並び順のキー
+//   - エラー（不明なキーの場合）
+func ParseDailyStockPriceSortKey(value string) (DailyStockPriceSortKey, error) {
+	switch key := DailyStockPriceSortKey(strings.ToLower(value)); key {
+	case "":
+		return SortByStock, nil
+	case SortByStock, SortByDate, SortByPrice:
+		return key, nil
+	default:
+		return "", fmt.Errorf("unknown sort key %q (expected %s, %s or %s)", value, SortByStock, SortByDate, SortByPrice)
+	}
+}
+
+// Validate は検索条件が正しいかどうかを検証します。
+//
+// 戻り値:
+//   - エラー（件数が負の場合、日付や株価の範囲の始点が終点より後ろの場合、並び順のキーが不正な場合）
+func (q DailyStockPriceQuery) Validate() error {
+	if q.Limit < 0 {
+		return fmt.Errorf("invalid query limit: %d", q.Limit)
+	}
+	if q.Offset < 0 {
+		return fmt.Errorf("invalid query offset: %d", q.Offset)
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && q.From.Format(time.DateOnly) > q.To.Format(time.DateOnly) {
+		return fmt.Errorf("query date range starts after it ends: %s to %s", q.From.Format(time.DateOnly), q.To.Format(time.DateOnly))
+	}
+	if q.MinPrice != nil && q.MaxPrice != nil && q.MinPrice.Cmp(*q.MaxPrice) > 0 {
+		return fmt.Errorf("query minimum price %s is above maximum price %s", q.MinPrice, q.MaxPrice)
+	}
+	switch q.SortBy {
+	case "", SortByStock, SortByDate, SortByPrice:
+	default:
+		return fmt.Errorf("unknown sort key %q (expected %s, %s or %s)", q.SortBy, SortByStock, SortByDate, SortByPrice)
+	}
+	if q.DistinctStocks && q.SortBy != "" && q.SortBy != SortByStock {
+		return fmt.Errorf("distinct stocks can only be sorted by %s, not %s", SortByStock, q.SortBy)
+	}
+	return nil
+}
+
+// Matches は日次株価情報が並び順と件数以外の条件に一致するかどうかを返します。
+// 日付は時刻を無視し、日付の部分のみで比較します。
+//
+// 引数:
+//   - dailyPrice: 日次株価情報
+//
+// 戻り値:
+//   - 条件に一致する場合は true
+func (q DailyStockPriceQuery) Matches(dailyPrice DailyStockPrice) bool {
+	if len(q.StockIDs) > 0 && !slices.Contains(q.StockIDs, dailyPrice.StockPrice.StockID) {
+		return false
+	}
+	dateStr := dailyPrice.PriceDate.Format(time.DateOnly)
+	if !q.From.IsZero() && dateStr < q.From.Format(time.DateOnly) {
+		return false
+	}
+	if !q.To.IsZero() && dateStr > q.To.Format(time.DateOnly) {
+		return false
+	}
+	if q.MinPrice != nil && dailyPrice.StockPrice.Price.Cmp(*q.MinPrice) < 0 {
+		return false
+	}
+	if q.MaxPrice != nil && dailyPrice.StockPrice.Price.Cmp(*q.MaxPrice) > 0 {
+		return false
+	}
+	return true
+}
+
+// Compare は2つの日次株価情報を検索条件の並び順で比較します。
+//
+// 引数:
+//   - a: 比較する日次株価情報
+//   - b: 比較する日次株価情報
+//
+// 戻り値:
+//   - a が先に並ぶ場合は負の値、同じ位置の場合は 0、後に並ぶ場合は正の値
+func (q DailyStockPriceQuery) Compare(a DailyStockPrice, b DailyStockPrice) int {
+	byStock := cmp.Or(
+		cmp.Compare(a.StockPrice.StockID, b.StockPrice.StockID),
+		cmp.Compare(a.PriceDate.Format(time.DateOnly), b.PriceDate.Format(time.DateOnly)),
+	)
+	var result int
+	switch q.SortBy {
+	case SortByDate:
+		result = cmp.Or(cmp.Compare(a.PriceDate.Format(time.DateOnly), b.PriceDate.Format(time.DateOnly)), byStock)
+	case SortByPrice:
+		result = cmp.Or(a.StockPrice.Price.Cmp(b.StockPrice.Price), byStock)
+	default:
+		result = byStock
+	}
+	if q.Descending {
+		return -result
+	}
+	return result
+}
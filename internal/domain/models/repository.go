@@ -0,0 +1,146 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// 既存の行と同じ銘柄コード・日付の行を取り込む場合の扱い
+type ConflictPolicy string
+
+const (
+	// 既存の行を新しい値で上書きする
+	ConflictPolicyOverwrite ConflictPolicy = "overwrite"
+	// 既存の行を残し、新しい値を破棄する
+	ConflictPolicyKeep ConflictPolicy = "keep"
+	// 取り込みをエラーで中断する
+	ConflictPolicyFail ConflictPolicy = "fail"
+)
+
+// 日次株価情報と銘柄マスタを永続化するリポジトリ
+// 実装は1つのデータベース接続（接続プール）を保持し、Close で解放する
+// 銘柄マスタに登録されていない銘柄の日次株価情報を書き込む場合は、銘柄コードのみの銘柄マスタを登録する
+// Close 以外の操作は ctx がキャンセルされると中断し、実行中のトランザクションをロールバックする
+type StockPriceRepository interface {
+	// InitializeDailyStockPriceTableFromSeq は全ての日次株価情報を削除し、
+	// イテレータから読み込んだ日次株価情報を挿入します。挿入した件数を返します。
+	// 削除と挿入は全件を読み込んでからまとめてコミットし、途中で失敗した場合は何も変更しません。
+	// chunkSize は全件を読み込むまでの間に1トランザクションで書き込む件数で、メモリ使用量を一定に保つために使用します。
+	// runID が NoImportRun 以外の場合は、書き込んだ行をその取り込みに紐付け、削除した行を取り消し用に保存します。
+	InitializeDailyStockPriceTableFromSeq(ctx context.Context, runID int64, dailyPrices iter.Seq2[DailyStockPrice, error], chunkSize int) (int, error)
+	// UpsertDailyStockPricesFromSeq は既存の日次株価情報を残したまま、
+	// イテレータから読み込んだ日次株価情報を policy に従って追加します。
+	// コミットされた行の取り込み結果を返します。
+	// runID が NoImportRun 以外の場合は、書き込んだ行をその取り込みに紐付け、上書きした行を取り消し用に保存します。
+	UpsertDailyStockPricesFromSeq(ctx context.Context, runID int64, dailyPrices iter.Seq2[DailyStockPrice, error], chunkSize int, policy ConflictPolicy) (UpsertResult, error)
+	// InitializeDailyStockBarTable は全ての日次株価情報を削除し、日次四本値を挿入します。
+	// runID の扱いは InitializeDailyStockPriceTableFromSeq と同じです。
+	InitializeDailyStockBarTable(ctx context.Context, runID int64, dailyBars []DailyStockBar) error
+	// GetDailyStockPrices は全ての日次株価情報を取得します。
+	GetDailyStockPrices(ctx context.Context) ([]DailyStockPrice, error)
+	// GetDailyStockPricesPage は (銘柄コード, 日付) の順で after より後ろの日次株価情報を最大 limit 件取得します。
+	GetDailyStockPricesPage(ctx context.Context, after DailyStockPriceCursor, limit int) (DailyStockPricePage, error)
+	// IterateDailyStockPrices は (銘柄コード, 日付) の順で after より後ろの日次株価情報を1件ずつ返すイテレータを返します。
+	// 全件をメモリ上に保持せずに読み進めます。
+	IterateDailyStockPrices(ctx context.Context, after DailyStockPriceCursor) iter.Seq2[DailyStockPrice, error]
+	// CountDailyStockPrices は日次株価情報の件数を取得します。
+	CountDailyStockPrices(ctx context.Context) (int, error)
+	// GetDailyStockPricesByDateRange は銘柄コードと日付範囲（両端を含む）に一致する日次株価情報を取得します。
+	// asOf がゼロ値以外の場合は、その日時に保存されていた版を取得します。
+	GetDailyStockPricesByDateRange(ctx context.Context, stockID string, startDate time.Time, endDate time.Time, asOf time.Time) ([]DailyStockPrice, error)
+	// QueryDailyStockPrices は検索条件に一致する日次株価情報、または DistinctStocks の場合は銘柄コードを取得します。
+	// 検索条件が不正な場合は何も取得せずにエラーを返します。
+	QueryDailyStockPrices(ctx context.Context, query DailyStockPriceQuery) (DailyStockPriceQueryResult, error)
+	// GetDailyStockPriceHistory は銘柄コードと日付に一致する日次株価情報の全ての版を記録日時の順に取得します。
+	// 上書きや削除された値も、取り込みの取り消しや修復で書き込まれた値も版として残ります。
+	// 1回の書き込み（トランザクション）の版は全て同じ日時に記録され、値の変わらない書き込みは版を増やしません。
+	GetDailyStockPriceHistory(ctx context.Context, stockID string, priceDate time.Time) ([]DailyStockPriceVersion, error)
+	// GetDailyStockBarsByDateRange は銘柄コードと日付範囲（両端を含む）に一致する日次四本値を取得します。
+	GetDailyStockBarsByDateRange(ctx context.Context, stockID string, startDate time.Time, endDate time.Time) ([]DailyStockBar, error)
+	// UpsertStocks は銘柄マスタを登録し、登録済みの銘柄は新しい値で上書きします。
+	UpsertStocks(ctx context.Context, stocks []Stock) (UpsertResult, error)
+	// GetStocks は銘柄コード順に全ての銘柄マスタを取得します。
+	GetStocks(ctx context.Context) ([]Stock, error)
+	// GetStock は銘柄コードに一致する銘柄マスタを取得します。登録されていない場合は false を返します。
+	GetStock(ctx context.Context, stockID string) (Stock, bool, error)
+	// StartImportRun は取り込みの開始を ImportRunRunning として記録し、取り込みIDを返します。
+	// run の ID・状態・終了日時・取り込み結果は無視します。
+	StartImportRun(ctx context.Context, run ImportRun) (int64, error)
+	// FinishImportRun は run の ID に一致する取り込みの終了を記録します。
+	// run の状態・SHA-256・取り込み結果を記録し、上書きまたは削除した行数はリポジトリが数えます。
+	FinishImportRun(ctx context.Context, run ImportRun) error
+	// GetImportRuns は取り込みID順に全ての取り込み履歴を取得します。
+	GetImportRuns(ctx context.Context) ([]ImportRun, error)
+	// GetImportRun は取り込みIDに一致する取り込み履歴を取得します。記録されていない場合は false を返します。
+	GetImportRun(ctx context.Context, runID int64) (ImportRun, bool, error)
+	// UndoImportRun は取り込みで書き込まれた行を削除し、上書きまたは削除された行を復元します。
+	// 後の取り込みが同じ行を変更している場合は ImportRunUndoConflictError を返し、何も変更しません。
+	UndoImportRun(ctx context.Context, runID int64) (UndoImportResult, error)
+	// Close はデータベース接続を閉じます。
+	Close() error
+}
+
+// ParseConflictPolicy は文字列を ConflictPolicy に変換します。
+//
+// 引数:
+//   - name: 衝突時の扱いの名前（overwrite, keep, fail）
+//
+// 戻り値:
+//   - 衝突時の扱い
+//   - エラー（未知の名前の場合）
+func ParseConflictPolicy(name string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(name); policy {
+	case ConflictPolicyOverwrite, ConflictPolicyKeep, ConflictPolicyFail:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown conflict policy %q (expected %s, %s or %s)", name, ConflictPolicyOverwrite, ConflictPolicyKeep, ConflictPolicyFail)
+	}
+}
+
+// 差分取り込みの結果を示す構造体
+type UpsertResult struct {
+	// 新しく挿入した行数
+	Inserted int
+	// 既存の行を上書きした行数
+	Updated int
+	// 既存の行と同じ値だった、または ConflictPolicyKeep で既存の行を残した行数
+	Unchanged int
+}
+
+// Total は取り込んだ行数の合計を返します。
+//
+// 戻り値:
+//   - 挿入・上書き・変更なしの行数の合計
+func (r UpsertResult) Total() int {
+	return r.Inserted + r.Updated + r.Unchanged
+}
+
+// Add は他の取り込み結果を加算した結果を返します。
+//
+// 引数:
+//   - other: 加算する取り込み結果
+//
+// 戻り値:
+//   - 加算した取り込み結果
+func (r UpsertResult) Add(other UpsertResult) UpsertResult {
+	return UpsertResult{
+		Inserted:  r.Inserted + other.Inserted,
+		Updated:   r.Updated + other.Updated,
+		Unchanged: r.Unchanged + other.Unchanged,
+	}
+}
+
+// DailyStockPriceConflictError は ConflictPolicyFail で既存の行と異なる株価の行を取り込もうとした場合のエラー
+type DailyStockPriceConflictError struct {
+	StockID       string
+	PriceDate     time.Time
+	ExistingPrice Price
+	NewPrice      Price
+}
+
+func (e *DailyStockPriceConflictError) Error() string {
+	return "conflicting daily stock price for " + e.StockID + " on " + e.PriceDate.Format(time.DateOnly) +
+		": existing " + e.ExistingPrice.String() + ", new " + e.NewPrice.String()
+}
@@ -0,0 +1,30 @@
+package models
+
+// 銘柄マスタに通貨が指定されていない場合の通貨
+const DefaultCurrency = "JPY"
+
+// 銘柄マスタの情報を示す構造体
+type Stock struct {
+	// 銘柄コード文字列
+	StockID string
+	// 銘柄名
+	Name string
+	// 上場市場（例: TSE Prime）
+	Market string
+	// 業種
+	Sector string
+	// 通貨コード（ISO 4217）
+	Currency string
+}
+
+// Label は銘柄コードと銘柄名を表示用に連結した文字列を返します。
+// 銘柄名が登録されていない場合は銘柄コードのみを返します。
+//
+// 戻り値:
+//   - 表示用の文字列（例: "7203 トヨタ自動車"）
+func (s Stock) Label() string {
+	if s.Name == "" {
+		return s.StockID
+	}
+	return s.StockID + " " + s.Name
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"iter"
 	"time"
 )
 
@@ -9,7 +10,7 @@ type StockPrice struct {
 	// 銘柄コード文字列
 	StockID string
 	// 株価
-	Price float64
+	Price Price
 }
 
 // 日次の株価情報を示す構造体
@@ -20,6 +21,89 @@ type DailyStockPrice struct {
 	StockPrice
 }
 
+// DailyStockPriceSeq は日次株価情報の配列を、リポジトリの FromSeq 系のメソッドに渡せるイテレータに変換します。
+//
+// 引数:
+//   - dailyPrices: 日次株価情報の配列
+//
+// 戻り値:
+//   - 日次株価情報とエラーの組を返すイテレータ（エラーは常にnil）
+func DailyStockPriceSeq(dailyPrices []DailyStockPrice) iter.Seq2[DailyStockPrice, error] {
+	return func(yield func(DailyStockPrice, error) bool) {
+		for _, dailyPrice := range dailyPrices {
+			if !yield(dailyPrice, nil) {
+				return
+			}
+		}
+	}
+}
+
+// 日次株価情報の1つの版を示す構造体
+// RecordedAt から SupersededAt の直前までリポジトリに保存されていた値を示す
+type DailyStockPriceVersion struct {
+	// 日次株価情報
+	DailyStockPrice
+	// 版を記録した日時
+	RecordedAt time.Time
+	// 次の版に置き換えられた、または削除された日時（現在の版はゼロ値）
+	SupersededAt time.Time
+}
+
+// IsCurrent は版が現在の値かどうかを判定します。
+//
+// 戻り値:
+//   - 置き換えも削除もされていない場合は true
+func (v DailyStockPriceVersion) IsCurrent() bool {
+	return v.SupersededAt.IsZero()
+}
+
+// ValidAt は指定された日時にこの版が保存されていたかどうかを判定します。
+//
+// 引数:
+//   - asOf: 判定する日時
+//
+// 戻り値:
+//   - RecordedAt 以降かつ SupersededAt より前の場合は true
+func (v DailyStockPriceVersion) ValidAt(asOf time.Time) bool {
+	return !asOf.Before(v.RecordedAt) && (v.IsCurrent() || asOf.Before(v.SupersededAt))
+}
+
+// 日次の四本値（始値・高値・安値・終値）と出来高を示す構造体
+// 終値のみのデータでは始値・高値・安値に終値と同じ値、出来高に0が入る
+type DailyStockBar struct {
+	// 株価が記録されている日付
+	PriceDate time.Time
+	// 銘柄コード文字列
+	StockID string
+	// 始値
+	Open Price
+	// 高値
+	High Price
+	// 安値
+	Low Price
+	// 終値
+	Close Price
+	// 出来高
+	Volume int64
+}
+
+// 日次四本値のうち統計の対象とする項目を示す型
+type StockBarField string
+
+// 日次四本値の項目
+const (
+	// 始値
+	StockBarFieldOpen StockBarField = "open"
+	// 高値
+	StockBarFieldHigh StockBarField = "high"
+	// 安値
+	StockBarFieldLow StockBarField = "low"
+	// 終値
+	StockBarFieldClose StockBarField = "close"
+	// 出来高
+	StockBarFieldVolume StockBarField = "volume"
+)
+
 // n個の株価情報の統計値を示す構造体
 type StockPriceStatistics struct {
 	// 銘柄コード文字列
@@ -42,4 +126,8 @@ type DailyStockPriceStatistics struct {
 	EndDate time.Time
 	// 株価統計情報
 	StockPriceStatistics
+	// 銘柄マスタの情報（銘柄マスタに登録されていない場合は銘柄コードのみ）
+	Stock Stock
+	// 計算に使った日次株価情報を保存していた日時（ゼロ値の場合は現在の値）
+	AsOf time.Time
 }
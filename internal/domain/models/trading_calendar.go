@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// 取引所の営業日を判定するカレンダー
+// 土曜日・日曜日、年末年始の休場日（12月31日〜1月3日）と、指定された休場日を営業日としない
+type TradingCalendar struct {
+	// 指定された休場日（UTCの0時）
+	holidays map[time.Time]bool
+}
+
+// NewTradingCalendar は休場日を指定してカレンダーを作成します。
+//
+// 引数:
+//   - holidays: 年末年始以外の休場日（祝日など、時刻は無視する）
+//
+// 戻り値:
+//   - カレンダー
+func NewTradingCalendar(holidays []time.Time) TradingCalendar {
+	calendar := TradingCalendar{holidays: make(map[time.Time]bool, len(holidays))}
+	for _, holiday := range holidays {
+		calendar.holidays[calendarDate(holiday)] = true
+	}
+	return calendar
+}
+
+// IsWeekend は日付が土曜日または日曜日かどうかを判定します。
+//
+// 引数:
+//   - date: 判定する日付
+//
+// 戻り値:
+//   - 土曜日または日曜日の場合は true
+func (c TradingCalendar) IsWeekend(date time.Time) bool {
+	weekday := date.Weekday()
+	return weekday == time.Saturday || weekday == time.Sunday
+}
+
+// IsHoliday は日付が平日の休場日（年末年始または指定された休場日）かどうかを判定します。
+//
+// 引数:
+//   - date: 判定する日付
+//
+// 戻り値:
+//   - 休場日の場合は true
+func (c TradingCalendar) IsHoliday(date time.Time) bool {
+	month, day := date.Month(), date.Day()
+	yearEnd := month == time.December && day == 31 || month == time.January && day <= 3
+	return yearEnd || c.holidays[calendarDate(date)]
+}
+
+// IsTradingDay は日付が営業日かどうかを判定します。
+//
+// 引数:
+//   - date: 判定する日付
+//
+// 戻り値:
+//   - 営業日の場合は true
+func (c TradingCalendar) IsTradingDay(date time.Time) bool {
+	return !c.IsWeekend(date) && !c.IsHoliday(date)
+}
+
+// TradingDaysBetween は2つの日付の間（両端を含まない）にある営業日の数を返します。
+//
+// 引数:
+//   - from: 始点の日付
+//   - to: 終点の日付（from 以前の場合は0を返す）
+//
+// 戻り値:
+//   - 営業日の数
+func (c TradingCalendar) TradingDaysBetween(from time.Time, to time.Time) int {
+	count := 0
+	for date := calendarDate(from).AddDate(0, 0, 1); date.Before(calendarDate(to)); date = date.AddDate(0, 0, 1) {
+		if c.IsTradingDay(date) {
+			count++
+		}
+	}
+	return count
+}
+
+// calendarDate は日時の年月日をUTCの0時にした日付を返します。
+//
+// 引数:
+//   - t: 日時
+//
+// 戻り値:
+//   - 日付
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
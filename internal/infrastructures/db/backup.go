@@ -0,0 +1,374 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 復元時にバックアップを接続するスキーマ名
+const restoreSourceSchema = "restore_source"
+
+// 整合性チェックのエラーに含める問題の最大件数
+const maxReportedIntegrityProblems = 5
+
+// IntegrityCheckError は PRAGMA integrity_check が問題を報告した場合のエラー
+type IntegrityCheckError struct {
+	// 検査したデータベースファイルのパス
+	Path string
+	// integrity_check が報告した問題
+	Problems []string
+}
+
+func (e *IntegrityCheckError) Error() string {
+	problems := e.Problems[:min(len(e.Problems), maxReportedIntegrityProblems)]
+	message := fmt.Sprintf("integrity check of %s failed: %s", e.Path, strings.Join(problems, "; "))
+	if len(e.Problems) > len(problems) {
+		message += fmt.Sprintf(" (and %d more)", len(e.Problems)-len(problems))
+	}
+	return message
+}
+
+// CheckIntegrity は PRAGMA integrity_check でデータベースファイルの整合性を検査します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - dbPath: 検査するSQLiteデータベースファイルのパス
+//
+// 戻り値:
+//   - エラー（ファイルが存在しない場合、問題が見つかった場合（IntegrityCheckError）や検査に失敗した場合）
+func CheckIntegrity(ctx context.Context, dbPath string) error {
+	// 存在しないファイルを開くと空のデータベースが作成されるため先に確認
+	if err := checkDatabaseFileExists(dbPath); err != nil {
+		return err
+	}
+	db, err := openSQLiteDatabase(ctx, dbPath, inspectionSQLiteOptions())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	problems, err := integrityProblems(ctx, db, dbPath)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return &IntegrityCheckError{Path: dbPath, Problems: problems}
+	}
+	return nil
+}
+
+// integrityProblems は PRAGMA integrity_check が報告した問題を返します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - db: 検査するデータベース接続
+//   - dbPath: エラーに含めるデータベースファイルのパス
+//
+// 戻り値:
+//   - 問題の配列（問題がない場合は空）
+//   - エラー（検査に失敗した場合）
+func integrityProblems(ctx context.Context, db *sql.DB, dbPath string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check integrity of %s: %w", dbPath, err)
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var problem string
+		if err := rows.Scan(&problem); err != nil {
+			return nil, fmt.Errorf("failed to check integrity of %s: %w", dbPath, err)
+		}
+		if problem != "ok" {
+			problems = append(problems, problem)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to check integrity of %s: %w", dbPath, err)
+	}
+	return problems, nil
+}
+
+// BackupDatabase は VACUUM INTO でデータベースをバックアップファイルに書き出し、
+// バックアップの整合性を検査します。
+// 書き出しは1つの読み込みトランザクションで行うため、他のプロセスが書き込み中でも
+// ある時点の一貫した内容がバックアップされます。
+// 整合性の検査に失敗した場合はバックアップファイルを削除します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - dbPath: バックアップするSQLiteデータベースファイルのパス
+//   - backupPath: バックアップファイルのパス（既に存在する場合はエラー）
+//
+// 戻り値:
+//   - エラー（データベースが存在しない場合、書き出しや整合性の検査に失敗した場合（IntegrityCheckError））
+func BackupDatabase(ctx context.Context, dbPath string, backupPath string) error {
+	if err := checkDatabaseFileExists(dbPath); err != nil {
+		return err
+	}
+	if _, err := os.Stat(backupPath); err == nil {
+		return fmt.Errorf("backup file already exists: %s", backupPath)
+	}
+	if err := vacuumInto(ctx, dbPath, backupPath); err != nil {
+		return err
+	}
+
+	if err := CheckIntegrity(ctx, backupPath); err != nil {
+		os.Remove(backupPath)
+		return err
+	}
+	return nil
+}
+
+// RestoreDatabase はバックアップファイルの内容でデータベースを置き換え、整合性を検査します。
+// バックアップのスキーマが古い場合は複製にマイグレーションを適用してから置き換えます。
+// 置き換えは復元先のデータベースに対する1つのトランザクションで行うため、
+// 他のプロセスが開いていても置き換え前か置き換え後のどちらかの内容だけが見えます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - backupPath: 復元するバックアップファイルのパス
+//   - dbPath: 復元先のSQLiteデータベースファイルのパス（存在しない場合は作成する）
+//
+// 戻り値:
+//   - エラー（バックアップが存在しないか壊れている場合（IntegrityCheckError）、
+//     バックアップのスキーマがバイナリより新しい場合（SchemaVersionError）や復元に失敗した場合）
+func RestoreDatabase(ctx context.Context, backupPath string, dbPath string) error {
+	// 壊れたバックアップで置き換えないように先に検査
+	if err := CheckIntegrity(ctx, backupPath); err != nil {
+		return err
+	}
+	// 空のファイルも空のデータベースとして開けるため、テーブルがあることを確認
+	if err := checkDatabaseHasTables(ctx, backupPath); err != nil {
+		return err
+	}
+
+	// バックアップ自体を変更しないように一時ディレクトリに複製してマイグレーションを適用
+	stagingDir, err := os.MkdirTemp("", "stock_price_restore")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+	stagingPath := filepath.Join(stagingDir, "restore.db")
+	if err := vacuumInto(ctx, backupPath, stagingPath); err != nil {
+		return err
+	}
+	if err := migrateDatabaseFile(ctx, stagingPath); err != nil {
+		return err
+	}
+
+	// 復元先を最新のスキーマにしてから全てのテーブルの内容を置き換える
+	db, err := openSQLiteDatabase(ctx, dbPath, DefaultSQLiteOptions())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := migrateDatabase(ctx, db); err != nil {
+		return err
+	}
+	if err := replaceDatabaseContents(ctx, db, stagingPath); err != nil {
+		return err
+	}
+	if err := db.Close(); err != nil {
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+
+	return CheckIntegrity(ctx, dbPath)
+}
+
+// replaceDatabaseContents は復元元のデータベースを接続し、1つのトランザクションで
+// 復元先の全てのテーブルの行を復元元の行で置き換えます。
+// ATTACH は接続ごとに有効なため、1つの接続を占有して実行します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - db: 復元先のデータベース接続（復元元と同じスキーマであること）
+//   - sourcePath: 復元元のSQLiteデータベースファイルのパス
+//
+// 戻り値:
+//   - エラー（置き換えに失敗した場合）
+func replaceDatabaseContents(ctx context.Context, db *sql.DB, sourcePath string) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to restore database: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS "+restoreSourceSchema, sourcePath); err != nil {
+		return fmt.Errorf("failed to attach backup: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "DETACH DATABASE "+restoreSourceSchema)
+
+	// sqlite_sequence（AUTOINCREMENT の採番状況）も含めて置き換える
+	var tables []string
+	rows, err := conn.QueryContext(ctx, "SELECT name FROM "+restoreSourceSchema+".sqlite_master WHERE type = 'table' AND (name NOT LIKE 'sqlite_%' OR name = 'sqlite_sequence') ORDER BY name")
+	if err != nil {
+		return fmt.Errorf("failed to list tables in backup: %w", err)
+	}
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to list tables in backup: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list tables in backup: %w", err)
+	}
+
+	// テーブルを順に置き換えるため、この接続の外部キー制約を無効にしてコミット前にまとめて検査する
+	// （INSERT ... SELECT * の転送最適化は遅延した外部キー制約の検査を通らないため defer_foreign_keys は使えない）
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "PRAGMA foreign_keys = ON")
+
+	tx, err := beginWriteTx(ctx, conn, DefaultSQLiteOptions().BusyRetries)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// daily_stock_price の置き換えでトリガーが記録した版は、名前順で後に置き換える daily_stock_price_history で上書きされる
+	for _, table := range tables {
+		quoted := quoteIdentifier(table)
+		if _, err := tx.ExecContext(ctx, "DELETE FROM main."+quoted); err != nil {
+			return fmt.Errorf("failed to clear table %s: %w", table, err)
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO main."+quoted+" SELECT * FROM "+restoreSourceSchema+"."+quoted); err != nil {
+			return fmt.Errorf("failed to restore table %s: %w", table, err)
+		}
+	}
+
+	// 置き換えた内容が外部キー制約を満たすことを確認
+	var violations int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM pragma_foreign_key_check").Scan(&violations); err != nil {
+		return fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	if violations > 0 {
+		return fmt.Errorf("backup violates %d foreign key constraints", violations)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// vacuumInto は VACUUM INTO でデータベースの内容を新しいファイルに書き出します。
+// 失敗した場合は書きかけのファイルを削除します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - sourcePath: 書き出すSQLiteデータベースファイルのパス
+//   - destinationPath: 書き出し先のファイルのパス
+//
+// 戻り値:
+//   - エラー（書き出しに失敗した場合）
+func vacuumInto(ctx context.Context, sourcePath string, destinationPath string) error {
+	db, err := openSQLiteDatabase(ctx, sourcePath, inspectionSQLiteOptions())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", destinationPath); err != nil {
+		os.Remove(destinationPath)
+		return fmt.Errorf("failed to write %s to %s: %w", sourcePath, destinationPath, err)
+	}
+	return nil
+}
+
+// checkDatabaseHasTables はデータベースファイルにテーブルが1つ以上あることを確認します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - dbPath: SQLiteデータベースファイルのパス
+//
+// 戻り値:
+//   - エラー（テーブルがない場合や確認に失敗した場合）
+func checkDatabaseHasTables(ctx context.Context, dbPath string) error {
+	db, err := openSQLiteDatabase(ctx, dbPath, inspectionSQLiteOptions())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var tables int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables); err != nil {
+		return fmt.Errorf("failed to list tables in %s: %w", dbPath, err)
+	}
+	if tables == 0 {
+		return fmt.Errorf("%s contains no tables", dbPath)
+	}
+	return nil
+}
+
+// migrateDatabaseFile はデータベースファイルを開き、未適用のマイグレーションを全て適用します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - dbPath: SQLiteデータベースファイルのパス
+//
+// 戻り値:
+//   - エラー（データベースがバイナリより新しい場合（SchemaVersionError）やマイグレーションに失敗した場合）
+func migrateDatabaseFile(ctx context.Context, dbPath string) error {
+	db, err := openSQLiteDatabase(ctx, dbPath, DefaultSQLiteOptions())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return migrateDatabase(ctx, db)
+}
+
+// inspectionSQLiteOptions はバックアップファイルの検査や読み出しに使用する接続設定を返します。
+// 開いただけでファイルが変更されないように、ジャーナルモードと同期モードは変更しません。
+//
+// 戻り値:
+//   - SQLiteデータベースの接続設定
+func inspectionSQLiteOptions() SQLiteOptions {
+	options := DefaultSQLiteOptions()
+	options.JournalMode = ""
+	options.Synchronous = ""
+	return options
+}
+
+// checkDatabaseFileExists はデータベースファイルが存在することを確認します。
+//
+// 引数:
+//   - dbPath: SQLiteデータベースファイルのパス
+//
+// 戻り値:
+//   - エラー（ファイルが存在しない場合やディレクトリの場合）
+func checkDatabaseFileExists(dbPath string) error {
+	info, err := os.Stat(dbPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("database file not found: %s", dbPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to access database file: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("database path is a directory: %s", dbPath)
+	}
+	return nil
+}
+
+// quoteIdentifier はテーブル名などの識別子をSQLに埋め込めるように二重引用符で囲みます。
+//
+// 引数:
+//   - name: 識別子
+//
+// 戻り値:
+//   - 二重引用符で囲んだ識別子
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
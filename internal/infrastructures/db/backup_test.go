@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+func TestBackupAndRestoreDatabase(t *testing.T) {
+	// Arrange - 取り込み履歴を含むデータベースをバックアップしてから内容を変更する
+	// テスト終了後にデータベースファイルを削除
+	dbPath := "./test_backup_restore.db"
+	repository := newTestRepository(t, dbPath)
+	ctx := context.Background()
+	date := func(day int) time.Time { return time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC) }
+	runID := startTestImportRun(t, repository, "first.tsv")
+	if _, err := repository.UpsertDailyStockPricesFromSeq(ctx, runID, models.DailyStockPriceSeq([]models.DailyStockPrice{
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2700, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+	}), 10, models.ConflictPolicyOverwrite); err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	backedUpPrices, _ := repository.GetDailyStockPrices(ctx)
+	backedUpRuns, _ := repository.GetImportRuns(ctx)
+
+	backupPath := filepath.Join(t.TempDir(), "backup.db")
+	if err := BackupDatabase(ctx, dbPath, backupPath); err != nil {
+		t.Fatalf("Failed to back up: %v", err)
+	}
+	startTestImportRun(t, repository, "second.tsv")
+	if err := repository.InitializeDailyStockPriceTable(ctx, []models.DailyStockPrice{
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8000, 0)}},
+	}); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	// Act - リポジトリを開いたまま復元する
+	err := RestoreDatabase(ctx, backupPath, dbPath)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	prices, _ := repository.GetDailyStockPrices(ctx)
+	if !reflect.DeepEqual(prices, backedUpPrices) {
+		t.Errorf("Expected the backed up prices.\nExpected: %+v\nGot: %+v", backedUpPrices, prices)
+	}
+	runs, _ := repository.GetImportRuns(ctx)
+	if !reflect.DeepEqual(runs, backedUpRuns) {
+		t.Errorf("Expected the backed up import runs.\nExpected: %+v\nGot: %+v", backedUpRuns, runs)
+	}
+	// AUTOINCREMENT の採番状況も復元される
+	if nextRunID := startTestImportRun(t, repository, "third.tsv"); nextRunID != runID+1 {
+		t.Errorf("Expected the next import run ID to be %d, but got %d", runID+1, nextRunID)
+	}
+	if err := BackupDatabase(ctx, dbPath, backupPath); err == nil {
+		t.Error("Expected an error when the backup file already exists, but got nil")
+	}
+}
+
+func TestRestoreDatabase_CorruptBackup(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	dbPath := "./test_restore_corrupt.db"
+	repository := newTestRepository(t, dbPath)
+	ctx := context.Background()
+	testPrices := []models.DailyStockPrice{
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+	}
+	if err := repository.InitializeDailyStockPriceTable(ctx, testPrices); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	backupPath := filepath.Join(t.TempDir(), "corrupt.db")
+	if err := os.WriteFile(backupPath, []byte("not a database"), 0o644); err != nil {
+		t.Fatalf("Failed to write backup: %v", err)
+	}
+
+	// Act
+	restoreErr := RestoreDatabase(ctx, backupPath, dbPath)
+	missingErr := RestoreDatabase(ctx, filepath.Join(t.TempDir(), "missing.db"), dbPath)
+
+	// Assert
+	if restoreErr == nil || missingErr == nil {
+		t.Fatalf("Expected errors, but got: %v, %v", restoreErr, missingErr)
+	}
+	prices, _ := repository.GetDailyStockPrices(ctx)
+	if !reflect.DeepEqual(prices, testPrices) {
+		t.Errorf("Expected the database to be left unchanged, but got %+v", prices)
+	}
+}
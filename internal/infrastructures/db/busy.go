@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	sqlite "github.com/glebarez/go-sqlite"
+)
+
+// SQLITE_BUSY の結果コード（拡張コードは下位8ビットがこの値になる）
+const sqliteBusyCode = 5
+
+// SQLITE_BUSY で失敗した書き込みを再試行するまでの待ち時間（再試行のたびにこの時間ずつ延ばす）
+const busyRetryInterval = 50 * time.Millisecond
+
+// トランザクションを開始できる接続（*sql.DB または *sql.Conn）
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// beginWriteTx は書き込みトランザクションを開始します。
+// 他の接続が書き込みロックを保持していて busy_timeout を過ぎても開始できない場合は、
+// 間隔を空けて r.busyRetries 回まで再試行します。
+// 日次株価の版の記録日時をトランザクションごとに揃えるため、commitVersionedWriteTx でコミットしてください。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - トランザクション
+//   - エラー（再試行しても開始できない場合やコンテキストがキャンセルされた場合）
+func (r *SQLiteStockPriceRepository) beginWriteTx(ctx context.Context) (*sql.Tx, error) {
+	return beginVersionedWriteTx(ctx, r.db, r.busyRetries)
+}
+
+// beginWriteTx は書き込みトランザクションを開始し、SQLITE_BUSY で失敗した場合は retries 回まで再試行します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - db: トランザクションを開始する接続
+//   - retries: 再試行する回数
+//
+// 戻り値:
+//   - トランザクション
+//   - エラー（再試行しても開始できない場合やコンテキストがキャンセルされた場合）
+func beginWriteTx(ctx context.Context, db txBeginner, retries int) (*sql.Tx, error) {
+	var tx *sql.Tx
+	err := retryOnBusy(ctx, retries, func() error {
+		var err error
+		tx, err = db.BeginTx(ctx, nil)
+		return err
+	})
+	return tx, err
+}
+
+// retryOnBusy は操作を実行し、SQLITE_BUSY で失敗した場合は間隔を空けて retries 回まで再試行します。
+// 操作は失敗した場合に何も変更していない（トランザクションの開始や1文の書き込み）必要があります。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - retries: 再試行する回数
+//   - operation: 実行する操作
+//
+// 戻り値:
+//   - エラー（最後に実行した操作のエラー、または待機中にコンテキストがキャンセルされた場合はそのエラー）
+func retryOnBusy(ctx context.Context, retries int, operation func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := operation()
+		if err == nil || attempt >= retries || !isBusyError(err) {
+			return err
+		}
+
+		timer := time.NewTimer(time.Duration(attempt+1) * busyRetryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isBusyError はエラーが他の接続のロックによる失敗（SQLITE_BUSY とその拡張コード）かどうかを判定します。
+//
+// 引数:
+//   - err: 判定するエラー
+//
+// 戻り値:
+//   - SQLITE_BUSY の場合は true
+func isBusyError(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code()&0xff == sqliteBusyCode
+}
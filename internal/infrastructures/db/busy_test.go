@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// newTestRepositoryWithOptions は接続設定を指定してテスト用のリポジトリを作成します。
+// テスト終了時にリポジトリを閉じます（データベースファイルは newTestRepository で作成したものを共有する）。
+func newTestRepositoryWithOptions(t *testing.T, dbPath string, options SQLiteOptions) *SQLiteStockPriceRepository {
+	t.Helper()
+	repository, err := NewSQLiteStockPriceRepositoryWithOptions(context.Background(), dbPath, options)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	t.Cleanup(func() { repository.Close() })
+	return repository
+}
+
+func TestSQLiteStockPriceRepository_RetriesWhenBusy(t *testing.T) {
+	// Arrange - 別の接続が書き込みロックを保持する
+	// テスト終了後にデータベースファイルを削除
+	dbPath := "./test_busy_retry.db"
+	repository := newTestRepository(t, dbPath)
+	ctx := context.Background()
+	lockTx, err := repository.db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to take the write lock: %v", err)
+	}
+	defer lockTx.Rollback()
+	if _, err := lockTx.ExecContext(ctx, "UPDATE "+importRunsTableName+" SET status = status"); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+
+	options := DefaultSQLiteOptions()
+	options.BusyTimeout = 10 * time.Millisecond
+	options.BusyRetries = 0
+	impatient := newTestRepositoryWithOptions(t, dbPath, options)
+	options.BusyRetries = 10
+	patient := newTestRepositoryWithOptions(t, dbPath, options)
+
+	// Act - ロックの保持中に読み込みと書き込みを行い、途中でロックを解放する
+	_, impatientErr := impatient.StartImportRun(ctx, models.ImportRun{SourcePath: "impatient.tsv"})
+	_, readErr := impatient.GetDailyStockPrices(ctx)
+	var patientErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, patientErr = patient.UpsertDailyStockPricesFromSeq(ctx, models.NoImportRun, models.DailyStockPriceSeq([]models.DailyStockPrice{
+			{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+		}), 10, models.ConflictPolicyOverwrite)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if err := lockTx.Commit(); err != nil {
+		t.Fatalf("Failed to release the write lock: %v", err)
+	}
+	<-done
+
+	// Assert
+	if !isBusyError(impatientErr) {
+		t.Errorf("Expected SQLITE_BUSY without retries, but got: %v", impatientErr)
+	}
+	if readErr != nil {
+		t.Errorf("Expected readers not to wait for the writer, but got: %v", readErr)
+	}
+	if patientErr != nil {
+		t.Errorf("Expected the write to succeed after retrying, but got: %v", patientErr)
+	}
+	var journalMode string
+	if err := repository.db.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode); err != nil || journalMode != "wal" {
+		t.Errorf("Expected WAL journal mode, but got %q (%v)", journalMode, err)
+	}
+}
+
+func TestSQLiteStockPriceRepository_ReadersSeeConsistentSnapshots(t *testing.T) {
+	// Arrange - 全ての行の株価を1にしておき、取り込みで (銘柄コード, 日付) の順に2へ上書きする
+	// テスト終了後にデータベースファイルを削除
+	const rowCount = 5000
+	const chunkSize = 100
+	dbPath := "./test_consistent_snapshots.db"
+	writer := newTestRepository(t, dbPath)
+	reader := newTestRepositoryWithOptions(t, dbPath, DefaultSQLiteOptions())
+	ctx := context.Background()
+	pricesAt := func(price int64) []models.DailyStockPrice {
+		dailyPrices := make([]models.DailyStockPrice, rowCount)
+		for i := range dailyPrices {
+			dailyPrices[i] = models.DailyStockPrice{
+				PriceDate:  time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
+				StockPrice: models.StockPrice{StockID: fmt.Sprintf("%05d", i), Price: models.NewPrice(price, 0)},
+			}
+		}
+		return dailyPrices
+	}
+	if err := writer.InitializeDailyStockPriceTable(ctx, pricesAt(1)); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	// Act - 取り込みがチャンクごとにコミットしている間、別の接続から全件を読み続ける
+	var importErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	importDone := make(chan struct{})
+	go func() {
+		defer wg.Done()
+		defer close(importDone)
+		_, importErr = writer.UpsertDailyStockPricesFromSeq(ctx, models.NoImportRun, models.DailyStockPriceSeq(pricesAt(2)), chunkSize, models.ConflictPolicyOverwrite)
+	}()
+	var snapshots [][]models.DailyStockPrice
+	var readErrs []error
+	for reading := true; reading; {
+		select {
+		case <-importDone:
+			reading = false
+		default:
+		}
+		dailyPrices, err := reader.GetDailyStockPrices(ctx)
+		if err != nil {
+			readErrs = append(readErrs, err)
+			continue
+		}
+		snapshots = append(snapshots, dailyPrices)
+	}
+	wg.Wait()
+
+	// Assert - 各読み込みはコミット済みのチャンクまでが上書きされた状態だけを見る
+	if importErr != nil {
+		t.Fatalf("Expected the import to succeed, but got: %v", importErr)
+	}
+	if len(readErrs) > 0 {
+		t.Fatalf("Expected readers not to fail during the import, but got %d errors, e.g. %v", len(readErrs), readErrs[0])
+	}
+	for _, snapshot := range snapshots {
+		if len(snapshot) != rowCount {
+			t.Fatalf("Expected %d rows in every snapshot, but got %d", rowCount, len(snapshot))
+		}
+		updated := 0
+		for updated < rowCount && snapshot[updated].StockPrice.Price == models.NewPrice(2, 0) {
+			updated++
+		}
+		for _, dailyPrice := range snapshot[updated:] {
+			if dailyPrice.StockPrice.Price != models.NewPrice(1, 0) {
+				t.Fatalf("Expected the overwritten rows to be a prefix, but %s has price %v after %d overwritten rows",
+					dailyPrice.StockPrice.StockID, dailyPrice.StockPrice.Price, updated)
+			}
+		}
+		if updated%chunkSize != 0 {
+			t.Fatalf("Expected whole chunks to be visible, but saw %d overwritten rows", updated)
+		}
+	}
+	if last := snapshots[len(snapshots)-1]; last[rowCount-1].StockPrice.Price != models.NewPrice(2, 0) {
+		t.Errorf("Expected the last read to see the whole import, but got %+v", last[rowCount-1])
+	}
+}
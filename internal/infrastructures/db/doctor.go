@@ -0,0 +1,503 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"fmt"
+	"hash"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 日次株価を検査のために (銘柄コード, 日付) の順に取得するSQL（銘柄マスタにない銘柄の桁数は NULL）
+const selectDoctorDailyStockPricesSQL = "SELECT p.rowid, p.stock_id, p.price_date, p.price, p.open, p.high, p.low, s.price_scale" +
+	" FROM " + dailyStockPriceTableName + " p LEFT JOIN " + stockTableName + " s ON s.stock_id = p.stock_id" +
+	" ORDER BY p.stock_id, p.price_date"
+
+// 修復できない日次株価の行を退避するテーブル名
+const dailyStockPriceQuarantineTableName = "daily_stock_price_quarantine"
+
+// 日次株価の行を退避テーブルにコピーするSQL（退避日時は修復のトランザクションの版の記録日時）
+const quarantineDailyStockPriceSQL = "INSERT INTO " + dailyStockPriceQuarantineTableName +
+	" (stock_id, price_date, price, open, high, low, volume, import_run_id, price_scale, reason, quarantined_at)" +
+	" SELECT p.stock_id, p.price_date, p.price, p.open, p.high, p.low, p.volume, p.import_run_id, s.price_scale, ?," +
+	" COALESCE((SELECT recorded_at FROM " + dailyStockPriceHistoryClockTableName + "), strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))" +
+	" FROM " + dailyStockPriceTableName + " p LEFT JOIN " + stockTableName + " s ON s.stock_id = p.stock_id" +
+	" WHERE p.rowid = ?"
+
+// 退避した日次株価の行を削除するSQL
+const deleteQuarantinedDailyStockPriceSQL = "DELETE FROM " + dailyStockPriceTableName + " WHERE rowid = ?"
+
+// 退避した日次株価の行の版を削除するSQL（日付を解釈できないため、時点を指定した参照で読めない）
+const deleteQuarantinedDailyStockPriceHistorySQL = "DELETE FROM " + dailyStockPriceHistoryTableName + " WHERE stock_id = ? AND price_date = ?"
+
+// YYYY-MM-DD形式でない日付を修復する際に解釈を試みる形式
+var repairableDateLayouts = []string{"2006/1/2", "2006-1-2", "2006.1.2", "20060102", "2006-01-02 15:04:05", time.RFC3339}
+
+// 日次株価の株価の列名（検査結果に含める名前）
+var doctorPriceColumns = []string{"price", "open", "high", "low"}
+
+// データベースの検査の設定
+type DoctorOptions struct {
+	// 営業日の判定に使用するカレンダー
+	Calendar models.TradingCalendar
+	// 同じ銘柄の株価の間で欠けている営業日がこの日数を超える場合に報告する（0の場合は検査しない）
+	MaxGapTradingDays int
+	// 他の銘柄と全ての株価が一致する系列を、この件数以上の場合に報告する（0の場合は検査しない）
+	MinDuplicateSeriesLength int
+	// 安全に修復できる問題を修復するかどうか
+	Fix bool
+}
+
+// DefaultDoctorOptions はデータベースの検査の既定の設定を返します。
+// 年末年始以外の休場日は指定しません。
+//
+// 戻り値:
+//   - 既定の検査の設定
+func DefaultDoctorOptions() DoctorOptions {
+	return DoctorOptions{
+		Calendar:                 models.NewTradingCalendar(nil),
+		MaxGapTradingDays:        5,
+		MinDuplicateSeriesLength: 5,
+	}
+}
+
+// DiagnoseDatabase はデータベースファイルを検査し、見つかった問題を重大度の高い順に返します。
+// PRAGMA integrity_check と PRAGMA foreign_key_check に続けて、日次株価に次の規則を適用します。
+//   - 株価（終値・始値・高値・安値）が正の値であること
+//   - 日付がYYYY-MM-DD形式であること
+//   - 日付が土曜日・日曜日や取引所の休場日でないこと
+//   - 同じ銘柄の株価の間で営業日が長く欠けていないこと
+//   - 全ての株価が他の銘柄と一致する系列がないこと
+//
+// integrity_check が問題を報告した場合は、破損したファイルを読み書きしないようにその問題のみを返します。
+// options.Fix を指定した場合は、検査と修復を1つの書き込みトランザクションで行い、
+// 修復できる問題（銘柄マスタにない銘柄の登録と、別の形式で保存された日付の変換）を修復して、修復した問題の Fixed を true にします。
+// 変換できない日付の行は daily_stock_price_quarantine テーブルに退避し、日次株価とその版から削除します。
+// 検査から修復までの間に他の接続が書き込まないため、修復は検査した行にのみ適用されます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - dbPath: 検査するSQLiteデータベースファイルのパス
+//   - options: 検査の設定
+//
+// 戻り値:
+//   - 見つかった問題の配列（問題がない場合は空）
+//   - エラー（ファイルが存在しない場合、スキーマが最新でない場合や検査・修復に失敗した場合）
+func DiagnoseDatabase(ctx context.Context, dbPath string, options DoctorOptions) ([]models.DatabaseFinding, error) {
+	if options.MaxGapTradingDays < 0 {
+		return nil, fmt.Errorf("invalid maximum gap: %d", options.MaxGapTradingDays)
+	}
+	if options.MinDuplicateSeriesLength < 0 {
+		return nil, fmt.Errorf("invalid minimum duplicate series length: %d", options.MinDuplicateSeriesLength)
+	}
+
+	// 存在しないファイルを開くと空のデータベースが作成されるため先に確認
+	if err := checkDatabaseFileExists(dbPath); err != nil {
+		return nil, err
+	}
+	db, err := openSQLiteDatabase(ctx, dbPath, inspectionSQLiteOptions())
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// ファイルが壊れている場合は他の検査や修復を行わない
+	problems, err := integrityProblems(ctx, db, dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		findings := make([]models.DatabaseFinding, 0, len(problems))
+		for _, problem := range problems {
+			findings = append(findings, models.DatabaseFinding{Severity: models.SeverityError, Check: models.CheckIntegrity, Message: problem})
+		}
+		return findings, nil
+	}
+
+	// 日次株価の規則はスキーマが最新であることを前提にする（検査でスキーマを変更しない）
+	if err := checkSchemaIsCurrent(ctx, db); err != nil {
+		return nil, err
+	}
+
+	// 修復する場合は検査の前に書き込みロックを取得し、修復を終えるまで保持する
+	var queryer sqlQueryer = db
+	var tx *sql.Tx
+	if options.Fix {
+		tx, err = beginVersionedWriteTx(ctx, db, inspectionSQLiteOptions().BusyRetries)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+		queryer = tx
+	}
+
+	doctor := &databaseDoctor{options: options, seriesOwners: make(map[[sha256.Size]byte]string)}
+	if err := doctor.checkForeignKeys(ctx, queryer); err != nil {
+		return nil, err
+	}
+	if err := doctor.checkDailyStockPrices(ctx, queryer); err != nil {
+		return nil, err
+	}
+	if options.Fix && len(doctor.repairs) > 0 {
+		if err := doctor.repair(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	models.SortDatabaseFindings(doctor.findings)
+	return doctor.findings, nil
+}
+
+// 検査の途中結果を保持する構造体
+type databaseDoctor struct {
+	// 検査の設定
+	options DoctorOptions
+	// 見つかった問題
+	findings []models.DatabaseFinding
+	// 修復できる問題の修復方法
+	repairs []doctorRepair
+	// 株価の系列のハッシュ → 最初にその系列を持っていた銘柄コード
+	seriesOwners map[[sha256.Size]byte]string
+}
+
+// 1つの問題を修復するSQLを示す構造体
+type doctorRepair struct {
+	// 修復する問題の findings での添え字
+	finding int
+	// 修復するSQL
+	query string
+	// SQLの引数
+	args []any
+}
+
+// addFinding は問題を記録し、修復方法がある場合は修復できる問題として記録します。
+//
+// 引数:
+//   - finding: 見つかった問題
+//   - repairQuery: 修復するSQL（修復できない場合は空文字列）
+//   - repairArgs: 修復するSQLの引数
+func (d *databaseDoctor) addFinding(finding models.DatabaseFinding, repairQuery string, repairArgs ...any) {
+	if repairQuery != "" {
+		finding.Fixable = true
+		d.repairs = append(d.repairs, doctorRepair{finding: len(d.findings), query: repairQuery, args: repairArgs})
+	}
+	d.findings = append(d.findings, finding)
+}
+
+// addRepairStatement は直前に記録した修復できる問題に、続けて実行する修復のSQLを追加します。
+//
+// 引数:
+//   - repairQuery: 修復するSQL
+//   - repairArgs: 修復するSQLの引数
+func (d *databaseDoctor) addRepairStatement(repairQuery string, repairArgs ...any) {
+	d.repairs = append(d.repairs, doctorRepair{finding: len(d.findings) - 1, query: repairQuery, args: repairArgs})
+}
+
+// checkForeignKeys は PRAGMA foreign_key_check で参照先のない行を検査します。
+// 銘柄マスタにない銘柄の日次株価は銘柄ごとに1つの問題にまとめ、銘柄コードのみの銘柄マスタを登録して修復できます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - db: データベース接続またはトランザクション
+//
+// 戻り値:
+//   - エラー（データベース操作に失敗した場合）
+func (d *databaseDoctor) checkForeignKeys(ctx context.Context, db sqlQueryer) error {
+	rows, err := db.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+	type violation struct {
+		table  string
+		rowID  sql.NullInt64
+		parent string
+	}
+	var violations []violation
+	for rows.Next() {
+		var v violation
+		var foreignKeyID int
+		if err := rows.Scan(&v.table, &v.rowID, &v.parent, &foreignKeyID); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to check foreign keys: %w", err)
+		}
+		violations = append(violations, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check foreign keys: %w", err)
+	}
+
+	// 銘柄マスタにない銘柄コード → 参照している日次株価の行数（銘柄コードは最初に見つかった順に報告する）
+	var missingStockIDs []string
+	missingStockRows := make(map[string]int)
+	for _, v := range violations {
+		finding := models.DatabaseFinding{
+			Severity: models.SeverityError,
+			Check:    models.CheckForeignKey,
+			Message:  fmt.Sprintf("%s row %d references a missing %s row", v.table, v.rowID.Int64, v.parent),
+		}
+
+		// 日次株価の行は銘柄コードと日付を報告する
+		if v.rowID.Valid && (v.table == dailyStockPriceTableName || v.table == importRunChangesTableName) {
+			err := db.QueryRowContext(ctx, "SELECT stock_id, price_date FROM "+quoteIdentifier(v.table)+" WHERE rowid = ?", v.rowID.Int64).
+				Scan(&finding.StockID, &finding.PriceDate)
+			if err != nil {
+				return fmt.Errorf("failed to read %s row %d: %w", v.table, v.rowID.Int64, err)
+			}
+		}
+		if v.table == dailyStockPriceTableName && v.parent == stockTableName {
+			if missingStockRows[finding.StockID] == 0 {
+				missingStockIDs = append(missingStockIDs, finding.StockID)
+			}
+			missingStockRows[finding.StockID]++
+			continue
+		}
+		d.addFinding(finding, "")
+	}
+
+	for _, stockID := range missingStockIDs {
+		d.addFinding(models.DatabaseFinding{
+			Severity: models.SeverityError,
+			Check:    models.CheckForeignKey,
+			StockID:  stockID,
+			Message: fmt.Sprintf("stock %s is not registered in the %s table (referenced by %d %s rows)",
+				stockID, stockTableName, missingStockRows[stockID], dailyStockPriceTableName),
+		}, registerStockSQL, stockID)
+	}
+	return nil
+}
+
+// 検査中の銘柄の日次株価を示す構造体
+type doctorStockSeries struct {
+	// 銘柄コード
+	stockID string
+	// 保存されている日付の文字列
+	dates map[string]bool
+	// YYYY-MM-DD形式として解釈できない日付の行
+	invalidDates []doctorInvalidDate
+	// 直前の有効な日付
+	previousDate time.Time
+	// 有効な日付の株価の件数
+	length int
+	// 有効な日付の (日付, 株価) の系列のハッシュ
+	hash hash.Hash
+}
+
+// YYYY-MM-DD形式として解釈できない日付の行を示す構造体
+type doctorInvalidDate struct {
+	// 行のrowid
+	rowID int64
+	// 保存されている日付の文字列
+	priceDate string
+}
+
+// checkDailyStockPrices は日次株価の各行に規則を適用します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - db: データベース接続またはトランザクション
+//
+// 戻り値:
+//   - エラー（データベース操作に失敗した場合）
+func (d *databaseDoctor) checkDailyStockPrices(ctx context.Context, db sqlQueryer) error {
+	rows, err := db.QueryContext(ctx, selectDoctorDailyStockPricesSQL)
+	if err != nil {
+		return fmt.Errorf("failed to query data: %w", err)
+	}
+	defer rows.Close()
+
+	var series *doctorStockSeries
+	for rows.Next() {
+		var rowID int64
+		var stockID, dateStr string
+		var ticks [4]sql.NullInt64
+		var scale sql.NullInt64
+		if err := rows.Scan(&rowID, &stockID, &dateStr, &ticks[0], &ticks[1], &ticks[2], &ticks[3], &scale); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		// 銘柄が変わったら前の銘柄の系列の検査を終える
+		if series == nil || series.stockID != stockID {
+			d.finishStockSeries(series)
+			series = &doctorStockSeries{stockID: stockID, dates: make(map[string]bool), hash: sha256.New()}
+		}
+		series.dates[dateStr] = true
+
+		// 株価が正の値であること（銘柄マスタにない銘柄は桁数を0とみなす）
+		prices := make([]models.Price, len(ticks))
+		for i, columnTicks := range ticks {
+			if !columnTicks.Valid {
+				continue
+			}
+			prices[i], err = priceFromTicks(columnTicks.Int64, int(scale.Int64))
+			if err != nil {
+				return fmt.Errorf("failed to read %s of %s on %s: %w", doctorPriceColumns[i], stockID, dateStr, err)
+			}
+			if columnTicks.Int64 <= 0 {
+				d.addFinding(models.DatabaseFinding{
+					Severity:  models.SeverityError,
+					Check:     models.CheckNonPositivePrice,
+					StockID:   stockID,
+					PriceDate: dateStr,
+					Message:   fmt.Sprintf("%s %s is not positive", doctorPriceColumns[i], prices[i]),
+				}, "")
+			}
+		}
+
+		// 日付がYYYY-MM-DD形式であること（修復できるかどうかは銘柄の全ての日付を読んでから判定する）
+		priceDate, err := time.Parse(time.DateOnly, dateStr)
+		if err != nil {
+			series.invalidDates = append(series.invalidDates, doctorInvalidDate{rowID: rowID, priceDate: dateStr})
+			continue
+		}
+		d.checkTradingDay(series, priceDate, dateStr)
+		fmt.Fprintf(series.hash, "%s\t%s\n", dateStr, prices[0])
+		series.length++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error during iteration: %w", err)
+	}
+	d.finishStockSeries(series)
+	return nil
+}
+
+// checkTradingDay は日付が営業日であることと、直前の日付との間で営業日が長く欠けていないことを検査します。
+//
+// 引数:
+//   - series: 検査中の銘柄の日次株価
+//   - priceDate: 日付
+//   - dateStr: 保存されている日付の文字列
+func (d *databaseDoctor) checkTradingDay(series *doctorStockSeries, priceDate time.Time, dateStr string) {
+	calendar := d.options.Calendar
+	switch {
+	case calendar.IsWeekend(priceDate):
+		d.addFinding(models.DatabaseFinding{
+			Severity:  models.SeverityWarning,
+			Check:     models.CheckWeekend,
+			StockID:   series.stockID,
+			PriceDate: dateStr,
+			Message:   fmt.Sprintf("%s is a %s", dateStr, priceDate.Weekday()),
+		}, "")
+	case calendar.IsHoliday(priceDate):
+		d.addFinding(models.DatabaseFinding{
+			Severity:  models.SeverityWarning,
+			Check:     models.CheckHoliday,
+			StockID:   series.stockID,
+			PriceDate: dateStr,
+			Message:   fmt.Sprintf("%s is an exchange holiday", dateStr),
+		}, "")
+	}
+
+	if !series.previousDate.IsZero() && d.options.MaxGapTradingDays > 0 {
+		if missing := calendar.TradingDaysBetween(series.previousDate, priceDate); missing > d.options.MaxGapTradingDays {
+			d.addFinding(models.DatabaseFinding{
+				Severity:  models.SeverityInfo,
+				Check:     models.CheckGap,
+				StockID:   series.stockID,
+				PriceDate: dateStr,
+				Message:   fmt.Sprintf("%d trading days are missing since %s", missing, series.previousDate.Format(time.DateOnly)),
+			}, "")
+		}
+	}
+	series.previousDate = priceDate
+}
+
+// finishStockSeries は1銘柄の全ての日次株価を読んだ後の検査を行います。
+// 解釈できない日付は、別の形式として解釈でき、変換後の日付の行が存在しない場合は変換して修復できる問題とし、
+// それ以外の場合は行を退避テーブルに移して修復できる問題とします（参照がその行で失敗しないようにする）。
+// 全ての株価が先に読んだ銘柄と一致する系列は、取り込みの誤りの可能性がある問題とします。
+//
+// 引数:
+//   - series: 検査を終える銘柄の日次株価（nil の場合は何もしない）
+func (d *databaseDoctor) finishStockSeries(series *doctorStockSeries) {
+	if series == nil {
+		return
+	}
+
+	for _, invalid := range series.invalidDates {
+		finding := models.DatabaseFinding{
+			Severity:  models.SeverityError,
+			Check:     models.CheckInvalidDate,
+			StockID:   series.stockID,
+			PriceDate: invalid.priceDate,
+			Message:   fmt.Sprintf("date %q is not in YYYY-MM-DD format", invalid.priceDate),
+		}
+		repairedDate, ok := repairDate(invalid.priceDate)
+		if !ok || series.dates[repairedDate] {
+			reason := finding.Message
+			if ok {
+				finding.Message += fmt.Sprintf(" (another row is stored as %s; can be moved to %s)", repairedDate, dailyStockPriceQuarantineTableName)
+			} else {
+				finding.Message += fmt.Sprintf(" (can be moved to %s)", dailyStockPriceQuarantineTableName)
+			}
+			d.addFinding(finding, quarantineDailyStockPriceSQL, reason, invalid.rowID)
+			d.addRepairStatement(deleteQuarantinedDailyStockPriceSQL, invalid.rowID)
+			d.addRepairStatement(deleteQuarantinedDailyStockPriceHistorySQL, series.stockID, invalid.priceDate)
+			continue
+		}
+		series.dates[repairedDate] = true
+		finding.Message += fmt.Sprintf(" (can be stored as %s)", repairedDate)
+		d.addFinding(finding, "UPDATE "+dailyStockPriceTableName+" SET price_date = ? WHERE rowid = ?", repairedDate, invalid.rowID)
+	}
+
+	if d.options.MinDuplicateSeriesLength == 0 || series.length < d.options.MinDuplicateSeriesLength {
+		return
+	}
+	var sum [sha256.Size]byte
+	series.hash.Sum(sum[:0])
+	if owner, ok := d.seriesOwners[sum]; ok {
+		d.addFinding(models.DatabaseFinding{
+			Severity: models.SeverityWarning,
+			Check:    models.CheckDuplicateSeries,
+			StockID:  series.stockID,
+			Message:  fmt.Sprintf("all %d daily prices are the same as those of %s", series.length, owner),
+		}, "")
+		return
+	}
+	d.seriesOwners[sum] = series.stockID
+}
+
+// repairDate は別の形式で保存された日付をYYYY-MM-DD形式に変換します。
+//
+// 引数:
+//   - dateStr: 保存されている日付の文字列
+//
+// 戻り値:
+//   - YYYY-MM-DD形式の日付
+//   - 変換できた場合は true
+func repairDate(dateStr string) (string, bool) {
+	for _, layout := range repairableDateLayouts {
+		if date, err := time.Parse(layout, dateStr); err == nil {
+			return date.Format(time.DateOnly), true
+		}
+	}
+	return "", false
+}
+
+// repair は修復できる問題を、検査に使用したトランザクションで修復してコミットします。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - tx: 検査に使用した書き込みトランザクション
+//
+// 戻り値:
+//   - エラー（修復に失敗した場合、全ての修復はロールバックされる）
+func (d *databaseDoctor) repair(ctx context.Context, tx *sql.Tx) error {
+	for _, repair := range d.repairs {
+		if _, err := tx.ExecContext(ctx, repair.query, repair.args...); err != nil {
+			finding := d.findings[repair.finding]
+			return fmt.Errorf("failed to fix %s of %s %s: %w", finding.Check, finding.StockID, finding.PriceDate, err)
+		}
+	}
+	if err := commitVersionedWriteTx(ctx, tx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, repair := range d.repairs {
+		d.findings[repair.finding].Fixed = true
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+func TestDiagnoseDatabase(t *testing.T) {
+	// Arrange - 規則に反する行と、他の銘柄と同じ株価が続く銘柄を用意する
+	// テスト終了後にデータベースファイルを削除
+	dbPath := "./test_doctor.db"
+	repository := newTestRepository(t, dbPath)
+	ctx := context.Background()
+	var dailyPrices []models.DailyStockPrice
+	for _, stockID := range []string{"7203", "7267"} {
+		for day := 3; day <= 7; day++ {
+			dailyPrices = append(dailyPrices, models.DailyStockPrice{
+				PriceDate:  time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC),
+				StockPrice: models.StockPrice{StockID: stockID, Price: models.NewPrice(int64(2800+day), 0)},
+			})
+		}
+	}
+	dailyPrices = append(dailyPrices,
+		models.DailyStockPrice{PriceDate: time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8000, 0)}},
+		models.DailyStockPrice{PriceDate: time.Date(2025, 2, 11, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8100, 0)}},
+		models.DailyStockPrice{PriceDate: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8200, 0)}},
+		models.DailyStockPrice{PriceDate: time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(0, 0)}},
+	)
+	if err := repository.InitializeDailyStockPriceTable(ctx, dailyPrices); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	// 外部キー制約を有効にしていない接続で書き込む
+	execTestSQL(t, dbPath, "INSERT INTO daily_stock_price (stock_id, price_date, price) VALUES ('1111', '2025/2/4', 100), ('1111', '2025-02-05', 101), ('9984', '2025/3/3', 8300), ('9984', '2025-13-45', 8400)")
+	options := DefaultDoctorOptions()
+	options.Calendar = models.NewTradingCalendar([]time.Time{time.Date(2025, 2, 11, 0, 0, 0, 0, time.UTC)})
+
+	// Act
+	findings, err := DiagnoseDatabase(ctx, dbPath, options)
+
+	// Assert - 重大度の高い順に並び、修復できる問題のみ fixable になる（銘柄マスタにない銘柄は銘柄ごとに1つ）
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	expected := []string{
+		"error foreign-key 1111  fixable=true",
+		"error invalid-date 1111 2025/2/4 fixable=true",
+		"error non-positive-price 9984 2025-03-08 fixable=false",
+		"error invalid-date 9984 2025-13-45 fixable=true",
+		"error invalid-date 9984 2025/3/3 fixable=true",
+		"warning duplicate-series 7267  fixable=false",
+		"warning holiday 9984 2025-02-11 fixable=false",
+		"warning weekend 9984 2025-03-08 fixable=false",
+		"info gap 9984 2025-03-03 fixable=false",
+	}
+	assertFindings(t, findings, expected)
+	if message := findings[0].Message; message != "stock 1111 is not registered in the stock table (referenced by 2 daily_stock_price rows)" {
+		t.Errorf("Expected the missing stock to be reported once with its row count, but got %q", message)
+	}
+
+	if _, err := repository.GetDailyStockPrices(ctx); err == nil {
+		t.Fatal("Expected reading the unreadable dates to fail before the fix, but got nil")
+	}
+
+	// Act - 修復できる問題を修復してから再度検査する
+	options.Fix = true
+	fixedFindings, fixErr := DiagnoseDatabase(ctx, dbPath, options)
+	options.Fix = false
+	remainingFindings, err := DiagnoseDatabase(ctx, dbPath, options)
+
+	// Assert
+	if fixErr != nil || err != nil {
+		t.Fatalf("Expected no error, but got: %v, %v", fixErr, err)
+	}
+	for i, finding := range fixedFindings {
+		if finding.Fixed != finding.Fixable {
+			t.Errorf("Expected only the fixable findings to be fixed, but got %+v at %d", finding, i)
+		}
+	}
+	assertFindings(t, remainingFindings, append(expected[2:3:3], expected[5:]...))
+	var stockCount int
+	if err := repository.db.QueryRow("SELECT COUNT(*) FROM stock WHERE stock_id = '1111'").Scan(&stockCount); err != nil || stockCount != 1 {
+		t.Errorf("Expected the missing stock to be registered, but got %d (%v)", stockCount, err)
+	}
+	// 日付を変換できない行は退避され、日次株価を全て読み込める
+	if _, err := repository.GetDailyStockPrices(ctx); err != nil {
+		t.Errorf("Expected all daily prices to be readable after the fix, but got: %v", err)
+	}
+	rows, err := repository.db.Query("SELECT price_date, price, price_scale FROM daily_stock_price_quarantine WHERE stock_id = '9984' ORDER BY price_date")
+	if err != nil {
+		t.Fatalf("Failed to query quarantined rows: %v", err)
+	}
+	defer rows.Close()
+	var quarantined []string
+	for rows.Next() {
+		var priceDate string
+		var price, scale int64
+		if err := rows.Scan(&priceDate, &price, &scale); err != nil {
+			t.Fatalf("Failed to scan quarantined row: %v", err)
+		}
+		quarantined = append(quarantined, fmt.Sprintf("%s %d %d", priceDate, price, scale))
+	}
+	if fmt.Sprint(quarantined) != "[2025-13-45 8400 0 2025/3/3 8300 0]" {
+		t.Errorf("Expected the unreadable rows to be quarantined, but got %q", quarantined)
+	}
+	var historyCount int
+	if err := repository.db.QueryRow("SELECT COUNT(*) FROM daily_stock_price_history WHERE price_date IN ('2025-13-45', '2025/3/3')").Scan(&historyCount); err != nil || historyCount != 0 {
+		t.Errorf("Expected the versions of the quarantined rows to be removed, but got %d (%v)", historyCount, err)
+	}
+}
+
+// assertFindings は問題を "重大度 検査項目 銘柄コード 日付 fixable=修復可否" の文字列に変換して比較します。
+func assertFindings(t *testing.T, findings []models.DatabaseFinding, expected []string) {
+	t.Helper()
+	var actual []string
+	for _, finding := range findings {
+		actual = append(actual, fmt.Sprintf("%s %s %s %s fixable=%t", finding.Severity, finding.Check, finding.StockID, finding.PriceDate, finding.Fixable))
+	}
+	if fmt.Sprint(actual) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected findings.\nExpected: %q\nGot: %q", expected, actual)
+	}
+}
+
+func TestDiagnoseDatabase_RequiresCurrentSchema(t *testing.T) {
+	// Arrange - マイグレーションが途中までのデータベース
+	// テスト終了後にデータベースファイルを削除
+	dbPath := "./test_doctor_legacy.db"
+	openTestMigrator(t, dbPath)
+	execTestSQL(t, dbPath, "CREATE TABLE daily_stock_price (stock_id TEXT NOT NULL, price_date TEXT NOT NULL, price REAL NOT NULL, PRIMARY KEY (stock_id, price_date))")
+
+	// Act
+	findings, err := DiagnoseDatabase(context.Background(), dbPath, DefaultDoctorOptions())
+
+	// Assert
+	if err == nil {
+		t.Errorf("Expected an error for an old schema, but got findings %+v", findings)
+	}
+	if _, err := DiagnoseDatabase(context.Background(), "./missing.db", DefaultDoctorOptions()); err == nil {
+		t.Error("Expected an error for a missing database file, but got nil")
+	}
+}
@@ -0,0 +1,345 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 取り込み履歴テーブル名
+const importRunsTableName = "import_runs"
+
+// 取り込みが上書きまたは削除した行を保存するテーブル名
+const importRunChangesTableName = "import_run_changes"
+
+// 取り込みの開始・終了・取り消し日時のフォーマット
+const importRunTimeFormat = time.RFC3339
+
+// 取り込み履歴を取得するための列リスト
+const selectImportRunColumns = "run_id, source_path, source_sha256, options, status, started_at, finished_at, undone_at," +
+	" inserted_count, updated_count, unchanged_count, replaced_count"
+
+// 日次株価テーブルと保存した行で共通の列リスト
+const dailyStockPriceRowColumns = "stock_id, price_date, price, open, high, low, volume, import_run_id"
+
+// 上書きする行を取り消し用に保存するSQL
+// 同じ取り込みが書き込んだ行は取り込み前の値ではないため保存せず、同じ行は最初の値のみ保存する
+const saveReplacedDailyStockPriceSQL = "INSERT OR IGNORE INTO " + importRunChangesTableName + " (run_id, " + dailyStockPriceRowColumns + ")" +
+	" SELECT ?, " + dailyStockPriceRowColumns + " FROM " + dailyStockPriceTableName +
+	" WHERE stock_id = ? AND price_date = ? AND import_run_id IS NOT ?"
+
+// 削除する全ての行を取り消し用に保存するSQL
+const saveAllReplacedDailyStockPricesSQL = "INSERT OR IGNORE INTO " + importRunChangesTableName + " (run_id, " + dailyStockPriceRowColumns + ")" +
+	" SELECT ?, " + dailyStockPriceRowColumns + " FROM " + dailyStockPriceTableName +
+	" WHERE import_run_id IS NOT ?"
+
+// 取り消す取り込みが書き込んだ行を、取り消していない後の取り込みが上書きまたは削除したかどうかを調べるSQL
+const selectOverwrittenByLaterRunSQL = "SELECT c.run_id, c.stock_id, c.price_date FROM " + importRunChangesTableName + " c" +
+	" JOIN " + importRunsTableName + " r ON r.run_id = c.run_id" +
+	" WHERE c.import_run_id = ? AND r.status <> ? ORDER BY c.run_id, c.stock_id, c.price_date LIMIT 1"
+
+// 取り消す取り込みが上書きまたは削除した行に、後から別の書き込みがあったかどうかを調べるSQL
+const selectRewrittenAfterRunSQL = "SELECT p.import_run_id, c.stock_id, c.price_date FROM " + importRunChangesTableName + " c" +
+	" JOIN " + dailyStockPriceTableName + " p ON p.stock_id = c.stock_id AND p.price_date = c.price_date" +
+	" WHERE c.run_id = ? AND p.import_run_id IS NOT ? ORDER BY c.stock_id, c.price_date LIMIT 1"
+
+// StartImportRun は取り込みの開始を記録し、取り込みIDを返します。
+// 開始日時には現在日時を記録し、run の入力ファイルのパス・SHA-256・設定以外は無視します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - run: 記録する取り込み
+//
+// 戻り値:
+//   - 取り込みID
+//   - エラー（データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) StartImportRun(ctx context.Context, run models.ImportRun) (int64, error) {
+	var result sql.Result
+	err := retryOnBusy(ctx, r.busyRetries, func() error {
+		var err error
+		result, err = r.db.ExecContext(ctx,
+			"INSERT INTO "+importRunsTableName+" (source_path, source_sha256, options, status, started_at) VALUES (?, ?, ?, ?, ?)",
+			run.SourcePath, run.SourceSHA256, run.Options, models.ImportRunRunning, time.Now().UTC().Format(importRunTimeFormat))
+		return err
+	})
+	if err != nil {
+		return models.NoImportRun, fmt.Errorf("failed to record import run: %w", err)
+	}
+
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return models.NoImportRun, fmt.Errorf("failed to record import run: %w", err)
+	}
+	return runID, nil
+}
+
+// FinishImportRun は取り込みの終了日時と、run の状態・SHA-256・取り込み結果を記録します。
+// 標準入力から読み込む場合など、SHA-256 は取り込みを終えるまで確定しないため終了時に記録し直します。
+// 上書きまたは削除した行数は取り消し用に保存した行から数えます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - run: 終了した取り込み（状態は ImportRunCompleted または ImportRunFailed）
+//
+// 戻り値:
+//   - エラー（取り込みが記録されていない場合やデータベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) FinishImportRun(ctx context.Context, run models.ImportRun) error {
+	var execResult sql.Result
+	err := retryOnBusy(ctx, r.busyRetries, func() error {
+		var err error
+		execResult, err = r.db.ExecContext(ctx,
+			"UPDATE "+importRunsTableName+" SET status = ?, finished_at = ?, source_sha256 = ?,"+
+				" inserted_count = ?, updated_count = ?, unchanged_count = ?,"+
+				" replaced_count = (SELECT COUNT(*) FROM "+importRunChangesTableName+" WHERE run_id = ?) WHERE run_id = ?",
+			run.Status, time.Now().UTC().Format(importRunTimeFormat), run.SourceSHA256,
+			run.Result.Inserted, run.Result.Updated, run.Result.Unchanged, run.ID, run.ID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record import run: %w", err)
+	}
+
+	updatedCount, err := execResult.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to record import run: %w", err)
+	}
+	if updatedCount == 0 {
+		return fmt.Errorf("import run %d not found", run.ID)
+	}
+	return nil
+}
+
+// GetImportRuns は取り込みID順に全ての取り込み履歴を取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - 取り込み履歴の配列
+//   - エラー（データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) GetImportRuns(ctx context.Context) ([]models.ImportRun, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+selectImportRunColumns+" FROM "+importRunsTableName+" ORDER BY run_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query import runs: %w", err)
+	}
+	defer rows.Close()
+
+	// 結果を格納するスライス
+	var runs []models.ImportRun
+
+	// 各行を処理
+	for rows.Next() {
+		run, err := scanImportRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+
+	// エラーをチェック
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	return runs, nil
+}
+
+// GetImportRun は取り込みIDに一致する取り込み履歴を取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - runID: 取り込みID
+//
+// 戻り値:
+//   - 取り込み履歴
+//   - 記録されている場合は true
+//   - エラー（データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) GetImportRun(ctx context.Context, runID int64) (models.ImportRun, bool, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+selectImportRunColumns+" FROM "+importRunsTableName+" WHERE run_id = ?", runID)
+	run, err := scanImportRun(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ImportRun{}, false, nil
+	}
+	if err != nil {
+		return models.ImportRun{}, false, err
+	}
+	return run, true, nil
+}
+
+// UndoImportRun は取り込みで書き込まれた行を削除し、取り込みが上書きまたは削除した行を
+// 取り込み前の値で復元します。全ての変更を1つのトランザクションで実行します。
+// 後の取り込みや取り込み履歴に記録されない書き込みが同じ行を変更している場合は、
+// 何も変更せずに models.ImportRunUndoConflictError を返します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - runID: 取り消す取り込みID
+//
+// 戻り値:
+//   - 削除・復元した行数
+//   - エラー（取り込みが記録されていない場合、取り消し済みの場合、後の変更と衝突する場合（models.ImportRunUndoConflictError）、
+//     データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) UndoImportRun(ctx context.Context, runID int64) (models.UndoImportResult, error) {
+	// トランザクションを開始
+	tx, err := r.beginWriteTx(ctx)
+	if err != nil {
+		return models.UndoImportResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 取り込みの状態を確認
+	var status models.ImportRunStatus
+	err = tx.QueryRowContext(ctx, "SELECT status FROM "+importRunsTableName+" WHERE run_id = ?", runID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.UndoImportResult{}, fmt.Errorf("import run %d not found", runID)
+	}
+	if err != nil {
+		return models.UndoImportResult{}, fmt.Errorf("failed to query import run: %w", err)
+	}
+	if status == models.ImportRunUndone {
+		return models.UndoImportResult{}, fmt.Errorf("import run %d has already been undone", runID)
+	}
+
+	// 後の変更と衝突しないことを確認
+	if err := checkImportRunUndoConflict(ctx, tx, runID); err != nil {
+		return models.UndoImportResult{}, err
+	}
+
+	// 取り込みで書き込まれた行を削除
+	var result models.UndoImportResult
+	execResult, err := tx.ExecContext(ctx, "DELETE FROM "+dailyStockPriceTableName+" WHERE import_run_id = ?", runID)
+	if err != nil {
+		return models.UndoImportResult{}, fmt.Errorf("failed to delete imported data: %w", err)
+	}
+	removedCount, err := execResult.RowsAffected()
+	if err != nil {
+		return models.UndoImportResult{}, fmt.Errorf("failed to delete imported data: %w", err)
+	}
+	result.Removed = int(removedCount)
+
+	// 上書きまたは削除された行を復元
+	execResult, err = tx.ExecContext(ctx,
+		"INSERT INTO "+dailyStockPriceTableName+" ("+dailyStockPriceRowColumns+")"+
+			" SELECT "+dailyStockPriceRowColumns+" FROM "+importRunChangesTableName+" WHERE run_id = ?", runID)
+	if err != nil {
+		return models.UndoImportResult{}, fmt.Errorf("failed to restore replaced data: %w", err)
+	}
+	restoredCount, err := execResult.RowsAffected()
+	if err != nil {
+		return models.UndoImportResult{}, fmt.Errorf("failed to restore replaced data: %w", err)
+	}
+	result.Restored = int(restoredCount)
+
+	// 取り消したことを記録
+	_, err = tx.ExecContext(ctx, "UPDATE "+importRunsTableName+" SET status = ?, undone_at = ? WHERE run_id = ?",
+		models.ImportRunUndone, time.Now().UTC().Format(importRunTimeFormat), runID)
+	if err != nil {
+		return models.UndoImportResult{}, fmt.Errorf("failed to record undo: %w", err)
+	}
+
+	// トランザクションをコミット
+	if err := commitVersionedWriteTx(ctx, tx); err != nil {
+		return models.UndoImportResult{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// checkImportRunUndoConflict は取り込みを取り消すと後の変更を失う行がないことを確認します。
+//   - 取り込みが書き込んだ行を、取り消していない後の取り込みが上書きまたは削除した場合
+//   - 取り込みが上書きまたは削除した行に、後から別の書き込みがあった場合
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - tx: トランザクション
+//   - runID: 取り消す取り込みID
+//
+// 戻り値:
+//   - エラー（後の変更と衝突する場合（models.ImportRunUndoConflictError）やデータベース操作に失敗した場合）
+func checkImportRunUndoConflict(ctx context.Context, tx *sql.Tx, runID int64) error {
+	var laterRunID sql.NullInt64
+	var stockID, dateStr string
+
+	err := tx.QueryRowContext(ctx, selectOverwrittenByLaterRunSQL, runID, models.ImportRunUndone).Scan(&laterRunID, &stockID, &dateStr)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = tx.QueryRowContext(ctx, selectRewrittenAfterRunSQL, runID, runID).Scan(&laterRunID, &stockID, &dateStr)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to query later changes: %w", err)
+	}
+
+	priceDate, err := time.Parse(time.RFC3339[:10], dateStr)
+	if err != nil {
+		return fmt.Errorf("failed to parse date: %w", err)
+	}
+	return &models.ImportRunUndoConflictError{
+		RunID:      runID,
+		LaterRunID: laterRunID.Int64,
+		StockID:    stockID,
+		PriceDate:  priceDate,
+	}
+}
+
+// scanImportRun は取り込み履歴の1行を読み込みます。
+//
+// 引数:
+//   - row: selectImportRunColumns の列を持つ行
+//
+// 戻り値:
+//   - 取り込み履歴
+//   - エラー（読み込みや日時の解析に失敗した場合、行がない場合は sql.ErrNoRows）
+func scanImportRun(row interface{ Scan(dest ...any) error }) (models.ImportRun, error) {
+	var run models.ImportRun
+	var startedAt string
+	var finishedAt, undoneAt sql.NullString
+	err := row.Scan(&run.ID, &run.SourcePath, &run.SourceSHA256, &run.Options, &run.Status, &startedAt, &finishedAt, &undoneAt,
+		&run.Result.Inserted, &run.Result.Updated, &run.Result.Unchanged, &run.Replaced)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ImportRun{}, err
+	}
+	if err != nil {
+		return models.ImportRun{}, fmt.Errorf("failed to scan import run: %w", err)
+	}
+
+	// 日時を解析
+	for _, field := range []struct {
+		value sql.NullString
+		dest  *time.Time
+	}{
+		{value: sql.NullString{String: startedAt, Valid: true}, dest: &run.StartedAt},
+		{value: finishedAt, dest: &run.FinishedAt},
+		{value: undoneAt, dest: &run.UndoneAt},
+	} {
+		if !field.value.Valid {
+			continue
+		}
+		parsed, err := time.Parse(importRunTimeFormat, field.value.String)
+		if err != nil {
+			return models.ImportRun{}, fmt.Errorf("failed to parse import run time: %w", err)
+		}
+		*field.dest = parsed
+	}
+
+	return run, nil
+}
+
+// nullableImportRunID は取り込みIDをSQLに渡す値に変換します。
+//
+// 引数:
+//   - runID: 取り込みID
+//
+// 戻り値:
+//   - models.NoImportRun の場合は nil（NULL）、それ以外は取り込みID
+func nullableImportRunID(runID int64) any {
+	if runID == models.NoImportRun {
+		return nil
+	}
+	return runID
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// startTestImportRun はテスト用に取り込みの開始を記録します。
+func startTestImportRun(t *testing.T, repository *SQLiteStockPriceRepository, sourcePath string) int64 {
+	t.Helper()
+	runID, err := repository.StartImportRun(context.Background(), models.ImportRun{SourcePath: sourcePath, SourceSHA256: "abc", Options: "-mode=upsert"})
+	if err != nil {
+		t.Fatalf("Failed to start import run: %v", err)
+	}
+	return runID
+}
+
+func TestUndoImportRun(t *testing.T) {
+	// Arrange - 取り込み1で置き換え、取り込み2で上書きと追加
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_import_run_undo.db")
+	ctx := context.Background()
+	date := func(day int) time.Time { return time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC) }
+	if err := repository.InitializeDailyStockPriceTable(ctx, []models.DailyStockPrice{
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2700, 0)}},
+	}); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	originalPrices, _ := repository.GetDailyStockPrices(ctx)
+
+	firstRunID := startTestImportRun(t, repository, "first.tsv")
+	if _, err := repository.InitializeDailyStockPriceTableFromSeq(ctx, firstRunID, models.DailyStockPriceSeq([]models.DailyStockPrice{
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2800, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+	}), 1); err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	firstPrices, _ := repository.GetDailyStockPrices(ctx)
+
+	secondRunID := startTestImportRun(t, repository, "second.tsv")
+	result, err := repository.UpsertDailyStockPricesFromSeq(ctx, secondRunID, models.DailyStockPriceSeq([]models.DailyStockPrice{
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2880, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2890, 0)}},
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903, 0)}},
+	}), 2, models.ConflictPolicyOverwrite)
+	if err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	if err := repository.FinishImportRun(ctx, models.ImportRun{ID: secondRunID, Status: models.ImportRunCompleted, SourceSHA256: "def", Result: result}); err != nil {
+		t.Fatalf("Failed to finish import run: %v", err)
+	}
+
+	// Act - 後の取り込みから順に取り消す
+	secondUndo, secondErr := repository.UndoImportRun(ctx, secondRunID)
+	afterSecondUndo, _ := repository.GetDailyStockPrices(ctx)
+	firstUndo, firstErr := repository.UndoImportRun(ctx, firstRunID)
+	afterFirstUndo, _ := repository.GetDailyStockPrices(ctx)
+
+	// Assert
+	if secondErr != nil || firstErr != nil {
+		t.Fatalf("Expected no error, but got: %v, %v", secondErr, firstErr)
+	}
+	if secondUndo != (models.UndoImportResult{Removed: 2, Restored: 1}) {
+		t.Errorf("Unexpected result of undoing the second run: %+v", secondUndo)
+	}
+	if !reflect.DeepEqual(afterSecondUndo, firstPrices) {
+		t.Errorf("Expected the prices after the first run.\nExpected: %+v\nGot: %+v", firstPrices, afterSecondUndo)
+	}
+	if firstUndo != (models.UndoImportResult{Removed: 2, Restored: 1}) {
+		t.Errorf("Unexpected result of undoing the first run: %+v", firstUndo)
+	}
+	if !reflect.DeepEqual(afterFirstUndo, originalPrices) {
+		t.Errorf("Expected the original prices.\nExpected: %+v\nGot: %+v", originalPrices, afterFirstUndo)
+	}
+	run, found, err := repository.GetImportRun(ctx, secondRunID)
+	if err != nil || !found {
+		t.Fatalf("Expected the import run to be recorded: %v", err)
+	}
+	if run.Status != models.ImportRunUndone || run.UndoneAt.IsZero() || run.FinishedAt.IsZero() {
+		t.Errorf("Expected the run to be finished and undone, but got %+v", run)
+	}
+	if run.Result != result || run.Replaced != 1 || run.SourceSHA256 != "def" || run.SourcePath != "second.tsv" {
+		t.Errorf("Unexpected recorded run: %+v", run)
+	}
+}
+
+func TestUndoImportRun_ConflictWithLaterRun(t *testing.T) {
+	// Arrange - 取り込み1が書き込んだ行を取り込み2が上書きする
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_import_run_conflict.db")
+	ctx := context.Background()
+	date := func(day int) time.Time { return time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC) }
+	firstRunID := startTestImportRun(t, repository, "first.tsv")
+	if _, err := repository.UpsertDailyStockPricesFromSeq(ctx, firstRunID, models.DailyStockPriceSeq([]models.DailyStockPrice{
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+	}), 10, models.ConflictPolicyOverwrite); err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+	secondRunID := startTestImportRun(t, repository, "second.tsv")
+	if _, err := repository.UpsertDailyStockPricesFromSeq(ctx, secondRunID, models.DailyStockPriceSeq([]models.DailyStockPrice{
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2880, 0)}},
+	}), 10, models.ConflictPolicyOverwrite); err != nil {
+		t.Fatalf("Failed to import: %v", err)
+	}
+
+	// Act
+	_, err := repository.UndoImportRun(ctx, firstRunID)
+
+	// Assert
+	var conflictErr *models.ImportRunUndoConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Expected ImportRunUndoConflictError, but got: %v", err)
+	}
+	if conflictErr.LaterRunID != secondRunID || conflictErr.StockID != "7203" || !conflictErr.PriceDate.Equal(date(4)) {
+		t.Errorf("Unexpected conflict: %+v", conflictErr)
+	}
+	prices, _ := repository.GetDailyStockPrices(ctx)
+	if len(prices) != 1 || prices[0].StockPrice.Price != models.NewPrice(2880, 0) {
+		t.Errorf("Expected nothing to be changed, but got %+v", prices)
+	}
+	if _, err := repository.UndoImportRun(ctx, 99); err == nil {
+		t.Error("Expected an error for an unknown import run, but got nil")
+	}
+}
@@ -0,0 +1,552 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 適用済みのマイグレーションを記録するテーブル名
+const schemaMigrationsTableName = "schema_migrations"
+
+// 適用済みのマイグレーションを記録するテーブルの作成SQL
+const createSchemaMigrationsTableSQL = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TEXT NOT NULL
+);
+`
+
+// マイグレーションファイルを格納するディレクトリ
+const migrationsDir = "migrations"
+
+// マイグレーションの適用日時のフォーマット
+const migrationTimeFormat = time.RFC3339
+
+// バイナリに埋め込んだマイグレーションファイル
+//
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// マイグレーションファイル名の形式（<番号>_<名前>.sql）
+var migrationFileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+
+// 番号付きのスキーマ変更を示す構造体
+type Migration struct {
+	// バージョン（1から始まる連番）
+	Version int
+	// 名前（ファイル名の番号と拡張子を除いた部分）
+	Name string
+	// 実行するSQL
+	SQL string
+}
+
+// マイグレーションの適用状況を示す構造体
+type MigrationStatus struct {
+	// バージョン
+	Version int
+	// 名前
+	Name string
+	// 適用済みかどうか
+	Applied bool
+	// 適用日時（マイグレーション導入前のデータベースから引き継いだ場合はゼロ値）
+	AppliedAt time.Time
+	// このバイナリに含まれるマイグレーションかどうか
+	Known bool
+}
+
+// SchemaVersionError はデータベースのスキーマがバイナリより新しい場合のエラー
+type SchemaVersionError struct {
+	// データベースのスキーマバージョン
+	DatabaseVersion int
+	// バイナリが対応するスキーマバージョン
+	SupportedVersion int
+}
+
+func (e *SchemaVersionError) Error() string {
+	return fmt.Sprintf("database schema version %d is newer than this binary supports (%d)", e.DatabaseVersion, e.SupportedVersion)
+}
+
+// Migrator はデータベースにマイグレーションを適用する構造体
+type Migrator struct {
+	// データベース接続
+	db *sql.DB
+	// 適用するマイグレーション（バージョン順）
+	migrations []Migration
+	// Close でデータベース接続を閉じるかどうか
+	ownsDB bool
+}
+
+// 適用済みのマイグレーションを示す構造体
+type appliedMigration struct {
+	// 名前
+	name string
+	// 適用日時
+	appliedAt time.Time
+}
+
+// QueryContext と QueryRowContext を持つデータベース接続またはトランザクション
+type sqlQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// NewMigrator はSQLiteデータベースを開き、バイナリに埋め込んだマイグレーションを
+// 適用する Migrator を作成します。使い終わったら Close を呼び出してください。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - dbPath: SQLiteデータベースファイルのパス
+//
+// 戻り値:
+//   - Migrator
+//   - エラー（データベースを開けない場合やマイグレーションファイルが不正な場合）
+func NewMigrator(ctx context.Context, dbPath string) (*Migrator, error) {
+	migrations, err := loadMigrations(embeddedMigrations)
+	if err != nil {
+		return nil, err
+	}
+
+	// データベース接続を開く
+	db, err := openSQLiteDatabase(ctx, dbPath, DefaultSQLiteOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations, ownsDB: true}, nil
+}
+
+// migrateDatabase はバイナリに埋め込んだ未適用のマイグレーションを全て適用します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - db: データベース接続
+//
+// 戻り値:
+//   - エラー（データベースがバイナリより新しい場合（SchemaVersionError）やマイグレーションに失敗した場合）
+func migrateDatabase(ctx context.Context, db *sql.DB) error {
+	migrations, err := loadMigrations(embeddedMigrations)
+	if err != nil {
+		return err
+	}
+	migrator := &Migrator{db: db, migrations: migrations}
+	_, err = migrator.Up(ctx)
+	return err
+}
+
+// checkSchemaIsCurrent はデータベースのスキーマがバイナリの最新のバージョンであることを確認します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - db: データベース接続
+//
+// 戻り値:
+//   - エラー（スキーマが古い場合、バイナリより新しい場合（SchemaVersionError）やデータベース操作に失敗した場合）
+func checkSchemaIsCurrent(ctx context.Context, db *sql.DB) error {
+	migrations, err := loadMigrations(embeddedMigrations)
+	if err != nil {
+		return err
+	}
+	migrator := &Migrator{db: db, migrations: migrations}
+	_, version, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if version > migrator.LatestVersion() {
+		return &SchemaVersionError{DatabaseVersion: version, SupportedVersion: migrator.LatestVersion()}
+	}
+	if version < migrator.LatestVersion() {
+		return fmt.Errorf("database is at schema version %d but this binary requires %d; run migrate up first", version, migrator.LatestVersion())
+	}
+	return nil
+}
+
+// Close はデータベース接続を閉じます。
+//
+// 戻り値:
+//   - エラー（接続を閉じられない場合）
+func (m *Migrator) Close() error {
+	if !m.ownsDB {
+		return nil
+	}
+	return m.db.Close()
+}
+
+// LatestVersion はバイナリが対応するスキーマバージョンを返します。
+//
+// 戻り値:
+//   - 最新のマイグレーションのバージョン
+func (m *Migrator) LatestVersion() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status はマイグレーションの適用状況をバージョン順に返します。
+// データベースを変更しないため、マイグレーション導入前のデータベースでは
+// 既存のテーブルから判定した適用状況を返します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - マイグレーションの適用状況（データベースにのみ記録されたバージョンを含む）
+//   - データベースのスキーマバージョン
+//   - エラー（データベース操作に失敗した場合）
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, int, error) {
+	applied, err := m.readAppliedMigrations(ctx, m.db)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// バイナリに含まれるマイグレーションの適用状況
+	var statuses []MigrationStatus
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name, Known: true}
+		if appliedMigration, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = appliedMigration.appliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	// データベースにのみ記録されたマイグレーション
+	for version, appliedMigration := range applied {
+		statuses = append(statuses, MigrationStatus{
+			Version:   version,
+			Name:      appliedMigration.name,
+			Applied:   true,
+			AppliedAt: appliedMigration.appliedAt,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, schemaVersion(statuses), nil
+}
+
+// Up は未適用のマイグレーションをバージョン順に適用します。
+// 各マイグレーションは適用の記録とともに1つのトランザクションで実行され、
+// 失敗した場合はそのマイグレーションのみロールバックされます。
+// マイグレーション導入前に作成されたデータベースの場合は、既存のテーブルから
+// 判定したバージョンまでを適用済みとして記録してから適用します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - 適用したマイグレーション
+//   - エラー（データベースがバイナリより新しい場合（SchemaVersionError）やマイグレーションに失敗した場合）
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	// 適用状況を記録するテーブルを作成
+	if err := m.createSchemaMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+
+	// データベースがバイナリより新しい場合は中断
+	statuses, databaseVersion, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if databaseVersion > m.LatestVersion() {
+		return nil, &SchemaVersionError{DatabaseVersion: databaseVersion, SupportedVersion: m.LatestVersion()}
+	}
+
+	// 未適用のマイグレーションを適用
+	appliedVersions := make(map[int]bool)
+	for _, status := range statuses {
+		appliedVersions[status.Version] = status.Applied
+	}
+	var appliedMigrations []Migration
+	for _, migration := range m.migrations {
+		if appliedVersions[migration.Version] {
+			continue
+		}
+		if err := m.apply(ctx, migration); err != nil {
+			return appliedMigrations, err
+		}
+		appliedMigrations = append(appliedMigrations, migration)
+	}
+
+	return appliedMigrations, nil
+}
+
+// apply は1つのマイグレーションとその記録を1つのトランザクションで実行します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - migration: 適用するマイグレーション
+//
+// 戻り値:
+//   - エラー（マイグレーションに失敗した場合）
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	tx, err := beginWriteTx(ctx, m.db, DefaultSQLiteOptions().BusyRetries)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.SQL); err != nil {
+		return fmt.Errorf("failed to apply migration %04d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if err := recordMigration(ctx, tx, migration, time.Now()); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %04d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// createSchemaMigrationsTable は適用状況を記録するテーブルを作成します。
+// マイグレーション導入前に作成されたデータベースの場合は、既存のテーブルから
+// 判定したバージョンまでのマイグレーションを同じトランザクションで記録します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - エラー（データベース操作に失敗した場合）
+func (m *Migrator) createSchemaMigrationsTable(ctx context.Context) error {
+	// 作成済みの場合は書き込みロックを取得しない（取り込み中のデータベースも待たずに開けるようにする）
+	exists, err := tableExists(ctx, m.db, schemaMigrationsTableName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	tx, err := beginWriteTx(ctx, m.db, DefaultSQLiteOptions().BusyRetries)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// 他の接続が先に作成した場合に備えてトランザクション内で確認し直す
+	exists, err = tableExists(ctx, tx, schemaMigrationsTableName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	// 既存のテーブルから適用済みのバージョンを判定
+	legacyVersion, err := legacySchemaVersion(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, createSchemaMigrationsTableSQL); err != nil {
+		return fmt.Errorf("failed to create table: %w", err)
+	}
+	for _, migration := range m.migrations {
+		if migration.Version > legacyVersion {
+			break
+		}
+		if err := recordMigration(ctx, tx, migration, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// readAppliedMigrations は適用済みのマイグレーションを取得します。
+// 適用状況を記録するテーブルが存在しない場合は既存のテーブルから判定します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - q: データベース接続またはトランザクション
+//
+// 戻り値:
+//   - バージョンをキーとした適用済みのマイグレーション
+//   - エラー（データベース操作に失敗した場合）
+func (m *Migrator) readAppliedMigrations(ctx context.Context, q sqlQueryer) (map[int]appliedMigration, error) {
+	applied := make(map[int]appliedMigration)
+
+	exists, err := tableExists(ctx, q, schemaMigrationsTableName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		legacyVersion, err := legacySchemaVersion(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		for _, migration := range m.migrations {
+			if migration.Version <= legacyVersion {
+				applied[migration.Version] = appliedMigration{name: migration.Name}
+			}
+		}
+		return applied, nil
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT version, name, applied_at FROM "+schemaMigrationsTableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var name, appliedAtStr string
+		if err := rows.Scan(&version, &name, &appliedAtStr); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		appliedAt, err := time.Parse(migrationTimeFormat, appliedAtStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse applied_at of migration %d: %w", version, err)
+		}
+		applied[version] = appliedMigration{name: name, appliedAt: appliedAt}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	return applied, nil
+}
+
+// recordMigration はマイグレーションを適用済みとして記録します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - tx: トランザクション
+//   - migration: 記録するマイグレーション
+//   - appliedAt: 適用日時
+//
+// 戻り値:
+//   - エラー（データベース操作に失敗した場合）
+func recordMigration(ctx context.Context, tx *sql.Tx, migration Migration, appliedAt time.Time) error {
+	_, err := tx.ExecContext(ctx, "INSERT INTO "+schemaMigrationsTableName+" (version, name, applied_at) VALUES (?, ?, ?)",
+		migration.Version, migration.Name, appliedAt.UTC().Format(migrationTimeFormat))
+	if err != nil {
+		return fmt.Errorf("failed to record migration %04d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// legacySchemaVersion はマイグレーション導入前に作成されたデータベースの
+// スキーマバージョンを既存のテーブルから判定します。
+//   - daily_stock_priceテーブルがない場合: 0
+//   - 四本値の列がない場合: 1（0001_create_daily_stock_price）
+//   - 四本値の列がある場合: 2（0002_add_daily_stock_price_ohlcv）
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - q: データベース接続またはトランザクション
+//
+// 戻り値:
+//   - スキーマバージョン
+//   - エラー（データベース操作に失敗した場合）
+func legacySchemaVersion(ctx context.Context, q sqlQueryer) (int, error) {
+	exists, err := tableExists(ctx, q, dailyStockPriceTableName)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	var openColumnCount int
+	err = q.QueryRowContext(ctx, "SELECT COUNT(*) FROM pragma_table_info('"+dailyStockPriceTableName+"') WHERE name = 'open'").Scan(&openColumnCount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query table info: %w", err)
+	}
+	if openColumnCount == 0 {
+		return 1, nil
+	}
+	return 2, nil
+}
+
+// tableExists はテーブルが存在するかどうかを返します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - q: データベース接続またはトランザクション
+//   - tableName: テーブル名
+//
+// 戻り値:
+//   - テーブルが存在するかどうか
+//   - エラー（データベース操作に失敗した場合）
+func tableExists(ctx context.Context, q sqlQueryer, tableName string) (bool, error) {
+	var count int
+	err := q.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", tableName).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to query table %s: %w", tableName, err)
+	}
+	return count > 0, nil
+}
+
+// schemaVersion は適用済みのマイグレーションの最大バージョンを返します。
+//
+// 引数:
+//   - statuses: マイグレーションの適用状況
+//
+// 戻り値:
+//   - スキーマバージョン（適用済みのマイグレーションがない場合は0）
+func schemaVersion(statuses []MigrationStatus) int {
+	version := 0
+	for _, status := range statuses {
+		if status.Applied && status.Version > version {
+			version = status.Version
+		}
+	}
+	return version
+}
+
+// loadMigrations はマイグレーションファイルを読み込み、バージョン順に並べて返します。
+// バージョンは1から始まる連番である必要があります。
+//
+// 引数:
+//   - migrationFiles: migrations ディレクトリを含むファイルシステム
+//
+// 戻り値:
+//   - バージョン順のマイグレーション
+//   - エラー（ファイル名が不正な場合やバージョンが連番でない場合）
+func loadMigrations(migrationFiles fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, migrationsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []Migration
+	for _, entry := range entries {
+		matches := migrationFileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+		version, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version: %s", entry.Name())
+		}
+		content, err := fs.ReadFile(migrationFiles, path.Join(migrationsDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: matches[2], SQL: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be consecutive from 1: found %04d_%s at position %d", migration.Version, migration.Name, i+1)
+		}
+	}
+
+	return migrations, nil
+}
@@ -0,0 +1,286 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// openTestMigrator はテスト用の Migrator を作成します。
+// テスト終了時に Migrator を閉じてデータベースファイルを削除します。
+func openTestMigrator(t *testing.T, dbPath string) *Migrator {
+	t.Helper()
+	migrator, err := NewMigrator(context.Background(), dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open migrator: %v", err)
+	}
+	t.Cleanup(func() {
+		migrator.Close()
+		os.Remove(dbPath)
+	})
+	return migrator
+}
+
+// execTestSQL はテスト用のデータベースでSQLを実行します。
+func execTestSQL(t *testing.T, dbPath string, query string) {
+	t.Helper()
+	db, err := sql.Open(sqliteDriverName, dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(query); err != nil {
+		t.Fatalf("Failed to execute %q: %v", query, err)
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	// Act
+	migrations, err := loadMigrations(embeddedMigrations)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(migrations) < 2 {
+		t.Fatalf("Expected at least 2 migrations, but got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "create_daily_stock_price" {
+		t.Errorf("Unexpected first migration: %+v", migrations[0])
+	}
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	testCases := map[string]fstest.MapFS{
+		"バージョンが連番でない": {
+			"migrations/0001_first.sql": {Data: []byte("SELECT 1;")},
+			"migrations/0003_third.sql": {Data: []byte("SELECT 1;")},
+		},
+		"ファイル名が不正": {
+			"migrations/first.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+
+	for name, migrationFiles := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			_, err := loadMigrations(migrationFiles)
+
+			// Assert
+			if err == nil {
+				t.Error("Expected an error, but got nil")
+			}
+		})
+	}
+}
+
+func TestMigrator_UpAndStatus(t *testing.T) {
+	// Arrange
+	migrator := openTestMigrator(t, "./test_migrate_up.db")
+
+	// Act
+	appliedMigrations, err := migrator.Up(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(appliedMigrations) != migrator.LatestVersion() {
+		t.Errorf("Expected %d applied migrations, but got %d", migrator.LatestVersion(), len(appliedMigrations))
+	}
+	statuses, version, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	if version != migrator.LatestVersion() {
+		t.Errorf("Expected version %d, but got %d", migrator.LatestVersion(), version)
+	}
+	for _, status := range statuses {
+		if !status.Applied || !status.Known || status.AppliedAt.IsZero() {
+			t.Errorf("Expected an applied migration with a timestamp, but got %+v", status)
+		}
+	}
+
+	// 2回目は何も適用しない
+	appliedMigrations, err = migrator.Up(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error on second run, but got: %v", err)
+	}
+	if len(appliedMigrations) != 0 {
+		t.Errorf("Expected no migrations on second run, but got %d", len(appliedMigrations))
+	}
+}
+
+func TestMigrator_LegacyDatabase(t *testing.T) {
+	testCases := []struct {
+		name            string
+		createTableSQL  string
+		expectedVersion int
+	}{
+		{
+			name:            "四本値の列がないテーブル",
+			createTableSQL:  "CREATE TABLE daily_stock_price (stock_id TEXT NOT NULL, price_date TEXT NOT NULL, price REAL NOT NULL, PRIMARY KEY (stock_id, price_date))",
+			expectedVersion: 1,
+		},
+		{
+			name:            "四本値の列があるテーブル",
+			createTableSQL:  "CREATE TABLE daily_stock_price (stock_id TEXT NOT NULL, price_date TEXT NOT NULL, price REAL NOT NULL, open REAL, high REAL, low REAL, volume INTEGER, PRIMARY KEY (stock_id, price_date))",
+			expectedVersion: 2,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			dbPath := "./test_migrate_legacy_" + string(rune('a'+i)) + ".db"
+			execTestSQL(t, dbPath, tc.createTableSQL)
+			execTestSQL(t, dbPath, "INSERT INTO daily_stock_price (stock_id, price_date, price) VALUES ('7203', '2025-02-04', 2873)")
+			migrator := openTestMigrator(t, dbPath)
+
+			// Act
+			_, version, err := migrator.Status(context.Background())
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Failed to get status: %v", err)
+			}
+			if version != tc.expectedVersion {
+				t.Errorf("Expected legacy version %d, but got %d", tc.expectedVersion, version)
+			}
+
+			// Act - 残りのマイグレーションを適用
+			appliedMigrations, err := migrator.Up(context.Background())
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Failed to migrate legacy database: %v", err)
+			}
+			if len(appliedMigrations) != migrator.LatestVersion()-tc.expectedVersion {
+				t.Errorf("Expected %d applied migrations, but got %d", migrator.LatestVersion()-tc.expectedVersion, len(appliedMigrations))
+			}
+			var count int
+			if err := migrator.db.QueryRow("SELECT COUNT(*) FROM daily_stock_price WHERE open IS NULL").Scan(&count); err != nil {
+				t.Fatalf("Failed to query migrated table: %v", err)
+			}
+			if count != 1 {
+				t.Errorf("Expected existing row to be kept, but got %d rows", count)
+			}
+		})
+	}
+}
+
+func TestMigrator_ConvertsRealPricesToTicks(t *testing.T) {
+	// Arrange - 株価を REAL で保存していたバージョン2のデータベース
+	// テスト終了後にデータベースファイルを削除
+	dbPath := "./test_migrate_price_ticks.db"
+	execTestSQL(t, dbPath, "CREATE TABLE daily_stock_price (stock_id TEXT NOT NULL, price_date TEXT NOT NULL, price REAL NOT NULL,"+
+		" open REAL, high REAL, low REAL, volume INTEGER, PRIMARY KEY (stock_id, price_date))")
+	execTestSQL(t, dbPath, "INSERT INTO daily_stock_price (stock_id, price_date, price, open, high, low, volume) VALUES"+
+		" ('7203', '2025-02-04', 2873, NULL, NULL, NULL, NULL),"+
+		" ('7203', '2025-02-05', 2903.5, 2870.25, 2910, 2865.1, 1200),"+
+		" ('9984', '2025-02-04', 8000, NULL, NULL, NULL, NULL)")
+
+	// Act
+	repository := newTestRepository(t, dbPath)
+	dailyBars, err := repository.GetDailyStockBarsByDateRange(context.Background(), "7203",
+		time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC))
+
+	// Assert - 銘柄ごとに全ての株価を表せる桁数の整数に変換される
+	if err != nil {
+		t.Fatalf("Failed to get migrated bars: %v", err)
+	}
+	if len(dailyBars) != 2 {
+		t.Fatalf("Expected 2 bars, but got %+v", dailyBars)
+	}
+	if dailyBars[0].Close != models.NewPrice(2873, 0) ||
+		dailyBars[1].Close != models.NewPrice(29035, 1) || dailyBars[1].Open != models.NewPrice(287025, 2) ||
+		dailyBars[1].High != models.NewPrice(2910, 0) || dailyBars[1].Low != models.NewPrice(28651, 1) {
+		t.Errorf("Expected exact prices after migration, but got %+v", dailyBars)
+	}
+	rows, err := repository.db.Query("SELECT stock_id, price_scale FROM stock ORDER BY stock_id")
+	if err != nil {
+		t.Fatalf("Failed to query price scales: %v", err)
+	}
+	defer rows.Close()
+	scales := make(map[string]int)
+	for rows.Next() {
+		var stockID string
+		var scale int
+		if err := rows.Scan(&stockID, &scale); err != nil {
+			t.Fatalf("Failed to scan price scale: %v", err)
+		}
+		scales[stockID] = scale
+	}
+	if scales["7203"] != 2 || scales["9984"] != 0 {
+		t.Errorf("Expected price scales 2 and 0, but got %v", scales)
+	}
+}
+
+func TestMigrator_RefusesNewerDatabase(t *testing.T) {
+	// Arrange
+	dbPath := "./test_migrate_newer.db"
+	migrator := openTestMigrator(t, dbPath)
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	execTestSQL(t, dbPath, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (9999, 'from_the_future', '2030-01-01T00:00:00Z')")
+
+	// Act
+	_, err := NewSQLiteStockPriceRepository(context.Background(), dbPath)
+
+	// Assert
+	var versionErr *SchemaVersionError
+	if !errors.As(err, &versionErr) {
+		t.Fatalf("Expected SchemaVersionError, but got: %v", err)
+	}
+	if versionErr.DatabaseVersion != 9999 || versionErr.SupportedVersion != migrator.LatestVersion() {
+		t.Errorf("Unexpected error: %+v", versionErr)
+	}
+	statuses, _, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Expected status to work on a newer database, but got: %v", err)
+	}
+	if last := statuses[len(statuses)-1]; last.Known || last.Name != "from_the_future" {
+		t.Errorf("Expected the unknown migration to be listed last, but got %+v", last)
+	}
+}
+
+func TestMigrator_FailedMigrationRollsBack(t *testing.T) {
+	// Arrange
+	migrator := openTestMigrator(t, "./test_migrate_rollback.db")
+	migrator.migrations = []Migration{
+		{Version: 1, Name: "create_table", SQL: "CREATE TABLE example (id INTEGER PRIMARY KEY);"},
+		{Version: 2, Name: "broken", SQL: "ALTER TABLE example ADD COLUMN name TEXT; ALTER TABLE missing ADD COLUMN name TEXT;"},
+	}
+
+	// Act
+	appliedMigrations, err := migrator.Up(context.Background())
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error, but got nil")
+	}
+	if len(appliedMigrations) != 1 {
+		t.Errorf("Expected 1 applied migration, but got %d", len(appliedMigrations))
+	}
+	_, version, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get status: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("Expected version 1 after the failed migration, but got %d", version)
+	}
+	var count int
+	if err := migrator.db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('example') WHERE name = 'name'").Scan(&count); err != nil {
+		t.Fatalf("Failed to query table info: %v", err)
+	}
+	if count != 0 {
+		t.Error("Expected the partial migration to be rolled back")
+	}
+}
@@ -0,0 +1,7 @@
+-- 日次株価テーブルを作成
+CREATE TABLE daily_stock_price (
+    stock_id TEXT NOT NULL,
+    price_date TEXT NOT NULL,
+    price REAL NOT NULL,
+    PRIMARY KEY (stock_id, price_date)
+);
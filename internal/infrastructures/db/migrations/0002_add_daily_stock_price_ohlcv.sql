@@ -0,0 +1,5 @@
+-- 四本値と出来高の列を追加（終値は price 列に格納する）
+ALTER TABLE daily_stock_price ADD COLUMN open REAL;
+ALTER TABLE daily_stock_price ADD COLUMN high REAL;
+ALTER TABLE daily_stock_price ADD COLUMN low REAL;
+ALTER TABLE daily_stock_price ADD COLUMN volume INTEGER;
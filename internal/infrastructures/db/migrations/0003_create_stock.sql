@@ -0,0 +1,27 @@
+-- 銘柄マスタテーブルを作成
+CREATE TABLE stock (
+    stock_id TEXT NOT NULL PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    market TEXT NOT NULL DEFAULT '',
+    sector TEXT NOT NULL DEFAULT '',
+    currency TEXT NOT NULL DEFAULT 'JPY'
+);
+
+-- 既存の日次株価の銘柄を銘柄コードのみで登録
+INSERT INTO stock (stock_id) SELECT DISTINCT stock_id FROM daily_stock_price;
+
+-- 銘柄マスタへの外部キーを追加するため日次株価テーブルを作り直す
+CREATE TABLE daily_stock_price_new (
+    stock_id TEXT NOT NULL REFERENCES stock (stock_id),
+    price_date TEXT NOT NULL,
+    price REAL NOT NULL,
+    open REAL,
+    high REAL,
+    low REAL,
+    volume INTEGER,
+    PRIMARY KEY (stock_id, price_date)
+);
+INSERT INTO daily_stock_price_new (stock_id, price_date, price, open, high, low, volume)
+    SELECT stock_id, price_date, price, open, high, low, volume FROM daily_stock_price;
+DROP TABLE daily_stock_price;
+ALTER TABLE daily_stock_price_new RENAME TO daily_stock_price;
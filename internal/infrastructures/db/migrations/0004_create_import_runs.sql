@@ -0,0 +1,34 @@
+-- 取り込みの履歴を記録するテーブルを作成
+CREATE TABLE import_runs (
+    run_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source_path TEXT NOT NULL,
+    source_sha256 TEXT NOT NULL,
+    options TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    started_at TEXT NOT NULL,
+    finished_at TEXT,
+    undone_at TEXT,
+    inserted_count INTEGER NOT NULL DEFAULT 0,
+    updated_count INTEGER NOT NULL DEFAULT 0,
+    unchanged_count INTEGER NOT NULL DEFAULT 0,
+    replaced_count INTEGER NOT NULL DEFAULT 0
+);
+
+-- 日次株価の各行を書き込んだ取り込みに紐付ける（マイグレーション前の行は NULL）
+ALTER TABLE daily_stock_price ADD COLUMN import_run_id INTEGER REFERENCES import_runs (run_id);
+CREATE INDEX daily_stock_price_import_run_id ON daily_stock_price (import_run_id);
+
+-- 取り込みが上書きまたは削除した行の取り込み前の値を保存するテーブルを作成
+CREATE TABLE import_run_changes (
+    run_id INTEGER NOT NULL REFERENCES import_runs (run_id),
+    stock_id TEXT NOT NULL,
+    price_date TEXT NOT NULL,
+    price REAL NOT NULL,
+    open REAL,
+    high REAL,
+    low REAL,
+    volume INTEGER,
+    import_run_id INTEGER,
+    PRIMARY KEY (run_id, stock_id, price_date)
+);
+CREATE INDEX import_run_changes_import_run_id ON import_run_changes (import_run_id);
@@ -0,0 +1,87 @@
+-- 株価を銘柄ごとの小数点以下の桁数（price_scale）を単位とする整数で保存する
+-- 例: price_scale が1の銘柄の 2903.5 は 29035 として保存する
+ALTER TABLE stock ADD COLUMN price_scale INTEGER NOT NULL DEFAULT 0;
+
+-- 取り消し用に保存した行の銘柄も銘柄マスタに登録する
+INSERT INTO stock (stock_id) SELECT DISTINCT stock_id FROM import_run_changes WHERE true
+    ON CONFLICT (stock_id) DO NOTHING;
+
+-- 既存の株価を全て表せる最小の桁数を銘柄ごとに求める（6桁で表せない端数は6桁に丸める）
+CREATE TEMP TABLE price_scale_factors (scale INTEGER PRIMARY KEY, factor INTEGER NOT NULL);
+INSERT INTO price_scale_factors (scale, factor)
+    VALUES (0, 1), (1, 10), (2, 100), (3, 1000), (4, 10000), (5, 100000), (6, 1000000);
+
+WITH prices (stock_id, value) AS (
+    SELECT stock_id, price FROM daily_stock_price
+    UNION ALL SELECT stock_id, open FROM daily_stock_price WHERE open IS NOT NULL
+    UNION ALL SELECT stock_id, high FROM daily_stock_price WHERE high IS NOT NULL
+    UNION ALL SELECT stock_id, low FROM daily_stock_price WHERE low IS NOT NULL
+    UNION ALL SELECT stock_id, price FROM import_run_changes
+    UNION ALL SELECT stock_id, open FROM import_run_changes WHERE open IS NOT NULL
+    UNION ALL SELECT stock_id, high FROM import_run_changes WHERE high IS NOT NULL
+    UNION ALL SELECT stock_id, low FROM import_run_changes WHERE low IS NOT NULL
+),
+required (stock_id, scale) AS (
+    SELECT p.stock_id, MAX(COALESCE((
+        SELECT MIN(f.scale) FROM price_scale_factors f
+        WHERE ABS(p.value * f.factor - ROUND(p.value * f.factor)) < 0.001
+    ), 6))
+    FROM prices p
+    GROUP BY p.stock_id
+)
+UPDATE stock SET price_scale = required.scale FROM required WHERE stock.stock_id = required.stock_id;
+
+-- 株価の列を整数にするため日次株価テーブルを作り直す
+CREATE TABLE daily_stock_price_new (
+    stock_id TEXT NOT NULL REFERENCES stock (stock_id),
+    price_date TEXT NOT NULL,
+    price INTEGER NOT NULL,
+    open INTEGER,
+    high INTEGER,
+    low INTEGER,
+    volume INTEGER,
+    import_run_id INTEGER REFERENCES import_runs (run_id),
+    PRIMARY KEY (stock_id, price_date)
+);
+INSERT INTO daily_stock_price_new (stock_id, price_date, price, open, high, low, volume, import_run_id)
+    SELECT p.stock_id, p.price_date,
+        CAST(ROUND(p.price * f.factor) AS INTEGER),
+        CAST(ROUND(p.open * f.factor) AS INTEGER),
+        CAST(ROUND(p.high * f.factor) AS INTEGER),
+        CAST(ROUND(p.low * f.factor) AS INTEGER),
+        p.volume, p.import_run_id
+    FROM daily_stock_price p
+    JOIN stock s ON s.stock_id = p.stock_id
+    JOIN price_scale_factors f ON f.scale = s.price_scale;
+DROP TABLE daily_stock_price;
+ALTER TABLE daily_stock_price_new RENAME TO daily_stock_price;
+CREATE INDEX daily_stock_price_import_run_id ON daily_stock_price (import_run_id);
+
+-- 取り消し用に保存した行も同じ単位の整数にする
+CREATE TABLE import_run_changes_new (
+    run_id INTEGER NOT NULL REFERENCES import_runs (run_id),
+    stock_id TEXT NOT NULL,
+    price_date TEXT NOT NULL,
+    price INTEGER NOT NULL,
+    open INTEGER,
+    high INTEGER,
+    low INTEGER,
+    volume INTEGER,
+    import_run_id INTEGER,
+    PRIMARY KEY (run_id, stock_id, price_date)
+);
+INSERT INTO import_run_changes_new (run_id, stock_id, price_date, price, open, high, low, volume, import_run_id)
+    SELECT c.run_id, c.stock_id, c.price_date,
+        CAST(ROUND(c.price * f.factor) AS INTEGER),
+        CAST(ROUND(c.open * f.factor) AS INTEGER),
+        CAST(ROUND(c.high * f.factor) AS INTEGER),
+        CAST(ROUND(c.low * f.factor) AS INTEGER),
+        c.volume, c.import_run_id
+    FROM import_run_changes c
+    JOIN stock s ON s.stock_id = c.stock_id
+    JOIN price_scale_factors f ON f.scale = s.price_scale;
+DROP TABLE import_run_changes;
+ALTER TABLE import_run_changes_new RENAME TO import_run_changes;
+CREATE INDEX import_run_changes_import_run_id ON import_run_changes (import_run_id);
+
+DROP TABLE price_scale_factors;
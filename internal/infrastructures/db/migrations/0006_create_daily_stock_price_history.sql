@@ -0,0 +1,58 @@
+-- 日次株価の全ての版を記録するテーブルを作成
+-- 各版は recorded_at から superseded_at の直前まで daily_stock_price に存在した値を示す（superseded_at が NULL の版が現在の値）
+-- 日時はミリ秒までのUTC（YYYY-MM-DDTHH:MM:SS.SSSZ）で、文字列の順序が日時の順序と一致する
+CREATE TABLE daily_stock_price_history (
+    stock_id TEXT NOT NULL,
+    price_date TEXT NOT NULL,
+    price INTEGER NOT NULL,
+    open INTEGER,
+    high INTEGER,
+    low INTEGER,
+    volume INTEGER,
+    import_run_id INTEGER,
+    recorded_at TEXT NOT NULL,
+    superseded_at TEXT
+);
+CREATE INDEX daily_stock_price_history_key ON daily_stock_price_history (stock_id, price_date, recorded_at);
+CREATE UNIQUE INDEX daily_stock_price_history_current ON daily_stock_price_history (stock_id, price_date)
+    WHERE superseded_at IS NULL;
+
+-- 既存の行を現在の版として記録する（記録日時は書き込んだ取り込みの開始日時、不明な場合はマイグレーションの日時）
+INSERT INTO daily_stock_price_history (stock_id, price_date, price, open, high, low, volume, import_run_id, recorded_at)
+    SELECT p.stock_id, p.price_date, p.price, p.open, p.high, p.low, p.volume, p.import_run_id,
+        COALESCE(strftime('%Y-%m-%dT%H:%M:%fZ', r.started_at), strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+    FROM daily_stock_price p
+    LEFT JOIN import_runs r ON r.run_id = p.import_run_id;
+
+-- daily_stock_price の変更を全て版として記録する
+-- 取り込み・取り消し・修復などの書き込み方法によらず履歴が残るようにトリガーで記録する
+CREATE TRIGGER daily_stock_price_history_insert AFTER INSERT ON daily_stock_price
+BEGIN
+    UPDATE daily_stock_price_history SET superseded_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+        WHERE stock_id = NEW.stock_id AND price_date = NEW.price_date AND superseded_at IS NULL;
+    INSERT INTO daily_stock_price_history (stock_id, price_date, price, open, high, low, volume, import_run_id, recorded_at)
+        VALUES (NEW.stock_id, NEW.price_date, NEW.price, NEW.open, NEW.high, NEW.low, NEW.volume, NEW.import_run_id,
+            strftime('%Y-%m-%dT%H:%M:%fZ', 'now'));
+END;
+
+-- 現在の版と値が同じ場合（取り込みIDのみの変更や、先に履歴を変換した桁数の変更）は版を作らない
+CREATE TRIGGER daily_stock_price_history_update AFTER UPDATE ON daily_stock_price
+WHEN NOT EXISTS (
+    SELECT 1 FROM daily_stock_price_history h
+    WHERE h.stock_id = NEW.stock_id AND h.price_date = NEW.price_date AND h.superseded_at IS NULL
+        AND h.price = NEW.price AND h.open IS NEW.open AND h.high IS NEW.high AND h.low IS NEW.low AND h.volume IS NEW.volume
+)
+BEGIN
+    UPDATE daily_stock_price_history SET superseded_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+        WHERE superseded_at IS NULL
+            AND ((stock_id = OLD.stock_id AND price_date = OLD.price_date) OR (stock_id = NEW.stock_id AND price_date = NEW.price_date));
+    INSERT INTO daily_stock_price_history (stock_id, price_date, price, open, high, low, volume, import_run_id, recorded_at)
+        VALUES (NEW.stock_id, NEW.price_date, NEW.price, NEW.open, NEW.high, NEW.low, NEW.volume, NEW.import_run_id,
+            strftime('%Y-%m-%dT%H:%M:%fZ', 'now'));
+END;
+
+CREATE TRIGGER daily_stock_price_history_delete AFTER DELETE ON daily_stock_price
+BEGIN
+    UPDATE daily_stock_price_history SET superseded_at = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+        WHERE stock_id = OLD.stock_id AND price_date = OLD.price_date AND superseded_at IS NULL;
+END;
@@ -0,0 +1,86 @@
+-- 日次株価の版の記録日時を書き込みトランザクションごとに1つにする
+-- リポジトリは書き込みトランザクションの開始時に recorded_at に記録日時を書き込み、コミットの直前に NULL に戻す
+-- recorded_at が NULL の場合（リポジトリを経由しない書き込み）は従来どおり文ごとの現在日時を使う
+-- last_recorded_at は最後にコミットした記録日時で、次のトランザクションの記録日時を必ずこれより後にするために使う
+CREATE TABLE daily_stock_price_history_clock (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    recorded_at TEXT,
+    last_recorded_at TEXT
+);
+INSERT INTO daily_stock_price_history_clock (id, last_recorded_at)
+    SELECT 1, MAX(MAX(recorded_at), COALESCE(MAX(superseded_at), '')) FROM daily_stock_price_history;
+
+-- 同じトランザクションの中で記録して置き換えた版は残さず、
+-- 同じトランザクションで置き換えた版と値が同じ行を書き込んだ場合は、新しい版を作らずにその版を現在の版に戻す
+-- （置き換えで削除してから同じ値を挿入した行や、取り込みの取り消しで元に戻った行は版が増えない）
+DROP TRIGGER daily_stock_price_history_insert;
+DROP TRIGGER daily_stock_price_history_update;
+DROP TRIGGER daily_stock_price_history_delete;
+
+CREATE TRIGGER daily_stock_price_history_insert AFTER INSERT ON daily_stock_price
+BEGIN
+    DELETE FROM daily_stock_price_history
+        WHERE stock_id = NEW.stock_id AND price_date = NEW.price_date AND superseded_at IS NULL
+            AND recorded_at = COALESCE((SELECT recorded_at FROM daily_stock_price_history_clock), strftime('%Y-%m-%dT%H:%M:%fZ', 'now'));
+    UPDATE daily_stock_price_history
+        SET superseded_at = COALESCE((SELECT recorded_at FROM daily_stock_price_history_clock), strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+        WHERE stock_id = NEW.stock_id AND price_date = NEW.price_date AND superseded_at IS NULL;
+    UPDATE daily_stock_price_history SET superseded_at = NULL
+        WHERE rowid = (
+            SELECT h.rowid FROM daily_stock_price_history h
+            WHERE h.stock_id = NEW.stock_id AND h.price_date = NEW.price_date
+                AND h.superseded_at = COALESCE((SELECT recorded_at FROM daily_stock_price_history_clock), strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+            ORDER BY h.recorded_at DESC LIMIT 1
+        )
+            AND price = NEW.price AND open IS NEW.open AND high IS NEW.high AND low IS NEW.low AND volume IS NEW.volume;
+    INSERT INTO daily_stock_price_history (stock_id, price_date, price, open, high, low, volume, import_run_id, recorded_at)
+        SELECT NEW.stock_id, NEW.price_date, NEW.price, NEW.open, NEW.high, NEW.low, NEW.volume, NEW.import_run_id,
+            COALESCE((SELECT recorded_at FROM daily_stock_price_history_clock), strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+        WHERE NOT EXISTS (
+            SELECT 1 FROM daily_stock_price_history h
+            WHERE h.stock_id = NEW.stock_id AND h.price_date = NEW.price_date AND h.superseded_at IS NULL
+        );
+END;
+
+-- 現在の版と値が同じ場合（取り込みIDのみの変更や、先に履歴を変換した桁数の変更）は版を作らない
+CREATE TRIGGER daily_stock_price_history_update AFTER UPDATE ON daily_stock_price
+WHEN NOT EXISTS (
+    SELECT 1 FROM daily_stock_price_history h
+    WHERE h.stock_id = NEW.stock_id AND h.price_date = NEW.price_date AND h.superseded_at IS NULL
+        AND h.price = NEW.price AND h.open IS NEW.open AND h.high IS NEW.high AND h.low IS NEW.low AND h.volume IS NEW.volume
+)
+BEGIN
+    DELETE FROM daily_stock_price_history
+        WHERE superseded_at IS NULL
+            AND recorded_at = COALESCE((SELECT recorded_at FROM daily_stock_price_history_clock), strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+            AND ((stock_id = OLD.stock_id AND price_date = OLD.price_date) OR (stock_id = NEW.stock_id AND price_date = NEW.price_date));
+    UPDATE daily_stock_price_history
+        SET superseded_at = COALESCE((SELECT recorded_at FROM daily_stock_price_history_clock), strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+        WHERE superseded_at IS NULL
+            AND ((stock_id = OLD.stock_id AND price_date = OLD.price_date) OR (stock_id = NEW.stock_id AND price_date = NEW.price_date));
+    UPDATE daily_stock_price_history SET superseded_at = NULL
+        WHERE rowid = (
+            SELECT h.rowid FROM daily_stock_price_history h
+            WHERE h.stock_id = NEW.stock_id AND h.price_date = NEW.price_date
+                AND h.superseded_at = COALESCE((SELECT recorded_at FROM daily_stock_price_history_clock), strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+            ORDER BY h.recorded_at DESC LIMIT 1
+        )
+            AND price = NEW.price AND open IS NEW.open AND high IS NEW.high AND low IS NEW.low AND volume IS NEW.volume;
+    INSERT INTO daily_stock_price_history (stock_id, price_date, price, open, high, low, volume, import_run_id, recorded_at)
+        SELECT NEW.stock_id, NEW.price_date, NEW.price, NEW.open, NEW.high, NEW.low, NEW.volume, NEW.import_run_id,
+            COALESCE((SELECT recorded_at FROM daily_stock_price_history_clock), strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+        WHERE NOT EXISTS (
+            SELECT 1 FROM daily_stock_price_history h
+            WHERE h.stock_id = NEW.stock_id AND h.price_date = NEW.price_date AND h.superseded_at IS NULL
+        );
+END;
+
+CREATE TRIGGER daily_stock_price_history_delete AFTER DELETE ON daily_stock_price
+BEGIN
+    DELETE FROM daily_stock_price_history
+        WHERE stock_id = OLD.stock_id AND price_date = OLD.price_date AND superseded_at IS NULL
+            AND recorded_at = COALESCE((SELECT recorded_at FROM daily_stock_price_history_clock), strftime('%Y-%m-%dT%H:%M:%fZ', 'now'));
+    UPDATE daily_stock_price_history
+        SET superseded_at = COALESCE((SELECT recorded_at FROM daily_stock_price_history_clock), strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+        WHERE stock_id = OLD.stock_id AND price_date = OLD.price_date AND superseded_at IS NULL;
+END;
@@ -0,0 +1,17 @@
+-- 検査で修復できない日次株価の行を退避するテーブルを作成
+-- stock_price_db doctor -fix は日付を解釈できない行を日次株価から移し、値を失わずに参照の対象から外す
+-- 株価は退避した時点の銘柄の桁数（price_scale、銘柄マスタにない銘柄は NULL）での整数で、桁数の変更では変換しない
+CREATE TABLE daily_stock_price_quarantine (
+    quarantine_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    stock_id TEXT NOT NULL,
+    price_date TEXT NOT NULL,
+    price INTEGER NOT NULL,
+    open INTEGER,
+    high INTEGER,
+    low INTEGER,
+    volume INTEGER,
+    import_run_id INTEGER,
+    price_scale INTEGER,
+    reason TEXT NOT NULL,
+    quarantined_at TEXT NOT NULL
+);
@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// SQLiteドライバ名
+const sqliteDriverName = "sqlite"
+
+// SQLiteデータベースの接続設定を示す構造体
+type SQLiteOptions struct {
+	// 他の接続がロックを保持している場合にロックの解放を待つ時間（PRAGMA busy_timeout）
+	BusyTimeout time.Duration
+	// ジャーナルモード（PRAGMA journal_mode、空の場合は変更しない）
+	JournalMode string
+	// 同期モード（PRAGMA synchronous、空の場合は変更しない）
+	Synchronous string
+	// 書き込みが SQLITE_BUSY で失敗した場合に再試行する回数
+	BusyRetries int
+	// 開くときに未適用のマイグレーションを適用するかどうか
+	// false の場合はデータベースファイルを作成せず、スキーマが最新でなければエラーにする
+	Migrate bool
+}
+
+// DefaultSQLiteOptions はSQLiteデータベースを開くためのデフォルト設定を返します。
+// WALモードでは読み込みと書き込みが互いを待たないため、取り込み中でも参照できます。
+//
+// 戻り値:
+//   - SQLiteデータベースの接続設定
+func DefaultSQLiteOptions() SQLiteOptions {
+	return SQLiteOptions{
+		BusyTimeout: 5 * time.Second,
+		JournalMode: "WAL",
+		Synchronous: "NORMAL",
+		BusyRetries: 3,
+	}
+}
+
+// SQLiteデータベースに日次株価情報を永続化するリポジトリ
+// 1つの *sql.DB（接続プール）を保持し、全ての操作で共有する
+type SQLiteStockPriceRepository struct {
+	// データベース接続
+	db *sql.DB
+	// 書き込みが SQLITE_BUSY で失敗した場合に再試行する回数
+	busyRetries int
+}
+
+// SQLiteStockPriceRepository が StockPriceRepository を実装していることを確認
+var _ models.StockPriceRepository = (*SQLiteStockPriceRepository)(nil)
+
+// NewSQLiteStockPriceRepository はデフォルト設定で既存のSQLiteデータベースを開き、リポジトリを作成します。
+// マイグレーションは適用しないため、スキーマが最新でない場合はエラーを返します
+// （バイナリより新しい場合は SchemaVersionError）。
+// 使い終わったら Close を呼び出してください。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト（接続とスキーマの確認にのみ使用する）
+//   - dbPath: SQLiteデータベースファイルのパス
+//
+// 戻り値:
+//   - リポジトリ
+//   - エラー（データベースファイルが存在しない場合、開けない場合やスキーマが最新でない場合）
+func NewSQLiteStockPriceRepository(ctx context.Context, dbPath string) (*SQLiteStockPriceRepository, error) {
+	return NewSQLiteStockPriceRepositoryWithOptions(ctx, dbPath, DefaultSQLiteOptions())
+}
+
+// NewSQLiteStockPriceRepositoryWithOptions は接続設定を指定してSQLiteデータベースを開き、
+// リポジトリを作成します。options.Migrate が true の場合はデータベースファイルがなければ作成し、
+// 未適用のマイグレーションを適用します。false の場合は既存のファイルのみを開き、
+// スキーマが最新でなければエラーを返します。使い終わったら Close を呼び出してください。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト（接続、スキーマの確認とマイグレーションにのみ使用する）
+//   - dbPath: SQLiteデータベースファイルのパス
+//   - options: 接続設定
+//
+// 戻り値:
+//   - リポジトリ
+//   - エラー（接続設定が不正な場合、データベースを開けない場合、スキーマが最新でない場合やマイグレーションに失敗した場合）
+func NewSQLiteStockPriceRepositoryWithOptions(ctx context.Context, dbPath string, options SQLiteOptions) (*SQLiteStockPriceRepository, error) {
+	// マイグレーションしない場合はデータベースファイルを作成しない
+	if !options.Migrate {
+		if err := checkDatabaseFileExists(dbPath); err != nil {
+			return nil, err
+		}
+	}
+
+	// データベース接続を開く
+	db, err := openSQLiteDatabase(ctx, dbPath, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// 未適用のマイグレーションを適用するか、スキーマが最新であることを確認
+	if options.Migrate {
+		err = migrateDatabase(ctx, db)
+	} else {
+		err = checkSchemaIsCurrent(ctx, db)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStockPriceRepository{db: db, busyRetries: options.BusyRetries}, nil
+}
+
+// openSQLiteDatabase は接続ごとのPRAGMAを設定してSQLiteデータベースを開き、接続を確認します。
+// データベースファイルが存在しない場合は作成します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - dbPath: SQLiteデータベースファイルのパス
+//   - options: 接続設定
+//
+// 戻り値:
+//   - データベース接続
+//   - エラー（接続設定が不正な場合やデータベースを開けない場合）
+func openSQLiteDatabase(ctx context.Context, dbPath string, options SQLiteOptions) (*sql.DB, error) {
+	if options.BusyTimeout < 0 {
+		return nil, fmt.Errorf("invalid busy timeout: %v", options.BusyTimeout)
+	}
+	if options.BusyRetries < 0 {
+		return nil, fmt.Errorf("invalid busy retries: %d", options.BusyRetries)
+	}
+
+	db, err := sql.Open(sqliteDriverName, sqliteDSN(dbPath, options))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return db, nil
+}
+
+// sqliteDSN はデータベースファイルのパスに接続ごとのPRAGMAを付けた接続文字列を返します。
+// 書き込みトランザクションは開始時に書き込みロックを取得するため（BEGIN IMMEDIATE）、
+// ロックの待機は開始時にのみ発生し、読み込みから書き込みへの昇格で失敗することはありません。
+// 読み込み専用のトランザクションはロックを取得せずに開始します。
+//
+// 引数:
+//   - dbPath: SQLiteデータベースファイルのパス
+//   - options: 接続設定
+//
+// 戻り値:
+//   - SQLiteドライバに渡す接続文字列
+func sqliteDSN(dbPath string, options SQLiteOptions) string {
+	query := url.Values{}
+	// 外部キー制約とロックの待機時間は接続ごとに設定する必要がある
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", options.BusyTimeout.Milliseconds()))
+	if options.JournalMode != "" {
+		query.Add("_pragma", "journal_mode("+options.JournalMode+")")
+	}
+	if options.Synchronous != "" {
+		query.Add("_pragma", "synchronous("+options.Synchronous+")")
+	}
+	query.Set("_txlock", "immediate")
+	return dbPath + "?" + query.Encode()
+}
+
+// Close はデータベース接続を閉じます。
+//
+// 戻り値:
+//   - エラー（接続を閉じられない場合）
+func (r *SQLiteStockPriceRepository) Close() error {
+	return r.db.Close()
+}
@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// newTestRepository はテスト用のリポジトリを作成します（データベースファイルを作成してマイグレーションを適用する）。
+// テスト終了時にリポジトリを閉じてデータベースファイルを削除します。
+func newTestRepository(t *testing.T, dbPath string) *SQLiteStockPriceRepository {
+	t.Helper()
+	options := DefaultSQLiteOptions()
+	options.Migrate = true
+	repository, err := NewSQLiteStockPriceRepositoryWithOptions(context.Background(), dbPath, options)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	t.Cleanup(func() {
+		repository.Close()
+		os.Remove(dbPath)
+	})
+	return repository
+}
+
+func TestSQLiteStockPriceRepository_SharesConnection(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_repository.db")
+	testPrices := []models.DailyStockPrice{
+		{
+			PriceDate:  time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
+			StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)},
+		},
+	}
+
+	// Act - 同じ接続で書き込みと読み込みを繰り返す
+	for i := 0; i < 3; i++ {
+		if err := repository.InitializeDailyStockPriceTable(context.Background(), testPrices); err != nil {
+			t.Fatalf("Failed to initialize table: %v", err)
+		}
+	}
+	count, err := repository.CountDailyStockPrices(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Failed to count prices: %v", err)
+	}
+	if count != len(testPrices) {
+		t.Errorf("Expected %d prices, but got %d", len(testPrices), count)
+	}
+	if openConnections := repository.db.Stats().OpenConnections; openConnections == 0 {
+		t.Errorf("Expected the connection pool to stay open between operations")
+	}
+}
+
+func TestSQLiteStockPriceRepository_EmptyDatabase(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_repository_empty.db")
+
+	// Act
+	count, err := repository.CountDailyStockPrices(context.Background())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the table to be created on open, but got: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected 0 prices, but got %d", count)
+	}
+}
+
+func TestSQLiteStockPriceRepository_Close(t *testing.T) {
+	// Arrange
+	dbPath := "./test_stock_price_repository_close.db"
+	defer os.Remove(dbPath)
+	options := DefaultSQLiteOptions()
+	options.Migrate = true
+	repository, err := NewSQLiteStockPriceRepositoryWithOptions(context.Background(), dbPath, options)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+
+	// Act
+	err = repository.Close()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Failed to close repository: %v", err)
+	}
+	if _, err := repository.CountDailyStockPrices(context.Background()); err == nil {
+		t.Error("Expected an error after Close, but got nil")
+	}
+}
+
+func TestNewSQLiteStockPriceRepository_DoesNotCreateMissingFile(t *testing.T) {
+	// Arrange
+	dbPath := "./test_stock_price_repository_missing.db"
+	defer os.Remove(dbPath)
+
+	// Act
+	_, err := NewSQLiteStockPriceRepository(context.Background(), dbPath)
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "database file not found") {
+		t.Fatalf("Expected a missing file error, but got: %v", err)
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Errorf("Expected the database file not to be created, but got: %v", err)
+	}
+}
+
+func TestNewSQLiteStockPriceRepository_RefusesOutdatedSchema(t *testing.T) {
+	// Arrange - マイグレーションを途中まで適用したデータベース
+	dbPath := "./test_stock_price_repository_outdated.db"
+	migrator := openTestMigrator(t, dbPath)
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	latestVersion := migrator.LatestVersion()
+	execTestSQL(t, dbPath, fmt.Sprintf("DELETE FROM schema_migrations WHERE version = %d", latestVersion))
+
+	// Act
+	_, err := NewSQLiteStockPriceRepository(context.Background(), dbPath)
+
+	// Assert - マイグレーションは適用されない
+	expected := fmt.Sprintf("database is at schema version %d but this binary requires %d; run migrate up first", latestVersion-1, latestVersion)
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Expected %q, but got: %v", expected, err)
+	}
+	_, version, err := migrator.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get migration status: %v", err)
+	}
+	if version != latestVersion-1 {
+		t.Errorf("Expected schema version %d to be kept, but got %d", latestVersion-1, version)
+	}
+}
@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 銘柄マスタテーブル名
+const stockTableName = "stock"
+
+// 銘柄マスタを取得するための列リスト
+const selectStockColumns = "stock_id, name, market, sector, currency"
+
+// 銘柄コードのみで銘柄マスタを登録するSQL（登録済みの場合は何もしない）
+const registerStockSQL = "INSERT INTO " + stockTableName + " (stock_id) VALUES (?) ON CONFLICT(stock_id) DO NOTHING"
+
+// 銘柄マスタを登録し、登録済みの場合は上書きするSQL
+const upsertStockSQL = "INSERT INTO " + stockTableName + " (" + selectStockColumns + ") VALUES (?, ?, ?, ?, ?)" +
+	" ON CONFLICT(stock_id) DO UPDATE SET" +
+	" name = excluded.name, market = excluded.market, sector = excluded.sector, currency = excluded.currency"
+
+// UpsertStocks はSQLiteのstockテーブルに銘柄マスタを登録します。
+// 登録済みの銘柄は新しい値で上書きし、値が同じ場合は変更なしとして数えます。
+// 全ての銘柄を1つのトランザクションで書き込みます。
+// 通貨が空の場合は models.DefaultCurrency を登録します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - stocks: 登録する銘柄マスタの配列
+//
+// 戻り値:
+//   - 登録結果
+//   - エラー（データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) UpsertStocks(ctx context.Context, stocks []models.Stock) (models.UpsertResult, error) {
+	// トランザクションを開始
+	tx, err := r.beginWriteTx(ctx)
+	if err != nil {
+		return models.UpsertResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Prepared Statementを作成
+	selectStmt, err := tx.PrepareContext(ctx, "SELECT "+selectStockColumns+" FROM "+stockTableName+" WHERE stock_id = ?")
+	if err != nil {
+		return models.UpsertResult{}, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer selectStmt.Close()
+	upsertStmt, err := tx.PrepareContext(ctx, upsertStockSQL)
+	if err != nil {
+		return models.UpsertResult{}, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer upsertStmt.Close()
+
+	// 各銘柄を登録
+	var result models.UpsertResult
+	for _, stock := range stocks {
+		if stock.Currency == "" {
+			stock.Currency = models.DefaultCurrency
+		}
+
+		// 登録済みの銘柄を取得
+		existingStock, err := scanStock(selectStmt.QueryRowContext(ctx, stock.StockID))
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			result.Inserted++
+		case err != nil:
+			return models.UpsertResult{}, fmt.Errorf("failed to query existing data: %w", err)
+		case existingStock == stock:
+			result.Unchanged++
+			continue
+		default:
+			result.Updated++
+		}
+
+		_, err = upsertStmt.ExecContext(ctx, stock.StockID, stock.Name, stock.Market, stock.Sector, stock.Currency)
+		if err != nil {
+			return models.UpsertResult{}, fmt.Errorf("failed to write stock %s: %w", stock.StockID, err)
+		}
+	}
+
+	// トランザクションをコミット
+	if err := commitVersionedWriteTx(ctx, tx); err != nil {
+		return models.UpsertResult{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// GetStocks はSQLiteのstockテーブルから銘柄コード順に全ての銘柄マスタを取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - 銘柄マスタの配列
+//   - エラー（データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) GetStocks(ctx context.Context) ([]models.Stock, error) {
+	// クエリを実行
+	rows, err := r.db.QueryContext(ctx, "SELECT "+selectStockColumns+" FROM "+stockTableName+" ORDER BY stock_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query data: %w", err)
+	}
+	defer rows.Close()
+
+	// 各行を処理
+	var stocks []models.Stock
+	for rows.Next() {
+		stock, err := scanStock(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		stocks = append(stocks, stock)
+	}
+
+	// エラーをチェック
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	return stocks, nil
+}
+
+// GetStock はSQLiteのstockテーブルから銘柄コードに一致する銘柄マスタを取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - stockID: 取得する銘柄コード
+//
+// 戻り値:
+//   - 銘柄マスタ
+//   - 登録されている場合は true
+//   - エラー（データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) GetStock(ctx context.Context, stockID string) (models.Stock, bool, error) {
+	stock, err := scanStock(r.db.QueryRowContext(ctx, "SELECT "+selectStockColumns+" FROM "+stockTableName+" WHERE stock_id = ?", stockID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Stock{}, false, nil
+	}
+	if err != nil {
+		return models.Stock{}, false, fmt.Errorf("failed to query data: %w", err)
+	}
+	return stock, true, nil
+}
+
+// scanStock は selectStockColumns の順に取得した1行を銘柄マスタに変換します。
+//
+// 引数:
+//   - row: *sql.Row または *sql.Rows
+//
+// 戻り値:
+//   - 銘柄マスタ
+//   - エラー（行が存在しない場合は sql.ErrNoRows）
+func scanStock(row interface{ Scan(dest ...any) error }) (models.Stock, error) {
+	var stock models.Stock
+	err := row.Scan(&stock.StockID, &stock.Name, &stock.Market, &stock.Sector, &stock.Currency)
+	return stock, err
+}
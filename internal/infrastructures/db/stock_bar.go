@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 日次四本値を銘柄の株価の桁数とともに取得するSQL
+// 終値のみで登録された行は始値・高値・安値を終値で、出来高を0で補う
+const selectDailyStockBarsSQL = "SELECT p.stock_id, p.price_date," +
+	" COALESCE(p.open, p.price), COALESCE(p.high, p.price), COALESCE(p.low, p.price), p.price, COALESCE(p.volume, 0), s.price_scale" +
+	" FROM " + dailyStockPriceTableName + " p JOIN " + stockTableName + " s ON s.stock_id = p.stock_id"
+
+// InitializeDailyStockBarTable はSQLiteのdaily_stock_priceテーブルを
+// 引数で渡された日次四本値配列で初期化します。
+// 全てのデータを削除してから新しいデータを挿入します。終値は price 列に格納されます。
+// runID を指定した場合は、削除する行を取り消し用に保存し、挿入した行をその取り込みに紐付けます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - runID: 書き込みを記録する取り込みID（models.NoImportRun の場合は記録しない）
+//   - dailyBars: 挿入する日次四本値の配列
+//
+// 戻り値:
+//   - エラー（データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) InitializeDailyStockBarTable(ctx context.Context, runID int64, dailyBars []models.DailyStockBar) error {
+	// トランザクションを開始
+	tx, err := r.beginWriteTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// 既存のデータを取り消し用に保存してから削除
+	err = deleteAllDailyStockPrices(ctx, tx, runID)
+	if err != nil {
+		return err
+	}
+
+	// Prepared Statementを作成
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO "+dailyStockPriceTableName+
+		" (stock_id, price_date, price, open, high, low, volume, import_run_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	// 各日次四本値をテーブルに挿入
+	scales := make(stockPriceScales)
+	for _, dailyBar := range dailyBars {
+		// 銘柄マスタに登録されていない銘柄を登録し、四本値を銘柄の桁数の整数に変換
+		var scale int
+		scale, err = scales.prepare(ctx, tx, dailyBar.StockID, dailyBar.Open, dailyBar.High, dailyBar.Low, dailyBar.Close)
+		if err != nil {
+			return err
+		}
+		var ticks [4]int64
+		for i, price := range []models.Price{dailyBar.Close, dailyBar.Open, dailyBar.High, dailyBar.Low} {
+			ticks[i], err = priceTicks(price, scale)
+			if err != nil {
+				return err
+			}
+		}
+
+		// 日付をISO 8601形式の文字列に変換
+		dateStr := dailyBar.PriceDate.Format(time.RFC3339[:10]) // YYYY-MM-DD形式
+
+		_, err = stmt.ExecContext(ctx,
+			dailyBar.StockID,
+			dateStr,
+			ticks[0],
+			ticks[1],
+			ticks[2],
+			ticks[3],
+			dailyBar.Volume,
+			nullableImportRunID(runID),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert data: %w", err)
+		}
+	}
+
+	// トランザクションをコミット
+	err = commitVersionedWriteTx(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetDailyStockBarsByDateRange はSQLiteのdaily_stock_priceテーブルから
+// 指定された銘柄コードと日付範囲に一致する日次四本値を取得します。
+// 終値のみで登録された行は始値・高値・安値に終値、出来高に0が設定されます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - stockID: 取得する銘柄コード
+//   - startDate: 取得する日付の始点（この日付を含む）
+//   - endDate: 取得する日付の終点（この日付を含む）
+//
+// 戻り値:
+//   - 条件に一致する日次四本値の配列
+//   - エラー（データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) GetDailyStockBarsByDateRange(ctx context.Context, stockID string, startDate time.Time, endDate time.Time) ([]models.DailyStockBar, error) {
+	// 日付をISO 8601形式の文字列に変換
+	startDateStr := startDate.Format(time.RFC3339[:10]) // YYYY-MM-DD形式
+	endDateStr := endDate.Format(time.RFC3339[:10])     // YYYY-MM-DD形式
+
+	// クエリを実行
+	query := selectDailyStockBarsSQL + " WHERE p.stock_id = ? AND p.price_date >= ? AND p.price_date <= ? ORDER BY p.price_date"
+	rows, err := r.db.QueryContext(ctx, query, stockID, startDateStr, endDateStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query data: %w", err)
+	}
+	defer rows.Close()
+
+	// 結果を格納するスライス
+	var dailyBars []models.DailyStockBar
+
+	// 各行を処理
+	for rows.Next() {
+		var dailyBar models.DailyStockBar
+		var dateStr string
+		var ticks [4]int64
+		var scale int
+
+		// 行のデータを取得
+		err := rows.Scan(
+			&dailyBar.StockID,
+			&dateStr,
+			&ticks[0],
+			&ticks[1],
+			&ticks[2],
+			&ticks[3],
+			&dailyBar.Volume,
+			&scale,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		// 銘柄の桁数の整数を四本値に変換
+		for i, price := range []*models.Price{&dailyBar.Open, &dailyBar.High, &dailyBar.Low, &dailyBar.Close} {
+			*price, err = priceFromTicks(ticks[i], scale)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// 日付文字列をtime.Time型に変換
+		dailyBar.PriceDate, err = time.Parse(time.RFC3339[:10], dateStr) // YYYY-MM-DD形式
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse date of %s (stock_price_db doctor -fix moves the row aside): %w", stockID, err)
+		}
+
+		// 結果に追加
+		dailyBars = append(dailyBars, dailyBar)
+	}
+
+	// エラーをチェック
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	return dailyBars, nil
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+func TestInitializeAndGetDailyStockBarsByDateRange(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_bar.db")
+
+	testBars := []models.DailyStockBar{
+		{
+			PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
+			StockID:   "7203",
+			Open:      models.NewPrice(2850, 0),
+			High:      models.NewPrice(2890, 0),
+			Low:       models.NewPrice(2840, 0),
+			Close:     models.NewPrice(2873, 0),
+			Volume:    15000000,
+		},
+		{
+			PriceDate: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC),
+			StockID:   "7203",
+			Open:      models.NewPrice(2880, 0),
+			High:      models.NewPrice(2970, 0),
+			Low:       models.NewPrice(2875, 0),
+			Close:     models.NewPrice(2963, 0),
+			Volume:    18000000,
+		},
+	}
+
+	// Act
+	err := repository.InitializeDailyStockBarTable(context.Background(), models.NoImportRun, testBars)
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	retrievedBars, err := repository.GetDailyStockBarsByDateRange(context.Background(), "7203", testBars[0].PriceDate, testBars[1].PriceDate)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Failed to get bars: %v", err)
+	}
+	if !reflect.DeepEqual(retrievedBars, testBars) {
+		t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", testBars, retrievedBars)
+	}
+}
+
+func TestGetDailyStockBarsByDateRange_CloseOnlyRows(t *testing.T) {
+	// Arrange
+	dbPath := "./test_stock_bar_close_only.db"
+
+	// 四本値の列が存在しない古いスキーマのテーブルを作成
+	legacyDB, err := sql.Open(sqliteDriverName, dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	_, err = legacyDB.Exec("CREATE TABLE daily_stock_price (stock_id TEXT NOT NULL, price_date TEXT NOT NULL, price REAL NOT NULL, PRIMARY KEY (stock_id, price_date))")
+	legacyDB.Close()
+	if err != nil {
+		t.Fatalf("Failed to create legacy table: %v", err)
+	}
+
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, dbPath)
+
+	priceDate := time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC)
+	testPrices := []models.DailyStockPrice{
+		{
+			PriceDate:  priceDate,
+			StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)},
+		},
+	}
+	expected := []models.DailyStockBar{
+		{
+			PriceDate: priceDate,
+			StockID:   "7203",
+			Open:      models.NewPrice(2873, 0),
+			High:      models.NewPrice(2873, 0),
+			Low:       models.NewPrice(2873, 0),
+			Close:     models.NewPrice(2873, 0),
+			Volume:    0,
+		},
+	}
+
+	// Act
+	err = repository.InitializeDailyStockPriceTable(context.Background(), testPrices)
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	retrievedBars, err := repository.GetDailyStockBarsByDateRange(context.Background(), "7203", priceDate, priceDate)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Failed to get bars: %v", err)
+	}
+	if !reflect.DeepEqual(retrievedBars, expected) {
+		t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", expected, retrievedBars)
+	}
+}
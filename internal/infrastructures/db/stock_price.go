@@ -1,8 +1,11 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"iter"
 	"time"
 
 	_ "github.com/glebarez/go-sqlite" // SQLiteドライバ
@@ -12,226 +15,452 @@ import (
 // 日次株価テーブル名
 const dailyStockPriceTableName = "daily_stock_price"
 
-// 日次株価テーブル作成SQL
-const createDailyStockPriceTableSQL = `
-CREATE TABLE IF NOT EXISTS daily_stock_price (
-    stock_id TEXT NOT NULL,
-    price_date TEXT NOT NULL,
-    price REAL NOT NULL,
-    PRIMARY KEY (stock_id, price_date)
-);
-`
+// 日次株価情報を挿入するSQL
+const insertDailyStockPriceSQL = "INSERT INTO " + dailyStockPriceTableName + " (stock_id, price_date, price, import_run_id) VALUES (?, ?, ?, ?)"
+
+// 置き換える日次株価情報を読み込みの間だけ保存する一時テーブル名（接続ごとに作成される）
+const dailyStockPriceStagingTableName = "temp.daily_stock_price_staging"
+
+// 一時テーブルを作成するSQL（株価は株価自身の桁数の整数で保存する）
+const createDailyStockPriceStagingSQL = "CREATE TABLE " + dailyStockPriceStagingTableName +
+	" (stock_id TEXT NOT NULL, price_date TEXT NOT NULL, price INTEGER NOT NULL, price_scale INTEGER NOT NULL," +
+	" PRIMARY KEY (stock_id, price_date))"
+
+// 一時テーブルを削除するSQL
+const dropDailyStockPriceStagingSQL = "DROP TABLE IF EXISTS " + dailyStockPriceStagingTableName
+
+// 一時テーブルに日次株価情報を書き込むSQL
+const insertDailyStockPriceStagingSQL = "INSERT INTO " + dailyStockPriceStagingTableName + " (stock_id, price_date, price, price_scale) VALUES (?, ?, ?, ?)"
+
+// 一時テーブルの銘柄ごとに株価を表すのに必要な桁数を取得するSQL
+const selectStagedStockPriceScalesSQL = "SELECT stock_id, MAX(price_scale) FROM " + dailyStockPriceStagingTableName + " GROUP BY stock_id ORDER BY stock_id"
+
+// 一時テーブルの株価を銘柄の桁数に広げるための10のべき乗
+var stagedPriceFactorSQL = priceScaleFactorSQL("s.price_scale - t.price_scale")
+
+// 銘柄の桁数に広げると int64 の範囲を超える株価を1件取得するSQL
+var selectStagedPriceOutOfRangeSQL = "SELECT t.stock_id, t.price_date, s.price_scale FROM " + dailyStockPriceStagingTableName + " t" +
+	" JOIN " + stockTableName + " s ON s.stock_id = t.stock_id" +
+	" WHERE t.price > 9223372036854775807 / (" + stagedPriceFactorSQL + ") OR t.price < -9223372036854775807 / (" + stagedPriceFactorSQL + ") LIMIT 1"
+
+// 一時テーブルにない日次株価情報を削除するSQL
+const deleteUnstagedDailyStockPricesSQL = "DELETE FROM " + dailyStockPriceTableName +
+	" WHERE NOT EXISTS (SELECT 1 FROM " + dailyStockPriceStagingTableName + " t" +
+	" WHERE t.stock_id = " + dailyStockPriceTableName + ".stock_id AND t.price_date = " + dailyStockPriceTableName + ".price_date)"
+
+// 一時テーブルの日次株価情報を銘柄の桁数の整数に変換して書き込むSQL
+// 既存の行は値か取り込みIDが異なる場合のみ更新する（四本値と出来高は株価のみの行で置き換えるため NULL にする）
+var upsertStagedDailyStockPricesSQL = "INSERT INTO " + dailyStockPriceTableName + " (stock_id, price_date, price, import_run_id)" +
+	" SELECT t.stock_id, t.price_date, t.price * (" + stagedPriceFactorSQL + "), ? FROM " + dailyStockPriceStagingTableName + " t" +
+	" JOIN " + stockTableName + " s ON s.stock_id = t.stock_id WHERE true ORDER BY t.stock_id, t.price_date" +
+	" ON CONFLICT (stock_id, price_date) DO UPDATE SET" +
+	" price = excluded.price, open = NULL, high = NULL, low = NULL, volume = NULL, import_run_id = excluded.import_run_id" +
+	" WHERE price IS NOT excluded.price OR open IS NOT NULL OR high IS NOT NULL OR low IS NOT NULL OR volume IS NOT NULL" +
+	" OR import_run_id IS NOT excluded.import_run_id"
 
 // InitializeDailyStockPriceTable はSQLiteのdaily_stock_priceテーブルを
 // 引数で渡された日次株価情報配列で初期化します。
-// テーブルが存在しない場合は作成し、存在する場合は全てのデータを削除してから
-// 新しいデータを挿入します。
+// 全てのデータを削除してから新しいデータを挿入します。
+// 削除と挿入は1つのトランザクションで実行されます。取り込み履歴には記録しません。
 //
 // 引数:
-//   - dbPath: SQLiteデータベースファイルのパス
+//   - ctx: キャンセルや期限を伝えるコンテキスト
 //   - dailyPrices: 挿入する日次株価情報の配列
 //
 // 戻り値:
 //   - エラー（データベース操作に失敗した場合）
-func InitializeDailyStockPriceTable(dbPath string, dailyPrices []models.DailyStockPrice) error {
-	// データベース接続を開く
-	db, err := sql.Open("sqlite", dbPath)
+func (r *SQLiteStockPriceRepository) InitializeDailyStockPriceTable(ctx context.Context, dailyPrices []models.DailyStockPrice) error {
+	// 全件を1つのトランザクションで書き込む
+	chunkSize := max(len(dailyPrices), 1)
+	_, err := r.InitializeDailyStockPriceTableFromSeq(ctx, models.NoImportRun, models.DailyStockPriceSeq(dailyPrices), chunkSize)
+	return err
+}
+
+// InitializeDailyStockPriceTableFromSeq はSQLiteのdaily_stock_priceテーブルを
+// イテレータから読み込んだ日次株価情報で初期化します。
+// 読み込んだ日次株価情報は chunkSize 件ごとにトランザクションをコミットしながら接続ごとの一時テーブルに書き込み、
+// 最後に1つのトランザクションでテーブルを一時テーブルの内容に置き換えます。
+// 置き換えでは一時テーブルにない行のみを削除し、値が変わった行のみを更新するため、
+// 同じ内容で置き換えても日次株価の版は増えません。
+// 全件をメモリ上に保持しないため、入力の件数によらずメモリ使用量は一定です。
+// 途中でエラーが発生した場合やコンテキストがキャンセルされた場合は、テーブルを変更しません。
+// runID を指定した場合は、削除する行を取り消し用に保存し、挿入した行をその取り込みに紐付けます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - runID: 書き込みを記録する取り込みID（models.NoImportRun の場合は記録しない）
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - chunkSize: 1トランザクションで一時テーブルに書き込む件数
+//
+// 戻り値:
+//   - 挿入した日次株価情報の件数（エラーの場合は0）
+//   - エラー（イテレータがエラーを返した場合やデータベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) InitializeDailyStockPriceTableFromSeq(ctx context.Context, runID int64, dailyPrices iter.Seq2[models.DailyStockPrice, error], chunkSize int) (int, error) {
+	if chunkSize <= 0 {
+		return 0, fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+
+	// 一時テーブルは接続ごとに作成されるため、置き換えが終わるまで同じ接続を使う
+	conn, err := r.db.Conn(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+		return 0, fmt.Errorf("failed to open connection: %w", err)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, dropDailyStockPriceStagingSQL); err != nil {
+		return 0, fmt.Errorf("failed to drop staging table: %w", err)
 	}
-	defer db.Close()
+	if _, err := conn.ExecContext(ctx, createDailyStockPriceStagingSQL); err != nil {
+		return 0, fmt.Errorf("failed to create staging table: %w", err)
+	}
+	// キャンセルされた場合も接続をプールに戻す前に一時テーブルを削除する
+	defer conn.ExecContext(context.WithoutCancel(ctx), dropDailyStockPriceStagingSQL)
 
-	// テーブルを作成
-	_, err = db.Exec(createDailyStockPriceTableSQL)
+	insertedCount, err := r.stageDailyStockPrices(ctx, conn, dailyPrices, chunkSize)
 	if err != nil {
-		return fmt.Errorf("failed to create table: %w", err)
+		return 0, err
+	}
+	if err := r.replaceWithStagedDailyStockPrices(ctx, conn, runID); err != nil {
+		return 0, err
 	}
+	return insertedCount, nil
+}
 
-	// トランザクションを開始
-	tx, err := db.Begin()
+// stageDailyStockPrices はイテレータから読み込んだ日次株価情報を
+// chunkSize 件ごとにトランザクションをコミットしながら一時テーブルに書き込みます。
+// 株価は銘柄の桁数に変換せず、株価自身の桁数とともに保存します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - conn: 一時テーブルを作成した接続
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - chunkSize: 1トランザクションで書き込む件数
+//
+// 戻り値:
+//   - 書き込んだ日次株価情報の件数
+//   - エラー（イテレータがエラーを返した場合や同じ銘柄コード・日付の行がある場合、データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) stageDailyStockPrices(ctx context.Context, conn *sql.Conn, dailyPrices iter.Seq2[models.DailyStockPrice, error], chunkSize int) (int, error) {
+	// 最初のチャンクのトランザクションを開始
+	chunk, err := beginDailyStockPriceChunk(ctx, conn, r.busyRetries, insertDailyStockPriceStagingSQL)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return 0, err
 	}
 	defer func() {
-		if err != nil {
-			tx.Rollback()
+		if chunk != nil {
+			chunk.rollback()
 		}
 	}()
 
-	// 既存のデータを削除
-	_, err = tx.Exec("DELETE FROM " + dailyStockPriceTableName)
-	if err != nil {
-		return fmt.Errorf("failed to delete existing data: %w", err)
-	}
-
-	// Prepared Statementを作成
-	stmt, err := tx.Prepare("INSERT INTO " + dailyStockPriceTableName + " (stock_id, price_date, price) VALUES (?, ?, ?)")
-	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
-	}
-	defer stmt.Close()
+	// 各日次株価情報を一時テーブルに書き込む
+	insertedCount := 0
+	for dailyPrice, err := range dailyPrices {
+		if err != nil {
+			return 0, err
+		}
 
-	// 各日次株価情報をテーブルに挿入
-	for _, dailyPrice := range dailyPrices {
 		// 日付をISO 8601形式の文字列に変換
 		dateStr := dailyPrice.PriceDate.Format(time.RFC3339[:10]) // YYYY-MM-DD形式
 
-		_, err = stmt.Exec(
+		_, err = chunk.stmts[0].ExecContext(ctx,
 			dailyPrice.StockPrice.StockID,
 			dateStr,
-			dailyPrice.StockPrice.Price,
+			dailyPrice.StockPrice.Price.Ticks(),
+			dailyPrice.StockPrice.Price.Scale(),
 		)
 		if err != nil {
-			return fmt.Errorf("failed to insert data: %w", err)
+			return 0, fmt.Errorf("failed to insert data: %w", err)
+		}
+		insertedCount++
+
+		// チャンクの件数に達したらコミットして次のトランザクションを開始
+		if insertedCount%chunkSize == 0 {
+			committedChunk := chunk
+			chunk = nil
+			if err := committedChunk.commit(ctx); err != nil {
+				return 0, err
+			}
+			chunk, err = beginDailyStockPriceChunk(ctx, conn, r.busyRetries, insertDailyStockPriceStagingSQL)
+			if err != nil {
+				return 0, err
+			}
 		}
 	}
 
-	// トランザクションをコミット
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	// 最後のチャンクをコミット
+	lastChunk := chunk
+	chunk = nil
+	if err := lastChunk.commit(ctx); err != nil {
+		return 0, err
 	}
-
-	return nil
+	return insertedCount, nil
 }
 
-// GetDailyStockPrices はSQLiteのdaily_stock_priceテーブルから
-// 全ての日次株価情報を取得します。
+// replaceWithStagedDailyStockPrices は1つのトランザクションでテーブルを一時テーブルの内容に置き換えます。
+// 一時テーブルにない行を削除し、銘柄マスタに登録されていない銘柄を登録し、一時テーブルの株価を表すのに必要な桁数まで
+// 銘柄の桁数を広げてから、株価を銘柄の桁数の整数に変換して書き込みます。値と取り込みIDが同じ行は変更しません。
 //
 // 引数:
-//   - dbPath: SQLiteデータベースファイルのパス
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - conn: 一時テーブルを作成した接続
+//   - runID: 書き込みを記録する取り込みID（models.NoImportRun の場合は記録しない）
 //
 // 戻り値:
-//   - 日次株価情報の配列
-//   - エラー（データベース操作に失敗した場合）
-func GetDailyStockPrices(dbPath string) ([]models.DailyStockPrice, error) {
-	// データベース接続を開く
-	db, err := sql.Open("sqlite", dbPath)
+//   - エラー（株価を銘柄の桁数で表せない場合やデータベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) replaceWithStagedDailyStockPrices(ctx context.Context, conn *sql.Conn, runID int64) error {
+	tx, err := beginVersionedWriteTx(ctx, conn, r.busyRetries)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer db.Close()
+	defer tx.Rollback()
 
-	// クエリを実行
-	rows, err := db.Query("SELECT stock_id, price_date, price FROM " + dailyStockPriceTableName)
+	// 既存のデータを取り消し用に保存してから、一時テーブルにない行を削除
+	if runID != models.NoImportRun {
+		if _, err := tx.ExecContext(ctx, saveAllReplacedDailyStockPricesSQL, runID, runID); err != nil {
+			return fmt.Errorf("failed to save existing data: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, deleteUnstagedDailyStockPricesSQL); err != nil {
+		return fmt.Errorf("failed to delete existing data: %w", err)
+	}
+
+	// 銘柄ごとに必要な桁数を求めてから、銘柄を登録して桁数を広げる
+	type stagedStock struct {
+		stockID string
+		scale   int
+	}
+	var stagedStocks []stagedStock
+	rows, err := tx.QueryContext(ctx, selectStagedStockPriceScalesSQL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query data: %w", err)
+		return fmt.Errorf("failed to query staged stocks: %w", err)
+	}
+	for rows.Next() {
+		var stock stagedStock
+		if err := rows.Scan(&stock.stockID, &stock.scale); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		stagedStocks = append(stagedStocks, stock)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error during iteration: %w", err)
+	}
+	scales := make(stockPriceScales)
+	for _, stock := range stagedStocks {
+		if _, err := scales.ensure(ctx, tx, stock.stockID, stock.scale); err != nil {
+			return err
+		}
 	}
-	defer rows.Close()
 
-	// 結果を格納するスライス
-	var dailyPrices []models.DailyStockPrice
+	// 桁を広げると int64 の範囲を超える株価がある場合は何も書き込まない
+	var stockID, dateStr string
+	var scale int
+	err = tx.QueryRowContext(ctx, selectStagedPriceOutOfRangeSQL).Scan(&stockID, &dateStr, &scale)
+	if err == nil {
+		return fmt.Errorf("price of %s on %s is out of range with %d decimal places", stockID, dateStr, scale)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to check staged prices: %w", err)
+	}
 
-	// 各行を処理
-	for rows.Next() {
-		var stockID string
-		var dateStr string
-		var price float64
+	if _, err := tx.ExecContext(ctx, upsertStagedDailyStockPricesSQL, nullableImportRunID(runID)); err != nil {
+		return fmt.Errorf("failed to insert data: %w", err)
+	}
+	if err := commitVersionedWriteTx(ctx, tx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
 
-		// 行のデータを取得
-		err := rows.Scan(&stockID, &dateStr, &price)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
-		}
+// 日次株価情報を書き込む1チャンク分のトランザクションを示す構造体
+type dailyStockPriceChunk struct {
+	// トランザクション
+	tx *sql.Tx
+	// Prepared Statement（beginDailyStockPriceChunk に渡したSQLの順）
+	stmts []*sql.Stmt
+	// このトランザクションで確認した銘柄ごとの株価の桁数
+	scales stockPriceScales
+}
 
-		// 日付文字列をtime.Time型に変換
-		priceDate, err := time.Parse(time.RFC3339[:10], dateStr) // YYYY-MM-DD形式
+// beginDailyStockPriceChunk はトランザクションを開始し、
+// 日次株価情報を書き込むためのPrepared Statementを作成します。
+// トランザクションは ctx がキャンセルされると自動的にロールバックされます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - queries: Prepared Statementを作成するSQL
+//
+// 戻り値:
+//   - 1チャンク分のトランザクション
+//   - エラー（データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) beginDailyStockPriceChunk(ctx context.Context, queries ...string) (*dailyStockPriceChunk, error) {
+	return beginDailyStockPriceChunk(ctx, r.db, r.busyRetries, queries...)
+}
+
+// beginDailyStockPriceChunk は接続でトランザクションを開始し、
+// 日次株価情報を書き込むためのPrepared Statementを作成します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - db: トランザクションを開始する接続
+//   - retries: SQLITE_BUSY で失敗した場合に再試行する回数
+//   - queries: Prepared Statementを作成するSQL
+//
+// 戻り値:
+//   - 1チャンク分のトランザクション
+//   - エラー（データベース操作に失敗した場合）
+func beginDailyStockPriceChunk(ctx context.Context, db txBeginner, retries int, queries ...string) (*dailyStockPriceChunk, error) {
+	// トランザクションを開始
+	tx, err := beginVersionedWriteTx(ctx, db, retries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Prepared Statementを作成
+	chunk := &dailyStockPriceChunk{tx: tx, scales: make(stockPriceScales)}
+	for _, query := range queries {
+		stmt, err := tx.PrepareContext(ctx, query)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse date: %w", err)
+			chunk.rollback()
+			return nil, fmt.Errorf("failed to prepare statement: %w", err)
 		}
+		chunk.stmts = append(chunk.stmts, stmt)
+	}
 
-		// 日次株価情報を作成
-		dailyPrice := models.DailyStockPrice{
-			PriceDate: priceDate,
-			StockPrice: models.StockPrice{
-				StockID: stockID,
-				Price:   price,
-			},
-		}
+	return chunk, nil
+}
 
-		// 結果に追加
-		dailyPrices = append(dailyPrices, dailyPrice)
+// commit はPrepared Statementを閉じてトランザクションをコミットします。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - エラー（コミットに失敗した場合）
+func (c *dailyStockPriceChunk) commit(ctx context.Context) error {
+	c.closeStatements()
+	if err := commitVersionedWriteTx(ctx, c.tx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	return nil
+}
 
-	// エラーをチェック
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error during iteration: %w", err)
-	}
+// rollback はPrepared Statementを閉じてトランザクションをロールバックします。
+func (c *dailyStockPriceChunk) rollback() {
+	c.closeStatements()
+	c.tx.Rollback()
+}
 
-	return dailyPrices, nil
+// closeStatements はチャンクのPrepared Statementを全て閉じます。
+func (c *dailyStockPriceChunk) closeStatements() {
+	for _, stmt := range c.stmts {
+		stmt.Close()
+	}
 }
 
-// GetDailyStockPricesByDateRange はSQLiteのdaily_stock_priceテーブルから
-// 指定された銘柄コードと日付範囲に一致する日次株価情報を取得します。
+// deleteAllDailyStockPrices は全ての日次株価情報を削除します。
+// runID を指定した場合は、削除する行を取り消し用に保存します。
 //
 // 引数:
-//   - dbPath: SQLiteデータベースファイルのパス
-//   - stockID: 取得する銘柄コード
-//   - startDate: 取得する日付の始点（この日付を含む）
-//   - endDate: 取得する日付の終点（この日付を含む）
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - tx: トランザクション
+//   - runID: 削除を記録する取り込みID（models.NoImportRun の場合は記録しない）
 //
 // 戻り値:
-//   - 条件に一致する日次株価情報の配列
 //   - エラー（データベース操作に失敗した場合）
-func GetDailyStockPricesByDateRange(dbPath string, stockID string, startDate time.Time, endDate time.Time) ([]models.DailyStockPrice, error) {
-	// データベース接続を開く
-	db, err := sql.Open("sqlite", dbPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+func deleteAllDailyStockPrices(ctx context.Context, tx *sql.Tx, runID int64) error {
+	if runID != models.NoImportRun {
+		_, err := tx.ExecContext(ctx, saveAllReplacedDailyStockPricesSQL, runID, runID)
+		if err != nil {
+			return fmt.Errorf("failed to save existing data: %w", err)
+		}
 	}
-	defer db.Close()
 
-	// 日付をISO 8601形式の文字列に変換
-	startDateStr := startDate.Format(time.RFC3339[:10]) // YYYY-MM-DD形式
-	endDateStr := endDate.Format(time.RFC3339[:10])     // YYYY-MM-DD形式
+	_, err := tx.ExecContext(ctx, "DELETE FROM "+dailyStockPriceTableName)
+	if err != nil {
+		return fmt.Errorf("failed to delete existing data: %w", err)
+	}
+	return nil
+}
 
-	// クエリを実行
-	query := "SELECT stock_id, price_date, price FROM " + dailyStockPriceTableName +
-		" WHERE stock_id = ? AND price_date >= ? AND price_date <= ?"
-	rows, err := db.Query(query, stockID, startDateStr, endDateStr)
+// GetDailyStockPrices はSQLiteのdaily_stock_priceテーブルから
+// 全ての日次株価情報を (銘柄コード, 日付) の順に取得します。
+// 全てのページを読み込み専用のトランザクションで取得するため、取り込みが途中のチャンクを
+// コミットしていても、ある時点の一貫した内容を返します。
+// 件数が多い場合は IterateDailyStockPrices や GetDailyStockPricesPage を使用してください。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - 日次株価情報の配列
+//   - エラー（データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) GetDailyStockPrices(ctx context.Context) ([]models.DailyStockPrice, error) {
+	// 読み込み専用のトランザクションはロックを取得せずに開始する
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
 	if err != nil {
-		return nil, fmt.Errorf("failed to query data: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
 	// 結果を格納するスライス
 	var dailyPrices []models.DailyStockPrice
 
-	// 各行を処理
-	for rows.Next() {
-		var stockID string
-		var dateStr string
-		var price float64
-
-		// 行のデータを取得
-		err := rows.Scan(&stockID, &dateStr, &price)
+	// 先頭から順に全件を取得
+	var cursor models.DailyStockPriceCursor
+	for {
+		page, err := getDailyStockPricesPage(ctx, tx, cursor, dailyStockPriceIteratorPageSize)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+			return nil, err
 		}
-
-		// 日付文字列をtime.Time型に変換
-		priceDate, err := time.Parse(time.RFC3339[:10], dateStr) // YYYY-MM-DD形式
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse date: %w", err)
-		}
-
-		// 日次株価情報を作成
-		dailyPrice := models.DailyStockPrice{
-			PriceDate: priceDate,
-			StockPrice: models.StockPrice{
-				StockID: stockID,
-				Price:   price,
-			},
+		dailyPrices = append(dailyPrices, page.Prices...)
+		if !page.HasNext() {
+			return dailyPrices, nil
 		}
-
-		// 結果に追加
-		dailyPrices = append(dailyPrices, dailyPrice)
+		cursor = page.Next
 	}
+}
 
-	// エラーをチェック
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error during iteration: %w", err)
+// CountDailyStockPrices はSQLiteのdaily_stock_priceテーブルに登録されている
+// 日次株価情報の件数を取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - 日次株価情報の件数
+//   - エラー（データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) CountDailyStockPrices(ctx context.Context) (int, error) {
+	// クエリを実行
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+dailyStockPriceTableName).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query data: %w", err)
 	}
 
-	return dailyPrices, nil
+	return count, nil
+}
+
+// GetDailyStockPricesByDateRange はSQLiteのdaily_stock_priceテーブルから
+// 指定された銘柄コードと日付範囲に一致する日次株価情報を取得します。
+// asOf がゼロ値以外の場合は、daily_stock_price_historyテーブルからその日時に保存されていた版を取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - stockID: 取得する銘柄コード
+//   - startDate: 取得する日付の始点（この日付を含む）
+//   - endDate: 取得する日付の終点（この日付を含む）
+//   - asOf: 取得する版の日時（ゼロ値の場合は現在の値）
+//
+// 戻り値:
+//   - 条件に一致する日次株価情報の配列
+//   - エラー（データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) GetDailyStockPricesByDateRange(ctx context.Context, stockID string, startDate time.Time, endDate time.Time, asOf time.Time) ([]models.DailyStockPrice, error) {
+	// 1銘柄の日付範囲の検索条件として取得する（並び順は日付順）
+	result, err := r.QueryDailyStockPrices(ctx, models.DailyStockPriceQuery{
+		StockIDs: []string{stockID},
+		From:     startDate,
+		To:       endDate,
+		AsOf:     asOf,
+	})
+	return result.Prices, err
 }
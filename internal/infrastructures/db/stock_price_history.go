@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 日次株価の全ての版を記録するテーブル名（daily_stock_price のトリガーで書き込まれる）
+const dailyStockPriceHistoryTableName = "daily_stock_price_history"
+
+// 書き込みトランザクションの版の記録日時を保持するテーブル名（1行のみ）
+const dailyStockPriceHistoryClockTableName = "daily_stock_price_history_clock"
+
+// 最後にコミットした記録日時を取得するSQL
+const selectLastRecordedAtSQL = "SELECT last_recorded_at FROM " + dailyStockPriceHistoryClockTableName
+
+// トランザクションの記録日時を設定するSQL
+const setHistoryClockSQL = "UPDATE " + dailyStockPriceHistoryClockTableName + " SET recorded_at = ?"
+
+// トランザクションの記録日時を最後にコミットした記録日時として残すSQL
+const resetHistoryClockSQL = "UPDATE " + dailyStockPriceHistoryClockTableName + " SET last_recorded_at = recorded_at, recorded_at = NULL"
+
+// 版の記録日時のフォーマット（トリガーの strftime('%Y-%m-%dT%H:%M:%fZ', 'now') と同じ形式）
+const recordedAtFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// 銘柄コードと日付に一致する全ての版を記録日時の順に取得するSQL
+const selectDailyStockPriceHistorySQL = "SELECT h.stock_id, h.price_date, h.price, s.price_scale, h.recorded_at, h.superseded_at" +
+	" FROM " + dailyStockPriceHistoryTableName + " h" +
+	" JOIN " + stockTableName + " s ON s.stock_id = h.stock_id" +
+	" WHERE h.stock_id = ? AND h.price_date = ?" +
+	" ORDER BY h.recorded_at, h.rowid"
+
+// GetDailyStockPriceHistory はSQLiteのdaily_stock_price_historyテーブルから
+// 銘柄コードと日付に一致する日次株価情報の全ての版を記録日時の順に取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - stockID: 取得する銘柄コード
+//   - priceDate: 取得する日付
+//
+// 戻り値:
+//   - 記録日時の順に並んだ版の配列
+//   - エラー（データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) GetDailyStockPriceHistory(ctx context.Context, stockID string, priceDate time.Time) ([]models.DailyStockPriceVersion, error) {
+	rows, err := r.db.QueryContext(ctx, selectDailyStockPriceHistorySQL, stockID, priceDate.Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query data: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []models.DailyStockPriceVersion
+	for rows.Next() {
+		var stockID, dateStr, recordedAt string
+		var ticks int64
+		var scale int
+		var supersededAt sql.NullString
+		if err := rows.Scan(&stockID, &dateStr, &ticks, &scale, &recordedAt, &supersededAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		var version models.DailyStockPriceVersion
+		if version.PriceDate, err = time.Parse(time.DateOnly, dateStr); err != nil {
+			return nil, fmt.Errorf("failed to parse date: %w", err)
+		}
+		version.StockPrice.StockID = stockID
+		if version.StockPrice.Price, err = priceFromTicks(ticks, scale); err != nil {
+			return nil, err
+		}
+		if version.RecordedAt, err = time.Parse(recordedAtFormat, recordedAt); err != nil {
+			return nil, fmt.Errorf("failed to parse recorded_at: %w", err)
+		}
+		if supersededAt.Valid {
+			if version.SupersededAt, err = time.Parse(recordedAtFormat, supersededAt.String); err != nil {
+				return nil, fmt.Errorf("failed to parse superseded_at: %w", err)
+			}
+		}
+		versions = append(versions, version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	return versions, nil
+}
+
+// formatRecordedAt は日時を版の記録日時と比較できる文字列に変換します。
+// 記録日時はミリ秒までのため、ミリ秒未満は切り捨てます。
+//
+// 引数:
+//   - t: 日時
+//
+// 戻り値:
+//   - YYYY-MM-DDTHH:MM:SS.SSSZ 形式のUTCの日時
+func formatRecordedAt(t time.Time) string {
+	return t.UTC().Format(recordedAtFormat)
+}
+
+// beginVersionedWriteTx は日次株価を書き込むトランザクションを開始し、
+// トランザクションの中の全ての書き込みの版の記録日時を1つの日時に揃えます。
+// 記録日時は現在日時と、最後にコミットした記録日時より後の日時のうち遅い方です。
+// commitVersionedWriteTx でコミットしてください。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - db: トランザクションを開始する接続
+//   - retries: SQLITE_BUSY で失敗した場合に再試行する回数
+//
+// 戻り値:
+//   - トランザクション
+//   - エラー（トランザクションを開始できない場合やデータベース操作に失敗した場合）
+func beginVersionedWriteTx(ctx context.Context, db txBeginner, retries int) (*sql.Tx, error) {
+	tx, err := beginWriteTx(ctx, db, retries)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同じミリ秒にコミットした前のトランザクションと記録日時が重ならないようにする
+	recordedAt := time.Now().UTC().Truncate(time.Millisecond)
+	var lastRecordedAt sql.NullString
+	if err := tx.QueryRowContext(ctx, selectLastRecordedAtSQL).Scan(&lastRecordedAt); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to read history clock: %w", err)
+	}
+	if lastRecordedAt.Valid && lastRecordedAt.String != "" {
+		last, err := time.Parse(recordedAtFormat, lastRecordedAt.String)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to parse history clock: %w", err)
+		}
+		if !recordedAt.After(last) {
+			recordedAt = last.Add(time.Millisecond)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, setHistoryClockSQL, formatRecordedAt(recordedAt)); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to set history clock: %w", err)
+	}
+	return tx, nil
+}
+
+// commitVersionedWriteTx は beginVersionedWriteTx で開始したトランザクションの記録日時を解除してコミットします。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - tx: beginVersionedWriteTx で開始したトランザクション
+//
+// 戻り値:
+//   - エラー（データベース操作やコミットに失敗した場合）
+func commitVersionedWriteTx(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, resetHistoryClockSQL); err != nil {
+		return fmt.Errorf("failed to reset history clock: %w", err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// waitForNextRecordedAt は版の記録日時（ミリ秒単位）が前後の書き込みと重ならないように待ってから現在日時を返します。
+func waitForNextRecordedAt() time.Time {
+	time.Sleep(5 * time.Millisecond)
+	now := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	return now
+}
+
+func TestGetDailyStockPricesByDateRange_AsOf(t *testing.T) {
+	// Arrange - 初期化・上書き（桁数の拡張を含む）・再初期化の各時点の版を記録する
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_history.db")
+	ctx := context.Background()
+	date := func(day int) time.Time { return time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC) }
+	price := func(day int, ticks int64, scale int) models.DailyStockPrice {
+		return models.DailyStockPrice{PriceDate: date(day), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(ticks, scale)}}
+	}
+	beforeAll := waitForNextRecordedAt()
+	if err := repository.InitializeDailyStockPriceTable(ctx, []models.DailyStockPrice{price(3, 2800, 0), price(4, 2873, 0)}); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	afterInitialize := waitForNextRecordedAt()
+	_, err := repository.UpsertDailyStockPricesFromSeq(ctx, models.NoImportRun,
+		models.DailyStockPriceSeq([]models.DailyStockPrice{price(4, 2880, 0), price(5, 28905, 1)}), 10, models.ConflictPolicyOverwrite)
+	if err != nil {
+		t.Fatalf("Failed to upsert prices: %v", err)
+	}
+	afterUpsert := waitForNextRecordedAt()
+	if err := repository.InitializeDailyStockPriceTable(ctx, []models.DailyStockPrice{price(4, 2880, 0)}); err != nil {
+		t.Fatalf("Failed to reinitialize table: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		asOf     time.Time
+		expected string
+	}{
+		{name: "before the first write", asOf: beforeAll, expected: "[]"},
+		{name: "after initialize", asOf: afterInitialize, expected: "[2800 2873]"},
+		{name: "after upsert", asOf: afterUpsert, expected: "[2800 2880 2890.5]"},
+		{name: "current values", asOf: time.Time{}, expected: "[2880]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			dailyPrices, err := repository.GetDailyStockPricesByDateRange(ctx, "7203", date(1), date(28), tt.asOf)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			var prices []string
+			for _, dailyPrice := range dailyPrices {
+				prices = append(prices, dailyPrice.StockPrice.Price.String())
+			}
+			if fmt.Sprint(prices) != tt.expected {
+				t.Errorf("Expected %s, but got %v", tt.expected, prices)
+			}
+		})
+	}
+
+	// Act - 同じ日付の全ての版
+	versions, err := repository.GetDailyStockPriceHistory(ctx, "7203", date(4))
+	unchangedVersions, unchangedErr := repository.GetDailyStockPriceHistory(ctx, "7203", date(3))
+
+	// Assert - 桁数の拡張と、値の変わらない行の再初期化では版が増えず、再初期化で削除した行のみ置き換えられる
+	if err != nil || unchangedErr != nil {
+		t.Fatalf("Expected no error, but got: %v, %v", err, unchangedErr)
+	}
+	if len(versions) != 2 || len(unchangedVersions) != 1 {
+		t.Fatalf("Expected 2 and 1 versions, but got %+v and %+v", versions, unchangedVersions)
+	}
+	for i, expected := range []string{"2873", "2880"} {
+		if versions[i].StockPrice.Price.String() != expected || versions[i].IsCurrent() != (i == 1) {
+			t.Errorf("Unexpected version %d: %+v", i, versions[i])
+		}
+	}
+	if !versions[0].ValidAt(afterInitialize) || versions[0].ValidAt(afterUpsert) || !versions[1].ValidAt(afterUpsert) {
+		t.Errorf("Unexpected validity of versions: %+v", versions)
+	}
+	if unchangedVersions[0].IsCurrent() || unchangedVersions[0].StockPrice.Price != models.NewPrice(2800, 0) {
+		t.Errorf("Expected the deleted row to be superseded, but got %+v", unchangedVersions[0])
+	}
+}
+
+func TestInitializeDailyStockPriceTableFromSeq_HistoryRecordsOnlyChanges(t *testing.T) {
+	// Arrange - 同じ内容で2回置き換えてから、1行の変更・1行の削除・1行の追加を含む内容で置き換える
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_history_changes.db")
+	ctx := context.Background()
+	date := func(day int) time.Time { return time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC) }
+	price := func(day int, ticks int64) models.DailyStockPrice {
+		return models.DailyStockPrice{PriceDate: date(day), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(ticks, 0)}}
+	}
+	initialPrices := []models.DailyStockPrice{price(3, 2800), price(4, 2873), price(5, 2890)}
+	countHistory := func() int {
+		t.Helper()
+		var count int
+		if err := repository.db.QueryRow("SELECT COUNT(*) FROM " + dailyStockPriceHistoryTableName).Scan(&count); err != nil {
+			t.Fatalf("Failed to count history: %v", err)
+		}
+		return count
+	}
+	if err := repository.InitializeDailyStockPriceTable(ctx, initialPrices); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	initialCount := countHistory()
+
+	// Act
+	for range 2 {
+		if err := repository.InitializeDailyStockPriceTable(ctx, initialPrices); err != nil {
+			t.Fatalf("Failed to replace table: %v", err)
+		}
+	}
+	unchangedCount := countHistory()
+	if err := repository.InitializeDailyStockPriceTable(ctx, []models.DailyStockPrice{price(3, 2800), price(4, 2880), price(6, 2900)}); err != nil {
+		t.Fatalf("Failed to replace table: %v", err)
+	}
+	changedVersions, err := repository.GetDailyStockPriceHistory(ctx, "7203", date(4))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	removedVersions, err := repository.GetDailyStockPriceHistory(ctx, "7203", date(5))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	addedVersions, err := repository.GetDailyStockPriceHistory(ctx, "7203", date(6))
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Assert - 同じ内容の置き換えでは版が増えず、変更した置き換えの版は全て1つの日時に記録される
+	if initialCount != 3 || unchangedCount != initialCount {
+		t.Errorf("Expected 3 versions before and after replacing with the same data, but got %d and %d", initialCount, unchangedCount)
+	}
+	if count := countHistory(); count != initialCount+2 {
+		t.Errorf("Expected 2 new versions after the changing replace, but got %d", count-initialCount)
+	}
+	if len(changedVersions) != 2 || len(removedVersions) != 1 || len(addedVersions) != 1 {
+		t.Fatalf("Unexpected versions: %+v, %+v, %+v", changedVersions, removedVersions, addedVersions)
+	}
+	recordedAt := changedVersions[1].RecordedAt
+	if !changedVersions[0].SupersededAt.Equal(recordedAt) || !removedVersions[0].SupersededAt.Equal(recordedAt) || !addedVersions[0].RecordedAt.Equal(recordedAt) {
+		t.Errorf("Expected every version of the replace to be recorded at %v, but got %+v, %+v, %+v", recordedAt, changedVersions, removedVersions, addedVersions)
+	}
+}
+
+func TestMigrator_RecordsExistingPricesAsHistory(t *testing.T) {
+	// Arrange - 履歴を記録する前のバージョン2のデータベース
+	// テスト終了後にデータベースファイルを削除
+	dbPath := "./test_migrate_price_history.db"
+	execTestSQL(t, dbPath, "CREATE TABLE daily_stock_price (stock_id TEXT NOT NULL, price_date TEXT NOT NULL, price REAL NOT NULL,"+
+		" open REAL, high REAL, low REAL, volume INTEGER, PRIMARY KEY (stock_id, price_date))")
+	execTestSQL(t, dbPath, "INSERT INTO daily_stock_price (stock_id, price_date, price) VALUES ('7203', '2025-02-04', 2903.5)")
+	beforeMigration := time.Now().Add(-time.Second)
+
+	// Act
+	repository := newTestRepository(t, dbPath)
+	versions, err := repository.GetDailyStockPriceHistory(context.Background(), "7203", time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC))
+
+	// Assert - 既存の行がマイグレーションの日時の現在の版として記録される
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(versions) != 1 || !versions[0].IsCurrent() || versions[0].StockPrice.Price != models.NewPrice(29035, 1) ||
+		versions[0].RecordedAt.Before(beforeMigration) {
+		t.Errorf("Expected one current version recorded by the migration, but got %+v", versions)
+	}
+}
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// IterateDailyStockPrices が1回のクエリで取得する件数
+const dailyStockPriceIteratorPageSize = 1000
+
+// 日次株価情報を銘柄の株価の桁数とともに取得するSQL（scanDailyStockPrice で変換する）
+const selectDailyStockPricesSQL = "SELECT p.stock_id, p.price_date, p.price, s.price_scale FROM " + dailyStockPriceTableName + " p" +
+	" JOIN " + stockTableName + " s ON s.stock_id = p.stock_id"
+
+// 日次株価情報を (銘柄コード, 日付) の順に取得するSQL（先頭から）
+const selectDailyStockPricesPageSQL = selectDailyStockPricesSQL + " ORDER BY p.stock_id, p.price_date LIMIT ?"
+
+// 日次株価情報を (銘柄コード, 日付) の順にカーソルより後ろから取得するSQL
+const selectDailyStockPricesPageAfterSQL = selectDailyStockPricesSQL +
+	" WHERE (p.stock_id, p.price_date) > (?, ?) ORDER BY p.stock_id, p.price_date LIMIT ?"
+
+// GetDailyStockPricesPage はSQLiteのdaily_stock_priceテーブルから
+// (銘柄コード, 日付) の順で after より後ろの日次株価情報を最大 limit 件取得します。
+// 主キーの索引を使ったキーセットページネーションのため、読み進めても1ページの取得にかかる時間は変わりません。
+// 次のページがあるかどうかを判定するため、limit より1件多く取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - after: 取得を再開するカーソル（ゼロ値の場合は先頭から）
+//   - limit: 1ページの最大件数
+//
+// 戻り値:
+//   - 1ページ分の日次株価情報と次のページのカーソル
+//   - エラー（limit が不正な場合やデータベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) GetDailyStockPricesPage(ctx context.Context, after models.DailyStockPriceCursor, limit int) (models.DailyStockPricePage, error) {
+	return getDailyStockPricesPage(ctx, r.db, after, limit)
+}
+
+// getDailyStockPricesPage は (銘柄コード, 日付) の順で after より後ろの日次株価情報を最大 limit 件取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - q: データベース接続またはトランザクション
+//   - after: 取得を再開するカーソル（ゼロ値の場合は先頭から）
+//   - limit: 1ページの最大件数
+//
+// 戻り値:
+//   - 1ページ分の日次株価情報と次のページのカーソル
+//   - エラー（limit が不正な場合やデータベース操作に失敗した場合）
+func getDailyStockPricesPage(ctx context.Context, q sqlQueryer, after models.DailyStockPriceCursor, limit int) (models.DailyStockPricePage, error) {
+	if limit <= 0 {
+		return models.DailyStockPricePage{}, fmt.Errorf("invalid page limit: %d", limit)
+	}
+
+	// クエリを実行
+	query := selectDailyStockPricesPageSQL
+	args := []any{limit + 1}
+	if !after.IsZero() {
+		query = selectDailyStockPricesPageAfterSQL
+		args = []any{after.StockID, after.PriceDate.Format(time.RFC3339[:10]), limit + 1}
+	}
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return models.DailyStockPricePage{}, fmt.Errorf("failed to query data: %w", err)
+	}
+	defer rows.Close()
+
+	// 各行を処理
+	var page models.DailyStockPricePage
+	for rows.Next() {
+		dailyPrice, err := scanDailyStockPrice(rows)
+		if err != nil {
+			return models.DailyStockPricePage{}, err
+		}
+		page.Prices = append(page.Prices, dailyPrice)
+	}
+
+	// エラーをチェック
+	if err := rows.Err(); err != nil {
+		return models.DailyStockPricePage{}, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	// limit より多く取得できた場合は次のページがある
+	if len(page.Prices) > limit {
+		page.Prices = page.Prices[:limit]
+		last := page.Prices[limit-1]
+		page.Next = models.DailyStockPriceCursor{StockID: last.StockPrice.StockID, PriceDate: last.PriceDate}
+	}
+
+	return page, nil
+}
+
+// IterateDailyStockPrices はSQLiteのdaily_stock_priceテーブルから
+// (銘柄コード, 日付) の順で after より後ろの日次株価情報を1件ずつ返すイテレータを返します。
+// dailyStockPriceIteratorPageSize 件ずつページ単位で取得するため、メモリ上に保持するのは1ページ分のみで、
+// 読み出しの間にデータベースのロックを保持し続けることもありません。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - after: 取得を開始するカーソル（ゼロ値の場合は先頭から）
+//
+// 戻り値:
+//   - 日次株価情報とエラーの組を返すイテレータ（エラーを返した後は終了する）
+func (r *SQLiteStockPriceRepository) IterateDailyStockPrices(ctx context.Context, after models.DailyStockPriceCursor) iter.Seq2[models.DailyStockPrice, error] {
+	return func(yield func(models.DailyStockPrice, error) bool) {
+		cursor := after
+		for {
+			page, err := r.GetDailyStockPricesPage(ctx, cursor, dailyStockPriceIteratorPageSize)
+			if err != nil {
+				yield(models.DailyStockPrice{}, err)
+				return
+			}
+			for _, dailyPrice := range page.Prices {
+				if !yield(dailyPrice, nil) {
+					return
+				}
+			}
+			if !page.HasNext() {
+				return
+			}
+			cursor = page.Next
+		}
+	}
+}
+
+// scanDailyStockPrice は stock_id, price_date, price, price_scale の順に取得した1行を日次株価情報に変換します。
+//
+// 引数:
+//   - row: *sql.Row または *sql.Rows
+//
+// 戻り値:
+//   - 日次株価情報
+//   - エラー（行の取得や日付の解析に失敗した場合）
+func scanDailyStockPrice(row interface{ Scan(dest ...any) error }) (models.DailyStockPrice, error) {
+	var stockID string
+	var dateStr string
+	var ticks int64
+	var scale int
+
+	// 行のデータを取得
+	if err := row.Scan(&stockID, &dateStr, &ticks, &scale); err != nil {
+		return models.DailyStockPrice{}, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	// 日付文字列をtime.Time型に変換
+	priceDate, err := time.Parse(time.RFC3339[:10], dateStr) // YYYY-MM-DD形式
+	if err != nil {
+		return models.DailyStockPrice{}, fmt.Errorf("failed to parse date of %s (stock_price_db doctor -fix moves the row aside): %w", stockID, err)
+	}
+
+	price, err := priceFromTicks(ticks, scale)
+	if err != nil {
+		return models.DailyStockPrice{}, err
+	}
+
+	return models.DailyStockPrice{
+		PriceDate: priceDate,
+		StockPrice: models.StockPrice{
+			StockID: stockID,
+			Price:   price,
+		},
+	}, nil
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// pageTestPrices はページネーションのテスト用に (銘柄コード, 日付) の順と異なる順序の日次株価情報を返します。
+func pageTestPrices() []models.DailyStockPrice {
+	return []models.DailyStockPrice{
+		{PriceDate: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8100, 0)}},
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8000, 0)}},
+		{PriceDate: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2963, 0)}},
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+		{PriceDate: time.Date(2025, 2, 6, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(29035, 1)}},
+	}
+}
+
+func TestGetDailyStockPricesPage(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_page.db")
+	testPrices := pageTestPrices()
+	if err := repository.InitializeDailyStockPriceTable(context.Background(), testPrices); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	expected := []models.DailyStockPrice{testPrices[3], testPrices[2], testPrices[4], testPrices[1], testPrices[0]}
+
+	// Act - 2件ずつ最後のページまで読み進める
+	var got []models.DailyStockPrice
+	var cursor models.DailyStockPriceCursor
+	pageCount := 0
+	for {
+		page, err := repository.GetDailyStockPricesPage(context.Background(), cursor, 2)
+		if err != nil {
+			t.Fatalf("Failed to get page: %v", err)
+		}
+		pageCount++
+		got = append(got, page.Prices...)
+		if !page.HasNext() {
+			break
+		}
+		cursor = page.Next
+	}
+
+	// Assert
+	if pageCount != 3 {
+		t.Errorf("Expected 3 pages, but got %d", pageCount)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", expected, got)
+	}
+}
+
+func TestGetDailyStockPricesPage_ExactLastPage(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_page_exact.db")
+	if err := repository.InitializeDailyStockPriceTable(context.Background(), pageTestPrices()); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	after := models.DailyStockPriceCursor{StockID: "7203", PriceDate: time.Date(2025, 2, 6, 0, 0, 0, 0, time.UTC)}
+
+	// Act - 残りの件数と同じ件数を指定
+	page, err := repository.GetDailyStockPricesPage(context.Background(), after, 2)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	if len(page.Prices) != 2 || page.Prices[0].StockPrice.StockID != "9984" {
+		t.Errorf("Unexpected page: %+v", page.Prices)
+	}
+	if page.HasNext() {
+		t.Errorf("Expected no next page, but got cursor %s", page.Next)
+	}
+}
+
+func TestGetDailyStockPricesPage_InvalidLimit(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_page_invalid.db")
+
+	// Act
+	_, err := repository.GetDailyStockPricesPage(context.Background(), models.DailyStockPriceCursor{}, 0)
+
+	// Assert
+	if err == nil {
+		t.Error("Expected an error for a non-positive limit, but got nil")
+	}
+}
+
+func TestIterateDailyStockPrices(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_iterate.db")
+	// 1ページに収まらない件数を登録
+	var testPrices []models.DailyStockPrice
+	startDate := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < dailyStockPriceIteratorPageSize+10; i++ {
+		testPrices = append(testPrices, models.DailyStockPrice{
+			PriceDate:  startDate.AddDate(0, 0, i),
+			StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(int64(i), 0)},
+		})
+	}
+	if err := repository.InitializeDailyStockPriceTable(context.Background(), testPrices); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	after := models.DailyStockPriceCursor{StockID: "7203", PriceDate: testPrices[4].PriceDate}
+
+	// Act
+	var got []models.DailyStockPrice
+	for dailyPrice, err := range repository.IterateDailyStockPrices(context.Background(), after) {
+		if err != nil {
+			t.Fatalf("Failed to iterate: %v", err)
+		}
+		got = append(got, dailyPrice)
+	}
+
+	// Assert
+	if !reflect.DeepEqual(got, testPrices[5:]) {
+		t.Errorf("Expected %d prices after the cursor, but got %d", len(testPrices)-5, len(got))
+	}
+}
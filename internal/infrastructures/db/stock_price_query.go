@@ -0,0 +1,234 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 銘柄ごとの桁数で保存された株価を、桁数の異なる銘柄の間で値の順に並べるためのSQLの式
+// 最大の桁数に揃えると int64 の範囲を超える場合があるため、整数部と models.MaxPriceScale 桁に揃えた小数部に分けて並べる
+var priceOrderSQL = []string{
+	"(p.price / (" + priceScaleFactorSQL("s.price_scale") + "))",
+	"((p.price % (" + priceScaleFactorSQL("s.price_scale") + ")) * (" + priceScaleFactorSQL(strconv.Itoa(models.MaxPriceScale)+" - s.price_scale") + "))",
+}
+
+// QueryDailyStockPrices はSQLiteのdaily_stock_priceテーブルから検索条件に一致する日次株価情報を取得します。
+// query.AsOf がゼロ値以外の場合は、daily_stock_price_historyテーブルからその日時に保存されていた版を検索します。
+// query.DistinctStocks の場合は、条件に一致する行を持つ銘柄コードのみを取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - query: 検索条件
+//
+// 戻り値:
+//   - 検索結果
+//   - エラー（検索条件が不正な場合やデータベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) QueryDailyStockPrices(ctx context.Context, query models.DailyStockPriceQuery) (models.DailyStockPriceQueryResult, error) {
+	querySQL, args, err := buildDailyStockPriceQuery(query)
+	if err != nil {
+		return models.DailyStockPriceQueryResult{}, err
+	}
+
+	// クエリを実行
+	rows, err := r.db.QueryContext(ctx, querySQL, args...)
+	if err != nil {
+		return models.DailyStockPriceQueryResult{}, fmt.Errorf("failed to query data: %w", err)
+	}
+	defer rows.Close()
+
+	// 各行を処理
+	var result models.DailyStockPriceQueryResult
+	for rows.Next() {
+		if query.DistinctStocks {
+			var stockID string
+			if err := rows.Scan(&stockID); err != nil {
+				return models.DailyStockPriceQueryResult{}, fmt.Errorf("failed to scan row: %w", err)
+			}
+			result.StockIDs = append(result.StockIDs, stockID)
+			continue
+		}
+		dailyPrice, err := scanDailyStockPrice(rows)
+		if err != nil {
+			return models.DailyStockPriceQueryResult{}, err
+		}
+		result.Prices = append(result.Prices, dailyPrice)
+	}
+
+	// エラーをチェック
+	if err := rows.Err(); err != nil {
+		return models.DailyStockPriceQueryResult{}, fmt.Errorf("error during iteration: %w", err)
+	}
+
+	return result, nil
+}
+
+// buildDailyStockPriceQuery は検索条件から日次株価情報を取得するSQLとその引数を作成します。
+// 値は全てプレースホルダで渡し、SQLに埋め込むのは列名と並び順のみです。
+// 日次株価情報は scanDailyStockPrice で変換できる列の順に、
+// DistinctStocks の場合は銘柄コードのみを取得します。
+//
+// 引数:
+//   - query: 検索条件
+//
+// 戻り値:
+//   - SQL
+//   - SQLの引数
+//   - エラー（検索条件が不正な場合）
+func buildDailyStockPriceQuery(query models.DailyStockPriceQuery) (string, []any, error) {
+	if err := query.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	// 現在の値は daily_stock_price から、過去の版は同じ列を持つ daily_stock_price_history から取得する
+	var conditions []string
+	var args []any
+	tableName := dailyStockPriceTableName
+	if !query.AsOf.IsZero() {
+		asOfStr := formatRecordedAt(query.AsOf)
+		tableName = dailyStockPriceHistoryTableName
+		conditions = append(conditions, "p.recorded_at <= ?", "(p.superseded_at IS NULL OR p.superseded_at > ?)")
+		args = append(args, asOfStr, asOfStr)
+	}
+
+	// 銘柄コードと日付範囲の条件
+	if len(query.StockIDs) > 0 {
+		conditions = append(conditions, "p.stock_id IN ("+strings.Repeat("?, ", len(query.StockIDs)-1)+"?)")
+		for _, stockID := range query.StockIDs {
+			args = append(args, stockID)
+		}
+	}
+	if !query.From.IsZero() {
+		conditions = append(conditions, "p.price_date >= ?")
+		args = append(args, query.From.Format(time.RFC3339[:10])) // YYYY-MM-DD形式
+	}
+	if !query.To.IsZero() {
+		conditions = append(conditions, "p.price_date <= ?")
+		args = append(args, query.To.Format(time.RFC3339[:10])) // YYYY-MM-DD形式
+	}
+
+	// 株価の範囲の条件（銘柄ごとに桁数が異なるため、範囲を銘柄の桁数の整数に変換して比較する）
+	if query.MinPrice != nil {
+		condition, boundArgs := priceBoundCondition(*query.MinPrice, true)
+		conditions = append(conditions, condition)
+		args = append(args, boundArgs...)
+	}
+	if query.MaxPrice != nil {
+		condition, boundArgs := priceBoundCondition(*query.MaxPrice, false)
+		conditions = append(conditions, condition)
+		args = append(args, boundArgs...)
+	}
+
+	// 取得する列と並び順
+	columns := "p.stock_id, p.price_date, p.price, s.price_scale"
+	orderColumns := []string{"p.stock_id", "p.price_date"}
+	switch {
+	case query.DistinctStocks:
+		columns = "DISTINCT p.stock_id"
+		orderColumns = []string{"p.stock_id"}
+	case query.SortBy == models.SortByDate:
+		orderColumns = []string{"p.price_date", "p.stock_id"}
+	case query.SortBy == models.SortByPrice:
+		orderColumns = append(slices.Clone(priceOrderSQL), "p.stock_id", "p.price_date")
+	}
+	if query.Descending {
+		for i := range orderColumns {
+			orderColumns[i] += " DESC"
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString("SELECT " + columns + " FROM " + tableName + " p")
+	builder.WriteString(" JOIN " + stockTableName + " s ON s.stock_id = p.stock_id")
+	if len(conditions) > 0 {
+		builder.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	}
+	builder.WriteString(" ORDER BY " + strings.Join(orderColumns, ", "))
+
+	// OFFSET は LIMIT と共にしか指定できないため、件数を制限しない場合は LIMIT -1 を指定する
+	if query.Limit > 0 || query.Offset > 0 {
+		limit := query.Limit
+		if limit == 0 {
+			limit = -1
+		}
+		builder.WriteString(" LIMIT ? OFFSET ?")
+		args = append(args, limit, query.Offset)
+	}
+
+	return builder.String(), args, nil
+}
+
+// priceBoundCondition は株価の下限または上限を、銘柄の桁数ごとの整数の比較に変換したSQLの条件とその引数を作成します。
+// 範囲は銘柄の桁数で表せる最も近い値に切り上げ（下限）または切り捨て（上限）、
+// 銘柄の桁数で int64 の範囲を超える場合は、全ての株価が範囲の内側または外側にあるものとして扱います。
+//
+// 引数:
+//   - bound: 株価の下限または上限
+//   - lower: 下限の場合は true
+//
+// 戻り値:
+//   - SQLの条件
+//   - SQLの引数
+func priceBoundCondition(bound models.Price, lower bool) (string, []any) {
+	operator := "<="
+	if lower {
+		operator = ">="
+	}
+
+	var builder strings.Builder
+	var args []any
+	builder.WriteString("(CASE s.price_scale")
+	for scale := 0; scale <= models.MaxPriceScale; scale++ {
+		builder.WriteString(" WHEN " + strconv.Itoa(scale) + " THEN ")
+		ticks, ok := boundTicksAt(bound, scale, lower)
+		if !ok {
+			// 範囲が int64 を超える場合、正の下限と負の上限には一致する株価がない
+			if (bound.Ticks() > 0) == lower {
+				builder.WriteString("0")
+			} else {
+				builder.WriteString("1")
+			}
+			continue
+		}
+		builder.WriteString("p.price " + operator + " ?")
+		args = append(args, ticks)
+	}
+	builder.WriteString(" END)")
+	return builder.String(), args
+}
+
+// boundTicksAt は株価の範囲を指定した桁数の整数に変換します。
+// 桁数が株価の桁数より少ない場合は、下限は切り上げ、上限は切り捨てます。
+//
+// 引数:
+//   - bound: 株価の下限または上限
+//   - scale: 変換する小数点以下の桁数
+//   - lower: 下限の場合は true
+//
+// 戻り値:
+//   - 10^-scale 単位で表した範囲
+//   - int64 の範囲に収まる場合は true
+func boundTicksAt(bound models.Price, scale int, lower bool) (int64, bool) {
+	if scale >= bound.Scale() {
+		ticks, err := bound.TicksAt(scale)
+		return ticks, err == nil
+	}
+
+	factor := int64(1)
+	for range bound.Scale() - scale {
+		factor *= 10
+	}
+	ticks, remainder := bound.Ticks()/factor, bound.Ticks()%factor
+	switch {
+	case lower && remainder > 0:
+		ticks++
+	case !lower && remainder < 0:
+		ticks--
+	}
+	return ticks, true
+}
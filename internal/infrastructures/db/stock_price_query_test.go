@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+func TestQueryDailyStockPrices(t *testing.T) {
+	// Arrange - 株価の桁数が異なる3銘柄
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_query.db")
+	date := func(day int) time.Time { return time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC) }
+	price := func(stockID string, day int, ticks int64, scale int) models.DailyStockPrice {
+		return models.DailyStockPrice{PriceDate: date(day), StockPrice: models.StockPrice{StockID: stockID, Price: models.NewPrice(ticks, scale)}}
+	}
+	if err := repository.InitializeDailyStockPriceTable(context.Background(), []models.DailyStockPrice{
+		price("7203", 3, 28005, 1), price("7203", 4, 2873, 0), price("7203", 5, 29035, 1),
+		price("9984", 3, 8100, 0), price("9984", 4, 8000, 0),
+		price("1301", 4, 350025, 2),
+	}); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	minPrice := models.NewPrice(2873, 0)
+	maxPrice := models.NewPrice(35003, 1)
+
+	tests := []struct {
+		name     string
+		query    models.DailyStockPriceQuery
+		expected string
+	}{
+		{name: "all prices", query: models.DailyStockPriceQuery{},
+			expected: "1301:2025-02-04=3500.25 7203:2025-02-03=2800.5 7203:2025-02-04=2873 7203:2025-02-05=2903.5 9984:2025-02-03=8100 9984:2025-02-04=8000"},
+		{name: "several stocks from a date", query: models.DailyStockPriceQuery{StockIDs: []string{"9984", "1301"}, From: date(4)},
+			expected: "1301:2025-02-04=3500.25 9984:2025-02-04=8000"},
+		{name: "until a date", query: models.DailyStockPriceQuery{To: date(3)},
+			expected: "7203:2025-02-03=2800.5 9984:2025-02-03=8100"},
+		{name: "price range across scales", query: models.DailyStockPriceQuery{MinPrice: &minPrice, MaxPrice: &maxPrice},
+			expected: "1301:2025-02-04=3500.25 7203:2025-02-04=2873 7203:2025-02-05=2903.5"},
+		{name: "sorted by price descending", query: models.DailyStockPriceQuery{SortBy: models.SortByPrice, Descending: true, Limit: 3},
+			expected: "9984:2025-02-03=8100 9984:2025-02-04=8000 1301:2025-02-04=3500.25"},
+		{name: "sorted by date with offset", query: models.DailyStockPriceQuery{SortBy: models.SortByDate, Offset: 4},
+			expected: "9984:2025-02-04=8000 7203:2025-02-05=2903.5"},
+		{name: "page in the middle", query: models.DailyStockPriceQuery{Limit: 2, Offset: 2},
+			expected: "7203:2025-02-04=2873 7203:2025-02-05=2903.5"},
+		{name: "distinct stocks", query: models.DailyStockPriceQuery{DistinctStocks: true, From: date(4), To: date(4), Descending: true},
+			expected: "9984 7203 1301"},
+		{name: "distinct stocks by price", query: models.DailyStockPriceQuery{DistinctStocks: true, MinPrice: &maxPrice, Limit: 1},
+			expected: "9984"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result, err := repository.QueryDailyStockPrices(context.Background(), tt.query)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			got := result.StockIDs
+			for _, dailyPrice := range result.Prices {
+				got = append(got, fmt.Sprintf("%s:%s=%s", dailyPrice.StockPrice.StockID, dailyPrice.PriceDate.Format(time.DateOnly), dailyPrice.StockPrice.Price))
+			}
+			if strings.Join(got, " ") != tt.expected {
+				t.Errorf("Expected %s, but got %s", tt.expected, strings.Join(got, " "))
+			}
+		})
+	}
+}
+
+func TestQueryDailyStockPrices_LargePrices(t *testing.T) {
+	// Arrange - 最大の桁数に揃えると int64 の範囲を超える株価の銘柄と、桁数6の銘柄
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_query_large.db")
+	date := func(day int) time.Time { return time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC) }
+	price := func(stockID string, day int, ticks int64, scale int) models.DailyStockPrice {
+		return models.DailyStockPrice{PriceDate: date(day), StockPrice: models.StockPrice{StockID: stockID, Price: models.NewPrice(ticks, scale)}}
+	}
+	if err := repository.InitializeDailyStockPriceTable(context.Background(), []models.DailyStockPrice{
+		price("9999", 3, 9_300_000_000_000, 0), price("9999", 4, 9_300_000_000_001, 0),
+		price("1111", 3, 1_000_001, 6), price("1111", 4, 2, 0),
+	}); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	halfAboveLarge := models.NewPrice(93_000_000_000_005, 1)
+	belowLarge := models.NewPrice(9_250_000_000_000, 0)
+	smallBound := models.NewPrice(15, 1)
+
+	tests := []struct {
+		name     string
+		query    models.DailyStockPriceQuery
+		expected string
+	}{
+		{name: "sorted by price descending", query: models.DailyStockPriceQuery{SortBy: models.SortByPrice, Descending: true},
+			expected: "9999:2025-02-04=9300000000001 9999:2025-02-03=9300000000000 1111:2025-02-04=2 1111:2025-02-03=1.000001"},
+		{name: "lower bound between large prices", query: models.DailyStockPriceQuery{MinPrice: &halfAboveLarge},
+			expected: "9999:2025-02-04=9300000000001"},
+		{name: "upper bound beyond the range of small scales", query: models.DailyStockPriceQuery{MaxPrice: &belowLarge},
+			expected: "1111:2025-02-03=1.000001 1111:2025-02-04=2"},
+		{name: "upper bound with more decimal places than a stock", query: models.DailyStockPriceQuery{StockIDs: []string{"9999"}, MaxPrice: &halfAboveLarge},
+			expected: "9999:2025-02-03=9300000000000"},
+		{name: "small upper bound", query: models.DailyStockPriceQuery{MaxPrice: &smallBound},
+			expected: "1111:2025-02-03=1.000001"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			result, err := repository.QueryDailyStockPrices(context.Background(), tt.query)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			var got []string
+			for _, dailyPrice := range result.Prices {
+				got = append(got, fmt.Sprintf("%s:%s=%s", dailyPrice.StockPrice.StockID, dailyPrice.PriceDate.Format(time.DateOnly), dailyPrice.StockPrice.Price))
+			}
+			if strings.Join(got, " ") != tt.expected {
+				t.Errorf("Expected %s, but got %s", tt.expected, strings.Join(got, " "))
+			}
+		})
+	}
+}
+
+func TestQueryDailyStockPrices_InvalidQuery(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_query_invalid.db")
+	minPrice := models.NewPrice(3000, 0)
+	maxPrice := models.NewPrice(2000, 0)
+
+	tests := []struct {
+		name  string
+		query models.DailyStockPriceQuery
+	}{
+		{name: "negative limit", query: models.DailyStockPriceQuery{Limit: -1}},
+		{name: "negative offset", query: models.DailyStockPriceQuery{Offset: -1}},
+		{name: "reversed date range", query: models.DailyStockPriceQuery{From: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC)}},
+		{name: "reversed price range", query: models.DailyStockPriceQuery{MinPrice: &minPrice, MaxPrice: &maxPrice}},
+		{name: "unknown sort key", query: models.DailyStockPriceQuery{SortBy: "volume"}},
+		{name: "distinct stocks sorted by price", query: models.DailyStockPriceQuery{DistinctStocks: true, SortBy: models.SortByPrice}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			_, err := repository.QueryDailyStockPrices(context.Background(), tt.query)
+
+			// Assert
+			if err == nil {
+				t.Error("Expected an error, but got nil")
+			}
+		})
+	}
+}
+
+func TestBuildDailyStockPriceQuery_Parameterized(t *testing.T) {
+	// Arrange - SQLの構文を含む銘柄コード
+	stockID := "7203'); DROP TABLE daily_stock_price; --"
+
+	// Act
+	query, args, err := buildDailyStockPriceQuery(models.DailyStockPriceQuery{StockIDs: []string{stockID}, Limit: 10})
+
+	// Assert - 値はSQLに埋め込まれずに引数で渡される
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if strings.Contains(query, "DROP") || strings.Count(query, "?") != len(args) {
+		t.Errorf("Expected placeholders only, but got %q with %v", query, args)
+	}
+	if len(args) != 3 || args[0] != stockID {
+		t.Errorf("Expected the stock ID as the first argument, but got %v", args)
+	}
+}
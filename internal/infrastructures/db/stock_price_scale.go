@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 銘柄の株価の小数点以下の桁数を取得するSQL
+const selectStockPriceScaleSQL = "SELECT price_scale FROM " + stockTableName + " WHERE stock_id = ?"
+
+// 銘柄の株価の小数点以下の桁数を更新するSQL
+const updateStockPriceScaleSQL = "UPDATE " + stockTableName + " SET price_scale = ? WHERE stock_id = ?"
+
+// 株価を保存しているテーブル（桁数を広げる場合に既存の値を変換する）
+// daily_stock_price の更新で新しい版が記録されないように、履歴を先に変換する
+var priceTickTableNames = []string{dailyStockPriceHistoryTableName, dailyStockPriceTableName, importRunChangesTableName}
+
+// 1つのトランザクションの中で確認した銘柄ごとの株価の小数点以下の桁数（銘柄コード → 桁数）
+// 他の接続が桁数を広げる可能性があるため、トランザクションごとに作り直す
+type stockPriceScales map[string]int
+
+// prepare は銘柄の株価を保存する前に呼び出し、保存に使う小数点以下の桁数を返します。
+// 銘柄マスタに登録されていない銘柄は銘柄コードのみで登録し、
+// 保存する株価の桁数が銘柄の桁数より多い場合は、銘柄の既存の株価を新しい桁数に変換してから桁数を広げます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - tx: トランザクション
+//   - stockID: 銘柄コード
+//   - prices: 保存する株価
+//
+// 戻り値:
+//   - 株価の保存に使う小数点以下の桁数
+//   - エラー（データベース操作に失敗した場合）
+func (s stockPriceScales) prepare(ctx context.Context, tx *sql.Tx, stockID string, prices ...models.Price) (int, error) {
+	required := 0
+	for _, price := range prices {
+		required = max(required, price.Scale())
+	}
+	return s.ensure(ctx, tx, stockID, required)
+}
+
+// ensure は銘柄の株価の桁数を required 以上にし、保存に使う小数点以下の桁数を返します。
+// 銘柄マスタに登録されていない銘柄は銘柄コードのみで登録し、
+// 銘柄の桁数が required より少ない場合は、銘柄の既存の株価を新しい桁数に変換してから桁数を広げます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - tx: トランザクション
+//   - stockID: 銘柄コード
+//   - required: 保存する株価を表すのに必要な小数点以下の桁数
+//
+// 戻り値:
+//   - 株価の保存に使う小数点以下の桁数
+//   - エラー（データベース操作に失敗した場合）
+func (s stockPriceScales) ensure(ctx context.Context, tx *sql.Tx, stockID string, required int) (int, error) {
+	// トランザクションの中で初めて扱う銘柄は登録してから桁数を取得
+	scale, ok := s[stockID]
+	if !ok {
+		if _, err := tx.ExecContext(ctx, registerStockSQL, stockID); err != nil {
+			return 0, fmt.Errorf("failed to register stock %s: %w", stockID, err)
+		}
+		if err := tx.QueryRowContext(ctx, selectStockPriceScaleSQL, stockID).Scan(&scale); err != nil {
+			return 0, fmt.Errorf("failed to query price scale of %s: %w", stockID, err)
+		}
+	}
+
+	// 保存する株価を表すのに必要な桁数まで広げる
+	if required > scale {
+		if err := widenStockPriceScale(ctx, tx, stockID, scale, required); err != nil {
+			return 0, err
+		}
+		scale = required
+	}
+	s[stockID] = scale
+	return scale, nil
+}
+
+// widenStockPriceScale は銘柄の既存の株価を新しい桁数の整数に変換し、銘柄の桁数を更新します。
+// SQLite は整数の乗算が int64 の範囲を超えると実数に変換するため、変換前に全てのテーブルの株価が範囲に収まることを確認します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - tx: トランザクション
+//   - stockID: 銘柄コード
+//   - from: 現在の小数点以下の桁数
+//   - to: 新しい小数点以下の桁数（from より大きい）
+//
+// 戻り値:
+//   - エラー（新しい桁数では int64 の範囲を超える株価がある場合やデータベース操作に失敗した場合）
+func widenStockPriceScale(ctx context.Context, tx *sql.Tx, stockID string, from int, to int) error {
+	factor := int64(1)
+	for range to - from {
+		factor *= 10
+	}
+
+	// 1つでも範囲を超える株価がある場合は何も変換しない
+	limit := math.MaxInt64 / factor
+	for _, tableName := range priceTickTableNames {
+		var dateStr string
+		err := tx.QueryRowContext(ctx, "SELECT price_date FROM "+tableName+" WHERE stock_id = ?1"+
+			" AND (price > ?2 OR price < -?2 OR open > ?2 OR open < -?2 OR high > ?2 OR high < -?2 OR low > ?2 OR low < -?2) LIMIT 1",
+			stockID, limit).Scan(&dateStr)
+		if err == nil {
+			return fmt.Errorf("price of %s on %s in %s is out of range with %d decimal places", stockID, dateStr, tableName, to)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to check prices of %s: %w", stockID, err)
+		}
+	}
+
+	for _, tableName := range priceTickTableNames {
+		_, err := tx.ExecContext(ctx, "UPDATE "+tableName+
+			" SET price = price * ?1, open = open * ?1, high = high * ?1, low = low * ?1 WHERE stock_id = ?2", factor, stockID)
+		if err != nil {
+			return fmt.Errorf("failed to convert prices of %s to %d decimal places: %w", stockID, to, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, updateStockPriceScaleSQL, to, stockID); err != nil {
+		return fmt.Errorf("failed to update price scale of %s: %w", stockID, err)
+	}
+	return nil
+}
+
+// priceScaleFactorSQL は桁数の差を表すSQLの式から、その差だけ桁を広げるための10のべき乗を求めるSQLの式を作成します。
+//
+// 引数:
+//   - scaleDifference: 0以上 models.MaxPriceScale 以下の桁数の差を表すSQLの式
+//
+// 戻り値:
+//   - 10^scaleDifference を表すSQLの式
+func priceScaleFactorSQL(scaleDifference string) string {
+	var builder strings.Builder
+	builder.WriteString("CASE " + scaleDifference)
+	factor := int64(1)
+	for difference := 0; difference <= models.MaxPriceScale; difference++ {
+		builder.WriteString(" WHEN " + strconv.Itoa(difference) + " THEN " + strconv.FormatInt(factor, 10))
+		factor *= 10
+	}
+	builder.WriteString(" END")
+	return builder.String()
+}
+
+// priceTicks は株価を銘柄の桁数の整数に変換します。
+//
+// 引数:
+//   - price: 株価
+//   - scale: 銘柄の小数点以下の桁数（stockPriceScales.prepare の戻り値）
+//
+// 戻り値:
+//   - 10^-scale 単位で表した株価
+//   - エラー（株価を表せない場合）
+func priceTicks(price models.Price, scale int) (int64, error) {
+	ticks, err := price.TicksAt(scale)
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert price: %w", err)
+	}
+	return ticks, nil
+}
+
+// priceFromTicks は銘柄の桁数の整数で保存された株価を変換します。
+//
+// 引数:
+//   - ticks: 10^-scale 単位で表した株価
+//   - scale: 銘柄の小数点以下の桁数
+//
+// 戻り値:
+//   - 株価
+//   - エラー（桁数が範囲外の場合）
+func priceFromTicks(ticks int64, scale int) (models.Price, error) {
+	if scale < 0 || scale > models.MaxPriceScale {
+		return models.Price{}, fmt.Errorf("invalid price scale: %d", scale)
+	}
+	return models.NewPrice(ticks, scale), nil
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+func TestUpsertDailyStockPrices_WidensPriceScale(t *testing.T) {
+	// Arrange - 整数の株価を取り込んだ後、取り消し用に保存される行がある状態で端数のある株価を取り込む
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_price_scale.db")
+	ctx := context.Background()
+	date := func(day int) time.Time { return time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC) }
+	if err := repository.InitializeDailyStockPriceTable(ctx, []models.DailyStockPrice{
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2880, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8000, 0)}},
+	}); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	runID, err := repository.StartImportRun(ctx, models.ImportRun{SourcePath: "half_yen.tsv"})
+	if err != nil {
+		t.Fatalf("Failed to start import run: %v", err)
+	}
+
+	// Act
+	result, err := repository.UpsertDailyStockPricesFromSeq(ctx, runID, models.DailyStockPriceSeq([]models.DailyStockPrice{
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(29035, 1)}},
+		{PriceDate: date(6), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2910125, 3)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(80000, 1)}},
+	}), 10, models.ConflictPolicyOverwrite)
+
+	// Assert - 既存の株価は値を変えずに新しい桁数に変換される
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if result != (models.UpsertResult{Inserted: 1, Updated: 1, Unchanged: 1}) {
+		t.Errorf("Expected 8000.0 to be unchanged, but got %+v", result)
+	}
+	dailyPrices, err := repository.GetDailyStockPrices(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get prices: %v", err)
+	}
+	expectedPrices := []string{"2873", "2903.5", "2910.125", "8000"}
+	if len(dailyPrices) != len(expectedPrices) {
+		t.Fatalf("Expected %d prices, but got %+v", len(expectedPrices), dailyPrices)
+	}
+	for i, dailyPrice := range dailyPrices {
+		if dailyPrice.StockPrice.Price.String() != expectedPrices[i] {
+			t.Errorf("Expected price %s at %d, but got %s", expectedPrices[i], i, dailyPrice.StockPrice.Price)
+		}
+	}
+	var scale int
+	var ticks int64
+	if err := repository.db.QueryRowContext(ctx, "SELECT s.price_scale, p.price FROM stock s JOIN daily_stock_price p ON p.stock_id = s.stock_id"+
+		" WHERE s.stock_id = '7203' AND p.price_date = '2025-02-04'").Scan(&scale, &ticks); err != nil {
+		t.Fatalf("Failed to query stored ticks: %v", err)
+	}
+	if scale != 3 || ticks != 2873000 {
+		t.Errorf("Expected 2873 to be stored as 2873000 with scale 3, but got %d with scale %d", ticks, scale)
+	}
+
+	// Act - 取り消すと上書き前の株価が新しい桁数のまま復元される
+	if _, err := repository.UndoImportRun(ctx, runID); err != nil {
+		t.Fatalf("Failed to undo import run: %v", err)
+	}
+	restored, err := repository.GetDailyStockPricesByDateRange(ctx, "7203", date(5), date(5), time.Time{})
+
+	// Assert
+	if err != nil || len(restored) != 1 || restored[0].StockPrice.Price != models.NewPrice(2880, 0) {
+		t.Errorf("Expected 2880 to be restored, but got %+v (%v)", restored, err)
+	}
+}
+
+func TestUpsertDailyStockPrices_WideningOutOfRangeFails(t *testing.T) {
+	// Arrange - 桁数0では保存できるが、桁数6では int64 の範囲を超える株価
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_price_scale_overflow.db")
+	ctx := context.Background()
+	date := func(day int) time.Time { return time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC) }
+	largePrice := models.NewPrice(9_300_000_000_000, 0)
+	if err := repository.InitializeDailyStockPriceTable(ctx, []models.DailyStockPrice{
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: largePrice}},
+	}); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	// Act
+	_, err := repository.UpsertDailyStockPricesFromSeq(ctx, models.NoImportRun, models.DailyStockPriceSeq([]models.DailyStockPrice{
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(1_000_001, 6)}},
+	}), 10, models.ConflictPolicyOverwrite)
+
+	// Assert - 桁数を広げずにエラーを返し、既存の株価は整数のまま残る
+	if err == nil || !strings.Contains(err.Error(), "out of range with 6 decimal places") {
+		t.Fatalf("Expected an out of range error, but got: %v", err)
+	}
+	var scale int
+	var storedType string
+	if err := repository.db.QueryRowContext(ctx, "SELECT s.price_scale, typeof(p.price) FROM stock s JOIN daily_stock_price p ON p.stock_id = s.stock_id"+
+		" WHERE s.stock_id = '7203'").Scan(&scale, &storedType); err != nil {
+		t.Fatalf("Failed to query stored ticks: %v", err)
+	}
+	dailyPrices, err := repository.GetDailyStockPrices(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get prices: %v", err)
+	}
+	if scale != 0 || storedType != "integer" || len(dailyPrices) != 1 || dailyPrices[0].StockPrice.Price != largePrice {
+		t.Errorf("Expected %s to be kept as an integer with scale 0, but got %+v (%s, scale %d)", largePrice, dailyPrices, storedType, scale)
+	}
+}
+
+func TestUpsertDailyStockPrices_ConflictKeepsExactPrices(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_price_scale_conflict.db")
+	ctx := context.Background()
+	date := time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC)
+	if err := repository.InitializeDailyStockPriceTable(ctx, []models.DailyStockPrice{
+		{PriceDate: date, StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(29035, 1)}},
+	}); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+
+	// Act
+	_, err := repository.UpsertDailyStockPricesFromSeq(ctx, models.NoImportRun, models.DailyStockPriceSeq([]models.DailyStockPrice{
+		{PriceDate: date, StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903501, 3)}},
+	}), 10, models.ConflictPolicyFail)
+
+	// Assert - 1/1000円の違いも衝突として扱う
+	var conflictErr *models.DailyStockPriceConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Expected DailyStockPriceConflictError, but got: %v", err)
+	}
+	if conflictErr.ExistingPrice != models.NewPrice(29035, 1) || conflictErr.NewPrice != models.NewPrice(2903501, 3) {
+		t.Errorf("Expected exact prices in the conflict, but got %s and %s", conflictErr.ExistingPrice, conflictErr.NewPrice)
+	}
+}
+
+func TestDailyStockBars_RoundTripExactPrices(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_price_scale_bars.db")
+	ctx := context.Background()
+	date := time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC)
+	bar := models.DailyStockBar{
+		StockID:   "7203",
+		PriceDate: date,
+		Open:      models.NewPrice(28705, 1),
+		High:      models.NewPrice(2910, 0),
+		Low:       models.NewPrice(2865000001, 6),
+		Close:     models.NewPrice(29035, 1),
+		Volume:    1200,
+	}
+
+	// Act
+	err := repository.InitializeDailyStockBarTable(ctx, models.NoImportRun, []models.DailyStockBar{bar})
+	bars, getErr := repository.GetDailyStockBarsByDateRange(ctx, "7203", date, date)
+
+	// Assert
+	if err != nil || getErr != nil {
+		t.Fatalf("Expected no error, but got: %v, %v", err, getErr)
+	}
+	if len(bars) != 1 || bars[0] != bar {
+		t.Errorf("Expected %+v, but got %+v", bar, bars)
+	}
+}
@@ -1,7 +1,9 @@
 package db
 
 import (
-	"os"
+	"context"
+	"errors"
+	"reflect"
 	"testing"
 	"time"
 
@@ -10,9 +12,8 @@ import (
 
 func TestInitializeAndGetDailyStockPriceTable(t *testing.T) {
 	// Arrange
-	dbPath := "./test_stock_price.db"
 	// テスト終了後にデータベースファイルを削除
-	defer os.Remove(dbPath)
+	repository := newTestRepository(t, "./test_stock_price.db")
 
 	// テスト用の日次株価情報を作成
 	testPrices := []models.DailyStockPrice{
@@ -20,27 +21,27 @@ func TestInitializeAndGetDailyStockPriceTable(t *testing.T) {
 			PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: "7203",
-				Price:   2873,
+				Price:   models.NewPrice(2873, 0),
 			},
 		},
 		{
 			PriceDate: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: "7203",
-				Price:   2963,
+				Price:   models.NewPrice(2963, 0),
 			},
 		},
 		{
 			PriceDate: time.Date(2025, 2, 6, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: "7203",
-				Price:   2903.5,
+				Price:   models.NewPrice(29035, 1),
 			},
 		},
 	}
 
 	// Act - テーブルを初期化
-	err := InitializeDailyStockPriceTable(dbPath, testPrices)
+	err := repository.InitializeDailyStockPriceTable(context.Background(), testPrices)
 
 	// Assert
 	if err != nil {
@@ -48,7 +49,7 @@ func TestInitializeAndGetDailyStockPriceTable(t *testing.T) {
 	}
 
 	// Act - テーブルからデータを取得
-	retrievedPrices, err := GetDailyStockPrices(dbPath)
+	retrievedPrices, err := repository.GetDailyStockPrices(context.Background())
 
 	// Assert
 	if err != nil {
@@ -88,13 +89,13 @@ func TestInitializeAndGetDailyStockPriceTable(t *testing.T) {
 			PriceDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: "7203",
-				Price:   3000,
+				Price:   models.NewPrice(3000, 0),
 			},
 		},
 	}
 
 	// Act - テーブルを再初期化
-	err = InitializeDailyStockPriceTable(dbPath, newTestPrices)
+	err = repository.InitializeDailyStockPriceTable(context.Background(), newTestPrices)
 
 	// Assert
 	if err != nil {
@@ -102,7 +103,7 @@ func TestInitializeAndGetDailyStockPriceTable(t *testing.T) {
 	}
 
 	// Act - テーブルからデータを再取得
-	retrievedPrices, err = GetDailyStockPrices(dbPath)
+	retrievedPrices, err = repository.GetDailyStockPrices(context.Background())
 
 	// Assert
 	if err != nil {
@@ -117,9 +118,8 @@ func TestInitializeAndGetDailyStockPriceTable(t *testing.T) {
 
 func TestGetDailyStockPricesByDateRange(t *testing.T) {
 	// Arrange
-	dbPath := "./test_stock_price_range.db"
 	// テスト終了後にデータベースファイルを削除
-	defer os.Remove(dbPath)
+	repository := newTestRepository(t, "./test_stock_price_range.db")
 
 	// テスト用の日次株価情報を作成
 	stockID := "7203"
@@ -128,35 +128,35 @@ func TestGetDailyStockPricesByDateRange(t *testing.T) {
 			PriceDate: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   2800,
+				Price:   models.NewPrice(2800, 0),
 			},
 		},
 		{
 			PriceDate: time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   2850,
+				Price:   models.NewPrice(2850, 0),
 			},
 		},
 		{
 			PriceDate: time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   2900,
+				Price:   models.NewPrice(2900, 0),
 			},
 		},
 		{
 			PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   2950,
+				Price:   models.NewPrice(2950, 0),
 			},
 		},
 		{
 			PriceDate: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   3000,
+				Price:   models.NewPrice(3000, 0),
 			},
 		},
 		// 別の銘柄コードのデータも追加
@@ -164,13 +164,13 @@ func TestGetDailyStockPricesByDateRange(t *testing.T) {
 			PriceDate: time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: "9984",
-				Price:   5000,
+				Price:   models.NewPrice(5000, 0),
 			},
 		},
 	}
 
 	// テーブルを初期化
-	err := InitializeDailyStockPriceTable(dbPath, testPrices)
+	err := repository.InitializeDailyStockPriceTable(context.Background(), testPrices)
 	if err != nil {
 		t.Fatalf("Failed to initialize table: %v", err)
 	}
@@ -216,7 +216,7 @@ func TestGetDailyStockPricesByDateRange(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Act
-			retrievedPrices, err := GetDailyStockPricesByDateRange(dbPath, tc.stockID, tc.startDate, tc.endDate)
+			retrievedPrices, err := repository.GetDailyStockPricesByDateRange(context.Background(), tc.stockID, tc.startDate, tc.endDate, time.Time{})
 
 			// Assert
 			if err != nil {
@@ -244,3 +244,170 @@ func TestGetDailyStockPricesByDateRange(t *testing.T) {
 		})
 	}
 }
+
+func TestInitializeDailyStockPriceTableFromSeq(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_seq.db")
+
+	// 5件の日次株価情報を返すイテレータを作成
+	startDate := time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)
+	dailyPrices := func(yield func(models.DailyStockPrice, error) bool) {
+		for i := 0; i < 5; i++ {
+			dailyPrice := models.DailyStockPrice{
+				PriceDate:  startDate.AddDate(0, 0, i),
+				StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(int64(2800+i), 0)},
+			}
+			if !yield(dailyPrice, nil) {
+				return
+			}
+		}
+	}
+
+	// Act
+	insertedCount, err := repository.InitializeDailyStockPriceTableFromSeq(context.Background(), models.NoImportRun, dailyPrices, 2)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	if insertedCount != 5 {
+		t.Errorf("Expected 5 inserted prices, but got %d", insertedCount)
+	}
+	retrievedPrices, err := repository.GetDailyStockPrices(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get prices: %v", err)
+	}
+	if len(retrievedPrices) != 5 {
+		t.Errorf("Expected 5 prices, but got %d", len(retrievedPrices))
+	}
+}
+
+// previousDailyStockPrices はテスト用に置き換え前の日次株価情報を登録し、登録した内容を返します。
+func previousDailyStockPrices(t *testing.T, repository *SQLiteStockPriceRepository) []models.DailyStockPrice {
+	t.Helper()
+	previousPrices := []models.DailyStockPrice{
+		{PriceDate: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(27005, 1)}},
+		{PriceDate: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8100, 0)}},
+	}
+	if err := repository.InitializeDailyStockPriceTable(context.Background(), previousPrices); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	retrievedPrices, err := repository.GetDailyStockPrices(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get prices: %v", err)
+	}
+	return retrievedPrices
+}
+
+func TestInitializeDailyStockPriceTableFromSeq_ErrorKeepsPreviousContents(t *testing.T) {
+	// Arrange - 置き換え前の日次株価情報を登録しておく
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_seq_error.db")
+	previousPrices := previousDailyStockPrices(t, repository)
+
+	// 1チャンク分を書き込んだ後、3件目でエラーを返すイテレータを作成
+	startDate := time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)
+	readErr := errors.New("broken line")
+	dailyPrices := func(yield func(models.DailyStockPrice, error) bool) {
+		for i := 0; i < 2; i++ {
+			dailyPrice := models.DailyStockPrice{
+				PriceDate:  startDate.AddDate(0, 0, i),
+				StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(int64(2800+i), 0)},
+			}
+			if !yield(dailyPrice, nil) {
+				return
+			}
+		}
+		yield(models.DailyStockPrice{}, readErr)
+	}
+
+	// Act
+	insertedCount, err := repository.InitializeDailyStockPriceTableFromSeq(context.Background(), models.NoImportRun, dailyPrices, 2)
+
+	// Assert - 置き換えは行われず、置き換え前の内容が残る
+	if !errors.Is(err, readErr) {
+		t.Fatalf("Expected iterator error, but got: %v", err)
+	}
+	if insertedCount != 0 {
+		t.Errorf("Expected no inserted prices, but got %d", insertedCount)
+	}
+	retrievedPrices, err := repository.GetDailyStockPrices(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get prices: %v", err)
+	}
+	if !reflect.DeepEqual(retrievedPrices, previousPrices) {
+		t.Errorf("Expected the previous prices %+v, but got %+v", previousPrices, retrievedPrices)
+	}
+}
+
+func TestInitializeDailyStockPriceTableFromSeq_CancelKeepsPreviousContents(t *testing.T) {
+	// Arrange - 置き換え前の日次株価情報を登録しておく
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_seq_cancel.db")
+	previousPrices := previousDailyStockPrices(t, repository)
+
+	// 1チャンク分をコミットした後、4件目を書き込む前にキャンセルするイテレータを作成
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startDate := time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)
+	dailyPrices := func(yield func(models.DailyStockPrice, error) bool) {
+		for i := 0; i < 4; i++ {
+			if i == 3 {
+				cancel()
+			}
+			dailyPrice := models.DailyStockPrice{
+				PriceDate:  startDate.AddDate(0, 0, i),
+				StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(int64(2800+i), 0)},
+			}
+			if !yield(dailyPrice, nil) {
+				return
+			}
+		}
+	}
+
+	// Act
+	insertedCount, err := repository.InitializeDailyStockPriceTableFromSeq(ctx, models.NoImportRun, dailyPrices, 2)
+
+	// Assert - 置き換えは行われず、置き換え前の内容が残る
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, but got: %v", err)
+	}
+	if insertedCount != 0 {
+		t.Errorf("Expected no inserted prices, but got %d", insertedCount)
+	}
+	retrievedPrices, err := repository.GetDailyStockPrices(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get prices: %v", err)
+	}
+	if !reflect.DeepEqual(retrievedPrices, previousPrices) {
+		t.Errorf("Expected the previous prices %+v, but got %+v", previousPrices, retrievedPrices)
+	}
+
+	// Act - 同じリポジトリで置き換え直す（一時テーブルが残っていない）
+	_, err = repository.InitializeDailyStockPriceTableFromSeq(context.Background(), models.NoImportRun, dailyPrices, 2)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected the retry to succeed, but got: %v", err)
+	}
+	if count, _ := repository.CountDailyStockPrices(context.Background()); count != 4 {
+		t.Errorf("Expected 4 prices after the retry, but got %d", count)
+	}
+}
+
+func TestGetDailyStockPrices_Canceled(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_canceled.db")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Act
+	_, err := repository.GetDailyStockPrices(ctx)
+
+	// Assert
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, but got: %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 既存の株価を銘柄の桁数の整数で取得するSQL
+const selectExistingDailyStockPriceSQL = "SELECT price FROM " + dailyStockPriceTableName + " WHERE stock_id = ? AND price_date = ?"
+
+// 衝突した行を上書きするSQL（四本値と出来高は株価のみの行で置き換えるため NULL にする）
+const upsertDailyStockPriceSQL = "INSERT INTO " + dailyStockPriceTableName + " (stock_id, price_date, price, import_run_id) VALUES (?, ?, ?, ?)" +
+	" ON CONFLICT(stock_id, price_date) DO UPDATE SET" +
+	" price = excluded.price, open = NULL, high = NULL, low = NULL, volume = NULL, import_run_id = excluded.import_run_id"
+
+// 衝突した行を残すSQL
+const insertOrKeepDailyStockPriceSQL = "INSERT INTO " + dailyStockPriceTableName + " (stock_id, price_date, price, import_run_id) VALUES (?, ?, ?, ?)" +
+	" ON CONFLICT(stock_id, price_date) DO NOTHING"
+
+// UpsertDailyStockPricesFromSeq はイテレータから読み込んだ日次株価情報を
+// 既存のデータを残したままdaily_stock_priceテーブルに追加します。
+// 同じ銘柄コード・日付の行が既に存在する場合は policy に従って扱います。
+// 既存の行と株価が同じ場合は衝突とみなさず、変更なしとして数えます。
+// chunkSize 件ごとにトランザクションをコミットし、途中でエラーが発生した場合は
+// 実行中のチャンクのみロールバックされます。
+// runID を指定した場合は、上書きする行を取り消し用に保存し、書き込んだ行をその取り込みに紐付けます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - runID: 書き込みを記録する取り込みID（models.NoImportRun の場合は記録しない）
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - chunkSize: 1トランザクションで書き込む件数
+//   - policy: 同じ銘柄コード・日付の行が存在する場合の扱い
+//
+// 戻り値:
+//   - コミットされた行の取り込み結果
+//   - エラー（イテレータがエラーを返した場合、ConflictPolicyFail で衝突した場合（models.DailyStockPriceConflictError）、
+//     データベース操作に失敗した場合）
+func (r *SQLiteStockPriceRepository) UpsertDailyStockPricesFromSeq(ctx context.Context, runID int64, dailyPrices iter.Seq2[models.DailyStockPrice, error], chunkSize int, policy models.ConflictPolicy) (models.UpsertResult, error) {
+	if chunkSize <= 0 {
+		return models.UpsertResult{}, fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+	if _, err := models.ParseConflictPolicy(string(policy)); err != nil {
+		return models.UpsertResult{}, err
+	}
+
+	// 衝突時の扱いに応じた書き込みSQLを選択
+	writeSQL := insertDailyStockPriceSQL
+	switch policy {
+	case models.ConflictPolicyOverwrite:
+		writeSQL = upsertDailyStockPriceSQL
+	case models.ConflictPolicyKeep:
+		writeSQL = insertOrKeepDailyStockPriceSQL
+	}
+
+	// 最初のチャンクのトランザクションを開始
+	chunk, err := r.beginDailyStockPriceChunk(ctx, selectExistingDailyStockPriceSQL, writeSQL, saveReplacedDailyStockPriceSQL)
+	if err != nil {
+		return models.UpsertResult{}, err
+	}
+	defer func() {
+		if chunk != nil {
+			chunk.rollback()
+		}
+	}()
+
+	// 各日次株価情報をテーブルに書き込む
+	var committedResult, chunkResult models.UpsertResult
+	chunkCount := 0
+	for dailyPrice, err := range dailyPrices {
+		if err != nil {
+			return committedResult, err
+		}
+
+		// 銘柄マスタに登録されていない銘柄を登録し、株価を銘柄の桁数の整数に変換
+		// （既存の株価と同じ桁数で比較するため、書き込まない場合も桁数を揃える）
+		scale, err := chunk.scales.prepare(ctx, chunk.tx, dailyPrice.StockPrice.StockID, dailyPrice.StockPrice.Price)
+		if err != nil {
+			return committedResult, err
+		}
+		ticks, err := priceTicks(dailyPrice.StockPrice.Price, scale)
+		if err != nil {
+			return committedResult, err
+		}
+
+		// 既存の株価を取得
+		dateStr := dailyPrice.PriceDate.Format(time.RFC3339[:10]) // YYYY-MM-DD形式
+		var existingTicks int64
+		err = chunk.stmts[0].QueryRowContext(ctx, dailyPrice.StockPrice.StockID, dateStr).Scan(&existingTicks)
+		exists := err == nil
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return committedResult, fmt.Errorf("failed to query existing data: %w", err)
+		}
+
+		// 既存の行との関係に応じて書き込む
+		needsWrite := false
+		switch {
+		case !exists:
+			chunkResult.Inserted++
+			needsWrite = true
+		case existingTicks == ticks || policy == models.ConflictPolicyKeep:
+			chunkResult.Unchanged++
+		case policy == models.ConflictPolicyFail:
+			return committedResult, &models.DailyStockPriceConflictError{
+				StockID:       dailyPrice.StockPrice.StockID,
+				PriceDate:     dailyPrice.PriceDate,
+				ExistingPrice: models.NewPrice(existingTicks, scale),
+				NewPrice:      dailyPrice.StockPrice.Price,
+			}
+		default:
+			chunkResult.Updated++
+			needsWrite = true
+
+			// 上書きする行を取り消し用に保存
+			if runID != models.NoImportRun {
+				_, err = chunk.stmts[2].ExecContext(ctx, runID, dailyPrice.StockPrice.StockID, dateStr, runID)
+				if err != nil {
+					return committedResult, fmt.Errorf("failed to save existing data: %w", err)
+				}
+			}
+		}
+		if needsWrite {
+			_, err = chunk.stmts[1].ExecContext(ctx, dailyPrice.StockPrice.StockID, dateStr, ticks, nullableImportRunID(runID))
+			if err != nil {
+				return committedResult, fmt.Errorf("failed to write data: %w", err)
+			}
+		}
+		chunkCount++
+
+		// チャンクの件数に達したらコミットして次のトランザクションを開始
+		if chunkCount == chunkSize {
+			committedChunk := chunk
+			chunk = nil
+			if err := committedChunk.commit(ctx); err != nil {
+				return committedResult, err
+			}
+			committedResult = committedResult.Add(chunkResult)
+			chunkResult = models.UpsertResult{}
+			chunkCount = 0
+
+			chunk, err = r.beginDailyStockPriceChunk(ctx, selectExistingDailyStockPriceSQL, writeSQL, saveReplacedDailyStockPriceSQL)
+			if err != nil {
+				return committedResult, err
+			}
+		}
+	}
+
+	// 最後のチャンクをコミット
+	lastChunk := chunk
+	chunk = nil
+	if err := lastChunk.commit(ctx); err != nil {
+		return committedResult, err
+	}
+
+	return committedResult.Add(chunkResult), nil
+}
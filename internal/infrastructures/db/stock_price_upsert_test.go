@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+func TestUpsertDailyStockPricesFromSeq(t *testing.T) {
+	// Arrange
+	date := func(day int) time.Time { return time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC) }
+	existingPrices := []models.DailyStockPrice{
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2800, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+	}
+	newPrices := []models.DailyStockPrice{
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2800, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2880, 0)}},
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903, 0)}},
+	}
+	testCases := []struct {
+		policy         models.ConflictPolicy
+		expectedResult models.UpsertResult
+		expectedPrice  models.Price
+	}{
+		{policy: models.ConflictPolicyOverwrite, expectedResult: models.UpsertResult{Inserted: 1, Updated: 1, Unchanged: 1}, expectedPrice: models.NewPrice(2880, 0)},
+		{policy: models.ConflictPolicyKeep, expectedResult: models.UpsertResult{Inserted: 1, Updated: 0, Unchanged: 2}, expectedPrice: models.NewPrice(2873, 0)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			// テスト終了後にデータベースファイルを削除
+			repository := newTestRepository(t, "./test_stock_price_upsert_"+string(tc.policy)+".db")
+			if err := repository.InitializeDailyStockPriceTable(context.Background(), existingPrices); err != nil {
+				t.Fatalf("Failed to initialize table: %v", err)
+			}
+
+			// Act
+			result, err := repository.UpsertDailyStockPricesFromSeq(context.Background(), models.NoImportRun, models.DailyStockPriceSeq(newPrices), 2, tc.policy)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if result != tc.expectedResult {
+				t.Errorf("Expected %+v, but got %+v", tc.expectedResult, result)
+			}
+			retrievedPrices, err := repository.GetDailyStockPrices(context.Background())
+			if err != nil {
+				t.Fatalf("Failed to get prices: %v", err)
+			}
+			if len(retrievedPrices) != 3 {
+				t.Fatalf("Expected existing rows to be kept and 3 prices in total, but got %d", len(retrievedPrices))
+			}
+			for _, price := range retrievedPrices {
+				if price.PriceDate.Equal(date(4)) && price.StockPrice.Price != tc.expectedPrice {
+					t.Errorf("Expected price %v on 2025-02-04, but got %v", tc.expectedPrice, price.StockPrice.Price)
+				}
+			}
+		})
+	}
+}
+
+func TestUpsertDailyStockPricesFromSeq_FailOnConflict(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_price_upsert_fail.db")
+	date := func(day int) time.Time { return time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC) }
+	existingPrices := []models.DailyStockPrice{
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+	}
+	if err := repository.InitializeDailyStockPriceTable(context.Background(), existingPrices); err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	newPrices := []models.DailyStockPrice{
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2800, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2880, 0)}},
+	}
+
+	// Act
+	result, err := repository.UpsertDailyStockPricesFromSeq(context.Background(), models.NoImportRun, models.DailyStockPriceSeq(newPrices), 10, models.ConflictPolicyFail)
+
+	// Assert
+	var conflictErr *models.DailyStockPriceConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Expected DailyStockPriceConflictError, but got: %v", err)
+	}
+	if conflictErr.ExistingPrice != models.NewPrice(2873, 0) || conflictErr.NewPrice != models.NewPrice(2880, 0) {
+		t.Errorf("Unexpected conflict: %+v", conflictErr)
+	}
+	if result.Total() != 0 {
+		t.Errorf("Expected no committed rows, but got %+v", result)
+	}
+	retrievedPrices, err := repository.GetDailyStockPrices(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get prices: %v", err)
+	}
+	if len(retrievedPrices) != 1 {
+		t.Errorf("Expected the chunk to be rolled back, but got %d prices", len(retrievedPrices))
+	}
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+func TestUpsertAndGetStocks(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock.db")
+	initialStocks := []models.Stock{
+		{StockID: "9984", Name: "ソフトバンクグループ", Market: "TSE Prime", Sector: "情報・通信業", Currency: "JPY"},
+		{StockID: "7203", Name: "トヨタ自動車", Market: "TSE Prime", Sector: "輸送用機器"},
+	}
+	if _, err := repository.UpsertStocks(context.Background(), initialStocks); err != nil {
+		t.Fatalf("Failed to upsert stocks: %v", err)
+	}
+	updatedStocks := []models.Stock{
+		{StockID: "9984", Name: "ソフトバンクグループ", Market: "TSE Prime", Sector: "情報・通信業", Currency: "JPY"},
+		{StockID: "7203", Name: "トヨタ自動車", Market: "TSE Prime", Sector: "輸送用機器", Currency: "USD"},
+		{StockID: "AAPL", Name: "Apple", Market: "NASDAQ", Sector: "Technology", Currency: "USD"},
+	}
+
+	// Act
+	result, err := repository.UpsertStocks(context.Background(), updatedStocks)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Failed to upsert stocks: %v", err)
+	}
+	expectedResult := models.UpsertResult{Inserted: 1, Updated: 1, Unchanged: 1}
+	if result != expectedResult {
+		t.Errorf("Expected %+v, but got %+v", expectedResult, result)
+	}
+	stocks, err := repository.GetStocks(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get stocks: %v", err)
+	}
+	expectedStocks := []models.Stock{updatedStocks[1], updatedStocks[0], updatedStocks[2]}
+	if !reflect.DeepEqual(stocks, expectedStocks) {
+		t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", expectedStocks, stocks)
+	}
+}
+
+func TestGetStock(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_get.db")
+	if _, err := repository.UpsertStocks(context.Background(), []models.Stock{{StockID: "7203", Name: "トヨタ自動車"}}); err != nil {
+		t.Fatalf("Failed to upsert stocks: %v", err)
+	}
+
+	// Act
+	stock, found, err := repository.GetStock(context.Background(), "7203")
+	_, missingFound, missingErr := repository.GetStock(context.Background(), "0000")
+
+	// Assert
+	if err != nil || missingErr != nil {
+		t.Fatalf("Expected no error, but got: %v, %v", err, missingErr)
+	}
+	if !found || stock.Name != "トヨタ自動車" || stock.Currency != models.DefaultCurrency {
+		t.Errorf("Unexpected stock: %+v (found: %v)", stock, found)
+	}
+	if missingFound {
+		t.Error("Expected an unregistered stock not to be found")
+	}
+}
+
+func TestDailyStockPrices_RegisterUnknownStocks(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_register.db")
+	if _, err := repository.UpsertStocks(context.Background(), []models.Stock{{StockID: "7203", Name: "トヨタ自動車"}}); err != nil {
+		t.Fatalf("Failed to upsert stocks: %v", err)
+	}
+	testPrices := []models.DailyStockPrice{
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8000, 0)}},
+	}
+
+	// Act
+	err := repository.InitializeDailyStockPriceTable(context.Background(), testPrices)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Failed to initialize table: %v", err)
+	}
+	stocks, err := repository.GetStocks(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get stocks: %v", err)
+	}
+	expectedStocks := []models.Stock{
+		{StockID: "7203", Name: "トヨタ自動車", Currency: models.DefaultCurrency},
+		{StockID: "9984", Currency: models.DefaultCurrency},
+	}
+	if !reflect.DeepEqual(stocks, expectedStocks) {
+		t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", expectedStocks, stocks)
+	}
+}
+
+func TestDailyStockPrices_ForeignKey(t *testing.T) {
+	// Arrange
+	// テスト終了後にデータベースファイルを削除
+	repository := newTestRepository(t, "./test_stock_foreign_key.db")
+
+	// Act - 銘柄マスタに登録されていない銘柄の株価を直接挿入
+	_, err := repository.db.Exec("INSERT INTO daily_stock_price (stock_id, price_date, price) VALUES ('0000', '2025-02-04', 100)")
+
+	// Assert
+	if err == nil {
+		t.Error("Expected a foreign key violation, but got nil")
+	}
+}
@@ -0,0 +1,116 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/file"
+)
+
+// writeTSV は日次株価情報を「銘柄コード\t日付\t株価」形式のTSVで書き込みます。
+// ReadDailyStockPriceFromTSV は値を引用符で囲まずに読み込むため、読み戻すと値が変わる行はエラーにします。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - options: 出力設定
+//
+// 戻り値:
+//   - 書き込んだ日次株価情報の件数
+//   - エラー（イテレータがエラーを返した場合、読み戻せない値がある場合や書き込みに失敗した場合）
+func writeTSV(writer io.Writer, dailyPrices iter.Seq2[models.DailyStockPrice, error], options Options) (int, error) {
+	bufferedWriter := bufio.NewWriter(writer)
+	if options.Header {
+		if _, err := bufferedWriter.WriteString(strings.Join(header, "\t") + "\n"); err != nil {
+			return 0, err
+		}
+	}
+
+	dateParser := file.DefaultDateParser()
+	count := 0
+	for dailyPrice, err := range dailyPrices {
+		if err != nil {
+			return count, err
+		}
+		stockID := dailyPrice.StockPrice.StockID
+		if stockID == "" || strings.TrimSpace(stockID) != stockID || strings.ContainsAny(stockID, "\t\r\n") {
+			return count, fmt.Errorf("stock ID %q cannot be written to TSV", stockID)
+		}
+		date, err := formatDate(dailyPrice, options.DateLayout, dateParser)
+		if err != nil {
+			return count, err
+		}
+		if _, err := bufferedWriter.WriteString(stockID + "\t" + date + "\t" + dailyPrice.StockPrice.Price.String() + "\n"); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, bufferedWriter.Flush()
+}
+
+// writeCSV は日次株価情報を「銘柄コード,日付,株価」形式のCSVで書き込みます。
+// カンマや引用符を含む値は RFC 4180 に従って引用符で囲みます。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - options: 出力設定
+//
+// 戻り値:
+//   - 書き込んだ日次株価情報の件数
+//   - エラー（イテレータがエラーを返した場合、読み戻せない日付がある場合や書き込みに失敗した場合）
+func writeCSV(writer io.Writer, dailyPrices iter.Seq2[models.DailyStockPrice, error], options Options) (int, error) {
+	csvWriter := csv.NewWriter(writer)
+	if options.Header {
+		if err := csvWriter.Write(header); err != nil {
+			return 0, err
+		}
+	}
+
+	dateParser := file.DefaultDateParser()
+	count := 0
+	for dailyPrice, err := range dailyPrices {
+		if err != nil {
+			return count, err
+		}
+		date, err := formatDate(dailyPrice, options.DateLayout, dateParser)
+		if err != nil {
+			return count, err
+		}
+		record := []string{dailyPrice.StockPrice.StockID, date, dailyPrice.StockPrice.Price.String()}
+		if err := csvWriter.Write(record); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	csvWriter.Flush()
+	return count, csvWriter.Error()
+}
+
+// formatDate は日次株価情報の日付を指定されたレイアウトで文字列に変換します。
+// 取り込み時の既定のレイアウトで元の日付に読み戻せないレイアウトはエラーにします。
+//
+// 引数:
+//   - dailyPrice: 日次株価情報
+//   - layout: 日付のフォーマット（time.Format のレイアウト）
+//   - dateParser: 読み戻しを確認する日付パーサー
+//
+// 戻り値:
+//   - 日付の文字列
+//   - エラー（読み戻すと日付が変わる場合）
+func formatDate(dailyPrice models.DailyStockPrice, layout string, dateParser *file.DateParser) (string, error) {
+	date := dailyPrice.PriceDate.Format(layout)
+	parsedDate, err := dateParser.Parse(date)
+	if err != nil || !parsedDate.Equal(dailyPrice.PriceDate) {
+		return "", fmt.Errorf("date layout %q writes %s as %q, which the importer cannot read back",
+			layout, dailyPrice.PriceDate.Format(time.DateOnly), date)
+	}
+	return date, nil
+}
@@ -0,0 +1,139 @@
+package exporter
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/file"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+func TestWriteDailyStockPrices_TSVRoundTripsSampleFile(t *testing.T) {
+	// Arrange - サンプルファイルと同じ日付形式で書き出す
+	filePath := "../../data/sample_daily_stock_price.tsv"
+	original, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read sample file: %v", err)
+	}
+	dailyPrices, err := file.ReadDailyStockPriceFromTSV(filePath)
+	if err != nil {
+		t.Fatalf("Failed to read sample file: %v", err)
+	}
+	options := DefaultOptions()
+	options.DateLayout = "2006/1/2"
+
+	// Act
+	var output bytes.Buffer
+	count, err := WriteDailyStockPrices(&output, models.DailyStockPriceSeq(dailyPrices), options)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if count != len(dailyPrices) {
+		t.Errorf("Expected %d rows, but got %d", len(dailyPrices), count)
+	}
+	if output.String() != string(original) {
+		t.Errorf("Expected the sample file byte for byte.\nExpected:\n%s\nGot:\n%s", original, output.String())
+	}
+}
+
+func TestWriteDailyStockPrices_TSVReadBack(t *testing.T) {
+	// Arrange - 小数点以下の桁数が異なる株価とヘッダー行
+	dailyPrices := []models.DailyStockPrice{
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(28735, 1)}},
+		{PriceDate: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8000000001, 6)}},
+	}
+	options := DefaultOptions()
+	options.Header = true
+
+	// Act - 書き出したTSVを取り込み、もう一度書き出す
+	var output bytes.Buffer
+	if _, err := WriteDailyStockPrices(&output, models.DailyStockPriceSeq(dailyPrices), options); err != nil {
+		t.Fatalf("Failed to write TSV: %v", err)
+	}
+	exported := output.String()
+	var readBack []models.DailyStockPrice
+	for dailyPrice, err := range file.StreamDailyStockPriceFromTSVReader(strings.NewReader(exported), file.DefaultTSVOptions()) {
+		if err != nil {
+			t.Fatalf("Failed to read exported TSV: %v", err)
+		}
+		readBack = append(readBack, dailyPrice)
+	}
+	output.Reset()
+	_, err := WriteDailyStockPrices(&output, models.DailyStockPriceSeq(readBack), options)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	expected := "stock_id\tdate\tprice\n7203\t2025-02-04\t2873.5\n9984\t2025-02-05\t8000.000001\n"
+	if exported != expected {
+		t.Errorf("Expected %q, but got %q", expected, exported)
+	}
+	if output.String() != exported {
+		t.Errorf("Expected the same TSV after a round trip, but got %q", output.String())
+	}
+}
+
+func TestWriteDailyStockPrices_TSVRejectsUnreadableValues(t *testing.T) {
+	date := time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		stockID    string
+		dateLayout string
+	}{
+		{name: "tab in stock ID", stockID: "72\t03", dateLayout: time.DateOnly},
+		{name: "surrounding spaces", stockID: " 7203", dateLayout: time.DateOnly},
+		{name: "empty stock ID", stockID: "", dateLayout: time.DateOnly},
+		{name: "date without year", stockID: "7203", dateLayout: "01/02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			dailyPrices := []models.DailyStockPrice{{PriceDate: date, StockPrice: models.StockPrice{StockID: tt.stockID, Price: models.NewPrice(2873, 0)}}}
+			options := DefaultOptions()
+			options.DateLayout = tt.dateLayout
+
+			// Act
+			_, err := WriteDailyStockPrices(&bytes.Buffer{}, models.DailyStockPriceSeq(dailyPrices), options)
+
+			// Assert
+			if err == nil {
+				t.Error("Expected an error, but got nil")
+			}
+		})
+	}
+}
+
+func TestWriteDailyStockPrices_CSV(t *testing.T) {
+	// Arrange - カンマを含む銘柄コードは引用符で囲まれる
+	dailyPrices := []models.DailyStockPrice{
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(28735, 1)}},
+		{PriceDate: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "A,B", Price: models.NewPrice(100, 0)}},
+	}
+	options := DefaultOptions()
+	options.Format = FormatCSV
+	options.Header = true
+
+	// Act
+	var output bytes.Buffer
+	count, err := WriteDailyStockPrices(&output, models.DailyStockPriceSeq(dailyPrices), options)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	expected := "stock_id,date,price\n7203,2025-02-04,2873.5\n\"A,B\",2025-02-05,100\n"
+	if count != 2 || output.String() != expected {
+		t.Errorf("Expected %q (2 rows), but got %q (%d rows)", expected, output.String(), count)
+	}
+	readBack, err := file.ReadDailyStockPriceFromCSV(testutil.WriteTempFile(t, "export.csv", output.String()), file.DefaultCSVOptions())
+	if err != nil || len(readBack) != 2 || readBack[1].StockPrice.StockID != "A,B" {
+		t.Errorf("Expected the CSV to be read back, but got %+v (%v)", readBack, err)
+	}
+}
@@ -0,0 +1,155 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 日次株価情報の出力形式
+type Format string
+
+const (
+	// タブ区切り（stock_price_importer の -tsv で読み込める）
+	FormatTSV Format = "tsv"
+	// カンマ区切り（RFC 4180）
+	FormatCSV Format = "csv"
+	// 1行に1件のJSON（JSON Lines）
+	FormatNDJSON Format = "ndjson"
+	// CREATE TABLE と INSERT 文のSQLダンプ
+	FormatSQL Format = "sql"
+)
+
+// 出力する列名（stock_price_importer のヘッダー行として認識される列名）
+var header = []string{"stock_id", "date", "price"}
+
+// ParseFormat は文字列を Format に変換します。
+//
+// 引数:
+//   - name: 出力形式の名前（tsv, csv, ndjson, sql）
+//
+// 戻り値:
+//   - 出力形式
+//   - エラー（未知の名前の場合）
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(name); format {
+	case FormatTSV, FormatCSV, FormatNDJSON, FormatSQL:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown export format %q (expected %s, %s, %s or %s)", name, FormatTSV, FormatCSV, FormatNDJSON, FormatSQL)
+	}
+}
+
+// 日次株価情報の出力設定を示す構造体
+type Options struct {
+	// 出力形式
+	Format Format
+	// 1行目にヘッダー行を出力するかどうか（TSV, CSV のみ）
+	Header bool
+	// 日付のフォーマット（TSV, CSV のみ、time.Format のレイアウト）
+	// NDJSON と SQL ダンプは常にYYYY-MM-DD形式で出力する
+	DateLayout string
+	// SQLダンプで作成するテーブル名
+	SQLTableName string
+}
+
+// DefaultOptions は日次株価情報の既定の出力設定を返します。
+// ReadDailyStockPriceFromTSV が読み込む「銘柄コード\t日付\t株価」形式（ヘッダー行なし）のTSVを出力します。
+//
+// 戻り値:
+//   - 出力設定
+func DefaultOptions() Options {
+	return Options{
+		Format:       FormatTSV,
+		DateLayout:   time.DateOnly,
+		SQLTableName: "daily_stock_price_export",
+	}
+}
+
+// WriteDailyStockPrices はイテレータから読み込んだ日次株価情報を指定された形式で1件ずつ書き込みます。
+// 株価は丸めずに、元の値を復元できる最短の10進数表記で出力します。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - options: 出力設定
+//
+// 戻り値:
+//   - 書き込んだ日次株価情報の件数
+//   - エラー（出力設定が不正な場合、イテレータがエラーを返した場合、
+//     値を出力形式で表せない場合や書き込みに失敗した場合）
+func WriteDailyStockPrices(writer io.Writer, dailyPrices iter.Seq2[models.DailyStockPrice, error], options Options) (int, error) {
+	if options.DateLayout == "" {
+		return 0, fmt.Errorf("date layout must not be empty")
+	}
+	switch options.Format {
+	case FormatTSV:
+		return writeTSV(writer, dailyPrices, options)
+	case FormatCSV:
+		return writeCSV(writer, dailyPrices, options)
+	case FormatNDJSON:
+		return writeNDJSON(writer, dailyPrices)
+	case FormatSQL:
+		return writeSQL(writer, dailyPrices, options)
+	default:
+		_, err := ParseFormat(string(options.Format))
+		return 0, err
+	}
+}
+
+// 出力する日次株価情報の条件を示す構造体
+// ゼロ値は全ての日次株価情報に一致する
+type Filter struct {
+	// 銘柄コード（空の場合は全ての銘柄）
+	StockIDs []string
+	// 日付の始点（この日付を含む、ゼロ値の場合は制限しない）
+	From time.Time
+	// 日付の終点（この日付を含む、ゼロ値の場合は制限しない）
+	To time.Time
+}
+
+// Matches は日次株価情報が条件に一致するかどうかを判定します。
+//
+// 引数:
+//   - dailyPrice: 判定する日次株価情報
+//
+// 戻り値:
+//   - 一致する場合は true
+func (f Filter) Matches(dailyPrice models.DailyStockPrice) bool {
+	if len(f.StockIDs) > 0 && !slices.Contains(f.StockIDs, dailyPrice.StockPrice.StockID) {
+		return false
+	}
+	if !f.From.IsZero() && dailyPrice.PriceDate.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && dailyPrice.PriceDate.After(f.To) {
+		return false
+	}
+	return true
+}
+
+// FilterDailyStockPrices は条件に一致する日次株価情報のみを返すイテレータを返します。
+// エラーはそのまま返します。
+//
+// 引数:
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - filter: 出力する日次株価情報の条件
+//
+// 戻り値:
+//   - 条件に一致する日次株価情報とエラーの組を返すイテレータ
+func FilterDailyStockPrices(dailyPrices iter.Seq2[models.DailyStockPrice, error], filter Filter) iter.Seq2[models.DailyStockPrice, error] {
+	return func(yield func(models.DailyStockPrice, error) bool) {
+		for dailyPrice, err := range dailyPrices {
+			if err == nil && !filter.Matches(dailyPrice) {
+				continue
+			}
+			if !yield(dailyPrice, err) {
+				return
+			}
+		}
+	}
+}
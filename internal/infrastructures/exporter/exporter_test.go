@@ -0,0 +1,114 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// testDailyStockPrices はテスト用の日次株価情報を返します。
+func testDailyStockPrices() []models.DailyStockPrice {
+	return []models.DailyStockPrice{
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+		{PriceDate: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(29635, 1)}},
+		{PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC), StockPrice: models.StockPrice{StockID: "O'Neil", Price: models.NewPrice(8000000001, 6)}},
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"tsv", "csv", "ndjson", "sql"} {
+		// Act
+		format, err := ParseFormat(name)
+
+		// Assert
+		if err != nil || string(format) != name {
+			t.Errorf("Expected %q, but got %q (%v)", name, format, err)
+		}
+	}
+	if _, err := ParseFormat("xlsx"); err == nil {
+		t.Error("Expected an error for an unknown format, but got nil")
+	}
+}
+
+func TestFilterDailyStockPrices(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		expected int
+	}{
+		{name: "zero value matches all", filter: Filter{}, expected: 3},
+		{name: "stock IDs", filter: Filter{StockIDs: []string{"7203"}}, expected: 2},
+		{name: "from only", filter: Filter{From: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)}, expected: 1},
+		{name: "to only", filter: Filter{To: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC)}, expected: 2},
+		{name: "stock and range", filter: Filter{StockIDs: []string{"O'Neil"}, From: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC)}, expected: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			count := 0
+			for _, err := range FilterDailyStockPrices(models.DailyStockPriceSeq(testDailyStockPrices()), tt.filter) {
+				if err != nil {
+					t.Fatalf("Expected no error, but got: %v", err)
+				}
+				count++
+			}
+
+			// Assert
+			if count != tt.expected {
+				t.Errorf("Expected %d rows, but got %d", tt.expected, count)
+			}
+		})
+	}
+}
+
+func TestWriteDailyStockPrices_NDJSON(t *testing.T) {
+	// Arrange
+	options := DefaultOptions()
+	options.Format = FormatNDJSON
+
+	// Act
+	var output bytes.Buffer
+	count, err := WriteDailyStockPrices(&output, models.DailyStockPriceSeq(testDailyStockPrices()), options)
+
+	// Assert - 株価は丸めない数値で出力される
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	expected := `{"stock_id":"7203","date":"2025-02-04","price":2873}
+{"stock_id":"7203","date":"2025-02-05","price":2963.5}
+{"stock_id":"O'Neil","date":"2025-02-04","price":8000.000001}
+`
+	if count != 3 || output.String() != expected {
+		t.Errorf("Expected %q (3 rows), but got %q (%d rows)", expected, output.String(), count)
+	}
+}
+
+func TestWriteDailyStockPrices_PropagatesErrors(t *testing.T) {
+	// Arrange - 2件目でエラーを返すイテレータ
+	readErr := errors.New("read failed")
+	dailyPrices := func(yield func(models.DailyStockPrice, error) bool) {
+		if yield(testDailyStockPrices()[0], nil) {
+			yield(models.DailyStockPrice{}, readErr)
+		}
+	}
+	for _, format := range []Format{FormatTSV, FormatCSV, FormatNDJSON, FormatSQL} {
+		options := DefaultOptions()
+		options.Format = format
+
+		// Act
+		count, err := WriteDailyStockPrices(&bytes.Buffer{}, dailyPrices, options)
+
+		// Assert
+		if !errors.Is(err, readErr) || count != 1 {
+			t.Errorf("%s: expected the read error after 1 row, but got %v after %d rows", format, err, count)
+		}
+	}
+	options := DefaultOptions()
+	options.Format = "xml"
+	if _, err := WriteDailyStockPrices(&bytes.Buffer{}, dailyPrices, options); err == nil {
+		t.Error("Expected an error for an unknown format, but got nil")
+	}
+}
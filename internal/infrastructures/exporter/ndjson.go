@@ -0,0 +1,47 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+	"iter"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// NDJSON形式で出力する1行分の日次株価情報
+type dailyStockPriceRecord struct {
+	StockID string       `json:"stock_id"`
+	Date    string       `json:"date"`
+	Price   models.Price `json:"price"`
+}
+
+// writeNDJSON は日次株価情報を1行に1件のJSONで書き込みます。
+// 日付はYYYY-MM-DD形式の文字列、株価は丸めない数値で出力します。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//
+// 戻り値:
+//   - 書き込んだ日次株価情報の件数
+//   - エラー（イテレータがエラーを返した場合や書き込みに失敗した場合）
+func writeNDJSON(writer io.Writer, dailyPrices iter.Seq2[models.DailyStockPrice, error]) (int, error) {
+	encoder := json.NewEncoder(writer)
+	count := 0
+	for dailyPrice, err := range dailyPrices {
+		if err != nil {
+			return count, err
+		}
+		record := dailyStockPriceRecord{
+			StockID: dailyPrice.StockPrice.StockID,
+			Date:    dailyPrice.PriceDate.Format(time.DateOnly),
+			Price:   dailyPrice.StockPrice.Price,
+		}
+		if err := encoder.Encode(record); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
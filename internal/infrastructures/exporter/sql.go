@@ -0,0 +1,86 @@
+package exporter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// SQLダンプで作成するテーブル（株価は丸めずに保存できる10進数型にする）
+const createExportTableSQL = `CREATE TABLE IF NOT EXISTS %s (
+    stock_id TEXT NOT NULL,
+    price_date DATE NOT NULL,
+    price DECIMAL(18, 6) NOT NULL,
+    PRIMARY KEY (stock_id, price_date)
+);
+`
+
+// writeSQL は日次株価情報を CREATE TABLE と INSERT 文のSQLダンプで書き込みます。
+// 全ての INSERT 文を1つのトランザクションで囲み、SQLite 以外のデータベースでも実行できる構文のみを使います。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - options: 出力設定
+//
+// 戻り値:
+//   - 書き込んだ日次株価情報の件数
+//   - エラー（テーブル名が不正な場合、イテレータがエラーを返した場合や書き込みに失敗した場合）
+func writeSQL(writer io.Writer, dailyPrices iter.Seq2[models.DailyStockPrice, error], options Options) (int, error) {
+	if options.SQLTableName == "" || strings.ContainsRune(options.SQLTableName, 0) {
+		return 0, fmt.Errorf("invalid SQL table name %q", options.SQLTableName)
+	}
+	tableName := quoteSQLIdentifier(options.SQLTableName)
+
+	bufferedWriter := bufio.NewWriter(writer)
+	if _, err := fmt.Fprintf(bufferedWriter, "BEGIN TRANSACTION;\n"+createExportTableSQL, tableName); err != nil {
+		return 0, err
+	}
+	count := 0
+	for dailyPrice, err := range dailyPrices {
+		if err != nil {
+			return count, err
+		}
+		_, err := fmt.Fprintf(bufferedWriter, "INSERT INTO %s (stock_id, price_date, price) VALUES (%s, %s, %s);\n",
+			tableName,
+			quoteSQLString(dailyPrice.StockPrice.StockID),
+			quoteSQLString(dailyPrice.PriceDate.Format(time.DateOnly)),
+			dailyPrice.StockPrice.Price.String(),
+		)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+	if _, err := bufferedWriter.WriteString("COMMIT;\n"); err != nil {
+		return count, err
+	}
+	return count, bufferedWriter.Flush()
+}
+
+// quoteSQLIdentifier は識別子を二重引用符で囲み、含まれる二重引用符をエスケープします。
+//
+// 引数:
+//   - identifier: 識別子
+//
+// 戻り値:
+//   - 引用符で囲んだ識別子
+func quoteSQLIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// quoteSQLString は文字列を単一引用符で囲み、含まれる単一引用符をエスケープします。
+//
+// 引数:
+//   - value: 文字列
+//
+// 戻り値:
+//   - SQLの文字列リテラル
+func quoteSQLString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
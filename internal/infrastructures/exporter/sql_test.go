@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/glebarez/go-sqlite"
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+func TestWriteDailyStockPrices_SQL(t *testing.T) {
+	// Arrange - 単一引用符を含む銘柄コードとテーブル名
+	options := DefaultOptions()
+	options.Format = FormatSQL
+	options.SQLTableName = `prices "2025"`
+
+	// Act
+	var output bytes.Buffer
+	count, err := WriteDailyStockPrices(&output, models.DailyStockPriceSeq(testDailyStockPrices()), options)
+
+	// Assert - SQLite で実行でき、株価が丸められずに保存される
+	if err != nil || count != 3 {
+		t.Fatalf("Expected 3 rows without error, but got %d rows (%v)", count, err)
+	}
+	database, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "dump.db"))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer database.Close()
+	if _, err := database.ExecContext(context.Background(), output.String()); err != nil {
+		t.Fatalf("Failed to execute SQL dump: %v\n%s", err, output.String())
+	}
+	var rows int
+	var price string
+	err = database.QueryRow(`SELECT COUNT(*), MAX(CAST(price AS TEXT)) FROM "prices ""2025""" WHERE stock_id = 'O''Neil' AND price_date = '2025-02-04'`).Scan(&rows, &price)
+	if err != nil || rows != 1 || price != "8000.000001" {
+		t.Errorf("Expected 1 row priced 8000.000001, but got %d rows priced %s (%v)", rows, price, err)
+	}
+
+	// Act - 不正なテーブル名
+	options.SQLTableName = ""
+	_, err = WriteDailyStockPrices(&bytes.Buffer{}, models.DailyStockPriceSeq(testDailyStockPrices()), options)
+
+	// Assert
+	if err == nil {
+		t.Error("Expected an error for an empty table name, but got nil")
+	}
+}
@@ -0,0 +1,368 @@
+package file
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 圧縮形式・アーカイブ形式を判定するために先読みするバイト数（tarヘッダーのマジックナンバーまで）
+const archiveSniffSize = 512
+
+// tarヘッダー内のマジックナンバーの位置
+const tarMagicOffset = 257
+
+// 圧縮形式・アーカイブ形式を示すマジックナンバー
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+	tarMagic  = []byte("ustar")
+)
+
+// gzip圧縮されたファイルの拡張子
+const gzipExtension = ".gz"
+
+// 入力ファイル、またはアーカイブ内の1ファイルを示す構造体
+type InputMember struct {
+	// メンバー名（アーカイブ内のファイルの場合は「アーカイブのパス!メンバーのパス」、それ以外はファイルのパス）
+	Name string
+	// アーカイブ内のファイルかどうか
+	IsArchiveMember bool
+	// 展開されたデータを読み込む Reader（イテレーションで次のメンバーに進むまで有効）
+	Reader io.Reader
+}
+
+// OpenInputMembers は入力ファイルを開き、展開したファイルを1つずつ返すイテレータを返します。
+// 圧縮形式・アーカイブ形式は拡張子ではなくファイルの先頭のマジックナンバーで判定します。
+//   - gzip: 展開したデータを1つのメンバーとして返します（中身がtarの場合はtarとして扱います）
+//   - zip, tar: ディレクトリなどを除く通常のファイルを格納順に返します
+//   - それ以外: ファイルをそのまま1つのメンバーとして返します
+//
+// ファイルを開けない場合やアーカイブが壊れている場合はエラーを返してイテレーションを終了します。
+//
+// 引数:
+//   - filePath: 入力ファイルのパス
+//
+// 戻り値:
+//   - メンバーとエラーの組を返すイテレータ
+func OpenInputMembers(filePath string) iter.Seq2[InputMember, error] {
+	return openInputMembers(filePath, nil)
+}
+
+// OpenInputMembersWithDigest は OpenInputMembers と同様にメンバーを返し、メンバーの展開に使用した
+// ファイルの内容をそのまま digest に書き込みます（ファイルを別に読み直さない）。
+// 全てのメンバーを返し終えると、メンバーの後に残ったデータ（tarの末尾の詰め物など）も読み込むため、
+// イテレーションをエラーなく最後まで終えた場合に限り digest にファイル全体が書き込まれます。
+// zip はファイルの末尾の目次から読み込むため、メンバーを返し終えてからファイル全体を先頭から読み込みます。
+//
+// 引数:
+//   - filePath: 入力ファイルのパス
+//   - digest: ファイルの内容の書き込み先（ハッシュなど）
+//
+// 戻り値:
+//   - メンバーとエラーの組を返すイテレータ
+func OpenInputMembersWithDigest(filePath string, digest io.Writer) iter.Seq2[InputMember, error] {
+	return openInputMembers(filePath, digest)
+}
+
+// openInputMembers は入力ファイルを開き、展開したファイルを1つずつ返すイテレータを返します。
+//
+// 引数:
+//   - filePath: 入力ファイルのパス
+//   - digest: ファイルの内容の書き込み先（nil の場合は書き込まない）
+//
+// 戻り値:
+//   - メンバーとエラーの組を返すイテレータ
+func openInputMembers(filePath string, digest io.Writer) iter.Seq2[InputMember, error] {
+	return func(yield func(InputMember, error) bool) {
+		// ファイルを開く
+		file, err := os.Open(filePath)
+		if err != nil {
+			yield(InputMember{}, err)
+			return
+		}
+		defer file.Close()
+
+		fileInfo, err := file.Stat()
+		if err != nil {
+			yield(InputMember{}, err)
+			return
+		}
+		yieldInputMembers(file, file, fileInfo.Size(), filePath, digest, yield)
+	}
+}
+
+// ReadInputMembers は reader から読み込んだデータを展開し、ファイルを1つずつ返すイテレータを返します。
+// 標準入力など、パスを持たない入力に使用します。形式の判定とエラーの扱いは OpenInputMembers と同じです。
+// zip はファイルの末尾に目次があるため、zip の場合のみ全体をメモリに読み込みます。
+//
+// 引数:
+//   - reader: 入力データを読み込む Reader
+//   - name: ログやエラーに表示する入力の名前
+//
+// 戻り値:
+//   - メンバーとエラーの組を返すイテレータ
+func ReadInputMembers(reader io.Reader, name string) iter.Seq2[InputMember, error] {
+	return func(yield func(InputMember, error) bool) {
+		yieldInputMembers(reader, nil, 0, name, nil, yield)
+	}
+}
+
+// ReadInputMembersWithDigest は ReadInputMembers と同様にメンバーを返し、reader から読み込んだデータを
+// そのまま digest に書き込みます。digest の扱いは OpenInputMembersWithDigest と同じです。
+//
+// 引数:
+//   - reader: 入力データを読み込む Reader
+//   - name: ログやエラーに表示する入力の名前
+//   - digest: 読み込んだデータの書き込み先（ハッシュなど）
+//
+// 戻り値:
+//   - メンバーとエラーの組を返すイテレータ
+func ReadInputMembersWithDigest(reader io.Reader, name string, digest io.Writer) iter.Seq2[InputMember, error] {
+	return func(yield func(InputMember, error) bool) {
+		yieldInputMembers(reader, nil, 0, name, digest, yield)
+	}
+}
+
+// yieldInputMembers は入力データの形式を判定し、展開したファイルをメンバーとして返します。
+//
+// 引数:
+//   - reader: 入力データを読み込む Reader
+//   - readerAt: zip の読み込みに使用する ReaderAt（nil の場合は全体をメモリに読み込む）
+//   - size: readerAt のデータの長さ
+//   - name: 入力の名前
+//   - digest: reader から読み込んだデータの書き込み先（nil の場合は書き込まない）
+//   - yield: メンバーを返す関数
+func yieldInputMembers(reader io.Reader, readerAt io.ReaderAt, size int64, name string, digest io.Writer, yield func(InputMember, error) bool) {
+	if digest != nil {
+		reader = io.TeeReader(reader, digest)
+	}
+
+	// 先頭を読み込んで形式を判定
+	bufferedReader := bufio.NewReaderSize(reader, archiveSniffSize)
+	header, err := bufferedReader.Peek(archiveSniffSize)
+	if err != nil && err != io.EOF {
+		yield(InputMember{}, err)
+		return
+	}
+
+	// 全てのメンバーを返し終えたら、digest に入力全体を書き込むため残りのデータを読み込む
+	if digest != nil {
+		stopped := false
+		yieldMember := yield
+		yield = func(member InputMember, err error) bool {
+			if !yieldMember(member, err) || err != nil {
+				stopped = true
+				return false
+			}
+			return true
+		}
+		defer func() {
+			if stopped {
+				return
+			}
+			if _, err := io.Copy(io.Discard, bufferedReader); err != nil {
+				yieldMember(InputMember{}, err)
+			}
+		}()
+	}
+
+	switch {
+	case bytes.HasPrefix(header, zipMagic):
+		if readerAt == nil {
+			data, err := io.ReadAll(bufferedReader)
+			if err != nil {
+				yield(InputMember{}, err)
+				return
+			}
+			readerAt, size = bytes.NewReader(data), int64(len(data))
+		}
+		yieldZipMembers(readerAt, size, name, yield)
+	case bytes.HasPrefix(header, gzipMagic):
+		yieldGzipMembers(bufferedReader, name, yield)
+	case isTarHeader(header):
+		yieldTarMembers(bufferedReader, name, yield)
+	default:
+		yield(InputMember{Name: name, Reader: bufferedReader}, nil)
+	}
+}
+
+// yieldGzipMembers はgzip圧縮されたデータを展開してメンバーとして返します。
+// 展開したデータがtarの場合はtarの各メンバーを返します。
+//
+// 引数:
+//   - reader: gzip圧縮されたデータを読み込む Reader
+//   - filePath: 入力ファイルのパス
+//   - yield: メンバーを返す関数
+func yieldGzipMembers(reader io.Reader, filePath string, yield func(InputMember, error) bool) {
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		yield(InputMember{}, err)
+		return
+	}
+	defer gzipReader.Close()
+
+	// 展開したデータの先頭を読み込んでtarかどうかを判定
+	bufferedReader := bufio.NewReaderSize(gzipReader, archiveSniffSize)
+	header, err := bufferedReader.Peek(archiveSniffSize)
+	if err != nil && err != io.EOF {
+		yield(InputMember{}, err)
+		return
+	}
+	if isTarHeader(header) {
+		yieldTarMembers(bufferedReader, filePath, yield)
+		return
+	}
+
+	// gzipヘッダーのファイル名がなければ拡張子を除いたファイル名をメンバー名にする
+	memberName := gzipReader.Name
+	if memberName == "" {
+		memberName = strings.TrimSuffix(filepath.Base(filePath), gzipExtension)
+	}
+	yield(InputMember{Name: archiveMemberName(filePath, memberName), IsArchiveMember: true, Reader: bufferedReader}, nil)
+}
+
+// yieldTarMembers はtarアーカイブ内の通常のファイルをメンバーとして返します。
+//
+// 引数:
+//   - reader: tarアーカイブを読み込む Reader
+//   - filePath: 入力ファイルのパス
+//   - yield: メンバーを返す関数
+func yieldTarMembers(reader io.Reader, filePath string, yield func(InputMember, error) bool) {
+	tarReader := tar.NewReader(reader)
+	for {
+		tarHeader, err := tarReader.Next()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			yield(InputMember{}, err)
+			return
+		}
+		if tarHeader.Typeflag != tar.TypeReg || isHiddenArchiveMember(tarHeader.Name) {
+			continue
+		}
+		member := InputMember{Name: archiveMemberName(filePath, tarHeader.Name), IsArchiveMember: true, Reader: tarReader}
+		if !yield(member, nil) {
+			return
+		}
+	}
+}
+
+// yieldZipMembers はzipアーカイブ内の通常のファイルをメンバーとして返します。
+//
+// 引数:
+//   - readerAt: zipアーカイブを読み込む ReaderAt
+//   - size: zipアーカイブの長さ
+//   - filePath: 入力ファイルのパス
+//   - yield: メンバーを返す関数
+func yieldZipMembers(readerAt io.ReaderAt, size int64, filePath string, yield func(InputMember, error) bool) {
+	zipReader, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		yield(InputMember{}, err)
+		return
+	}
+
+	for _, zipFile := range zipReader.File {
+		if !zipFile.Mode().IsRegular() || isHiddenArchiveMember(zipFile.Name) {
+			continue
+		}
+		memberReader, err := zipFile.Open()
+		if err != nil {
+			yield(InputMember{}, err)
+			return
+		}
+		member := InputMember{Name: archiveMemberName(filePath, zipFile.Name), IsArchiveMember: true, Reader: memberReader}
+		continued := yield(member, nil)
+		memberReader.Close()
+		if !continued {
+			return
+		}
+	}
+}
+
+// isTarHeader はデータの先頭がtarヘッダーかどうかを判定します。
+//
+// 引数:
+//   - header: データの先頭
+//
+// 戻り値:
+//   - tarヘッダーの場合は true
+func isTarHeader(header []byte) bool {
+	return len(header) >= tarMagicOffset+len(tarMagic) &&
+		bytes.Equal(header[tarMagicOffset:tarMagicOffset+len(tarMagic)], tarMagic)
+}
+
+// isHiddenArchiveMember はアーカイブ作成ツールが追加する隠しファイルかどうかを判定します。
+// macOS が追加する __MACOSX ディレクトリや「._」で始まるファイルなどが該当します。
+//
+// 引数:
+//   - memberPath: アーカイブ内のパス
+//
+// 戻り値:
+//   - 隠しファイルの場合は true
+func isHiddenArchiveMember(memberPath string) bool {
+	if strings.HasPrefix(memberPath, "__MACOSX/") {
+		return true
+	}
+	return strings.HasPrefix(path.Base(memberPath), ".")
+}
+
+// archiveMemberName はログやエラーに表示するアーカイブ内のファイル名を返します。
+//
+// 引数:
+//   - filePath: アーカイブのパス
+//   - memberPath: アーカイブ内のパス
+//
+// 戻り値:
+//   - 「アーカイブのパス!メンバーのパス」形式の名前
+func archiveMemberName(filePath string, memberPath string) string {
+	return filePath + "!" + memberPath
+}
+
+// WithSource は日次株価情報のイテレータが返すエラーに読み込み元の名前を付けたイテレータを返します。
+// 付けた名前は SourceError として取得でき、NewValidationIssue で ValidationIssue.Source に設定されます。
+//
+// 引数:
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - source: 読み込み元の名前
+//
+// 戻り値:
+//   - エラーに読み込み元の名前を付けたイテレータ
+func WithSource(dailyPrices iter.Seq2[models.DailyStockPrice, error], source string) iter.Seq2[models.DailyStockPrice, error] {
+	return func(yield func(models.DailyStockPrice, error) bool) {
+		for dailyPrice, err := range dailyPrices {
+			if err != nil {
+				err = &SourceError{Source: source, Err: err}
+			}
+			if !yield(dailyPrice, err) {
+				return
+			}
+		}
+	}
+}
+
+// SourceError は読み込み元の名前を付けたエラー
+type SourceError struct {
+	// 読み込み元の名前（アーカイブ内のファイル名など）
+	Source string
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return e.Source + ": " + e.Err.Error()
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
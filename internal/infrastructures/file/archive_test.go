@@ -0,0 +1,259 @@
+package file
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOpenInputMembers(t *testing.T) {
+	// Arrange
+	members := map[string]string{
+		"7203.tsv": "7203\t2025/2/4\t2873\n",
+		"6758.tsv": "6758\t2025/2/4\t3500\n",
+	}
+	memberOrder := []string{"7203.tsv", "6758.tsv"}
+	dir := t.TempDir()
+	testCases := []struct {
+		name            string
+		content         []byte
+		expectedNames   []string
+		expectedContent []string
+	}{
+		{
+			name:            "plain.tsv",
+			content:         []byte(members["7203.tsv"]),
+			expectedNames:   []string{filepath.Join(dir, "plain.tsv")},
+			expectedContent: []string{members["7203.tsv"]},
+		},
+		{
+			name:            "prices.tsv.gz",
+			content:         gzipBytes(t, []byte(members["7203.tsv"])),
+			expectedNames:   []string{filepath.Join(dir, "prices.tsv.gz") + "!prices.tsv"},
+			expectedContent: []string{members["7203.tsv"]},
+		},
+		{
+			name:            "prices.zip",
+			content:         zipBytes(t, memberOrder, members),
+			expectedNames:   []string{filepath.Join(dir, "prices.zip") + "!7203.tsv", filepath.Join(dir, "prices.zip") + "!6758.tsv"},
+			expectedContent: []string{members["7203.tsv"], members["6758.tsv"]},
+		},
+		{
+			name:            "prices.tar.gz",
+			content:         gzipBytes(t, tarBytes(t, memberOrder, members)),
+			expectedNames:   []string{filepath.Join(dir, "prices.tar.gz") + "!7203.tsv", filepath.Join(dir, "prices.tar.gz") + "!6758.tsv"},
+			expectedContent: []string{members["7203.tsv"], members["6758.tsv"]},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(dir, tc.name)
+			if err := os.WriteFile(filePath, tc.content, 0644); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+
+			// Act
+			var names, contents []string
+			for member, err := range OpenInputMembers(filePath) {
+				if err != nil {
+					t.Fatalf("Expected no error, but got: %v", err)
+				}
+				content, err := io.ReadAll(member.Reader)
+				if err != nil {
+					t.Fatalf("Failed to read member %s: %v", member.Name, err)
+				}
+				names = append(names, member.Name)
+				contents = append(contents, string(content))
+			}
+
+			// Assert
+			if !reflect.DeepEqual(names, tc.expectedNames) {
+				t.Errorf("Expected member names %v, but got %v", tc.expectedNames, names)
+			}
+			if !reflect.DeepEqual(contents, tc.expectedContent) {
+				t.Errorf("Expected member contents %q, but got %q", tc.expectedContent, contents)
+			}
+		})
+	}
+}
+
+func TestOpenInputMembersWithDigest(t *testing.T) {
+	// Arrange - メンバーの後にデータが残るアーカイブを含める
+	members := map[string]string{
+		"7203.tsv": "7203\t2025/2/4\t2873\n",
+		"6758.tsv": "6758\t2025/2/4\t3500\n",
+	}
+	memberOrder := []string{"7203.tsv", "6758.tsv"}
+	dir := t.TempDir()
+	testCases := map[string][]byte{
+		"plain.tsv":     []byte(members["7203.tsv"]),
+		"prices.tsv.gz": gzipBytes(t, []byte(members["7203.tsv"])),
+		"prices.zip":    zipBytes(t, memberOrder, members),
+		"prices.tar":    append(tarBytes(t, memberOrder, members), make([]byte, 10240)...),
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			filePath := filepath.Join(dir, name)
+			if err := os.WriteFile(filePath, content, 0644); err != nil {
+				t.Fatalf("Failed to write test file: %v", err)
+			}
+
+			// Act
+			digest := sha256.New()
+			for member, err := range OpenInputMembersWithDigest(filePath, digest) {
+				if err != nil {
+					t.Fatalf("Expected no error, but got: %v", err)
+				}
+				if _, err := io.ReadAll(member.Reader); err != nil {
+					t.Fatalf("Failed to read member %s: %v", member.Name, err)
+				}
+			}
+
+			// Assert - ファイル全体のハッシュと一致する
+			expected := sha256.Sum256(content)
+			if !bytes.Equal(digest.Sum(nil), expected[:]) {
+				t.Errorf("Expected the digest of the whole file %x, but got %x", expected, digest.Sum(nil))
+			}
+		})
+	}
+}
+
+func TestReadInputMembersWithDigest_StoppedEarly(t *testing.T) {
+	// Arrange
+	members := map[string]string{"7203.tsv": "7203\t2025/2/4\t2873\n", "6758.tsv": "6758\t2025/2/4\t3500\n"}
+	content := append(tarBytes(t, []string{"7203.tsv", "6758.tsv"}, members), make([]byte, 10240)...)
+
+	// Act - 最初のメンバーで読み込みを中断する
+	digest := sha256.New()
+	for range ReadInputMembersWithDigest(bytes.NewReader(content), "stdin", digest) {
+		break
+	}
+
+	// Assert - 残りのデータは読み込まない
+	expected := sha256.Sum256(content)
+	if bytes.Equal(digest.Sum(nil), expected[:]) {
+		t.Error("Expected a partial digest when the iteration stops early, but got the digest of the whole input")
+	}
+}
+
+func TestWithSource(t *testing.T) {
+	// Arrange
+	content := "7203\t2025/2/4\t2873\n7203\t2025/2/5\tN/A\n"
+	var report ValidationReport
+
+	// Act
+	dailyPrices := WithSource(StreamDailyStockPriceFromTSVReader(strings.NewReader(content), DefaultTSVOptions()), "prices.zip!7203.tsv")
+	for _, err := range SkipInvalidRows(dailyPrices, &report, UnlimitedRejectedRows) {
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	}
+
+	// Assert
+	if len(report.Issues) != 1 {
+		t.Fatalf("Expected 1 issue, but got %d", len(report.Issues))
+	}
+	issue := report.Issues[0]
+	if issue.Source != "prices.zip!7203.tsv" || issue.LineNumber != 2 || issue.Kind != IssueKindPrice {
+		t.Errorf("Expected price issue at prices.zip!7203.tsv line 2, but got %+v", issue)
+	}
+}
+
+// gzipBytes はデータをgzip圧縮したバイト列を返す関数
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	if _, err := gzipWriter.Write(data); err != nil {
+		t.Fatalf("Failed to write gzip data: %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+// zipBytes は指定された順序でファイルを格納したzipアーカイブのバイト列を返す関数
+func zipBytes(t *testing.T, names []string, contents map[string]string) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	zipWriter := zip.NewWriter(&buffer)
+	// macOS が追加する隠しファイルは無視される
+	for _, name := range append([]string{"__MACOSX/._7203.tsv"}, names...) {
+		writer, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create zip member: %v", err)
+		}
+		if _, err := writer.Write([]byte(contents[name])); err != nil {
+			t.Fatalf("Failed to write zip member: %v", err)
+		}
+	}
+	if err := zipWriter.Close(); err != nil {
+		t.Fatalf("Failed to close zip writer: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+// tarBytes は指定された順序でファイルを格納したtarアーカイブのバイト列を返す関数
+func tarBytes(t *testing.T, names []string, contents map[string]string) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	tarWriter := tar.NewWriter(&buffer)
+	if err := tarWriter.WriteHeader(&tar.Header{Name: "prices/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatalf("Failed to write tar directory: %v", err)
+	}
+	for _, name := range names {
+		header := &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(contents[name]))}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := tarWriter.Write([]byte(contents[name])); err != nil {
+			t.Fatalf("Failed to write tar member: %v", err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	return buffer.Bytes()
+}
+
+func TestReadInputMembers(t *testing.T) {
+	// Arrange
+	members := map[string]string{"7203.tsv": "7203\t2025/2/4\t2873\n"}
+	testCases := map[string][]byte{
+		"plain": []byte(members["7203.tsv"]),
+		"zip":   zipBytes(t, []string{"7203.tsv"}, members),
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			var contents []string
+			for member, err := range ReadInputMembers(bytes.NewBuffer(content), "stdin") {
+				if err != nil {
+					t.Fatalf("Expected no error, but got: %v", err)
+				}
+				memberContent, err := io.ReadAll(member.Reader)
+				if err != nil {
+					t.Fatalf("Failed to read member %s: %v", member.Name, err)
+				}
+				contents = append(contents, string(memberContent))
+			}
+
+			// Assert
+			if !reflect.DeepEqual(contents, []string{members["7203.tsv"]}) {
+				t.Errorf("Expected member contents %q, but got %q", members["7203.tsv"], contents)
+			}
+		})
+	}
+}
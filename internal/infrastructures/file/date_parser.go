@@ -0,0 +1,209 @@
+package file
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 和暦の日付を示すレイアウト名
+// 「令和7年2月4日」「令和元年5月1日」「R7.2.4」「H31/4/30」などの形式を受け付ける
+const WarekiLayout = "wareki"
+
+// 日付レイアウトの別名
+var dateLayoutAliases = map[string]string{
+	"slash":   "2006/1/2",
+	"iso":     "2006-1-2",
+	"compact": "20060102",
+	"kanji":   "2006年1月2日",
+	"wareki":  WarekiLayout,
+}
+
+// DefaultDateLayouts は日付の解析で試すデフォルトのレイアウトです。先頭から順に試します。
+var DefaultDateLayouts = []string{
+	"2006/1/2",
+	"2006-1-2",
+	"20060102",
+	"2006年1月2日",
+	WarekiLayout,
+}
+
+// 元号の開始日と略称を示す構造体
+type japaneseEra struct {
+	// 元号名
+	Name string
+	// アルファベットの略称
+	Abbreviation string
+	// 元号の開始日
+	StartDate time.Time
+}
+
+// 元号の一覧（新しい順）
+var japaneseEras = []japaneseEra{
+	{Name: "令和", Abbreviation: "R", StartDate: time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)},
+	{Name: "平成", Abbreviation: "H", StartDate: time.Date(1989, 1, 8, 0, 0, 0, 0, time.UTC)},
+	{Name: "昭和", Abbreviation: "S", StartDate: time.Date(1926, 12, 25, 0, 0, 0, 0, time.UTC)},
+	{Name: "大正", Abbreviation: "T", StartDate: time.Date(1912, 7, 30, 0, 0, 0, 0, time.UTC)},
+	{Name: "明治", Abbreviation: "M", StartDate: time.Date(1868, 10, 23, 0, 0, 0, 0, time.UTC)},
+}
+
+// 和暦の日付を解析する正規表現
+// 元号, 年（「元」は1年）, 月, 日 をキャプチャする
+var warekiPattern = regexp.MustCompile(`^(令和|平成|昭和|大正|明治|[RHSTMrhstm])\s*(元|\d{1,2})\s*[年./-]\s*(\d{1,2})\s*[月./-]\s*(\d{1,2})\s*日?$`)
+
+// 全角数字を半角数字に置き換える Replacer
+var fullWidthDigitReplacer = strings.NewReplacer(
+	"０", "0", "１", "1", "２", "2", "３", "3", "４", "4",
+	"５", "5", "６", "6", "７", "7", "８", "8", "９", "9",
+	"／", "/", "－", "-", "．", ".",
+)
+
+// 日付文字列を解析するパーサー
+type DateParser struct {
+	// 試すレイアウト（先頭から順に試す）
+	layouts []string
+}
+
+// NewDateParser は指定されたレイアウトを順に試す日付パーサーを作成します。
+// レイアウトには time.Parse のレイアウト文字列か WarekiLayout を指定します。
+//
+// 引数:
+//   - layouts: 試すレイアウト（先頭から順に試す）
+//
+// 戻り値:
+//   - 日付パーサー
+//   - エラー（レイアウトが空の場合）
+func NewDateParser(layouts []string) (*DateParser, error) {
+	if len(layouts) == 0 {
+		return nil, errors.New("date layouts must not be empty")
+	}
+	return &DateParser{layouts: append([]string(nil), layouts...)}, nil
+}
+
+// DefaultDateParser は DefaultDateLayouts を順に試す日付パーサーを返します。
+//
+// 戻り値:
+//   - 日付パーサー
+func DefaultDateParser() *DateParser {
+	return &DateParser{layouts: DefaultDateLayouts}
+}
+
+// ParseDateLayouts はカンマ区切りのレイアウト指定をレイアウトの配列に変換します。
+// slash, iso, compact, kanji, wareki の別名を受け付けます。
+//
+// 引数:
+//   - spec: カンマ区切りのレイアウト指定（例: "iso,wareki,2006.1.2"）
+//
+// 戻り値:
+//   - レイアウトの配列
+//   - エラー（レイアウトが1つも指定されていない場合）
+func ParseDateLayouts(spec string) ([]string, error) {
+	var layouts []string
+	for _, layout := range strings.Split(spec, ",") {
+		layout = strings.TrimSpace(layout)
+		if layout == "" {
+			continue
+		}
+		if aliasedLayout, ok := dateLayoutAliases[strings.ToLower(layout)]; ok {
+			layout = aliasedLayout
+		}
+		layouts = append(layouts, layout)
+	}
+	if len(layouts) == 0 {
+		return nil, errors.New("no date layouts specified: " + spec)
+	}
+	return layouts, nil
+}
+
+// Layouts は日付パーサーが試すレイアウトを返します。
+//
+// 戻り値:
+//   - レイアウトの配列
+func (p *DateParser) Layouts() []string {
+	return append([]string(nil), p.layouts...)
+}
+
+// Parse は日付文字列をレイアウトの順に解析し、最初に解析できた日付を返します。
+// 全角数字は半角数字として扱います。
+//
+// 引数:
+//   - dateStr: 日付文字列
+//
+// 戻り値:
+//   - 日付（UTC）
+//   - エラー（どのレイアウトでも解析できない場合）
+func (p *DateParser) Parse(dateStr string) (time.Time, error) {
+	normalizedDateStr := fullWidthDigitReplacer.Replace(strings.TrimSpace(dateStr))
+
+	var lastErr error
+	for _, layout := range p.layouts {
+		var parsedDate time.Time
+		var err error
+		if layout == WarekiLayout {
+			parsedDate, err = parseWarekiDate(normalizedDateStr)
+		} else {
+			parsedDate, err = time.Parse(layout, normalizedDateStr)
+		}
+		if err == nil {
+			return parsedDate, nil
+		}
+		lastErr = err
+	}
+	return time.Time{}, lastErr
+}
+
+// parseWarekiDate は和暦の日付文字列を西暦の日付に変換します。
+// 元号の開始日より前や、次の元号の開始日以降の日付はエラーになります。
+//
+// 引数:
+//   - dateStr: 和暦の日付文字列
+//
+// 戻り値:
+//   - 日付（UTC）
+//   - エラー（和暦の日付として解析できない場合）
+func parseWarekiDate(dateStr string) (time.Time, error) {
+	matches := warekiPattern.FindStringSubmatch(dateStr)
+	if matches == nil {
+		return time.Time{}, errors.New("not a Japanese era date: " + dateStr)
+	}
+
+	// 元号を特定
+	eraIndex := -1
+	for i, era := range japaneseEras {
+		if matches[1] == era.Name || strings.EqualFold(matches[1], era.Abbreviation) {
+			eraIndex = i
+			break
+		}
+	}
+	era := japaneseEras[eraIndex]
+
+	// 和暦の年を取得（「元」は1年）
+	eraYear := 1
+	if matches[2] != "元" {
+		eraYear, _ = strconv.Atoi(matches[2])
+	}
+	month, _ := strconv.Atoi(matches[3])
+	day, _ := strconv.Atoi(matches[4])
+	if eraYear < 1 || month < 1 || month > 12 || day < 1 {
+		return time.Time{}, errors.New("invalid Japanese era date: " + dateStr)
+	}
+
+	// 西暦に変換し、存在しない日付（2月30日など）を除外
+	year := era.StartDate.Year() + eraYear - 1
+	convertedDate := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if convertedDate.Month() != time.Month(month) || convertedDate.Day() != day {
+		return time.Time{}, errors.New("invalid Japanese era date: " + dateStr)
+	}
+
+	// 元号の期間内であることを確認
+	if convertedDate.Before(era.StartDate) {
+		return time.Time{}, errors.New("date is before the start of " + era.Name + ": " + dateStr)
+	}
+	if eraIndex > 0 && !convertedDate.Before(japaneseEras[eraIndex-1].StartDate) {
+		return time.Time{}, errors.New("date is after the end of " + era.Name + ": " + dateStr)
+	}
+
+	return convertedDate, nil
+}
@@ -0,0 +1,133 @@
+package file
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDateParser_DefaultLayouts(t *testing.T) {
+	// Arrange
+	parser := DefaultDateParser()
+	expected := time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC)
+	dateStrs := []string{
+		"2025/2/4",
+		"2025/02/04",
+		"2025-02-04",
+		"20250204",
+		"2025年2月4日",
+		"２０２５年２月４日",
+		"令和7年2月4日",
+		"R7.2.4",
+		"r07/02/04",
+	}
+
+	for _, dateStr := range dateStrs {
+		// Act
+		actual, err := parser.Parse(dateStr)
+
+		// Assert
+		if err != nil {
+			t.Errorf("Expected no error for %s, but got: %v", dateStr, err)
+			continue
+		}
+		if !actual.Equal(expected) {
+			t.Errorf("Expected %v for %s, but got %v", expected, dateStr, actual)
+		}
+	}
+}
+
+func TestDateParser_JapaneseEraBoundaries(t *testing.T) {
+	// Arrange
+	parser, err := NewDateParser([]string{WarekiLayout})
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	testCases := []struct {
+		dateStr  string
+		expected time.Time
+		wantErr  bool
+	}{
+		{dateStr: "令和元年5月1日", expected: time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{dateStr: "平成31年4月30日", expected: time.Date(2019, 4, 30, 0, 0, 0, 0, time.UTC)},
+		{dateStr: "平成元年1月8日", expected: time.Date(1989, 1, 8, 0, 0, 0, 0, time.UTC)},
+		{dateStr: "昭和64年1月7日", expected: time.Date(1989, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{dateStr: "S60/12/30", expected: time.Date(1985, 12, 30, 0, 0, 0, 0, time.UTC)},
+		{dateStr: "平成31年5月1日", wantErr: true},
+		{dateStr: "令和元年4月30日", wantErr: true},
+		{dateStr: "令和7年2月30日", wantErr: true},
+		{dateStr: "2025/2/4", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.dateStr, func(t *testing.T) {
+			// Act
+			actual, err := parser.Parse(tc.dateStr)
+
+			// Assert
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, but got %v", actual)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if !actual.Equal(tc.expected) {
+				t.Errorf("Expected %v, but got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseDateLayouts(t *testing.T) {
+	// Arrange
+	spec := "iso, wareki,2006.1.2"
+	expected := []string{"2006-1-2", WarekiLayout, "2006.1.2"}
+
+	// Act
+	layouts, err := ParseDateLayouts(spec)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !reflect.DeepEqual(layouts, expected) {
+		t.Errorf("Expected %v, but got %v", expected, layouts)
+	}
+}
+
+func TestStreamDailyStockPriceFromTSVReader_ConfiguredLayouts(t *testing.T) {
+	// Arrange
+	parser, err := NewDateParser([]string{"2006-1-2"})
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	content := "7203\t2025-02-04\t2873\n7203\t2025/2/5\t2963\n"
+
+	// Act
+	var errs []error
+	for _, err := range StreamDailyStockPriceFromTSVReader(strings.NewReader(content), TSVOptions{DateParser: parser}) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Assert
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error, but got %d: %v", len(errs), errs)
+	}
+	var dateErr *InvalidDateFormatError
+	if !errors.As(errs[0], &dateErr) {
+		t.Fatalf("Expected InvalidDateFormatError, but got: %v", errs[0])
+	}
+	if !reflect.DeepEqual(dateErr.Layouts, []string{"2006-1-2"}) {
+		t.Errorf("Expected tried layouts in error, but got %v", dateErr.Layouts)
+	}
+	if !strings.Contains(dateErr.Error(), "2006-1-2") {
+		t.Errorf("Expected error message to list tried layouts, but got: %s", dateErr.Error())
+	}
+}
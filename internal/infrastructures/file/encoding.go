@@ -0,0 +1,288 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// 入力ファイルの文字エンコーディングを示す型
+type TextEncoding string
+
+// 対応している文字エンコーディング
+const (
+	// 先頭のデータから自動判定する
+	EncodingAuto TextEncoding = "auto"
+	// UTF-8（BOMの有無は問わない）
+	EncodingUTF8 TextEncoding = "utf-8"
+	// Shift_JIS（Windows-31J / CP932 の拡張文字を含む）
+	EncodingShiftJIS TextEncoding = "shift_jis"
+	// EUC-JP
+	EncodingEUCJP TextEncoding = "euc-jp"
+	// UTF-16 リトルエンディアン
+	EncodingUTF16LE TextEncoding = "utf-16le"
+	// UTF-16 ビッグエンディアン
+	EncodingUTF16BE TextEncoding = "utf-16be"
+)
+
+// 文字エンコーディングの判定に使用する先頭データのバイト数
+const encodingDetectionSampleSize = 64 * 1024
+
+// バイトオーダーマーク
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// UTF-16 とみなすNULバイトの割合の下限
+const utf16NullByteRatioThreshold = 0.3
+
+// 文字エンコーディング名の別名
+var textEncodingAliases = map[string]TextEncoding{
+	"auto":        EncodingAuto,
+	"utf-8":       EncodingUTF8,
+	"utf8":        EncodingUTF8,
+	"utf-8-bom":   EncodingUTF8,
+	"shift_jis":   EncodingShiftJIS,
+	"shift-jis":   EncodingShiftJIS,
+	"sjis":        EncodingShiftJIS,
+	"cp932":       EncodingShiftJIS,
+	"windows-31j": EncodingShiftJIS,
+	"euc-jp":      EncodingEUCJP,
+	"eucjp":       EncodingEUCJP,
+	"utf-16le":    EncodingUTF16LE,
+	"utf16le":     EncodingUTF16LE,
+	"utf-16be":    EncodingUTF16BE,
+	"utf16be":     EncodingUTF16BE,
+}
+
+// ParseTextEncoding は文字エンコーディング名を TextEncoding に変換します。
+// 大文字小文字は区別せず、sjis や cp932 などの別名も受け付けます。
+//
+// 引数:
+//   - name: 文字エンコーディング名
+//
+// 戻り値:
+//   - 文字エンコーディング
+//   - エラー（未対応の文字エンコーディング名の場合）
+func ParseTextEncoding(name string) (TextEncoding, error) {
+	textEncoding, ok := textEncodingAliases[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return "", errors.New("unsupported encoding: " + name)
+	}
+	return textEncoding, nil
+}
+
+// NewDecodingReader は reader から読み込んだデータを UTF-8 に変換する Reader を返します。
+// textEncoding が EncodingAuto の場合は先頭のデータから文字エンコーディングを判定します。
+// 先頭にBOMがある場合は指定された文字エンコーディングよりもBOMを優先し、BOMは取り除かれます。
+//
+// 引数:
+//   - reader: 読み込み元の Reader
+//   - textEncoding: 読み込み元の文字エンコーディング
+//
+// 戻り値:
+//   - UTF-8 に変換されたデータを返す Reader
+//   - 使用した文字エンコーディング
+//   - エラー（先頭データの読み込みに失敗した場合や未対応の文字エンコーディングの場合）
+func NewDecodingReader(reader io.Reader, textEncoding TextEncoding) (io.Reader, TextEncoding, error) {
+	// 先頭のデータを読み込み位置を進めずに取得
+	bufferedReader := bufio.NewReaderSize(reader, encodingDetectionSampleSize)
+	sample, err := bufferedReader.Peek(encodingDetectionSampleSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", err
+	}
+
+	// BOMがある場合はBOMの文字エンコーディングを使用
+	if bomEncoding, ok := detectBOM(sample); ok {
+		textEncoding = bomEncoding
+	} else if textEncoding == EncodingAuto {
+		textEncoding = DetectTextEncoding(sample)
+	}
+
+	decoder, err := textEncodingDecoder(textEncoding)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// BOMを取り除きながら UTF-8 に変換
+	return transform.NewReader(bufferedReader, unicode.BOMOverride(decoder.NewDecoder())), textEncoding, nil
+}
+
+// DetectTextEncoding はファイルの先頭データから文字エンコーディングを推定します。
+// BOM、UTF-8 としての妥当性、NULバイトの位置（UTF-16）の順に判定し、
+// いずれにも当てはまらない場合は Shift_JIS と EUC-JP のうち日本語として自然な方を返します。
+//
+// 引数:
+//   - sample: ファイルの先頭データ
+//
+// 戻り値:
+//   - 推定した文字エンコーディング
+func DetectTextEncoding(sample []byte) TextEncoding {
+	// BOMによる判定
+	if bomEncoding, ok := detectBOM(sample); ok {
+		return bomEncoding
+	}
+
+	// UTF-8 として妥当かどうか（末尾で途切れた文字は無視する）
+	if utf8.Valid(trimIncompleteUTF8(sample)) {
+		return EncodingUTF8
+	}
+
+	// NULバイトの位置による UTF-16 の判定
+	if utf16Encoding, ok := detectUTF16WithoutBOM(sample); ok {
+		return utf16Encoding
+	}
+
+	// Shift_JIS と EUC-JP のうち不自然な文字が少ない方を採用
+	shiftJISPenalty := decodedTextPenalty(sample, japanese.ShiftJIS)
+	eucJPPenalty := decodedTextPenalty(sample, japanese.EUCJP)
+	if eucJPPenalty < shiftJISPenalty {
+		return EncodingEUCJP
+	}
+	return EncodingShiftJIS
+}
+
+// detectBOM はデータの先頭のBOMから文字エンコーディングを判定します。
+//
+// 引数:
+//   - sample: ファイルの先頭データ
+//
+// 戻り値:
+//   - BOMが示す文字エンコーディング
+//   - BOMがある場合は true
+func detectBOM(sample []byte) (TextEncoding, bool) {
+	switch {
+	case bytes.HasPrefix(sample, utf8BOM):
+		return EncodingUTF8, true
+	case bytes.HasPrefix(sample, utf16LEBOM):
+		return EncodingUTF16LE, true
+	case bytes.HasPrefix(sample, utf16BEBOM):
+		return EncodingUTF16BE, true
+	default:
+		return "", false
+	}
+}
+
+// detectUTF16WithoutBOM はBOMのないデータについて、NULバイトが偶数位置と奇数位置の
+// どちらに偏っているかで UTF-16 のバイトオーダーを判定します。
+//
+// 引数:
+//   - sample: ファイルの先頭データ
+//
+// 戻り値:
+//   - UTF-16 のバイトオーダー
+//   - UTF-16 と判定した場合は true
+func detectUTF16WithoutBOM(sample []byte) (TextEncoding, bool) {
+	if len(sample) < 2 {
+		return "", false
+	}
+
+	evenNullCount, oddNullCount := 0, 0
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenNullCount++
+		} else {
+			oddNullCount++
+		}
+	}
+
+	threshold := int(float64(len(sample)/2) * utf16NullByteRatioThreshold)
+	switch {
+	case oddNullCount > threshold && evenNullCount < oddNullCount/4:
+		// ASCII文字の上位バイトが後ろにある
+		return EncodingUTF16LE, true
+	case evenNullCount > threshold && oddNullCount < evenNullCount/4:
+		// ASCII文字の上位バイトが前にある
+		return EncodingUTF16BE, true
+	default:
+		return "", false
+	}
+}
+
+// decodedTextPenalty はデータを指定された文字エンコーディングで変換した結果の不自然さを数値化します。
+// 変換できないバイト列と、日本語の文書ではまれな半角カナや私用領域の文字に罰点を付けます。
+//
+// 引数:
+//   - sample: ファイルの先頭データ
+//   - candidate: 候補の文字エンコーディング
+//
+// 戻り値:
+//   - 罰点の合計（小さいほど自然）
+func decodedTextPenalty(sample []byte, candidate encoding.Encoding) int {
+	decoded, _ := candidate.NewDecoder().Bytes(sample)
+
+	penalty := 0
+	for _, r := range string(decoded) {
+		switch {
+		case r == utf8.RuneError:
+			penalty += 10
+		case r >= 0xFF61 && r <= 0xFF9F:
+			// 半角カナ
+			penalty += 2
+		case r >= 0xE000 && r <= 0xF8FF:
+			// 私用領域
+			penalty += 5
+		}
+	}
+	return penalty
+}
+
+// trimIncompleteUTF8 はデータの末尾で途切れている UTF-8 の文字を取り除きます。
+//
+// 引数:
+//   - sample: ファイルの先頭データ
+//
+// 戻り値:
+//   - 末尾の途切れた文字を除いたデータ
+func trimIncompleteUTF8(sample []byte) []byte {
+	// UTF-8 の1文字は最大4バイトのため、末尾3バイトまでを確認する
+	for trimmed := 0; trimmed < utf8.UTFMax && trimmed < len(sample); trimmed++ {
+		candidate := sample[:len(sample)-trimmed]
+		if utf8.Valid(candidate) {
+			return candidate
+		}
+		lastRune, _ := utf8.DecodeLastRune(candidate)
+		if lastRune != utf8.RuneError {
+			break
+		}
+	}
+	return sample
+}
+
+// textEncodingDecoder は文字エンコーディングに対応する変換器を返します。
+//
+// 引数:
+//   - textEncoding: 文字エンコーディング
+//
+// 戻り値:
+//   - 変換器
+//   - エラー（未対応の文字エンコーディングの場合）
+func textEncodingDecoder(textEncoding TextEncoding) (encoding.Encoding, error) {
+	switch textEncoding {
+	case EncodingUTF8:
+		return unicode.UTF8, nil
+	case EncodingShiftJIS:
+		return japanese.ShiftJIS, nil
+	case EncodingEUCJP:
+		return japanese.EUCJP, nil
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), nil
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), nil
+	default:
+		return nil, errors.New("unsupported encoding: " + string(textEncoding))
+	}
+}
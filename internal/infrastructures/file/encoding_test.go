@@ -0,0 +1,114 @@
+package file
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestNewDecodingReader_AutoDetection(t *testing.T) {
+	// Arrange
+	text := "銘柄コード\t日付\t終値\n7203\t2025/2/4\t2873\n"
+	testCases := []struct {
+		name             string
+		encoder          encoding.Encoding
+		prefix           []byte
+		expectedEncoding TextEncoding
+	}{
+		{name: "UTF-8", encoder: unicode.UTF8, expectedEncoding: EncodingUTF8},
+		{name: "UTF-8 BOM", encoder: unicode.UTF8, prefix: utf8BOM, expectedEncoding: EncodingUTF8},
+		{name: "Shift_JIS", encoder: japanese.ShiftJIS, expectedEncoding: EncodingShiftJIS},
+		{name: "EUC-JP", encoder: japanese.EUCJP, expectedEncoding: EncodingEUCJP},
+		{name: "UTF-16LE BOM", encoder: unicode.UTF16(unicode.LittleEndian, unicode.UseBOM), expectedEncoding: EncodingUTF16LE},
+		{name: "UTF-16BE BOM", encoder: unicode.UTF16(unicode.BigEndian, unicode.UseBOM), expectedEncoding: EncodingUTF16BE},
+		{name: "UTF-16LE", encoder: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), expectedEncoding: EncodingUTF16LE},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			encoded, err := tc.encoder.NewEncoder().Bytes([]byte(text))
+			if err != nil {
+				t.Fatalf("Failed to encode test data: %v", err)
+			}
+			input := append(append([]byte{}, tc.prefix...), encoded...)
+
+			// Act
+			reader, detectedEncoding, err := NewDecodingReader(bytes.NewReader(input), EncodingAuto)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			decoded, err := io.ReadAll(reader)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Failed to read decoded data: %v", err)
+			}
+			if detectedEncoding != tc.expectedEncoding {
+				t.Errorf("Expected encoding %s, but got %s", tc.expectedEncoding, detectedEncoding)
+			}
+			if string(decoded) != text {
+				t.Errorf("Decoded text mismatch.\nExpected: %q\nGot: %q", text, string(decoded))
+			}
+		})
+	}
+}
+
+func TestNewDecodingReader_ForcedEncoding(t *testing.T) {
+	// Arrange
+	text := "7203\t2025/2/4\tｶﾌﾞ\n"
+	encoded, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		t.Fatalf("Failed to encode test data: %v", err)
+	}
+
+	// Act
+	reader, usedEncoding, err := NewDecodingReader(bytes.NewReader(encoded), EncodingShiftJIS)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	decoded, err := io.ReadAll(reader)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Failed to read decoded data: %v", err)
+	}
+	if usedEncoding != EncodingShiftJIS {
+		t.Errorf("Expected encoding %s, but got %s", EncodingShiftJIS, usedEncoding)
+	}
+	if string(decoded) != text {
+		t.Errorf("Decoded text mismatch.\nExpected: %q\nGot: %q", text, string(decoded))
+	}
+}
+
+func TestParseTextEncoding(t *testing.T) {
+	// Arrange
+	testCases := map[string]TextEncoding{
+		"CP932":    EncodingShiftJIS,
+		"sjis":     EncodingShiftJIS,
+		"EUC-JP":   EncodingEUCJP,
+		"utf8":     EncodingUTF8,
+		"auto":     EncodingAuto,
+		"UTF-16LE": EncodingUTF16LE,
+	}
+
+	for name, expected := range testCases {
+		// Act
+		actual, err := ParseTextEncoding(name)
+
+		// Assert
+		if err != nil {
+			t.Errorf("Expected no error for %s, but got: %v", name, err)
+		}
+		if actual != expected {
+			t.Errorf("Expected %s for %s, but got %s", expected, name, actual)
+		}
+	}
+
+	if _, err := ParseTextEncoding("latin1"); err == nil {
+		t.Error("Expected an error for unsupported encoding, but got nil")
+	}
+}
@@ -0,0 +1,187 @@
+package file
+
+import (
+	"errors"
+	"strings"
+)
+
+// ヘッダー行として判定するために必要な列
+var requiredHeaderColumns = []string{ColumnStockID, ColumnDate, ColumnPrice}
+
+// ヘッダー行で認識する列（割り当ての優先順）
+var headerColumnOrder = []string{ColumnStockID, ColumnDate, ColumnPrice, ColumnOpen, ColumnHigh, ColumnLow, ColumnVolume}
+
+// ColumnAliases は列名（ColumnStockID など）ごとに、ヘッダー行で認識する別名を示すマップです。
+// 別名の比較では前後の空白と英字の大文字・小文字を区別しません。
+// 四本値を読み込む場合の終値は ColumnPrice の別名として指定します。
+type ColumnAliases map[string][]string
+
+// DefaultColumnAliases はヘッダー行で認識する英語と日本語のデフォルトの別名を返します。
+//
+// 戻り値:
+//   - 列名ごとの別名
+func DefaultColumnAliases() ColumnAliases {
+	return ColumnAliases{
+		ColumnStockID: {"stock_id", "code", "ticker", "symbol", "銘柄コード", "コード", "証券コード"},
+		ColumnDate:    {"date", "price_date", "日付", "年月日", "取引日"},
+		ColumnPrice:   {"price", "close", "終値", "株価"},
+		ColumnOpen:    {"open", "始値"},
+		ColumnHigh:    {"high", "高値"},
+		ColumnLow:     {"low", "安値"},
+		ColumnVolume:  {"volume", "出来高"},
+	}
+}
+
+// Extend は「列名=別名,別名;列名=別名」形式の指定で別名を追加した ColumnAliases を返します。
+// 元の ColumnAliases は変更しません。
+//
+// 引数:
+//   - spec: 追加する別名の指定（例: "stock_id=ticker_code;price=adj_close,調整後終値"）
+//
+// 戻り値:
+//   - 別名を追加した ColumnAliases
+//   - エラー（指定の形式が不正な場合や未知の列名が指定された場合）
+func (a ColumnAliases) Extend(spec string) (ColumnAliases, error) {
+	// 元の別名をコピー
+	extendedAliases := make(ColumnAliases, len(a))
+	for column, aliases := range a {
+		extendedAliases[column] = append([]string(nil), aliases...)
+	}
+
+	for _, entry := range strings.Split(spec, ";") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		column, aliasList, found := strings.Cut(entry, "=")
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !found || !isHeaderColumn(column) {
+			return nil, errors.New("invalid column alias: " + entry + " (expected <column>=<alias>[,<alias>...] with column one of " + strings.Join(headerColumnOrder, ", ") + ")")
+		}
+		for _, alias := range strings.Split(aliasList, ",") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				extendedAliases[column] = append(extendedAliases[column], alias)
+			}
+		}
+	}
+	return extendedAliases, nil
+}
+
+// 列名ごとにヘッダー行から割り当てた列番号（0始まり）を示すマップ
+type headerColumns map[string]int
+
+// index は列名に割り当てた列番号を返します。割り当てていない場合は NoColumn を返します。
+//
+// 引数:
+//   - column: 列名
+//
+// 戻り値:
+//   - 列番号
+func (c headerColumns) index(column string) int {
+	if index, ok := c[column]; ok {
+		return index
+	}
+	return NoColumn
+}
+
+// detectHeaderColumns は1行目のレコードがヘッダー行かどうかを判定し、
+// ヘッダー行の場合は列名ごとの列番号を返します。
+// 別名に一致する列が1つもない場合はデータ行と判定します。
+// 別名に一致する列があっても必要な列が揃っていない場合はエラーを返します。
+//
+// 引数:
+//   - fields: 1行目のレコードの各列の値
+//   - aliases: 列名ごとの別名
+//   - line: エラーに含める行
+//
+// 戻り値:
+//   - 列名ごとの列番号
+//   - ヘッダー行の場合は true
+//   - エラー（必要な列が不足したヘッダー行の場合は MissingHeaderColumnError）
+func detectHeaderColumns(fields []string, aliases ColumnAliases, line sourceLine) (headerColumns, bool, error) {
+	columns := make(headerColumns)
+	for index, field := range fields {
+		normalizedField := normalizeHeaderField(field)
+		for _, column := range headerColumnOrder {
+			// 既に割り当てた列は先に現れた列を優先
+			if _, assigned := columns[column]; assigned {
+				continue
+			}
+			if matchesColumnAlias(normalizedField, aliases[column]) {
+				columns[column] = index
+				break
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil, false, nil
+	}
+
+	// 必要な列が揃っていることを確認
+	var missingColumns []string
+	for _, column := range requiredHeaderColumns {
+		if _, ok := columns[column]; !ok {
+			missingColumns = append(missingColumns, column)
+		}
+	}
+	if len(missingColumns) > 0 {
+		return nil, false, &MissingHeaderColumnError{Columns: missingColumns, Line: line.Text, LineNumber: line.Number}
+	}
+	return columns, true, nil
+}
+
+// matchesColumnAlias は正規化したヘッダーの値がいずれかの別名に一致するかを判定します。
+//
+// 引数:
+//   - normalizedField: 正規化したヘッダーの値
+//   - aliases: 別名
+//
+// 戻り値:
+//   - 一致する場合は true
+func matchesColumnAlias(normalizedField string, aliases []string) bool {
+	for _, alias := range aliases {
+		if normalizedField == normalizeHeaderField(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHeaderField はヘッダーの値を比較用に正規化します。
+// 先頭のBOM、前後の空白を除き、英字を小文字にします。
+//
+// 引数:
+//   - field: ヘッダーの値
+//
+// 戻り値:
+//   - 正規化した値
+func normalizeHeaderField(field string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(field, "\ufeff")))
+}
+
+// isHeaderColumn はヘッダー行で認識する列名かどうかを判定します。
+//
+// 引数:
+//   - column: 列名
+//
+// 戻り値:
+//   - 認識する列名の場合は true
+func isHeaderColumn(column string) bool {
+	for _, headerColumn := range headerColumnOrder {
+		if column == headerColumn {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingHeaderColumnError はヘッダー行に必要な列が存在しない場合のエラー
+type MissingHeaderColumnError struct {
+	// 不足している列名
+	Columns    []string
+	Line       string
+	LineNumber int
+}
+
+func (e *MissingHeaderColumnError) Error() string {
+	return "header row is missing columns: " + strings.Join(e.Columns, ", ") + " at " + lineDescription(e.LineNumber, e.Line)
+}
@@ -0,0 +1,141 @@
+package file
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+func TestStreamDailyStockPriceFromTSVReader_Header(t *testing.T) {
+	// Arrange
+	expected := []models.DailyStockPrice{
+		{
+			PriceDate:  time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
+			StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)},
+		},
+	}
+	testCases := map[string]string{
+		"english":        "code\tdate\tclose\n7203\t2025/2/4\t2873\n",
+		"japanese":       "銘柄コード\t日付\t終値\n7203\t2025/2/4\t2873\n",
+		"reordered":      "Close\tName\tDate\tCode\n2873\tトヨタ自動車\t2025/2/4\t7203\n",
+		"bom and spaces": "\ufeff 銘柄コード \t 日付 \t 終値 \n7203\t2025/2/4\t2873\n",
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			dailyPrices, err := collectDailyStockPrices(StreamDailyStockPriceFromTSVReader(strings.NewReader(content), DefaultTSVOptions()))
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if !reflect.DeepEqual(dailyPrices, expected) {
+				t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", expected, dailyPrices)
+			}
+		})
+	}
+}
+
+func TestStreamDailyStockPriceFromTSVReader_MissingHeaderColumn(t *testing.T) {
+	// Arrange
+	content := "code\tclose\n7203\t2873\n"
+
+	// Act
+	_, err := collectDailyStockPrices(StreamDailyStockPriceFromTSVReader(strings.NewReader(content), DefaultTSVOptions()))
+
+	// Assert
+	var headerErr *MissingHeaderColumnError
+	if !errors.As(err, &headerErr) {
+		t.Fatalf("Expected MissingHeaderColumnError, but got: %v", err)
+	}
+	if !reflect.DeepEqual(headerErr.Columns, []string{ColumnDate}) {
+		t.Errorf("Expected missing column %s, but got %v", ColumnDate, headerErr.Columns)
+	}
+}
+
+func TestReadDailyStockPriceFromCSV_Header(t *testing.T) {
+	// Arrange
+	content := "日付,出来高,銘柄コード,株価\n2025/2/4,15000000,7203,2873\n"
+	filePath := testutil.WriteTempFile(t, "prices.csv", content)
+	expected := []models.DailyStockPrice{
+		{
+			PriceDate:  time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
+			StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)},
+		},
+	}
+
+	// Act
+	dailyPrices, err := ReadDailyStockPriceFromCSV(filePath, DefaultCSVOptions())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !reflect.DeepEqual(dailyPrices, expected) {
+		t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", expected, dailyPrices)
+	}
+}
+
+func TestReadDailyStockBarFromTSV_Header(t *testing.T) {
+	// Arrange
+	content := "date\tcode\tvolume\tclose\thigh\tlow\topen\n" +
+		"2025/2/4\t7203\t15000000\t2873\t2890\t2840\t2850\n"
+	filePath := testutil.WriteTempFile(t, "bars.tsv", content)
+	expected := []models.DailyStockBar{
+		{
+			PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
+			StockID:   "7203",
+			Open:      models.NewPrice(2850, 0),
+			High:      models.NewPrice(2890, 0),
+			Low:       models.NewPrice(2840, 0),
+			Close:     models.NewPrice(2873, 0),
+			Volume:    15000000,
+		},
+	}
+
+	// Act
+	dailyBars, err := ReadDailyStockBarFromTSV(filePath)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !reflect.DeepEqual(dailyBars, expected) {
+		t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", expected, dailyBars)
+	}
+}
+
+func TestColumnAliases_Extend(t *testing.T) {
+	// Arrange
+	aliases := DefaultColumnAliases()
+	content := "ticker_code\tdate\tadj_close\n7203\t2025/2/4\t2873\n"
+
+	// Act
+	extendedAliases, err := aliases.Extend("stock_id=ticker_code; price=adj_close,調整後終値")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	options := TSVOptions{ColumnAliases: extendedAliases}
+	dailyPrices, readErr := collectDailyStockPrices(StreamDailyStockPriceFromTSVReader(strings.NewReader(content), options))
+	_, invalidErr := aliases.Extend("unknown=foo")
+
+	// Assert
+	if readErr != nil {
+		t.Fatalf("Expected no error, but got: %v", readErr)
+	}
+	if len(dailyPrices) != 1 || dailyPrices[0].StockPrice.StockID != "7203" {
+		t.Errorf("Expected 1 price for 7203, but got %+v", dailyPrices)
+	}
+	if len(aliases[ColumnStockID]) != len(DefaultColumnAliases()[ColumnStockID]) {
+		t.Errorf("Expected original aliases to be unchanged, but got %v", aliases[ColumnStockID])
+	}
+	if invalidErr == nil {
+		t.Error("Expected an error for an unknown column, but got nil")
+	}
+}
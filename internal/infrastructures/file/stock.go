@@ -0,0 +1,141 @@
+package file
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 銘柄マスタのTSVの最小列数（銘柄コード, 銘柄名）
+const minStockTSVFieldCount = 2
+
+// 銘柄マスタのTSVの最大列数（銘柄コード, 銘柄名, 市場, 業種, 通貨）
+const maxStockTSVFieldCount = 5
+
+// ReadStocksFromTSV は指定されたTSVファイルから銘柄マスタを読み込みます。
+// TSVファイルの各行は「銘柄コード\t銘柄名\t市場\t業種\t通貨」の形式で、
+// 市場・業種・通貨は省略できます。通貨を省略した場合は models.DefaultCurrency とします。
+// 1列目が銘柄コードの列名（stock_id, 銘柄コード など）の行はヘッダー行としてスキップします。
+//
+// 引数:
+//   - filePath: 読み込むTSVファイルのパス
+//
+// 戻り値:
+//   - 銘柄マスタの配列
+//   - エラー（ファイル読み込みや解析に失敗した場合）
+func ReadStocksFromTSV(filePath string) ([]models.Stock, error) {
+	// ファイルを開く
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadStocksFromTSVReader(file)
+}
+
+// ReadStocksFromTSVReader は reader からTSV形式の銘柄マスタを読み込みます。
+// 行の形式は ReadStocksFromTSV と同じです。
+//
+// 引数:
+//   - reader: TSV形式のデータを読み込む Reader
+//
+// 戻り値:
+//   - 銘柄マスタの配列
+//   - エラー（読み込みや解析に失敗した場合）
+func ReadStocksFromTSVReader(reader io.Reader) ([]models.Stock, error) {
+	// 結果を格納するスライス
+	var stocks []models.Stock
+
+	// スキャナーを作成
+	scanner := bufio.NewScanner(reader)
+
+	// 各行を読み込む
+	isFirstLine := true
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := sourceLine{Text: scanner.Text(), Number: lineNumber}
+
+		// 空行をスキップ
+		if len(strings.TrimSpace(line.Text)) == 0 {
+			continue
+		}
+
+		// タブで分割
+		fields := strings.Split(line.Text, "\t")
+
+		// 1行目がヘッダー行の場合はスキップ
+		if isFirstLine {
+			isFirstLine = false
+			if matchesColumnAlias(normalizeHeaderField(fields[0]), DefaultColumnAliases()[ColumnStockID]) {
+				continue
+			}
+		}
+
+		// 行を銘柄マスタに変換
+		stock, err := parseStockFields(fields, line)
+		if err != nil {
+			return nil, err
+		}
+		stocks = append(stocks, stock)
+	}
+
+	// スキャナーのエラーをチェック
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return stocks, nil
+}
+
+// parseStockFields はTSVファイルの1行の各列から銘柄マスタを作成します。
+//
+// 引数:
+//   - fields: 1行の各列の値
+//   - line: エラーに含める行
+//
+// 戻り値:
+//   - 銘柄マスタ
+//   - エラー（列数が不正な場合や銘柄コード・銘柄名が空の場合は InvalidStockFormatError）
+func parseStockFields(fields []string, line sourceLine) (models.Stock, error) {
+	if len(fields) < minStockTSVFieldCount || len(fields) > maxStockTSVFieldCount {
+		return models.Stock{}, &InvalidStockFormatError{Line: line.Text, LineNumber: line.Number}
+	}
+
+	// 省略された列は空文字列として扱う
+	values := make([]string, maxStockTSVFieldCount)
+	for i, field := range fields {
+		values[i] = strings.TrimSpace(field)
+	}
+	stock := models.Stock{
+		StockID:  values[0],
+		Name:     values[1],
+		Market:   values[2],
+		Sector:   values[3],
+		Currency: strings.ToUpper(values[4]),
+	}
+	if stock.StockID == "" || stock.Name == "" {
+		return models.Stock{}, &InvalidStockFormatError{Line: line.Text, LineNumber: line.Number}
+	}
+	if stock.Currency == "" {
+		stock.Currency = models.DefaultCurrency
+	}
+
+	return stock, nil
+}
+
+// InvalidStockFormatError は銘柄マスタのTSVファイルのフォーマットが不正な場合のエラー
+type InvalidStockFormatError struct {
+	Line       string
+	LineNumber int
+}
+
+func (e *InvalidStockFormatError) Error() string {
+	return "invalid stock format: expected " + strconv.Itoa(minStockTSVFieldCount) + " to " + strconv.Itoa(maxStockTSVFieldCount) +
+		" fields (stock ID, name, market, sector, currency) with a non-empty stock ID and name at " + lineDescription(e.LineNumber, e.Line)
+}
@@ -0,0 +1,401 @@
+package file
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 終値のみのTSVの列数（銘柄コード, 日付, 終値）
+const closeOnlyTSVFieldCount = 3
+
+// 四本値と出来高を含むTSVの列数（銘柄コード, 日付, 始値, 高値, 安値, 終値, 出来高）
+const ohlcvTSVFieldCount = 7
+
+// ReadDailyStockBarFromTSV は指定されたTSVファイルから日次四本値を読み込みます。
+// TSVファイルの各行は「銘柄コード\t日付\t始値\t高値\t安値\t終値\t出来高」または
+// 終値のみの「銘柄コード\t日付\t終値」の形式である必要があります。
+// 終値のみの行は始値・高値・安値に終値を、出来高に0を設定します。
+//
+// 引数:
+//   - filePath: 読み込むTSVファイルのパス
+//
+// 戻り値:
+//   - 日次四本値の配列
+//   - エラー（ファイル読み込みや解析に失敗した場合）
+func ReadDailyStockBarFromTSV(filePath string) ([]models.DailyStockBar, error) {
+	// ファイルを開く
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadDailyStockBarFromTSVReader(file, DefaultTSVOptions())
+}
+
+// ReadDailyStockBarFromTSVReader は reader からTSV形式の日次四本値を読み込みます。
+// 行の形式は ReadDailyStockBarFromTSV と同じです。
+// options.ColumnAliases が設定されていて1行目がヘッダー行の場合は、列名で列を割り当てます。
+// この場合、ヘッダー行に存在しない始値・高値・安値は終値で補い、出来高は0とします。
+//
+// 引数:
+//   - reader: TSV形式のデータを読み込む Reader
+//   - options: TSVファイルの読み込み設定
+//
+// 戻り値:
+//   - 日次四本値の配列
+//   - エラー（読み込みや解析に失敗した場合）
+func ReadDailyStockBarFromTSVReader(reader io.Reader, options TSVOptions) ([]models.DailyStockBar, error) {
+	// 結果を格納するスライス
+	var dailyBars []models.DailyStockBar
+
+	// スキャナーを作成
+	scanner := bufio.NewScanner(reader)
+
+	// 各行を読み込む
+	var columns headerColumns
+	isFirstLine := true
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := sourceLine{Text: scanner.Text(), Number: lineNumber}
+
+		// 空行をスキップ
+		if len(strings.TrimSpace(line.Text)) == 0 {
+			continue
+		}
+
+		// タブで分割
+		fields := strings.Split(line.Text, "\t")
+
+		// 1行目がヘッダー行の場合は列を割り当ててスキップ
+		if isFirstLine {
+			isFirstLine = false
+			if options.ColumnAliases != nil {
+				detectedColumns, isHeader, err := detectHeaderColumns(fields, options.ColumnAliases, line)
+				if err != nil {
+					return nil, err
+				}
+				if isHeader {
+					columns = detectedColumns
+					continue
+				}
+			}
+		}
+
+		var dailyBar models.DailyStockBar
+		var err error
+		switch {
+		case columns != nil:
+			dailyBar, err = parseDailyStockBarTSVColumns(fields, columns, options.DateParser, line)
+		case len(fields) == closeOnlyTSVFieldCount:
+			dailyBar, err = parseDailyStockBarFields(fields[0], fields[1], "", "", "", fields[2], "", options.DateParser, line)
+		case len(fields) == ohlcvTSVFieldCount:
+			dailyBar, err = parseDailyStockBarFields(fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6], options.DateParser, line)
+		default:
+			err = &InvalidStockBarFormatError{Line: line.Text, LineNumber: line.Number}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// 結果に追加
+		dailyBars = append(dailyBars, dailyBar)
+	}
+
+	// スキャナーのエラーをチェック
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return dailyBars, nil
+}
+
+// parseDailyStockBarTSVColumns はヘッダー行で割り当てた列番号に従って、
+// TSVファイルの1行を日次四本値に変換します。
+//
+// 引数:
+//   - fields: タブで分割した行の各列の値
+//   - columns: ヘッダー行から割り当てた列番号
+//   - dateParser: 日付の解析に使用するパーサー（nil の場合は DefaultDateParser を使用）
+//   - line: エラーに含める行
+//
+// 戻り値:
+//   - 日次四本値
+//   - エラー（行のフォーマットが不正な場合）
+func parseDailyStockBarTSVColumns(fields []string, columns headerColumns, dateParser *DateParser, line sourceLine) (models.DailyStockBar, error) {
+	// ヘッダー行で割り当てた列が全て含まれていることを確認
+	requiredFields := 0
+	for _, index := range columns {
+		requiredFields = max(requiredFields, index+1)
+	}
+	if len(fields) < requiredFields {
+		return models.DailyStockBar{}, &InvalidTSVFormatError{Line: line.Text, LineNumber: line.Number, RequiredFields: requiredFields}
+	}
+
+	return parseDailyStockBarFields(
+		fields[columns.index(ColumnStockID)],
+		fields[columns.index(ColumnDate)],
+		fieldOrEmpty(fields, columns.index(ColumnOpen)),
+		fieldOrEmpty(fields, columns.index(ColumnHigh)),
+		fieldOrEmpty(fields, columns.index(ColumnLow)),
+		fields[columns.index(ColumnPrice)],
+		fieldOrEmpty(fields, columns.index(ColumnVolume)),
+		dateParser,
+		line,
+	)
+}
+
+// ReadDailyStockBarFromCSV は指定されたCSVファイルから日次四本値を読み込みます。
+// options.PriceColumn を終値の列として扱います。
+// 始値・高値・安値の列は3つ全てを指定するか、全て NoColumn にする必要があります。
+// NoColumn の場合は終値で補い、出来高の列が NoColumn の場合は出来高を0とします。
+//
+// 引数:
+//   - filePath: 読み込むCSVファイルのパス
+//   - options: CSVファイルの読み込み設定
+//
+// 戻り値:
+//   - 日次四本値の配列
+//   - エラー（ファイル読み込みや解析に失敗した場合）
+func ReadDailyStockBarFromCSV(filePath string, options CSVOptions) ([]models.DailyStockBar, error) {
+	// ファイルを開く
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadDailyStockBarFromCSVReader(file, options)
+}
+
+// ReadDailyStockBarFromCSVReader は reader からCSV形式の日次四本値を読み込みます。
+// 列の割り当ては ReadDailyStockBarFromCSV と同じです。
+//
+// 引数:
+//   - reader: CSV形式のデータを読み込む Reader
+//   - options: CSVファイルの読み込み設定
+//
+// 戻り値:
+//   - 日次四本値の配列
+//   - エラー（読み込みや解析に失敗した場合）
+func ReadDailyStockBarFromCSVReader(reader io.Reader, options CSVOptions) ([]models.DailyStockBar, error) {
+	// 結果を格納するスライス
+	var dailyBars []models.DailyStockBar
+
+	// 各レコードを日次四本値に変換
+	for record, err := range csvRecords(reader, &options, requiredCSVBarFields) {
+		if err != nil {
+			return nil, err
+		}
+
+		dailyBar, err := parseDailyStockBarFields(
+			record.Fields[options.StockIDColumn],
+			record.Fields[options.DateColumn],
+			fieldOrEmpty(record.Fields, options.OpenColumn),
+			fieldOrEmpty(record.Fields, options.HighColumn),
+			fieldOrEmpty(record.Fields, options.LowColumn),
+			record.Fields[options.PriceColumn],
+			fieldOrEmpty(record.Fields, options.VolumeColumn),
+			options.DateParser,
+			record.sourceLine(),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// 結果に追加
+		dailyBars = append(dailyBars, dailyBar)
+	}
+
+	return dailyBars, nil
+}
+
+// parseDailyStockBarFields は各項目の文字列から日次四本値を作成します。
+// 始値・高値・安値が空文字列の場合は終値を、出来高が空文字列の場合は0を設定します。
+//
+// 引数:
+//   - stockIDField: 銘柄コード文字列
+//   - dateField: 日付文字列
+//   - openField: 始値文字列
+//   - highField: 高値文字列
+//   - lowField: 安値文字列
+//   - closeField: 終値文字列
+//   - volumeField: 出来高文字列
+//   - dateParser: 日付の解析に使用するパーサー（nil の場合は DefaultDateParser を使用）
+//   - line: エラーに含める行
+//
+// 戻り値:
+//   - 日次四本値
+//   - エラー（いずれかの項目のフォーマットが不正な場合）
+func parseDailyStockBarFields(stockIDField, dateField, openField, highField, lowField, closeField, volumeField string, dateParser *DateParser, line sourceLine) (models.DailyStockBar, error) {
+	// 日付を解析
+	priceDate, err := parseDateField(dateField, dateParser, line)
+	if err != nil {
+		return models.DailyStockBar{}, err
+	}
+
+	// 終値を解析
+	closePrice, err := parsePriceField(closeField, ColumnClose, line)
+	if err != nil {
+		return models.DailyStockBar{}, err
+	}
+
+	// 始値・高値・安値を解析（存在しない場合は終値で補う）
+	openPrice, err := parseOptionalPriceField(openField, ColumnOpen, closePrice, line)
+	if err != nil {
+		return models.DailyStockBar{}, err
+	}
+	highPrice, err := parseOptionalPriceField(highField, ColumnHigh, closePrice, line)
+	if err != nil {
+		return models.DailyStockBar{}, err
+	}
+	lowPrice, err := parseOptionalPriceField(lowField, ColumnLow, closePrice, line)
+	if err != nil {
+		return models.DailyStockBar{}, err
+	}
+
+	// 出来高を解析（存在しない場合は0）
+	var volume int64
+	volumeStr := strings.TrimSpace(volumeField)
+	if volumeStr != "" {
+		volume, err = strconv.ParseInt(volumeStr, 10, 64)
+		if err != nil {
+			return models.DailyStockBar{}, &InvalidVolumeFormatError{VolumeStr: volumeStr, Line: line.Text, LineNumber: line.Number}
+		}
+	}
+
+	return models.DailyStockBar{
+		PriceDate: priceDate,
+		StockID:   strings.TrimSpace(stockIDField),
+		Open:      openPrice,
+		High:      highPrice,
+		Low:       lowPrice,
+		Close:     closePrice,
+		Volume:    volume,
+	}, nil
+}
+
+// parseOptionalPriceField は株価文字列を解析します。
+// 空文字列の場合は defaultPrice を返します。
+//
+// 引数:
+//   - priceField: 株価文字列（前後の空白は無視される）
+//   - column: エラーに含める列名
+//   - defaultPrice: 空文字列の場合に返す株価
+//   - line: エラーに含める行
+//
+// 戻り値:
+//   - 株価
+//   - エラー（株価のフォーマットが不正な場合は InvalidPriceFormatError）
+func parseOptionalPriceField(priceField string, column string, defaultPrice models.Price, line sourceLine) (models.Price, error) {
+	if strings.TrimSpace(priceField) == "" {
+		return defaultPrice, nil
+	}
+	return parsePriceField(priceField, column, line)
+}
+
+// fieldOrEmpty はレコードの指定された列の値を返します。
+// 列番号が NoColumn の場合は空文字列を返します。
+//
+// 引数:
+//   - record: レコード
+//   - column: 列番号
+//
+// 戻り値:
+//   - 列の値
+func fieldOrEmpty(record []string, column int) string {
+	if column == NoColumn {
+		return ""
+	}
+	return record[column]
+}
+
+// requiredCSVBarFields は日次四本値を読み込むための列の割り当てを検証し、
+// 割り当てられた列を全て含むために必要な列数を返します。
+//
+// 引数:
+//   - options: CSVファイルの読み込み設定
+//
+// 戻り値:
+//   - 1レコードに必要な列数
+//   - エラー（設定が不正な場合）
+func requiredCSVBarFields(options CSVOptions) (int, error) {
+	if err := validateCSVOptions(options); err != nil {
+		return 0, err
+	}
+	if err := validateCSVBarColumns(options); err != nil {
+		return 0, err
+	}
+	return max(options.StockIDColumn, options.DateColumn, options.PriceColumn,
+		options.OpenColumn, options.HighColumn, options.LowColumn, options.VolumeColumn) + 1, nil
+}
+
+// validateCSVBarColumns は四本値の列の割り当てが有効かどうかを検証します。
+//
+// 引数:
+//   - options: 検証するCSVファイルの読み込み設定
+//
+// 戻り値:
+//   - エラー（設定が不正な場合）
+func validateCSVBarColumns(options CSVOptions) error {
+	ohlColumns := []int{options.OpenColumn, options.HighColumn, options.LowColumn}
+	assignedOHLColumnCount := 0
+	for _, column := range append(ohlColumns, options.VolumeColumn) {
+		if column < NoColumn {
+			return errors.New("invalid CSV column mapping: column numbers must not be negative")
+		}
+	}
+	for _, column := range ohlColumns {
+		if column != NoColumn {
+			assignedOHLColumnCount++
+		}
+	}
+	if assignedOHLColumnCount != 0 && assignedOHLColumnCount != len(ohlColumns) {
+		return errors.New("invalid CSV column mapping: open, high and low columns must be set together")
+	}
+
+	// 割り当てられた列が重複していないことを確認
+	assignedColumns := map[int]bool{
+		options.StockIDColumn: true,
+		options.DateColumn:    true,
+		options.PriceColumn:   true,
+	}
+	for _, column := range append(ohlColumns, options.VolumeColumn) {
+		if column == NoColumn {
+			continue
+		}
+		if assignedColumns[column] {
+			return errors.New("invalid CSV column mapping: columns must be distinct")
+		}
+		assignedColumns[column] = true
+	}
+	return nil
+}
+
+// InvalidStockBarFormatError は四本値TSVファイルのフォーマットが不正な場合のエラー
+type InvalidStockBarFormatError struct {
+	Line       string
+	LineNumber int
+}
+
+func (e *InvalidStockBarFormatError) Error() string {
+	return "invalid TSV format: expected 3 or 7 fields separated by tabs at " + lineDescription(e.LineNumber, e.Line)
+}
+
+// InvalidVolumeFormatError は出来高のフォーマットが不正な場合のエラー
+type InvalidVolumeFormatError struct {
+	VolumeStr  string
+	Line       string
+	LineNumber int
+}
+
+func (e *InvalidVolumeFormatError) Error() string {
+	return "invalid volume format: " + e.VolumeStr + " in " + lineDescription(e.LineNumber, e.Line)
+}
@@ -0,0 +1,109 @@
+package file
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+func TestReadDailyStockBarFromTSV(t *testing.T) {
+	// Arrange
+	content := "7203\t2025/2/4\t2850\t2890\t2840\t2873\t15000000\n" +
+		"7203\t2025/2/5\t2903.5\n"
+	filePath := testutil.WriteTempFile(t, "bars.tsv", content)
+	expected := []models.DailyStockBar{
+		{
+			PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
+			StockID:   "7203",
+			Open:      models.NewPrice(2850, 0),
+			High:      models.NewPrice(2890, 0),
+			Low:       models.NewPrice(2840, 0),
+			Close:     models.NewPrice(2873, 0),
+			Volume:    15000000,
+		},
+		{
+			PriceDate: time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC),
+			StockID:   "7203",
+			Open:      models.NewPrice(29035, 1),
+			High:      models.NewPrice(29035, 1),
+			Low:       models.NewPrice(29035, 1),
+			Close:     models.NewPrice(29035, 1),
+			Volume:    0,
+		},
+	}
+
+	// Act
+	dailyBars, err := ReadDailyStockBarFromTSV(filePath)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !reflect.DeepEqual(dailyBars, expected) {
+		t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", expected, dailyBars)
+	}
+}
+
+func TestReadDailyStockBarFromCSV(t *testing.T) {
+	// Arrange
+	content := "date,code,open,high,low,close,volume\n" +
+		"2025/2/4,7203,2850,2890,2840,2873,15000000\n"
+	filePath := testutil.WriteTempFile(t, "bars.csv", content)
+	options := CSVOptions{
+		Delimiter:     ',',
+		StockIDColumn: 1,
+		DateColumn:    0,
+		PriceColumn:   5,
+		OpenColumn:    2,
+		HighColumn:    3,
+		LowColumn:     4,
+		VolumeColumn:  6,
+		SkipHeader:    true,
+	}
+	expected := []models.DailyStockBar{
+		{
+			PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
+			StockID:   "7203",
+			Open:      models.NewPrice(2850, 0),
+			High:      models.NewPrice(2890, 0),
+			Low:       models.NewPrice(2840, 0),
+			Close:     models.NewPrice(2873, 0),
+			Volume:    15000000,
+		},
+	}
+
+	// Act
+	dailyBars, err := ReadDailyStockBarFromCSV(filePath, options)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !reflect.DeepEqual(dailyBars, expected) {
+		t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", expected, dailyBars)
+	}
+}
+
+func TestReadDailyStockBarFromCSV_CloseOnly(t *testing.T) {
+	// Arrange
+	content := "7203,2025/2/4,2873\n"
+	filePath := testutil.WriteTempFile(t, "bars.csv", content)
+
+	// Act
+	dailyBars, err := ReadDailyStockBarFromCSV(filePath, DefaultCSVOptions())
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(dailyBars) != 1 {
+		t.Fatalf("Expected 1 bar, but got %d", len(dailyBars))
+	}
+	bar := dailyBars[0]
+	if bar.Open != models.NewPrice(2873, 0) || bar.High != models.NewPrice(2873, 0) || bar.Low != models.NewPrice(2873, 0) || bar.Close != models.NewPrice(2873, 0) || bar.Volume != 0 {
+		t.Errorf("Expected close-only bar filled with close price, but got %+v", bar)
+	}
+}
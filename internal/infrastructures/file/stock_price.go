@@ -2,6 +2,8 @@ package file
 
 import (
 	"bufio"
+	"io"
+	"iter"
 	"os"
 	"strconv"
 	"strings"
@@ -10,11 +12,37 @@ import (
 	"github.com/toriwasa/sqlite-playground/internal/domain/models"
 )
 
-// 日付フォーマット定数
-const dateFormat = "2006/1/2"
+// TSVファイルの読み込み設定を示す構造体
+type TSVOptions struct {
+	// 日付の解析に使用するパーサー（nil の場合は DefaultDateParser を使用）
+	DateParser *DateParser
+	// ヘッダー行の判定に使用する列名の別名（nil の場合はヘッダー行を判定せず、1行目からデータとして読み込む）
+	ColumnAliases ColumnAliases
+}
+
+// DefaultTSVOptions はTSVファイルを読み込むためのデフォルト設定を返します。
+//
+// 戻り値:
+//   - TSVファイルの読み込み設定
+func DefaultTSVOptions() TSVOptions {
+	return TSVOptions{DateParser: DefaultDateParser(), ColumnAliases: DefaultColumnAliases()}
+}
+
+// エラーや検証結果で使用する列名
+const (
+	ColumnStockID = "stock_id"
+	ColumnDate    = "date"
+	ColumnPrice   = "price"
+	ColumnOpen    = "open"
+	ColumnHigh    = "high"
+	ColumnLow     = "low"
+	ColumnClose   = "close"
+	ColumnVolume  = "volume"
+)
 
 // ReadDailyStockPriceFromTSV は指定されたTSVファイルから日次株価情報を読み込みます。
 // TSVファイルは「銘柄コード\t日付\t株価」の形式である必要があります。
+// 全ての行をメモリ上に保持するため、大きなファイルには StreamDailyStockPriceFromTSV を使用してください。
 //
 // 引数:
 //   - filePath: 読み込むTSVファイルのパス
@@ -23,97 +51,303 @@ const dateFormat = "2006/1/2"
 //   - 日次株価情報の配列
 //   - エラー（ファイル読み込みや解析に失敗した場合）
 func ReadDailyStockPriceFromTSV(filePath string) ([]models.DailyStockPrice, error) {
-	// ファイルを開く
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
+	return collectDailyStockPrices(StreamDailyStockPriceFromTSV(filePath))
+}
+
+// StreamDailyStockPriceFromTSV は指定されたTSVファイルから日次株価情報を1行ずつ読み込む
+// イテレータを返します。ファイルはイテレーションの開始時に開かれ、終了時に閉じられます。
+// 解析できない行ではエラーを返し、呼び出し元がイテレーションを続けた場合は次の行から読み込みを再開します。
+// ファイルの読み込み自体に失敗した場合はエラーを返してイテレーションを終了します。
+//
+// 引数:
+//   - filePath: 読み込むTSVファイルのパス
+//
+// 戻り値:
+//   - 日次株価情報とエラーの組を返すイテレータ
+func StreamDailyStockPriceFromTSV(filePath string) iter.Seq2[models.DailyStockPrice, error] {
+	return func(yield func(models.DailyStockPrice, error) bool) {
+		// ファイルを開く
+		file, err := os.Open(filePath)
+		if err != nil {
+			yield(models.DailyStockPrice{}, err)
+			return
+		}
+		defer file.Close()
+
+		for dailyPrice, err := range StreamDailyStockPriceFromTSVReader(file, DefaultTSVOptions()) {
+			if !yield(dailyPrice, err) {
+				return
+			}
+		}
 	}
-	defer file.Close()
+}
 
-	// 結果を格納するスライス
-	var dailyPrices []models.DailyStockPrice
+// StreamDailyStockPriceFromTSVReader は reader から「銘柄コード\t日付\t株価」形式の
+// 日次株価情報を1行ずつ読み込むイテレータを返します。
+// options.ColumnAliases が設定されていて1行目がヘッダー行の場合は、列名で列を割り当てます。
+// この場合、列の順序は問わず、割り当てられていない列は無視されます。
+// エラーの扱いは StreamDailyStockPriceFromTSV と同じです。
+// ヘッダー行に必要な列が不足している場合はエラーを返してイテレーションを終了します。
+//
+// 引数:
+//   - reader: TSV形式のデータを読み込む Reader
+//   - options: TSVファイルの読み込み設定
+//
+// 戻り値:
+//   - 日次株価情報とエラーの組を返すイテレータ
+func StreamDailyStockPriceFromTSVReader(reader io.Reader, options TSVOptions) iter.Seq2[models.DailyStockPrice, error] {
+	return func(yield func(models.DailyStockPrice, error) bool) {
+		// スキャナーを作成
+		scanner := bufio.NewScanner(reader)
 
-	// スキャナーを作成
-	scanner := bufio.NewScanner(file)
+		// 各行を読み込む
+		var columns headerColumns
+		isFirstLine := true
+		lineNumber := 0
+		for scanner.Scan() {
+			lineNumber++
+			line := sourceLine{Text: scanner.Text(), Number: lineNumber}
 
-	// 各行を読み込む
-	for scanner.Scan() {
-		line := scanner.Text()
+			// 空行をスキップ
+			if len(strings.TrimSpace(line.Text)) == 0 {
+				continue
+			}
 
-		// 空行をスキップ
-		if len(strings.TrimSpace(line)) == 0 {
-			continue
+			// 1行目がヘッダー行の場合は列を割り当ててスキップ
+			if isFirstLine {
+				isFirstLine = false
+				if options.ColumnAliases != nil {
+					detectedColumns, isHeader, err := detectHeaderColumns(strings.Split(line.Text, "\t"), options.ColumnAliases, line)
+					if err != nil {
+						yield(models.DailyStockPrice{}, err)
+						return
+					}
+					if isHeader {
+						columns = detectedColumns
+						continue
+					}
+				}
+			}
+
+			// 行を日次株価情報に変換
+			dailyPrice, err := parseDailyStockPriceTSVLine(line, columns, options.DateParser)
+			if !yield(dailyPrice, err) {
+				return
+			}
 		}
 
-		// タブで分割
-		fields := strings.Split(line, "\t")
+		// スキャナーのエラーをチェック
+		if err := scanner.Err(); err != nil {
+			yield(models.DailyStockPrice{}, err)
+		}
+	}
+}
+
+// parseDailyStockPriceTSVLine はTSVファイルの1行を日次株価情報に変換します。
+// columns が nil の場合は「銘柄コード\t日付\t株価」形式の行として扱います。
+//
+// 引数:
+//   - line: TSVファイルの1行
+//   - columns: ヘッダー行から割り当てた列番号（ヘッダー行がない場合は nil）
+//   - dateParser: 日付の解析に使用するパーサー（nil の場合は DefaultDateParser を使用）
+//
+// 戻り値:
+//   - 日次株価情報
+//   - エラー（行のフォーマットが不正な場合）
+func parseDailyStockPriceTSVLine(line sourceLine, columns headerColumns, dateParser *DateParser) (models.DailyStockPrice, error) {
+	// タブで分割
+	fields := strings.Split(line.Text, "\t")
+	if columns == nil {
 		if len(fields) != 3 {
-			return nil, &InvalidTSVFormatError{Line: line}
+			return models.DailyStockPrice{}, &InvalidTSVFormatError{Line: line.Text, LineNumber: line.Number}
 		}
+		return parseDailyStockPriceFields(fields[0], fields[1], fields[2], dateParser, line)
+	}
 
-		// 銘柄コード
-		stockID := strings.TrimSpace(fields[0])
+	// ヘッダー行で割り当てた列が全て含まれていることを確認
+	stockIDColumn, dateColumn, priceColumn := columns.index(ColumnStockID), columns.index(ColumnDate), columns.index(ColumnPrice)
+	requiredFields := max(stockIDColumn, dateColumn, priceColumn) + 1
+	if len(fields) < requiredFields {
+		return models.DailyStockPrice{}, &InvalidTSVFormatError{Line: line.Text, LineNumber: line.Number, RequiredFields: requiredFields}
+	}
+	return parseDailyStockPriceFields(fields[stockIDColumn], fields[dateColumn], fields[priceColumn], dateParser, line)
+}
 
-		// 日付を解析
-		dateStr := strings.TrimSpace(fields[1])
-		priceDate, err := time.Parse(dateFormat, dateStr)
-		if err != nil {
-			return nil, &InvalidDateFormatError{DateStr: dateStr, Line: line}
-		}
+// parseDailyStockPriceFields は各項目の文字列から日次株価情報を作成します。
+//
+// 引数:
+//   - stockIDField: 銘柄コード文字列
+//   - dateField: 日付文字列
+//   - priceField: 株価文字列
+//   - dateParser: 日付の解析に使用するパーサー（nil の場合は DefaultDateParser を使用）
+//   - line: エラーに含める行
+//
+// 戻り値:
+//   - 日次株価情報
+//   - エラー（いずれかの項目のフォーマットが不正な場合）
+func parseDailyStockPriceFields(stockIDField, dateField, priceField string, dateParser *DateParser, line sourceLine) (models.DailyStockPrice, error) {
+	// 銘柄コード
+	stockID := strings.TrimSpace(stockIDField)
 
-		// 株価を解析
-		priceStr := strings.TrimSpace(fields[2])
-		price, err := strconv.ParseFloat(priceStr, 64)
+	// 日付を解析
+	priceDate, err := parseDateField(dateField, dateParser, line)
+	if err != nil {
+		return models.DailyStockPrice{}, err
+	}
+
+	// 株価を解析
+	price, err := parsePriceField(priceField, ColumnPrice, line)
+	if err != nil {
+		return models.DailyStockPrice{}, err
+	}
+
+	// 日次株価情報を作成
+	return models.DailyStockPrice{
+		PriceDate: priceDate,
+		StockPrice: models.StockPrice{
+			StockID: stockID,
+			Price:   price,
+		},
+	}, nil
+}
+
+// collectDailyStockPrices はイテレータから全ての日次株価情報を読み込んで配列にします。
+// 最初に発生したエラーで読み込みを中断します。
+//
+// 引数:
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//
+// 戻り値:
+//   - 日次株価情報の配列
+//   - エラー（イテレータがエラーを返した場合）
+func collectDailyStockPrices(dailyPrices iter.Seq2[models.DailyStockPrice, error]) ([]models.DailyStockPrice, error) {
+	// 結果を格納するスライス
+	var collectedPrices []models.DailyStockPrice
+
+	for dailyPrice, err := range dailyPrices {
 		if err != nil {
-			return nil, &InvalidPriceFormatError{PriceStr: priceStr, Line: line}
+			return nil, err
 		}
+		collectedPrices = append(collectedPrices, dailyPrice)
+	}
 
-		// 日次株価情報を作成
-		dailyPrice := models.DailyStockPrice{
-			PriceDate: priceDate,
-			StockPrice: models.StockPrice{
-				StockID: stockID,
-				Price:   price,
-			},
-		}
+	return collectedPrices, nil
+}
 
-		// 結果に追加
-		dailyPrices = append(dailyPrices, dailyPrice)
+// parseDateField は日付文字列を解析します。
+//
+// 引数:
+//   - dateField: 日付文字列（前後の空白は無視される）
+//   - dateParser: 日付の解析に使用するパーサー（nil の場合は DefaultDateParser を使用）
+//   - line: エラーに含める行
+//
+// 戻り値:
+//   - 日付
+//   - エラー（日付のフォーマットが不正な場合は InvalidDateFormatError）
+func parseDateField(dateField string, dateParser *DateParser, line sourceLine) (time.Time, error) {
+	if dateParser == nil {
+		dateParser = DefaultDateParser()
 	}
 
-	// スキャナーのエラーをチェック
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	dateStr := strings.TrimSpace(dateField)
+	priceDate, err := dateParser.Parse(dateStr)
+	if err != nil {
+		return time.Time{}, &InvalidDateFormatError{
+			DateStr:    dateStr,
+			Layouts:    dateParser.Layouts(),
+			Line:       line.Text,
+			LineNumber: line.Number,
+		}
 	}
+	return priceDate, nil
+}
+
+// parsePriceField は株価文字列を解析します。
+//
+// 引数:
+//   - priceField: 株価文字列（前後の空白は無視される）
+//   - column: エラーに含める列名
+//   - line: エラーに含める行
+//
+// 戻り値:
+//   - 株価
+//   - エラー（株価のフォーマットが不正な場合は InvalidPriceFormatError）
+func parsePriceField(priceField string, column string, line sourceLine) (models.Price, error) {
+	priceStr := strings.TrimSpace(priceField)
+	price, err := models.ParsePrice(priceStr)
+	if err != nil {
+		return models.Price{}, &InvalidPriceFormatError{PriceStr: priceStr, Column: column, Line: line.Text, LineNumber: line.Number}
+	}
+	return price, nil
+}
 
-	return dailyPrices, nil
+// 読み込み元ファイルの1行を示す構造体
+type sourceLine struct {
+	// 行の内容
+	Text string
+	// 行番号（1始まり）
+	Number int
+}
+
+// lineDescription はエラーメッセージに含める行の説明を返します。
+//
+// 引数:
+//   - lineNumber: 行番号（不明な場合は0）
+//   - line: 行の内容
+//
+// 戻り値:
+//   - 行の説明
+func lineDescription(lineNumber int, line string) string {
+	if lineNumber <= 0 {
+		return "line: " + line
+	}
+	return "line " + strconv.Itoa(lineNumber) + ": " + line
 }
 
 // InvalidTSVFormatError はTSVファイルのフォーマットが不正な場合のエラー
 type InvalidTSVFormatError struct {
-	Line string
+	Line       string
+	LineNumber int
+	// ヘッダー行で列を割り当てた場合に必要な列数（0 の場合は「銘柄コード\t日付\t株価」の3列）
+	RequiredFields int
 }
 
 func (e *InvalidTSVFormatError) Error() string {
-	return "invalid TSV format: expected 3 fields separated by tabs: " + e.Line
+	if e.RequiredFields > 0 {
+		return "invalid TSV format: expected at least " + strconv.Itoa(e.RequiredFields) + " fields separated by tabs at " + lineDescription(e.LineNumber, e.Line)
+	}
+	if e.LineNumber <= 0 {
+		return "invalid TSV format: expected 3 fields separated by tabs: " + e.Line
+	}
+	return "invalid TSV format: expected 3 fields separated by tabs at " + lineDescription(e.LineNumber, e.Line)
 }
 
 // InvalidDateFormatError は日付のフォーマットが不正な場合のエラー
 type InvalidDateFormatError struct {
 	DateStr string
-	Line    string
+	// 試したレイアウト
+	Layouts    []string
+	Line       string
+	LineNumber int
 }
 
 func (e *InvalidDateFormatError) Error() string {
-	return "invalid date format: " + e.DateStr + " in line: " + e.Line
+	message := "invalid date format: " + e.DateStr
+	if len(e.Layouts) > 0 {
+		message += " (tried layouts: " + strings.Join(e.Layouts, ", ") + ")"
+	}
+	return message + " in " + lineDescription(e.LineNumber, e.Line)
 }
 
 // InvalidPriceFormatError は株価のフォーマットが不正な場合のエラー
 type InvalidPriceFormatError struct {
 	PriceStr string
-	Line     string
+	// 株価の列名（price, open, high, low, close のいずれか）
+	Column     string
+	Line       string
+	LineNumber int
 }
 
 func (e *InvalidPriceFormatError) Error() string {
-	return "invalid price format: " + e.PriceStr + " in line: " + e.Line
+	return "invalid price format: " + e.PriceStr + " in " + lineDescription(e.LineNumber, e.Line)
 }
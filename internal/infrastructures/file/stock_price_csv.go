@@ -0,0 +1,358 @@
+package file
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"iter"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// CSVのデフォルト区切り文字
+const defaultCSVDelimiter = ','
+
+// CSVのデフォルト列番号（0始まり）
+const (
+	defaultCSVStockIDColumn = 0
+	defaultCSVDateColumn    = 1
+	defaultCSVPriceColumn   = 2
+)
+
+// 列が存在しないことを示す列番号
+const NoColumn = -1
+
+// CSVファイルの読み込み設定を示す構造体
+type CSVOptions struct {
+	// 区切り文字
+	Delimiter rune
+	// 銘柄コードが格納されている列番号（0始まり）
+	StockIDColumn int
+	// 日付が格納されている列番号（0始まり）
+	DateColumn int
+	// 株価（四本値を読み込む場合は終値）が格納されている列番号（0始まり）
+	PriceColumn int
+	// 始値が格納されている列番号（0始まり、四本値を読み込む場合のみ使用、存在しない場合は NoColumn）
+	OpenColumn int
+	// 高値が格納されている列番号（0始まり、四本値を読み込む場合のみ使用、存在しない場合は NoColumn）
+	HighColumn int
+	// 安値が格納されている列番号（0始まり、四本値を読み込む場合のみ使用、存在しない場合は NoColumn）
+	LowColumn int
+	// 出来高が格納されている列番号（0始まり、四本値を読み込む場合のみ使用、存在しない場合は NoColumn）
+	VolumeColumn int
+	// 先頭行をヘッダー行として読み飛ばすかどうか
+	SkipHeader bool
+	// 日付の解析に使用するパーサー（nil の場合は DefaultDateParser を使用）
+	DateParser *DateParser
+	// ヘッダー行の判定に使用する列名の別名（nil の場合はヘッダー行を判定しない）
+	// 1行目がヘッダー行と判定された場合は、列番号の設定より列名による割り当てを優先します。
+	ColumnAliases ColumnAliases
+}
+
+// DefaultCSVOptions は「銘柄コード,日付,株価」形式のCSVを読み込むための
+// デフォルト設定を返します。
+//
+// 戻り値:
+//   - CSVファイルの読み込み設定
+func DefaultCSVOptions() CSVOptions {
+	return CSVOptions{
+		Delimiter:     defaultCSVDelimiter,
+		StockIDColumn: defaultCSVStockIDColumn,
+		DateColumn:    defaultCSVDateColumn,
+		PriceColumn:   defaultCSVPriceColumn,
+		OpenColumn:    NoColumn,
+		HighColumn:    NoColumn,
+		LowColumn:     NoColumn,
+		VolumeColumn:  NoColumn,
+		DateParser:    DefaultDateParser(),
+		ColumnAliases: DefaultColumnAliases(),
+	}
+}
+
+// ReadDailyStockPriceFromCSV は指定されたCSVファイルから日次株価情報を読み込みます。
+// RFC 4180 形式のクォートに対応し、区切り文字と列の割り当ては options で指定します。
+// 割り当てられていない列は無視されます。
+//
+// 引数:
+//   - filePath: 読み込むCSVファイルのパス
+//   - options: CSVファイルの読み込み設定
+//
+// 戻り値:
+//   - 日次株価情報の配列
+//   - エラー（ファイル読み込みや解析に失敗した場合）
+func ReadDailyStockPriceFromCSV(filePath string, options CSVOptions) ([]models.DailyStockPrice, error) {
+	return collectDailyStockPrices(StreamDailyStockPriceFromCSV(filePath, options))
+}
+
+// StreamDailyStockPriceFromCSV は指定されたCSVファイルから日次株価情報を1レコードずつ読み込む
+// イテレータを返します。エラーの扱いは StreamDailyStockPriceFromTSV と同じです。
+//
+// 引数:
+//   - filePath: 読み込むCSVファイルのパス
+//   - options: CSVファイルの読み込み設定
+//
+// 戻り値:
+//   - 日次株価情報とエラーの組を返すイテレータ
+func StreamDailyStockPriceFromCSV(filePath string, options CSVOptions) iter.Seq2[models.DailyStockPrice, error] {
+	return func(yield func(models.DailyStockPrice, error) bool) {
+		// ファイルを開く
+		file, err := os.Open(filePath)
+		if err != nil {
+			yield(models.DailyStockPrice{}, err)
+			return
+		}
+		defer file.Close()
+
+		for dailyPrice, err := range StreamDailyStockPriceFromCSVReader(file, options) {
+			if !yield(dailyPrice, err) {
+				return
+			}
+		}
+	}
+}
+
+// StreamDailyStockPriceFromCSVReader は reader からCSV形式の日次株価情報を
+// 1レコードずつ読み込むイテレータを返します。
+// options.ColumnAliases が設定されていて1行目がヘッダー行の場合は、列名で列を割り当てます。
+// 読み込み設定やヘッダー行が不正な場合はエラーを1つ返してイテレーションを終了します。
+//
+// 引数:
+//   - reader: CSV形式のデータを読み込む Reader
+//   - options: CSVファイルの読み込み設定
+//
+// 戻り値:
+//   - 日次株価情報とエラーの組を返すイテレータ
+func StreamDailyStockPriceFromCSVReader(reader io.Reader, options CSVOptions) iter.Seq2[models.DailyStockPrice, error] {
+	return func(yield func(models.DailyStockPrice, error) bool) {
+		// 各レコードを日次株価情報に変換
+		for record, err := range csvRecords(reader, &options, requiredCSVPriceFields) {
+			if err != nil {
+				if !yield(models.DailyStockPrice{}, err) {
+					return
+				}
+				continue
+			}
+
+			dailyPrice, err := parseDailyStockPriceCSVRecord(record, options)
+			if !yield(dailyPrice, err) {
+				return
+			}
+		}
+	}
+}
+
+// parseDailyStockPriceCSVRecord はCSVレコードを日次株価情報に変換します。
+//
+// 引数:
+//   - record: CSVレコード
+//   - options: CSVファイルの読み込み設定
+//
+// 戻り値:
+//   - 日次株価情報
+//   - エラー（レコードのフォーマットが不正な場合）
+func parseDailyStockPriceCSVRecord(record csvRecord, options CSVOptions) (models.DailyStockPrice, error) {
+	return parseDailyStockPriceFields(
+		record.Fields[options.StockIDColumn],
+		record.Fields[options.DateColumn],
+		record.Fields[options.PriceColumn],
+		options.DateParser,
+		record.sourceLine(),
+	)
+}
+
+// CSVファイルの1レコードを示す構造体
+type csvRecord struct {
+	// 各列の値
+	Fields []string
+	// 区切り文字で連結した行文字列（エラーメッセージ用）
+	Line string
+	// レコードの開始行番号（1始まり）
+	LineNumber int
+}
+
+// sourceLine はレコードを読み込み元ファイルの行として返します。
+//
+// 戻り値:
+//   - 読み込み元ファイルの行
+func (r csvRecord) sourceLine() sourceLine {
+	return sourceLine{Text: r.Line, Number: r.LineNumber}
+}
+
+// csvRecords は reader から空行とヘッダー行を除いたCSVレコードを順番に返すイテレータを作成します。
+// 1行目がヘッダー行と判定された場合は、options の列番号をヘッダー行の列名に合わせて書き換えます。
+// 列数が不足しているレコードや、クォートが不正なレコードではエラーを返し、
+// 呼び出し元がイテレーションを続けた場合は次のレコードから読み込みを再開します。
+// 列の割り当てやヘッダー行が不正な場合はエラーを返してイテレーションを終了します。
+//
+// 引数:
+//   - reader: CSV形式のデータを読み込む Reader
+//   - options: CSVファイルの読み込み設定（ヘッダー行に合わせて書き換えられる）
+//   - requiredFields: 列の割り当てを検証し、1レコードに必要な列数を返す関数
+//
+// 戻り値:
+//   - CSVレコードとエラーの組を返すイテレータ
+func csvRecords(reader io.Reader, options *CSVOptions, requiredFields func(CSVOptions) (int, error)) iter.Seq2[csvRecord, error] {
+	return func(yield func(csvRecord, error) bool) {
+		// 列の割り当てを検証
+		requiredFieldCount, err := requiredFields(*options)
+		if err != nil {
+			yield(csvRecord{}, err)
+			return
+		}
+
+		// CSVリーダーを作成
+		csvReader := csv.NewReader(reader)
+		csvReader.Comma = options.Delimiter
+		// 列数はレコードごとに異なってもよい
+		csvReader.FieldsPerRecord = -1
+
+		isFirstRecord := true
+		for {
+			fields, err := csvReader.Read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				// クォートの不正などレコード単位のエラーは次のレコードから再開できる
+				var parseErr *csv.ParseError
+				if errors.As(err, &parseErr) {
+					isFirstRecord = false
+					if !yield(csvRecord{}, err) {
+						return
+					}
+					continue
+				}
+				yield(csvRecord{}, err)
+				return
+			}
+
+			// 空行をスキップ
+			if isBlankRecord(fields) {
+				continue
+			}
+
+			// 行番号を取得
+			lineNumber, _ := csvReader.FieldPos(0)
+			record := csvRecord{
+				Fields:     fields,
+				Line:       strings.Join(fields, string(options.Delimiter)),
+				LineNumber: lineNumber,
+			}
+
+			// ヘッダー行を判定してスキップ
+			if isFirstRecord {
+				isFirstRecord = false
+				if options.ColumnAliases != nil {
+					columns, isHeader, err := detectHeaderColumns(fields, options.ColumnAliases, record.sourceLine())
+					if err == nil && isHeader {
+						applyHeaderColumns(options, columns)
+						requiredFieldCount, err = requiredFields(*options)
+					}
+					if err != nil {
+						yield(csvRecord{}, err)
+						return
+					}
+					if isHeader {
+						continue
+					}
+				}
+				if options.SkipHeader {
+					continue
+				}
+			}
+
+			// 列数を確認
+			if len(fields) < requiredFieldCount {
+				err = &InvalidCSVFormatError{LineNumber: lineNumber, RequiredFields: requiredFieldCount, Line: record.Line}
+			}
+			if !yield(record, err) {
+				return
+			}
+		}
+	}
+}
+
+// validateCSVOptions はCSVファイルの読み込み設定が有効かどうかを検証します。
+//
+// 引数:
+//   - options: 検証するCSVファイルの読み込み設定
+//
+// 戻り値:
+//   - エラー（設定が不正な場合）
+func validateCSVOptions(options CSVOptions) error {
+	if options.Delimiter == 0 || options.Delimiter == '"' || options.Delimiter == '\r' || options.Delimiter == '\n' {
+		return errors.New("invalid CSV delimiter: " + strconv.QuoteRune(options.Delimiter))
+	}
+	if options.StockIDColumn < 0 || options.DateColumn < 0 || options.PriceColumn < 0 {
+		return errors.New("invalid CSV column mapping: column numbers must not be negative")
+	}
+	if options.StockIDColumn == options.DateColumn ||
+		options.StockIDColumn == options.PriceColumn ||
+		options.DateColumn == options.PriceColumn {
+		return errors.New("invalid CSV column mapping: columns must be distinct")
+	}
+	return nil
+}
+
+// requiredCSVPriceFields は日次株価情報を読み込むための列の割り当てを検証し、
+// 割り当てられた列を全て含むために必要な列数を返します。
+//
+// 引数:
+//   - options: CSVファイルの読み込み設定
+//
+// 戻り値:
+//   - 1レコードに必要な列数
+//   - エラー（設定が不正な場合）
+func requiredCSVPriceFields(options CSVOptions) (int, error) {
+	if err := validateCSVOptions(options); err != nil {
+		return 0, err
+	}
+	return max(options.StockIDColumn, options.DateColumn, options.PriceColumn) + 1, nil
+}
+
+// applyHeaderColumns はヘッダー行から割り当てた列番号を読み込み設定に反映します。
+// ヘッダー行に存在しない四本値と出来高の列は NoColumn にします。
+//
+// 引数:
+//   - options: 書き換えるCSVファイルの読み込み設定
+//   - columns: ヘッダー行から割り当てた列番号
+func applyHeaderColumns(options *CSVOptions, columns headerColumns) {
+	options.StockIDColumn = columns.index(ColumnStockID)
+	options.DateColumn = columns.index(ColumnDate)
+	options.PriceColumn = columns.index(ColumnPrice)
+	options.OpenColumn = columns.index(ColumnOpen)
+	options.HighColumn = columns.index(ColumnHigh)
+	options.LowColumn = columns.index(ColumnLow)
+	options.VolumeColumn = columns.index(ColumnVolume)
+}
+
+// isBlankRecord はレコードの全ての列が空白のみかどうかを判定します。
+//
+// 引数:
+//   - record: 判定するレコード
+//
+// 戻り値:
+//   - 全ての列が空白のみの場合は true
+func isBlankRecord(record []string) bool {
+	for _, field := range record {
+		if len(strings.TrimSpace(field)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// InvalidCSVFormatError はCSVファイルのレコードの列数が不足している場合のエラー
+type InvalidCSVFormatError struct {
+	LineNumber     int
+	RequiredFields int
+	Line           string
+}
+
+func (e *InvalidCSVFormatError) Error() string {
+	return "invalid CSV format: expected at least " + strconv.Itoa(e.RequiredFields) +
+		" fields at line " + strconv.Itoa(e.LineNumber) + ": " + e.Line
+}
@@ -0,0 +1,88 @@
+package file
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+func TestReadDailyStockPriceFromCSV(t *testing.T) {
+	// Arrange
+	content := "code,name,date,close,volume\n" +
+		"7203,\"Toyota Motor, Corp.\",2025/2/4,2873,1000\n" +
+		"\"7203\",\"Toyota \"\"TM\"\"\",2025/2/5,\"2903.5\",2000\n"
+	filePath := testutil.WriteTempFile(t, "prices.csv", content)
+	options := CSVOptions{
+		Delimiter:     ',',
+		StockIDColumn: 0,
+		DateColumn:    2,
+		PriceColumn:   3,
+		SkipHeader:    true,
+	}
+	expected := []models.DailyStockPrice{
+		{
+			PriceDate:  time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
+			StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)},
+		},
+		{
+			PriceDate:  time.Date(2025, 2, 5, 0, 0, 0, 0, time.UTC),
+			StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(29035, 1)},
+		},
+	}
+
+	// Act
+	dailyPrices, err := ReadDailyStockPriceFromCSV(filePath, options)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !reflect.DeepEqual(dailyPrices, expected) {
+		t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", expected, dailyPrices)
+	}
+}
+
+func TestReadDailyStockPriceFromCSV_CustomDelimiter(t *testing.T) {
+	// Arrange
+	content := "2025/2/4;\"7203;A\";2873\n"
+	filePath := testutil.WriteTempFile(t, "prices.csv", content)
+	options := CSVOptions{
+		Delimiter:     ';',
+		StockIDColumn: 1,
+		DateColumn:    0,
+		PriceColumn:   2,
+	}
+
+	// Act
+	dailyPrices, err := ReadDailyStockPriceFromCSV(filePath, options)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if len(dailyPrices) != 1 || dailyPrices[0].StockPrice.StockID != "7203;A" {
+		t.Errorf("Expected quoted stock ID containing the delimiter, but got %+v", dailyPrices)
+	}
+}
+
+func TestReadDailyStockPriceFromCSV_MissingColumns(t *testing.T) {
+	// Arrange
+	content := "7203,2025/2/4,2873\n7203,2025/2/5\n"
+	filePath := testutil.WriteTempFile(t, "prices.csv", content)
+
+	// Act
+	_, err := ReadDailyStockPriceFromCSV(filePath, DefaultCSVOptions())
+
+	// Assert
+	var formatErr *InvalidCSVFormatError
+	if !errors.As(err, &formatErr) {
+		t.Fatalf("Expected InvalidCSVFormatError, but got: %v", err)
+	}
+	if formatErr.LineNumber != 2 {
+		t.Errorf("Expected line number 2, but got %d", formatErr.LineNumber)
+	}
+}
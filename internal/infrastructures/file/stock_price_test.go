@@ -1,11 +1,14 @@
 package file
 
 import (
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
 )
 
 func TestReadDailyStockPriceFromTSV(t *testing.T) {
@@ -15,7 +18,7 @@ func TestReadDailyStockPriceFromTSV(t *testing.T) {
 		PriceDate: time.Date(2025, 2, 4, 0, 0, 0, 0, time.UTC),
 		StockPrice: models.StockPrice{
 			StockID: "7203",
-			Price:   2873,
+			Price:   models.NewPrice(2873, 0),
 		},
 	}
 
@@ -44,3 +47,52 @@ func TestReadDailyStockPriceFromTSV(t *testing.T) {
 		}
 	}
 }
+
+func TestStreamDailyStockPriceFromTSVReader_ContinuesAfterInvalidLine(t *testing.T) {
+	// Arrange
+	content := "7203\t2025/2/4\t2873\n" +
+		"7203\t2025/2/5\tN/A\n" +
+		"\n" +
+		"7203\t2025/2/6\t2903.5\n"
+	expectedPrices := []models.Price{models.NewPrice(2873, 0), models.NewPrice(29035, 1)}
+
+	// Act
+	var prices []models.Price
+	var errs []error
+	for dailyPrice, err := range StreamDailyStockPriceFromTSVReader(strings.NewReader(content), DefaultTSVOptions()) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		prices = append(prices, dailyPrice.StockPrice.Price)
+	}
+
+	// Assert
+	if !reflect.DeepEqual(prices, expectedPrices) {
+		t.Errorf("Expected prices %v, but got %v", expectedPrices, prices)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error, but got %d: %v", len(errs), errs)
+	}
+	var priceErr *InvalidPriceFormatError
+	if !errors.As(errs[0], &priceErr) {
+		t.Errorf("Expected InvalidPriceFormatError, but got: %v", errs[0])
+	}
+}
+
+func TestReadDailyStockPriceFromTSV_StopsAtFirstInvalidLine(t *testing.T) {
+	// Arrange
+	filePath := testutil.WriteTempFile(t, "prices.tsv", "7203\t2025/2/4\t2873\n7203\tnot-a-date\t2963\n")
+
+	// Act
+	dailyPrices, err := ReadDailyStockPriceFromTSV(filePath)
+
+	// Assert
+	var dateErr *InvalidDateFormatError
+	if !errors.As(err, &dateErr) {
+		t.Fatalf("Expected InvalidDateFormatError, but got: %v", err)
+	}
+	if dailyPrices != nil {
+		t.Errorf("Expected nil result, but got %+v", dailyPrices)
+	}
+}
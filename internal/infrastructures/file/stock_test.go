@@ -0,0 +1,59 @@
+package file
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+func TestReadStocksFromTSVReader(t *testing.T) {
+	// Arrange
+	input := "銘柄コード\t銘柄名\t市場\t業種\t通貨\n" +
+		"7203\tトヨタ自動車\tTSE Prime\t輸送用機器\tJPY\n" +
+		"\n" +
+		"AAPL\tApple\tNASDAQ\tTechnology\tusd\n" +
+		"9999\t未上場銘柄\n"
+	expected := []models.Stock{
+		{StockID: "7203", Name: "トヨタ自動車", Market: "TSE Prime", Sector: "輸送用機器", Currency: "JPY"},
+		{StockID: "AAPL", Name: "Apple", Market: "NASDAQ", Sector: "Technology", Currency: "USD"},
+		{StockID: "9999", Name: "未上場銘柄", Currency: models.DefaultCurrency},
+	}
+
+	// Act
+	stocks, err := ReadStocksFromTSVReader(strings.NewReader(input))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !reflect.DeepEqual(stocks, expected) {
+		t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", expected, stocks)
+	}
+}
+
+func TestReadStocksFromTSVReader_InvalidFormat(t *testing.T) {
+	testCases := map[string]string{
+		"列が不足":   "7203\n",
+		"列が多すぎる": "7203\tトヨタ自動車\tTSE Prime\t輸送用機器\tJPY\textra\n",
+		"銘柄名が空":  "7203\t\tTSE Prime\n",
+	}
+
+	for name, input := range testCases {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			_, err := ReadStocksFromTSVReader(strings.NewReader(input))
+
+			// Assert
+			var formatErr *InvalidStockFormatError
+			if !errors.As(err, &formatErr) {
+				t.Fatalf("Expected InvalidStockFormatError, but got: %v", err)
+			}
+			if formatErr.LineNumber != 1 {
+				t.Errorf("Expected line 1, but got %d", formatErr.LineNumber)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package file
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 検証で検出した問題の種類
+const (
+	// 列数の不足やクォートの不正など行全体のフォーマット不正
+	IssueKindFormat = "format"
+	// 日付のフォーマット不正
+	IssueKindDate = "date"
+	// 株価のフォーマット不正
+	IssueKindPrice = "price"
+	// 出来高のフォーマット不正
+	IssueKindVolume = "volume"
+)
+
+// 検証結果TSVのヘッダー行
+const validationReportTSVHeader = "line\tcolumn\tkind\tvalue\tmessage\tsource"
+
+// 取り込みを中断しない上限なしを示す不正行数の上限値
+const UnlimitedRejectedRows = -1
+
+// 取り込み時に検出した1行分の問題を示す構造体
+type ValidationIssue struct {
+	// 問題のある行番号（1始まり）
+	LineNumber int `json:"line"`
+	// 問題のある列名（行全体の問題の場合は空文字列）
+	Column string `json:"column,omitempty"`
+	// 問題の種類
+	Kind string `json:"kind"`
+	// 問題のある値
+	Value string `json:"value,omitempty"`
+	// エラーメッセージ
+	Message string `json:"message"`
+	// 問題のある行の読み込み元（アーカイブ内のファイル名など、不明な場合は空文字列）
+	Source string `json:"source,omitempty"`
+}
+
+// 寛容モードでの取り込み結果を示す構造体
+type ValidationReport struct {
+	// 取り込んだ行数
+	AcceptedRows int `json:"accepted_rows"`
+	// スキップした行数
+	RejectedRows int `json:"rejected_rows"`
+	// 検出した問題の一覧
+	Issues []ValidationIssue `json:"issues"`
+}
+
+// NewValidationIssue は読み込み時のエラーを1行分の問題に変換します。
+// 行単位のエラーでない場合（ファイルの読み込み失敗など）は false を返します。
+//
+// 引数:
+//   - err: 読み込み時のエラー
+//
+// 戻り値:
+//   - 1行分の問題
+//   - 行単位のエラーの場合は true
+func NewValidationIssue(err error) (ValidationIssue, bool) {
+	issue := ValidationIssue{Message: err.Error()}
+
+	var tsvFormatErr *InvalidTSVFormatError
+	var barFormatErr *InvalidStockBarFormatError
+	var csvFormatErr *InvalidCSVFormatError
+	var csvParseErr *csv.ParseError
+	var dateErr *InvalidDateFormatError
+	var priceErr *InvalidPriceFormatError
+	var volumeErr *InvalidVolumeFormatError
+	switch {
+	case errors.As(err, &tsvFormatErr):
+		issue.LineNumber = tsvFormatErr.LineNumber
+		issue.Kind = IssueKindFormat
+	case errors.As(err, &barFormatErr):
+		issue.LineNumber = barFormatErr.LineNumber
+		issue.Kind = IssueKindFormat
+	case errors.As(err, &csvFormatErr):
+		issue.LineNumber = csvFormatErr.LineNumber
+		issue.Kind = IssueKindFormat
+	case errors.As(err, &csvParseErr):
+		issue.LineNumber = csvParseErr.StartLine
+		issue.Kind = IssueKindFormat
+	case errors.As(err, &dateErr):
+		issue.LineNumber = dateErr.LineNumber
+		issue.Column = ColumnDate
+		issue.Kind = IssueKindDate
+		issue.Value = dateErr.DateStr
+	case errors.As(err, &priceErr):
+		issue.LineNumber = priceErr.LineNumber
+		issue.Column = priceErr.Column
+		issue.Kind = IssueKindPrice
+		issue.Value = priceErr.PriceStr
+	case errors.As(err, &volumeErr):
+		issue.LineNumber = volumeErr.LineNumber
+		issue.Column = ColumnVolume
+		issue.Kind = IssueKindVolume
+		issue.Value = volumeErr.VolumeStr
+	default:
+		return ValidationIssue{}, false
+	}
+
+	// 読み込み元の名前が付いている場合は記録
+	var sourceErr *SourceError
+	if errors.As(err, &sourceErr) {
+		issue.Source = sourceErr.Source
+	}
+
+	return issue, true
+}
+
+// SkipInvalidRows は日次株価情報のイテレータから解析できない行を取り除き、
+// 取り除いた行の問題を report に記録するイテレータを返します。
+// 行単位でないエラーはそのまま返してイテレーションを終了します。
+// スキップした行数が maxRejectedRows を超えた場合は TooManyRejectedRowsError を返して終了します。
+//
+// 引数:
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - report: 取り込み結果を記録する構造体
+//   - maxRejectedRows: 許容するスキップ行数の上限（UnlimitedRejectedRows の場合は上限なし）
+//
+// 戻り値:
+//   - 解析できた日次株価情報とエラーの組を返すイテレータ
+func SkipInvalidRows(dailyPrices iter.Seq2[models.DailyStockPrice, error], report *ValidationReport, maxRejectedRows int) iter.Seq2[models.DailyStockPrice, error] {
+	return func(yield func(models.DailyStockPrice, error) bool) {
+		for dailyPrice, err := range dailyPrices {
+			if err == nil {
+				report.AcceptedRows++
+				if !yield(dailyPrice, nil) {
+					return
+				}
+				continue
+			}
+
+			// 行単位でないエラーは取り込みを中断する
+			issue, isRowError := NewValidationIssue(err)
+			if !isRowError {
+				yield(models.DailyStockPrice{}, err)
+				return
+			}
+
+			// 問題を記録して次の行へ進む
+			report.RejectedRows++
+			report.Issues = append(report.Issues, issue)
+			if maxRejectedRows != UnlimitedRejectedRows && report.RejectedRows > maxRejectedRows {
+				yield(models.DailyStockPrice{}, &TooManyRejectedRowsError{MaxRejectedRows: maxRejectedRows})
+				return
+			}
+		}
+	}
+}
+
+// WriteValidationReportJSON は取り込み結果をJSON形式で書き込みます。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - report: 取り込み結果
+//
+// 戻り値:
+//   - エラー（書き込みに失敗した場合）
+func WriteValidationReportJSON(writer io.Writer, report ValidationReport) error {
+	// 問題がない場合も空配列として出力する
+	if report.Issues == nil {
+		report.Issues = []ValidationIssue{}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteValidationReportTSV は取り込み結果の問題一覧をヘッダー付きのTSV形式で書き込みます。
+// 値に含まれるタブと改行は空白に置き換えられます。
+//
+// 引数:
+//   - writer: 書き込み先
+//   - report: 取り込み結果
+//
+// 戻り値:
+//   - エラー（書き込みに失敗した場合）
+func WriteValidationReportTSV(writer io.Writer, report ValidationReport) error {
+	if _, err := fmt.Fprintln(writer, validationReportTSVHeader); err != nil {
+		return err
+	}
+	for _, issue := range report.Issues {
+		fields := []string{
+			strconv.Itoa(issue.LineNumber),
+			issue.Column,
+			issue.Kind,
+			sanitizeTSVField(issue.Value),
+			sanitizeTSVField(issue.Message),
+			sanitizeTSVField(issue.Source),
+		}
+		if _, err := fmt.Fprintln(writer, strings.Join(fields, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sanitizeTSVField はTSVの1列に書き込めるようにタブと改行を空白に置き換えます。
+//
+// 引数:
+//   - field: 列の値
+//
+// 戻り値:
+//   - タブと改行を含まない列の値
+func sanitizeTSVField(field string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(field)
+}
+
+// TooManyRejectedRowsError はスキップした行数が上限を超えた場合のエラー
+type TooManyRejectedRowsError struct {
+	MaxRejectedRows int
+}
+
+func (e *TooManyRejectedRowsError) Error() string {
+	return "too many rejected rows: more than " + strconv.Itoa(e.MaxRejectedRows)
+}
@@ -0,0 +1,109 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSkipInvalidRows(t *testing.T) {
+	// Arrange
+	content := "7203\t2025/2/4\t2873\n" +
+		"7203\t2025/2/5\n" +
+		"7203\tyesterday\t2963\n" +
+		"7203\t2025/2/7\t-\n" +
+		"7203\t2025/2/10\t2825.5\n"
+	var report ValidationReport
+	expectedIssues := []ValidationIssue{
+		{LineNumber: 2, Kind: IssueKindFormat},
+		{LineNumber: 3, Column: ColumnDate, Kind: IssueKindDate, Value: "yesterday"},
+		{LineNumber: 4, Column: ColumnPrice, Kind: IssueKindPrice, Value: "-"},
+	}
+
+	// Act
+	acceptedCount := 0
+	for _, err := range SkipInvalidRows(StreamDailyStockPriceFromTSVReader(strings.NewReader(content), DefaultTSVOptions()), &report, UnlimitedRejectedRows) {
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		acceptedCount++
+	}
+
+	// Assert
+	if acceptedCount != 2 || report.AcceptedRows != 2 {
+		t.Errorf("Expected 2 accepted rows, but got %d (report: %d)", acceptedCount, report.AcceptedRows)
+	}
+	if report.RejectedRows != 3 {
+		t.Errorf("Expected 3 rejected rows, but got %d", report.RejectedRows)
+	}
+	// メッセージ以外の項目を比較
+	actualIssues := make([]ValidationIssue, len(report.Issues))
+	for i, issue := range report.Issues {
+		if issue.Message == "" {
+			t.Errorf("Issue %d has empty message", i)
+		}
+		issue.Message = ""
+		actualIssues[i] = issue
+	}
+	if !reflect.DeepEqual(actualIssues, expectedIssues) {
+		t.Errorf("Issues mismatch.\nExpected: %+v\nGot: %+v", expectedIssues, actualIssues)
+	}
+}
+
+func TestSkipInvalidRows_TooManyRejectedRows(t *testing.T) {
+	// Arrange
+	content := "7203\tbad\t1\n7203\tbad\t2\n7203\t2025/2/4\t3\n"
+	var report ValidationReport
+
+	// Act
+	var lastErr error
+	for _, err := range SkipInvalidRows(StreamDailyStockPriceFromTSVReader(strings.NewReader(content), DefaultTSVOptions()), &report, 1) {
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	// Assert
+	var tooManyErr *TooManyRejectedRowsError
+	if !errors.As(lastErr, &tooManyErr) {
+		t.Fatalf("Expected TooManyRejectedRowsError, but got: %v", lastErr)
+	}
+	if report.AcceptedRows != 0 {
+		t.Errorf("Expected import to stop before the valid row, but %d rows were accepted", report.AcceptedRows)
+	}
+}
+
+func TestWriteValidationReport(t *testing.T) {
+	// Arrange
+	report := ValidationReport{
+		AcceptedRows: 1,
+		RejectedRows: 1,
+		Issues: []ValidationIssue{
+			{LineNumber: 2, Column: ColumnPrice, Kind: IssueKindPrice, Value: "1\t0", Message: "invalid price", Source: "prices.zip!7203.tsv"},
+		},
+	}
+	expectedTSV := "line\tcolumn\tkind\tvalue\tmessage\tsource\n2\tprice\tprice\t1 0\tinvalid price\tprices.zip!7203.tsv\n"
+
+	// Act
+	var tsvBuffer, jsonBuffer bytes.Buffer
+	tsvErr := WriteValidationReportTSV(&tsvBuffer, report)
+	jsonErr := WriteValidationReportJSON(&jsonBuffer, report)
+
+	// Assert
+	if tsvErr != nil || jsonErr != nil {
+		t.Fatalf("Expected no error, but got: %v, %v", tsvErr, jsonErr)
+	}
+	if tsvBuffer.String() != expectedTSV {
+		t.Errorf("TSV mismatch.\nExpected: %q\nGot: %q", expectedTSV, tsvBuffer.String())
+	}
+	var decoded ValidationReport
+	if err := json.Unmarshal(jsonBuffer.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode JSON report: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, report) {
+		t.Errorf("JSON mismatch.\nExpected: %+v\nGot: %+v", report, decoded)
+	}
+}
@@ -0,0 +1,283 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// StartImportRun は取り込みの開始を記録し、取り込みIDを返します。
+// 開始日時には現在日時を記録し、run の入力ファイルのパス・SHA-256・設定以外は無視します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - run: 記録する取り込み
+//
+// 戻り値:
+//   - 取り込みID
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされた場合）
+func (r *InMemoryStockPriceRepository) StartImportRun(ctx context.Context, run models.ImportRun) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return models.NoImportRun, err
+	}
+
+	r.importRuns = append(r.importRuns, models.ImportRun{
+		ID:           int64(len(r.importRuns) + 1),
+		SourcePath:   run.SourcePath,
+		SourceSHA256: run.SourceSHA256,
+		Options:      run.Options,
+		Status:       models.ImportRunRunning,
+		StartedAt:    importRunNow(),
+	})
+	return int64(len(r.importRuns)), nil
+}
+
+// FinishImportRun は取り込みの終了日時と、run の状態・SHA-256・取り込み結果を記録します。
+// 上書きまたは削除した行数は取り消し用に保存した行から数えます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - run: 終了した取り込み（状態は ImportRunCompleted または ImportRunFailed）
+//
+// 戻り値:
+//   - エラー（取り込みが記録されていない場合やリポジトリが閉じられている場合）
+func (r *InMemoryStockPriceRepository) FinishImportRun(ctx context.Context, run models.ImportRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return err
+	}
+
+	recordedRun := r.importRun(run.ID)
+	if recordedRun == nil {
+		return fmt.Errorf("import run %d not found", run.ID)
+	}
+	recordedRun.Status = run.Status
+	recordedRun.FinishedAt = importRunNow()
+	recordedRun.SourceSHA256 = run.SourceSHA256
+	recordedRun.Result = run.Result
+	recordedRun.Replaced = len(r.importRunChanges[run.ID])
+	return nil
+}
+
+// GetImportRuns は取り込みID順に全ての取り込み履歴を取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - 取り込み履歴の配列
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされた場合）
+func (r *InMemoryStockPriceRepository) GetImportRuns(ctx context.Context) ([]models.ImportRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return nil, err
+	}
+	return slices.Clone(r.importRuns), nil
+}
+
+// GetImportRun は取り込みIDに一致する取り込み履歴を取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - runID: 取り込みID
+//
+// 戻り値:
+//   - 取り込み履歴
+//   - 記録されている場合は true
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされた場合）
+func (r *InMemoryStockPriceRepository) GetImportRun(ctx context.Context, runID int64) (models.ImportRun, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return models.ImportRun{}, false, err
+	}
+
+	run := r.importRun(runID)
+	if run == nil {
+		return models.ImportRun{}, false, nil
+	}
+	return *run, true, nil
+}
+
+// UndoImportRun は取り込みで書き込まれた行を削除し、取り込みが上書きまたは削除した行を
+// 取り込み前の値で復元します。
+// 後の取り込みや取り込み履歴に記録されない書き込みが同じ行を変更している場合は、
+// 何も変更せずに models.ImportRunUndoConflictError を返します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - runID: 取り消す取り込みID
+//
+// 戻り値:
+//   - 削除・復元した行数
+//   - エラー（取り込みが記録されていない場合、取り消し済みの場合、後の変更と衝突する場合（models.ImportRunUndoConflictError））
+func (r *InMemoryStockPriceRepository) UndoImportRun(ctx context.Context, runID int64) (models.UndoImportResult, error) {
+	// 実行中の書き込みが終わるまで待つ
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return models.UndoImportResult{}, err
+	}
+
+	// 取り込みの状態を確認
+	run := r.importRun(runID)
+	if run == nil {
+		return models.UndoImportResult{}, fmt.Errorf("import run %d not found", runID)
+	}
+	if run.Status == models.ImportRunUndone {
+		return models.UndoImportResult{}, fmt.Errorf("import run %d has already been undone", runID)
+	}
+
+	// 後の変更と衝突しないことを確認
+	if err := r.checkImportRunUndoConflict(runID); err != nil {
+		return models.UndoImportResult{}, err
+	}
+
+	// 取り込みで書き込まれた行を削除
+	var result models.UndoImportResult
+	rows := slices.DeleteFunc(slices.Clone(r.rows), func(row dailyStockPriceRow) bool {
+		return row.runID == runID
+	})
+	result.Removed = len(r.rows) - len(rows)
+	// 復元する行は値が変わった場合のみ版を記録するため、ここでは復元しない行の版のみ置き換える
+	now := historyTime(time.Now())
+	for _, row := range r.rows {
+		if _, restored := r.importRunChanges[runID][row.key]; row.runID == runID && !restored {
+			r.closeVersion(row.key, now)
+		}
+	}
+
+	// 上書きまたは削除された行を復元
+	var restoredRows []dailyStockPriceRow
+	for _, row := range r.importRunChanges[runID] {
+		restoredRows = append(restoredRows, row)
+	}
+	slices.SortFunc(restoredRows, func(a, b dailyStockPriceRow) int {
+		return compareRowKeys(a.key, b.key)
+	})
+	for _, row := range restoredRows {
+		r.recordVersion(row, now)
+	}
+	r.rows = mergeRows(rows, restoredRows)
+	result.Restored = len(restoredRows)
+
+	// 取り消したことを記録
+	run.Status = models.ImportRunUndone
+	run.UndoneAt = importRunNow()
+
+	return result, nil
+}
+
+// checkImportRunUndoConflict は取り込みを取り消すと後の変更を失う行がないことを確認します。
+//   - 取り込みが書き込んだ行を、取り消していない後の取り込みが上書きまたは削除した場合
+//   - 取り込みが上書きまたは削除した行に、後から別の書き込みがあった場合
+//
+// 呼び出し元は mu を読み込みまたは書き込みでロックしている必要があります。
+//
+// 引数:
+//   - runID: 取り消す取り込みID
+//
+// 戻り値:
+//   - エラー（後の変更と衝突する場合（models.ImportRunUndoConflictError））
+func (r *InMemoryStockPriceRepository) checkImportRunUndoConflict(runID int64) error {
+	// 取り込みが書き込んだ行を後の取り込みが上書きまたは削除していないか確認
+	for _, laterRun := range r.importRuns {
+		if laterRun.Status == models.ImportRunUndone {
+			continue
+		}
+		if key, found := firstChangedKey(r.importRunChanges[laterRun.ID], func(row dailyStockPriceRow) bool {
+			return row.runID == runID
+		}); found {
+			return newImportRunUndoConflictError(runID, laterRun.ID, key)
+		}
+	}
+
+	// 取り込みが上書きまたは削除した行に後から書き込みがないか確認
+	var laterRunID int64
+	key, found := firstChangedKey(r.importRunChanges[runID], func(row dailyStockPriceRow) bool {
+		index, exists := r.searchRow(row.key)
+		if !exists || r.rows[index].runID == runID {
+			return false
+		}
+		laterRunID = r.rows[index].runID
+		return true
+	})
+	if found {
+		return newImportRunUndoConflictError(runID, laterRunID, key)
+	}
+	return nil
+}
+
+// firstChangedKey は保存した行のうち条件に一致する (銘柄コード, 日付) の順で最初の行のキーを返します。
+//
+// 引数:
+//   - changes: 取り込みが保存した行
+//   - match: 行が条件に一致するかどうかを返す関数
+//
+// 戻り値:
+//   - 最初に一致した行のキー
+//   - 一致する行がある場合は true
+func firstChangedKey(changes map[rowKey]dailyStockPriceRow, match func(row dailyStockPriceRow) bool) (rowKey, bool) {
+	keys := make([]rowKey, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, compareRowKeys)
+	for _, key := range keys {
+		if match(changes[key]) {
+			return key, true
+		}
+	}
+	return rowKey{}, false
+}
+
+// newImportRunUndoConflictError は取り消しが後の変更と衝突した場合のエラーを作成します。
+//
+// 引数:
+//   - runID: 取り消そうとした取り込みID
+//   - laterRunID: 行を変更した後の取り込みID
+//   - key: 変更された行のキー
+//
+// 戻り値:
+//   - エラー
+func newImportRunUndoConflictError(runID int64, laterRunID int64, key rowKey) error {
+	return &models.ImportRunUndoConflictError{
+		RunID:      runID,
+		LaterRunID: laterRunID,
+		StockID:    key.stockID,
+		PriceDate:  key.priceDate,
+	}
+}
+
+// importRun は取り込みIDに一致する取り込み履歴を返します。
+// 呼び出し元は mu をロックしている必要があります。
+//
+// 引数:
+//   - runID: 取り込みID
+//
+// 戻り値:
+//   - 取り込み履歴（記録されていない場合は nil）
+func (r *InMemoryStockPriceRepository) importRun(runID int64) *models.ImportRun {
+	if runID <= 0 || runID > int64(len(r.importRuns)) {
+		return nil
+	}
+	return &r.importRuns[runID-1]
+}
+
+// importRunNow は取り込み履歴に記録する現在日時を返します。
+// SQLite に保存する場合と同じく、UTCの秒単位に切り捨てます。
+//
+// 戻り値:
+//   - 現在日時
+func importRunNow() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+func TestUndoImportRun_MatchesSQLite(t *testing.T) {
+	// Arrange - 同じ取り込みと取り消しをメモリ上のリポジトリとSQLiteのリポジトリに適用する
+	dbPath := "./test_memory_import_run_matches_sqlite.db"
+	sqliteRepository := testutil.NewSQLiteRepository(t, dbPath)
+	memoryRepository := NewInMemoryStockPriceRepository()
+	defer memoryRepository.Close()
+
+	// Act
+	type snapshot struct {
+		AfterImports  []models.DailyStockPrice
+		ConflictRunID int64
+		SecondUndo    models.UndoImportResult
+		AfterSecond   []models.DailyStockPrice
+		FirstUndo     models.UndoImportResult
+		AfterFirst    []models.DailyStockPrice
+		Runs          []models.ImportRun
+	}
+	run := func(repository models.StockPriceRepository) snapshot {
+		ctx := context.Background()
+		var s snapshot
+		if _, err := repository.InitializeDailyStockPriceTableFromSeq(ctx, models.NoImportRun, models.DailyStockPriceSeq([]models.DailyStockPrice{
+			{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2700, 0)}},
+			{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8000, 0)}},
+		}), 10); err != nil {
+			t.Fatalf("Failed to initialize: %v", err)
+		}
+
+		// 取り込み1は全件を置き換え、取り込み2は取り込み1の行を上書きする
+		firstRunID, err := repository.StartImportRun(ctx, models.ImportRun{SourcePath: "first.tsv", Options: "-mode=replace"})
+		if err != nil {
+			t.Fatalf("Failed to start import run: %v", err)
+		}
+		firstCount, err := repository.InitializeDailyStockPriceTableFromSeq(ctx, firstRunID, models.DailyStockPriceSeq([]models.DailyStockPrice{
+			{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2800, 0)}},
+			{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+		}), 1)
+		if err != nil {
+			t.Fatalf("Failed to import: %v", err)
+		}
+		secondRunID, err := repository.StartImportRun(ctx, models.ImportRun{SourcePath: "second.tsv", Options: "-mode=upsert"})
+		if err != nil {
+			t.Fatalf("Failed to start import run: %v", err)
+		}
+		secondResult, err := repository.UpsertDailyStockPricesFromSeq(ctx, secondRunID, models.DailyStockPriceSeq([]models.DailyStockPrice{
+			{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2880, 0)}},
+			{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2890, 0)}},
+			{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903, 0)}},
+		}), 2, models.ConflictPolicyOverwrite)
+		if err != nil {
+			t.Fatalf("Failed to import: %v", err)
+		}
+		for _, finished := range []models.ImportRun{
+			{ID: firstRunID, Status: models.ImportRunCompleted, SourceSHA256: "abc", Result: models.UpsertResult{Inserted: firstCount}},
+			{ID: secondRunID, Status: models.ImportRunCompleted, SourceSHA256: "def", Result: secondResult},
+		} {
+			if err := repository.FinishImportRun(ctx, finished); err != nil {
+				t.Fatalf("Failed to finish import run: %v", err)
+			}
+		}
+		if s.AfterImports, err = repository.GetDailyStockPrices(ctx); err != nil {
+			t.Fatalf("Failed to get prices: %v", err)
+		}
+
+		// 取り込み1は取り込み2と衝突するため先に取り込み2を取り消す
+		var conflictErr *models.ImportRunUndoConflictError
+		if _, err := repository.UndoImportRun(ctx, firstRunID); !errors.As(err, &conflictErr) {
+			t.Fatalf("Expected ImportRunUndoConflictError, but got: %v", err)
+		}
+		s.ConflictRunID = conflictErr.LaterRunID
+		if s.SecondUndo, err = repository.UndoImportRun(ctx, secondRunID); err != nil {
+			t.Fatalf("Failed to undo: %v", err)
+		}
+		if s.AfterSecond, err = repository.GetDailyStockPrices(ctx); err != nil {
+			t.Fatalf("Failed to get prices: %v", err)
+		}
+		if s.FirstUndo, err = repository.UndoImportRun(ctx, firstRunID); err != nil {
+			t.Fatalf("Failed to undo: %v", err)
+		}
+		if s.AfterFirst, err = repository.GetDailyStockPrices(ctx); err != nil {
+			t.Fatalf("Failed to get prices: %v", err)
+		}
+
+		// 日時は実行ごとに異なるため記録されたことのみ確認する
+		runs, err := repository.GetImportRuns(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get import runs: %v", err)
+		}
+		for _, importRun := range runs {
+			if importRun.StartedAt.IsZero() || importRun.FinishedAt.IsZero() || importRun.UndoneAt.IsZero() {
+				t.Errorf("Expected start, finish and undo times to be recorded, but got %+v", importRun)
+			}
+			importRun.StartedAt, importRun.FinishedAt, importRun.UndoneAt = time.Time{}, time.Time{}, time.Time{}
+			s.Runs = append(s.Runs, importRun)
+		}
+		return s
+	}
+	expected := run(sqliteRepository)
+	got := run(memoryRepository)
+
+	// Assert
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("In-memory repository differs from SQLite.\nSQLite: %+v\nMemory: %+v", expected, got)
+	}
+	if len(expected.AfterFirst) != 2 || expected.AfterFirst[0].StockPrice.Price != models.NewPrice(2700, 0) {
+		t.Errorf("Expected the original prices to be restored, but got %+v", expected.AfterFirst)
+	}
+}
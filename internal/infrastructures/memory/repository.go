@@ -0,0 +1,420 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// コマンドラインで -db に指定するとメモリ上のリポジトリを使用するデータベースのパス
+const DatabasePath = ":memory:"
+
+// Close 後に操作した場合のエラー
+var errRepositoryClosed = errors.New("repository is closed")
+
+// メモリ上に日次株価情報と銘柄マスタを保持するリポジトリ
+// SQLiteStockPriceRepository と同じ検索条件・並び順・取り込み結果を返し、ディスクには何も書き込まない
+// 書き込みは SQLite と同じく1つずつ実行し、コミットされるまで読み込み側からは見えない
+type InMemoryStockPriceRepository struct {
+	// 書き込み処理を1つずつ実行するためのロック
+	writeMu sync.Mutex
+	// 保持しているデータを保護するロック
+	mu sync.RWMutex
+	// (銘柄コード, 日付) の順に並んだ日次株価情報
+	rows []dailyStockPriceRow
+	// 銘柄コードをキーとする銘柄マスタ
+	stocks map[string]models.Stock
+	// 取り込みID順の取り込み履歴（取り込みIDは位置+1）
+	importRuns []models.ImportRun
+	// 取り込みIDごとの、取り込みが上書きまたは削除した行の取り込み前の値
+	importRunChanges map[int64]map[rowKey]dailyStockPriceRow
+	// 行のキーごとの、記録日時の順に並んだ日次株価情報の全ての版
+	history map[rowKey][]dailyStockPriceVersion
+	// Close が呼び出されたかどうか
+	closed bool
+}
+
+// InMemoryStockPriceRepository が StockPriceRepository を実装していることを確認
+var _ models.StockPriceRepository = (*InMemoryStockPriceRepository)(nil)
+
+// 日次株価情報の1行を示す構造体
+type dailyStockPriceRow struct {
+	// 銘柄コードと日付
+	key rowKey
+	// 株価（終値）
+	price models.Price
+	// 四本値と出来高（株価のみで登録された行は nil）
+	bar *dailyStockBarValues
+	// 行を書き込んだ取り込みID（取り込み履歴に記録せずに書き込んだ場合は models.NoImportRun）
+	runID int64
+}
+
+// 日次株価情報の行を一意に識別するキー
+type rowKey struct {
+	// 銘柄コード
+	stockID string
+	// 日付（UTCの0時に正規化したもの）
+	priceDate time.Time
+}
+
+// 始値・高値・安値と出来高を示す構造体
+type dailyStockBarValues struct {
+	open   models.Price
+	high   models.Price
+	low    models.Price
+	volume int64
+}
+
+// NewInMemoryStockPriceRepository は空のリポジトリを作成します。
+//
+// 戻り値:
+//   - リポジトリ
+func NewInMemoryStockPriceRepository() *InMemoryStockPriceRepository {
+	return &InMemoryStockPriceRepository{
+		stocks:           make(map[string]models.Stock),
+		importRunChanges: make(map[int64]map[rowKey]dailyStockPriceRow),
+		history:          make(map[rowKey][]dailyStockPriceVersion),
+	}
+}
+
+// Close はリポジトリを閉じ、保持しているデータを破棄します。
+// 閉じた後の操作はエラーを返します。
+//
+// 戻り値:
+//   - エラー（常にnil）
+func (r *InMemoryStockPriceRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	r.rows = nil
+	r.stocks = nil
+	r.importRuns = nil
+	r.importRunChanges = nil
+	r.history = nil
+	return nil
+}
+
+// checkOpen は操作を開始できるかどうかを確認します。
+// 呼び出し元は mu を読み込みまたは書き込みでロックしている必要があります。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされている場合）
+func (r *InMemoryStockPriceRepository) checkOpen(ctx context.Context) error {
+	if r.closed {
+		return errRepositoryClosed
+	}
+	return ctx.Err()
+}
+
+// newRowKey は銘柄コードと日付から行のキーを作成します。
+// SQLiteに YYYY-MM-DD 形式で保存する場合と同じく、時刻とタイムゾーンを切り捨てます。
+//
+// 引数:
+//   - stockID: 銘柄コード
+//   - priceDate: 日付
+//
+// 戻り値:
+//   - 行のキー
+func newRowKey(stockID string, priceDate time.Time) rowKey {
+	return rowKey{stockID: stockID, priceDate: normalizeDate(priceDate)}
+}
+
+// normalizeDate は日付の時刻とタイムゾーンを切り捨て、UTCの0時にします。
+//
+// 引数:
+//   - date: 日付
+//
+// 戻り値:
+//   - UTCの0時の日付
+func normalizeDate(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// compareRowKeys は (銘柄コード, 日付) の順で2つのキーを比較します。
+//
+// 引数:
+//   - a: 比較するキー
+//   - b: 比較するキー
+//
+// 戻り値:
+//   - a が前の場合は負の値、同じ場合は0、後の場合は正の値
+func compareRowKeys(a rowKey, b rowKey) int {
+	if c := cmp.Compare(a.stockID, b.stockID); c != 0 {
+		return c
+	}
+	return a.priceDate.Compare(b.priceDate)
+}
+
+// searchRow は (銘柄コード, 日付) の順に並んだ行から key 以降の最初の位置を探します。
+// 呼び出し元は mu を読み込みまたは書き込みでロックしている必要があります。
+//
+// 引数:
+//   - key: 探すキー
+//
+// 戻り値:
+//   - key 以降の最初の行の位置
+//   - key と一致する行がある場合は true
+func (r *InMemoryStockPriceRepository) searchRow(key rowKey) (int, bool) {
+	return slices.BinarySearchFunc(r.rows, key, func(row dailyStockPriceRow, key rowKey) int {
+		return compareRowKeys(row.key, key)
+	})
+}
+
+// lookupRow はキーに一致する行を取得します。
+//
+// 引数:
+//   - key: 取得する行のキー
+//
+// 戻り値:
+//   - 行
+//   - 一致する行がある場合は true
+func (r *InMemoryStockPriceRepository) lookupRow(key rowKey) (dailyStockPriceRow, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	index, found := r.searchRow(key)
+	if !found {
+		return dailyStockPriceRow{}, false
+	}
+	return r.rows[index], true
+}
+
+// lookupStock は銘柄コードに一致する銘柄マスタを取得します。
+//
+// 引数:
+//   - stockID: 銘柄コード
+//
+// 戻り値:
+//   - 銘柄マスタ
+//   - 登録されている場合は true
+func (r *InMemoryStockPriceRepository) lookupStock(stockID string) (models.Stock, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	stock, found := r.stocks[stockID]
+	return stock, found
+}
+
+// メモリ上のリポジトリに対するコミット前の書き込みを示す構造体
+// SQLite のトランザクションと同じく、commit するまでリポジトリには反映されない
+type memoryTx struct {
+	// 書き込み先のリポジトリ
+	repository *InMemoryStockPriceRepository
+	// 書き込みを記録する取り込みID（models.NoImportRun の場合は記録しない）
+	runID int64
+	// 取り込みが上書きまたは削除した行の取り込み前の値
+	replaced map[rowKey]dailyStockPriceRow
+	// 既存の日次株価情報を全て削除したかどうか
+	cleared bool
+	// 書き込んだ日次株価情報
+	rows map[rowKey]dailyStockPriceRow
+	// 書き込んだ銘柄マスタ
+	stocks map[string]models.Stock
+}
+
+// begin は書き込みを開始します。
+// 書き込みは1つずつ実行されるため、先に開始した書き込みが終わるまで待ちます。
+// 書き込みを終えたら commit または rollback を呼び出してください。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - runID: 書き込みを記録する取り込みID（models.NoImportRun の場合は記録しない）
+//
+// 戻り値:
+//   - コミット前の書き込み
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされている場合）
+func (r *InMemoryStockPriceRepository) begin(ctx context.Context, runID int64) (*memoryTx, error) {
+	r.writeMu.Lock()
+	r.mu.RLock()
+	err := r.checkOpen(ctx)
+	r.mu.RUnlock()
+	if err != nil {
+		r.writeMu.Unlock()
+		return nil, err
+	}
+	return &memoryTx{
+		repository: r,
+		runID:      runID,
+		replaced:   make(map[rowKey]dailyStockPriceRow),
+		rows:       make(map[rowKey]dailyStockPriceRow),
+		stocks:     make(map[string]models.Stock),
+	}, nil
+}
+
+// clear は既存の日次株価情報を全て削除します。
+// 取り込みIDを指定した場合は、削除する行を取り消し用に保存します。
+func (tx *memoryTx) clear() {
+	if !tx.cleared {
+		tx.repository.mu.RLock()
+		for _, row := range tx.repository.rows {
+			if _, written := tx.rows[row.key]; !written {
+				tx.saveReplaced(row)
+			}
+		}
+		tx.repository.mu.RUnlock()
+	}
+	for _, row := range tx.rows {
+		tx.saveReplaced(row)
+	}
+	tx.cleared = true
+	clear(tx.rows)
+}
+
+// saveReplaced は上書きまたは削除する行を取り消し用に保存します。
+// 取り込みIDを指定していない場合や、同じ取り込みが書き込んだ行の場合は保存しません。
+// 同じ行は最初の値のみ保存します。
+//
+// 引数:
+//   - row: 上書きまたは削除する行
+func (tx *memoryTx) saveReplaced(row dailyStockPriceRow) {
+	if tx.runID == models.NoImportRun || row.runID == tx.runID {
+		return
+	}
+	if _, saved := tx.replaced[row.key]; !saved {
+		tx.replaced[row.key] = row
+	}
+}
+
+// lookup はコミット前の書き込みを反映した状態でキーに一致する行を取得します。
+//
+// 引数:
+//   - key: 取得する行のキー
+//
+// 戻り値:
+//   - 行
+//   - 一致する行がある場合は true
+func (tx *memoryTx) lookup(key rowKey) (dailyStockPriceRow, bool) {
+	if row, found := tx.rows[key]; found {
+		return row, true
+	}
+	if tx.cleared {
+		return dailyStockPriceRow{}, false
+	}
+	return tx.repository.lookupRow(key)
+}
+
+// put は行を書き込みます。同じキーの行は置き換えます。
+// 銘柄マスタに登録されていない銘柄は銘柄コードのみで登録します。
+//
+// 引数:
+//   - row: 書き込む行（取り込みIDは書き込みの取り込みIDで置き換える）
+func (tx *memoryTx) put(row dailyStockPriceRow) {
+	row.runID = tx.runID
+	tx.rows[row.key] = row
+	if _, found := tx.lookupStock(row.key.stockID); !found {
+		tx.stocks[row.key.stockID] = models.Stock{StockID: row.key.stockID, Currency: models.DefaultCurrency}
+	}
+}
+
+// lookupStock はコミット前の書き込みを反映した状態で銘柄マスタを取得します。
+//
+// 引数:
+//   - stockID: 銘柄コード
+//
+// 戻り値:
+//   - 銘柄マスタ
+//   - 登録されている場合は true
+func (tx *memoryTx) lookupStock(stockID string) (models.Stock, bool) {
+	if stock, found := tx.stocks[stockID]; found {
+		return stock, true
+	}
+	return tx.repository.lookupStock(stockID)
+}
+
+// commit は書き込みをリポジトリに反映し、書き込みを終了します。
+//
+// 戻り値:
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされている場合）
+func (tx *memoryTx) commit(ctx context.Context) error {
+	r := tx.repository
+	defer r.writeMu.Unlock()
+
+	// 書き込んだ行を (銘柄コード, 日付) の順に並べる
+	updates := make([]dailyStockPriceRow, 0, len(tx.rows))
+	for _, row := range tx.rows {
+		updates = append(updates, row)
+	}
+	slices.SortFunc(updates, func(a, b dailyStockPriceRow) int {
+		return compareRowKeys(a.key, b.key)
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return err
+	}
+	// 削除した行と値が変わった行を版として記録（置き換えで同じ値を書き込んだ行は版を増やさない）
+	now := historyTime(time.Now())
+	base := r.rows
+	if tx.cleared {
+		for _, row := range base {
+			if _, written := tx.rows[row.key]; !written {
+				r.closeVersion(row.key, now)
+			}
+		}
+		base = nil
+	}
+	for _, row := range updates {
+		r.recordVersion(row, now)
+	}
+	r.rows = mergeRows(base, updates)
+	for stockID, stock := range tx.stocks {
+		r.stocks[stockID] = stock
+	}
+
+	// 取り消し用に保存した行を反映（同じ行は最初の値のみ残す）
+	if len(tx.replaced) > 0 {
+		changes := r.importRunChanges[tx.runID]
+		if changes == nil {
+			changes = make(map[rowKey]dailyStockPriceRow)
+			r.importRunChanges[tx.runID] = changes
+		}
+		for key, row := range tx.replaced {
+			if _, saved := changes[key]; !saved {
+				changes[key] = row
+			}
+		}
+	}
+	return nil
+}
+
+// rollback は書き込みを破棄し、書き込みを終了します。
+func (tx *memoryTx) rollback() {
+	tx.repository.writeMu.Unlock()
+}
+
+// mergeRows は (銘柄コード, 日付) の順に並んだ2つの行の配列を1つにまとめます。
+// 同じキーの行は updates の行で置き換えます。
+//
+// 引数:
+//   - base: 既存の行
+//   - updates: 書き込む行
+//
+// 戻り値:
+//   - (銘柄コード, 日付) の順に並んだ行
+func mergeRows(base []dailyStockPriceRow, updates []dailyStockPriceRow) []dailyStockPriceRow {
+	merged := make([]dailyStockPriceRow, 0, len(base)+len(updates))
+	i, j := 0, 0
+	for i < len(base) && j < len(updates) {
+		switch c := compareRowKeys(base[i].key, updates[j].key); {
+		case c < 0:
+			merged = append(merged, base[i])
+			i++
+		case c > 0:
+			merged = append(merged, updates[j])
+			j++
+		default:
+			merged = append(merged, updates[j])
+			i++
+			j++
+		}
+	}
+	merged = append(merged, base[i:]...)
+	return append(merged, updates[j:]...)
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// UpsertStocks は銘柄マスタを登録し、登録済みの銘柄は新しい値で上書きします。
+// 値が同じ場合は変更なしとして数えます。全ての銘柄を1回でコミットします。
+// 通貨が空の場合は models.DefaultCurrency を登録します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - stocks: 登録する銘柄マスタの配列
+//
+// 戻り値:
+//   - 登録結果
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされた場合）
+func (r *InMemoryStockPriceRepository) UpsertStocks(ctx context.Context, stocks []models.Stock) (models.UpsertResult, error) {
+	tx, err := r.begin(ctx, models.NoImportRun)
+	if err != nil {
+		return models.UpsertResult{}, err
+	}
+
+	// 各銘柄を登録
+	var result models.UpsertResult
+	for _, stock := range stocks {
+		if stock.Currency == "" {
+			stock.Currency = models.DefaultCurrency
+		}
+
+		existingStock, found := tx.lookupStock(stock.StockID)
+		switch {
+		case !found:
+			result.Inserted++
+		case existingStock == stock:
+			result.Unchanged++
+			continue
+		default:
+			result.Updated++
+		}
+		tx.stocks[stock.StockID] = stock
+	}
+
+	if err := tx.commit(ctx); err != nil {
+		return models.UpsertResult{}, err
+	}
+	return result, nil
+}
+
+// GetStocks は銘柄コード順に全ての銘柄マスタを取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - 銘柄マスタの配列
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされた場合）
+func (r *InMemoryStockPriceRepository) GetStocks(ctx context.Context) ([]models.Stock, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return nil, err
+	}
+
+	var stocks []models.Stock
+	for _, stock := range r.stocks {
+		stocks = append(stocks, stock)
+	}
+	slices.SortFunc(stocks, func(a, b models.Stock) int {
+		return cmp.Compare(a.StockID, b.StockID)
+	})
+	return stocks, nil
+}
+
+// GetStock は銘柄コードに一致する銘柄マスタを取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - stockID: 取得する銘柄コード
+//
+// 戻り値:
+//   - 銘柄マスタ
+//   - 登録されている場合は true
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされた場合）
+func (r *InMemoryStockPriceRepository) GetStock(ctx context.Context, stockID string) (models.Stock, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return models.Stock{}, false, err
+	}
+
+	stock, found := r.stocks[stockID]
+	return stock, found, nil
+}
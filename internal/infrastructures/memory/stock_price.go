@@ -0,0 +1,454 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// IterateDailyStockPrices が1回に取得する件数
+const dailyStockPriceIteratorPageSize = 1000
+
+// InitializeDailyStockPriceTableFromSeq は全ての日次株価情報を削除し、
+// イテレータから読み込んだ日次株価情報を挿入します。
+// SQLite と同じく、削除と挿入は全件を読み込んでから1回でコミットし、
+// 途中でエラーが発生した場合やコンテキストがキャンセルされた場合は何も変更しません。
+// メモリ上のリポジトリは全件をメモリ上に保持するため、chunkSize はコミットの単位に影響しません。
+// runID を指定した場合は、削除する行を取り消し用に保存し、挿入した行をその取り込みに紐付けます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - runID: 書き込みを記録する取り込みID（models.NoImportRun の場合は記録しない）
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - chunkSize: SQLite で1トランザクションに書き込む件数（1以上）
+//
+// 戻り値:
+//   - 挿入した日次株価情報の件数（エラーの場合は0）
+//   - エラー（イテレータがエラーを返した場合や同じ銘柄コード・日付の行を挿入しようとした場合）
+func (r *InMemoryStockPriceRepository) InitializeDailyStockPriceTableFromSeq(ctx context.Context, runID int64, dailyPrices iter.Seq2[models.DailyStockPrice, error], chunkSize int) (int, error) {
+	if chunkSize <= 0 {
+		return 0, fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+
+	// 既存のデータを削除
+	tx, err := r.begin(ctx, runID)
+	if err != nil {
+		return 0, err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			tx.rollback()
+		}
+	}()
+	tx.clear()
+
+	// 各日次株価情報を挿入
+	insertedCount := 0
+	for dailyPrice, err := range dailyPrices {
+		if err != nil {
+			return 0, err
+		}
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if err := insertRow(tx, newDailyStockPriceRow(dailyPrice)); err != nil {
+			return 0, err
+		}
+		insertedCount++
+	}
+
+	// 削除と挿入をまとめてコミット
+	committed = true
+	if err := tx.commit(ctx); err != nil {
+		return 0, err
+	}
+	return insertedCount, nil
+}
+
+// UpsertDailyStockPricesFromSeq は既存の日次株価情報を残したまま、
+// イテレータから読み込んだ日次株価情報を policy に従って追加します。
+// 既存の行と株価が同じ場合は衝突とみなさず、変更なしとして数えます。
+// chunkSize 件ごとにコミットし、途中でエラーが発生した場合は実行中のチャンクのみ破棄されます。
+// runID を指定した場合は、上書きする行を取り消し用に保存し、書き込んだ行をその取り込みに紐付けます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - runID: 書き込みを記録する取り込みID（models.NoImportRun の場合は記録しない）
+//   - dailyPrices: 日次株価情報とエラーの組を返すイテレータ
+//   - chunkSize: 1回のコミットで書き込む件数
+//   - policy: 同じ銘柄コード・日付の行が存在する場合の扱い
+//
+// 戻り値:
+//   - コミットされた行の取り込み結果
+//   - エラー（イテレータがエラーを返した場合、ConflictPolicyFail で衝突した場合（models.DailyStockPriceConflictError））
+func (r *InMemoryStockPriceRepository) UpsertDailyStockPricesFromSeq(ctx context.Context, runID int64, dailyPrices iter.Seq2[models.DailyStockPrice, error], chunkSize int, policy models.ConflictPolicy) (models.UpsertResult, error) {
+	if chunkSize <= 0 {
+		return models.UpsertResult{}, fmt.Errorf("invalid chunk size: %d", chunkSize)
+	}
+	if _, err := models.ParseConflictPolicy(string(policy)); err != nil {
+		return models.UpsertResult{}, err
+	}
+
+	// 最初のチャンクを開始
+	tx, err := r.begin(ctx, runID)
+	if err != nil {
+		return models.UpsertResult{}, err
+	}
+	defer func() {
+		if tx != nil {
+			tx.rollback()
+		}
+	}()
+
+	// 各日次株価情報を書き込む
+	var committedResult, chunkResult models.UpsertResult
+	chunkCount := 0
+	for dailyPrice, err := range dailyPrices {
+		if err != nil {
+			return committedResult, err
+		}
+		if err := ctx.Err(); err != nil {
+			return committedResult, err
+		}
+
+		// 既存の行との関係に応じて書き込む
+		row := newDailyStockPriceRow(dailyPrice)
+		existingRow, exists := tx.lookup(row.key)
+		switch {
+		case !exists:
+			chunkResult.Inserted++
+			tx.put(row)
+		case existingRow.price == row.price || policy == models.ConflictPolicyKeep:
+			chunkResult.Unchanged++
+		case policy == models.ConflictPolicyFail:
+			return committedResult, &models.DailyStockPriceConflictError{
+				StockID:       dailyPrice.StockPrice.StockID,
+				PriceDate:     dailyPrice.PriceDate,
+				ExistingPrice: existingRow.price,
+				NewPrice:      dailyPrice.StockPrice.Price,
+			}
+		default:
+			// 四本値と出来高は株価のみの行で置き換える
+			chunkResult.Updated++
+			tx.saveReplaced(existingRow)
+			tx.put(row)
+		}
+		chunkCount++
+
+		// チャンクの件数に達したらコミットして次のチャンクを開始
+		if chunkCount == chunkSize {
+			committedTx := tx
+			tx = nil
+			if err := committedTx.commit(ctx); err != nil {
+				return committedResult, err
+			}
+			committedResult = committedResult.Add(chunkResult)
+			chunkResult = models.UpsertResult{}
+			chunkCount = 0
+
+			if tx, err = r.begin(ctx, runID); err != nil {
+				return committedResult, err
+			}
+		}
+	}
+
+	// 最後のチャンクをコミット
+	lastTx := tx
+	tx = nil
+	if err := lastTx.commit(ctx); err != nil {
+		return committedResult, err
+	}
+
+	return committedResult.Add(chunkResult), nil
+}
+
+// InitializeDailyStockBarTable は全ての日次株価情報を削除し、日次四本値を挿入します。
+// 削除と挿入は1回でコミットされます。終値は株価として保持します。
+// runID を指定した場合は、削除する行を取り消し用に保存し、挿入した行をその取り込みに紐付けます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - runID: 書き込みを記録する取り込みID（models.NoImportRun の場合は記録しない）
+//   - dailyBars: 挿入する日次四本値の配列
+//
+// 戻り値:
+//   - エラー（同じ銘柄コード・日付の行を挿入しようとした場合など）
+func (r *InMemoryStockPriceRepository) InitializeDailyStockBarTable(ctx context.Context, runID int64, dailyBars []models.DailyStockBar) error {
+	tx, err := r.begin(ctx, runID)
+	if err != nil {
+		return err
+	}
+	tx.clear()
+
+	// 各日次四本値を挿入
+	for _, dailyBar := range dailyBars {
+		if err := ctx.Err(); err != nil {
+			tx.rollback()
+			return err
+		}
+		row := dailyStockPriceRow{
+			key:   newRowKey(dailyBar.StockID, dailyBar.PriceDate),
+			price: dailyBar.Close,
+			bar: &dailyStockBarValues{
+				open:   dailyBar.Open,
+				high:   dailyBar.High,
+				low:    dailyBar.Low,
+				volume: dailyBar.Volume,
+			},
+		}
+		if err := insertRow(tx, row); err != nil {
+			tx.rollback()
+			return err
+		}
+	}
+
+	return tx.commit(ctx)
+}
+
+// GetDailyStockPrices は全ての日次株価情報を (銘柄コード, 日付) の順に取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - 日次株価情報の配列
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされた場合）
+func (r *InMemoryStockPriceRepository) GetDailyStockPrices(ctx context.Context) ([]models.DailyStockPrice, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return nil, err
+	}
+
+	var dailyPrices []models.DailyStockPrice
+	for _, row := range r.rows {
+		dailyPrices = append(dailyPrices, row.dailyStockPrice())
+	}
+	return dailyPrices, nil
+}
+
+// GetDailyStockPricesPage は (銘柄コード, 日付) の順で after より後ろの日次株価情報を最大 limit 件取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - after: 取得を再開するカーソル（ゼロ値の場合は先頭から）
+//   - limit: 1ページの最大件数
+//
+// 戻り値:
+//   - 1ページ分の日次株価情報と次のページのカーソル
+//   - エラー（limit が不正な場合やリポジトリが閉じられている場合）
+func (r *InMemoryStockPriceRepository) GetDailyStockPricesPage(ctx context.Context, after models.DailyStockPriceCursor, limit int) (models.DailyStockPricePage, error) {
+	if limit <= 0 {
+		return models.DailyStockPricePage{}, fmt.Errorf("invalid page limit: %d", limit)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return models.DailyStockPricePage{}, err
+	}
+
+	// カーソルより後ろの最初の行を探す
+	start := 0
+	if !after.IsZero() {
+		index, found := r.searchRow(newRowKey(after.StockID, after.PriceDate))
+		start = index
+		if found {
+			start++
+		}
+	}
+	end := min(start+limit, len(r.rows))
+
+	var page models.DailyStockPricePage
+	for _, row := range r.rows[start:end] {
+		page.Prices = append(page.Prices, row.dailyStockPrice())
+	}
+
+	// limit より後ろに行が残っている場合は次のページがある
+	if end < len(r.rows) {
+		last := r.rows[end-1]
+		page.Next = models.DailyStockPriceCursor{StockID: last.key.stockID, PriceDate: last.key.priceDate}
+	}
+
+	return page, nil
+}
+
+// IterateDailyStockPrices は (銘柄コード, 日付) の順で after より後ろの日次株価情報を1件ずつ返すイテレータを返します。
+// dailyStockPriceIteratorPageSize 件ずつページ単位で取得するため、読み出しの間にロックを保持し続けません。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - after: 取得を開始するカーソル（ゼロ値の場合は先頭から）
+//
+// 戻り値:
+//   - 日次株価情報とエラーの組を返すイテレータ（エラーを返した後は終了する）
+func (r *InMemoryStockPriceRepository) IterateDailyStockPrices(ctx context.Context, after models.DailyStockPriceCursor) iter.Seq2[models.DailyStockPrice, error] {
+	return func(yield func(models.DailyStockPrice, error) bool) {
+		cursor := after
+		for {
+			page, err := r.GetDailyStockPricesPage(ctx, cursor, dailyStockPriceIteratorPageSize)
+			if err != nil {
+				yield(models.DailyStockPrice{}, err)
+				return
+			}
+			for _, dailyPrice := range page.Prices {
+				if !yield(dailyPrice, nil) {
+					return
+				}
+			}
+			if !page.HasNext() {
+				return
+			}
+			cursor = page.Next
+		}
+	}
+}
+
+// CountDailyStockPrices は日次株価情報の件数を取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//
+// 戻り値:
+//   - 日次株価情報の件数
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされた場合）
+func (r *InMemoryStockPriceRepository) CountDailyStockPrices(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return 0, err
+	}
+	return len(r.rows), nil
+}
+
+// GetDailyStockPricesByDateRange は銘柄コードと日付範囲（両端を含む）に一致する日次株価情報を日付順に取得します。
+// asOf がゼロ値以外の場合は、その日時に保存されていた版を取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - stockID: 取得する銘柄コード
+//   - startDate: 取得する日付の始点（この日付を含む）
+//   - endDate: 取得する日付の終点（この日付を含む）
+//   - asOf: 取得する版の日時（ゼロ値の場合は現在の値）
+//
+// 戻り値:
+//   - 条件に一致する日次株価情報の配列
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされた場合）
+func (r *InMemoryStockPriceRepository) GetDailyStockPricesByDateRange(ctx context.Context, stockID string, startDate time.Time, endDate time.Time, asOf time.Time) ([]models.DailyStockPrice, error) {
+	result, err := r.QueryDailyStockPrices(ctx, models.DailyStockPriceQuery{
+		StockIDs: []string{stockID},
+		From:     startDate,
+		To:       endDate,
+		AsOf:     asOf,
+	})
+	return result.Prices, err
+}
+
+// GetDailyStockBarsByDateRange は銘柄コードと日付範囲（両端を含む）に一致する日次四本値を日付順に取得します。
+// 株価のみで登録された行は始値・高値・安値に終値、出来高に0が設定されます。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - stockID: 取得する銘柄コード
+//   - startDate: 取得する日付の始点（この日付を含む）
+//   - endDate: 取得する日付の終点（この日付を含む）
+//
+// 戻り値:
+//   - 条件に一致する日次四本値の配列
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされた場合）
+func (r *InMemoryStockPriceRepository) GetDailyStockBarsByDateRange(ctx context.Context, stockID string, startDate time.Time, endDate time.Time) ([]models.DailyStockBar, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return nil, err
+	}
+
+	var dailyBars []models.DailyStockBar
+	for _, row := range r.rowsInDateRange(stockID, startDate, endDate) {
+		dailyBar := models.DailyStockBar{
+			PriceDate: row.key.priceDate,
+			StockID:   row.key.stockID,
+			Open:      row.price,
+			High:      row.price,
+			Low:       row.price,
+			Close:     row.price,
+		}
+		if row.bar != nil {
+			dailyBar.Open = row.bar.open
+			dailyBar.High = row.bar.high
+			dailyBar.Low = row.bar.low
+			dailyBar.Volume = row.bar.volume
+		}
+		dailyBars = append(dailyBars, dailyBar)
+	}
+	return dailyBars, nil
+}
+
+// rowsInDateRange は銘柄コードと日付範囲（両端を含む）に一致する行を日付順に返します。
+// 呼び出し元は mu を読み込みでロックしている必要があります。
+//
+// 引数:
+//   - stockID: 銘柄コード
+//   - startDate: 日付の始点（この日付を含む）
+//   - endDate: 日付の終点（この日付を含む）
+//
+// 戻り値:
+//   - 条件に一致する行（保持している配列の一部のため変更しないこと）
+func (r *InMemoryStockPriceRepository) rowsInDateRange(stockID string, startDate time.Time, endDate time.Time) []dailyStockPriceRow {
+	start, _ := r.searchRow(newRowKey(stockID, startDate))
+	end := start
+	endKey := newRowKey(stockID, endDate)
+	for end < len(r.rows) && compareRowKeys(r.rows[end].key, endKey) <= 0 {
+		end++
+	}
+	return r.rows[start:end]
+}
+
+// newDailyStockPriceRow は日次株価情報から株価のみの行を作成します。
+//
+// 引数:
+//   - dailyPrice: 日次株価情報
+//
+// 戻り値:
+//   - 行
+func newDailyStockPriceRow(dailyPrice models.DailyStockPrice) dailyStockPriceRow {
+	return dailyStockPriceRow{
+		key:   newRowKey(dailyPrice.StockPrice.StockID, dailyPrice.PriceDate),
+		price: dailyPrice.StockPrice.Price,
+	}
+}
+
+// dailyStockPrice は行を日次株価情報に変換します。
+//
+// 戻り値:
+//   - 日次株価情報
+func (row dailyStockPriceRow) dailyStockPrice() models.DailyStockPrice {
+	return models.DailyStockPrice{
+		PriceDate: row.key.priceDate,
+		StockPrice: models.StockPrice{
+			StockID: row.key.stockID,
+			Price:   row.price,
+		},
+	}
+}
+
+// insertRow は行を挿入します。SQLite の主キー制約と同じく、同じ銘柄コード・日付の行がある場合はエラーを返します。
+//
+// 引数:
+//   - tx: コミット前の書き込み
+//   - row: 挿入する行
+//
+// 戻り値:
+//   - エラー（同じ銘柄コード・日付の行がある場合）
+func insertRow(tx *memoryTx, row dailyStockPriceRow) error {
+	if _, exists := tx.lookup(row.key); exists {
+		return fmt.Errorf("failed to insert data: duplicate daily stock price for %s on %s",
+			row.key.stockID, row.key.priceDate.Format(time.DateOnly))
+	}
+	tx.put(row)
+	return nil
+}
@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// 日次株価情報の1つの版を示す構造体
+type dailyStockPriceVersion struct {
+	// 版の値
+	row dailyStockPriceRow
+	// 版を記録した日時
+	recordedAt time.Time
+	// 次の版に置き換えられた、または削除された日時（現在の版はゼロ値）
+	supersededAt time.Time
+}
+
+// GetDailyStockPriceHistory は銘柄コードと日付に一致する日次株価情報の全ての版を記録日時の順に取得します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - stockID: 取得する銘柄コード
+//   - priceDate: 取得する日付
+//
+// 戻り値:
+//   - 記録日時の順に並んだ版の配列
+//   - エラー（リポジトリが閉じられている場合やコンテキストがキャンセルされた場合）
+func (r *InMemoryStockPriceRepository) GetDailyStockPriceHistory(ctx context.Context, stockID string, priceDate time.Time) ([]models.DailyStockPriceVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return nil, err
+	}
+
+	var versions []models.DailyStockPriceVersion
+	for _, version := range r.history[newRowKey(stockID, priceDate)] {
+		versions = append(versions, models.DailyStockPriceVersion{
+			DailyStockPrice: version.row.dailyStockPrice(),
+			RecordedAt:      version.recordedAt,
+			SupersededAt:    version.supersededAt,
+		})
+	}
+	return versions, nil
+}
+
+// rowsAsOf は asOf の日時に保存されていた版の行を (銘柄コード, 日付) の順に返します。
+// 呼び出し元は mu を読み込みでロックしている必要があります。
+//
+// 引数:
+//   - asOf: 取得する版の日時
+//
+// 戻り値:
+//   - その日時に保存されていた全ての行
+func (r *InMemoryStockPriceRepository) rowsAsOf(asOf time.Time) []dailyStockPriceRow {
+	asOf = historyTime(asOf)
+
+	var rows []dailyStockPriceRow
+	for _, versions := range r.history {
+		for _, version := range versions {
+			if !asOf.Before(version.recordedAt) && (version.supersededAt.IsZero() || asOf.Before(version.supersededAt)) {
+				rows = append(rows, version.row)
+				break
+			}
+		}
+	}
+	slices.SortFunc(rows, func(a, b dailyStockPriceRow) int {
+		return compareRowKeys(a.key, b.key)
+	})
+	return rows
+}
+
+// closeVersion はキーに一致する行の現在の版を now で置き換えられたものとして記録します。
+// SQLite のトリガーと同じく、行の削除時と書き込み時に呼び出します。
+// 呼び出し元は mu を書き込みでロックしている必要があります。
+//
+// 引数:
+//   - key: 行のキー
+//   - now: 版を置き換えた日時
+func (r *InMemoryStockPriceRepository) closeVersion(key rowKey, now time.Time) {
+	versions := r.history[key]
+	if len(versions) > 0 && versions[len(versions)-1].supersededAt.IsZero() {
+		versions[len(versions)-1].supersededAt = now
+	}
+}
+
+// recordVersion は行を新しい現在の版として記録し、それまでの現在の版を置き換えます。
+// 現在の版と値（株価・四本値・出来高）が同じ場合は何もしません（SQLite のトリガーと同じ扱い）。
+// 呼び出し元は mu を書き込みでロックしている必要があります。
+//
+// 引数:
+//   - row: 書き込んだ行
+//   - now: 版を記録した日時
+func (r *InMemoryStockPriceRepository) recordVersion(row dailyStockPriceRow, now time.Time) {
+	versions := r.history[row.key]
+	if len(versions) > 0 {
+		current := versions[len(versions)-1]
+		if current.supersededAt.IsZero() && sameRowValues(current.row, row) {
+			return
+		}
+	}
+	r.closeVersion(row.key, now)
+	r.history[row.key] = append(r.history[row.key], dailyStockPriceVersion{row: row, recordedAt: now})
+}
+
+// sameRowValues は2つの行の株価・四本値・出来高が同じかどうかを判定します。
+//
+// 引数:
+//   - a: 比較する行
+//   - b: 比較する行
+//
+// 戻り値:
+//   - 同じ場合は true
+func sameRowValues(a dailyStockPriceRow, b dailyStockPriceRow) bool {
+	if a.price != b.price || (a.bar == nil) != (b.bar == nil) {
+		return false
+	}
+	return a.bar == nil || *a.bar == *b.bar
+}
+
+// historyTime は版の記録日時として保存する日時を返します。
+// SQLite に保存する場合と同じく、UTCのミリ秒単位に切り捨てます。
+//
+// 引数:
+//   - t: 日時
+//
+// 戻り値:
+//   - UTCのミリ秒単位に切り捨てた日時
+func historyTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Millisecond)
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+func TestDailyStockPriceHistory_MatchesSQLite(t *testing.T) {
+	// Arrange - 同じ書き込みをメモリ上のリポジトリとSQLiteのリポジトリに適用し、各時点の版を比較する
+	dbPath := "./test_memory_history_matches_sqlite.db"
+	sqliteRepository := testutil.NewSQLiteRepository(t, dbPath)
+	memoryRepository := NewInMemoryStockPriceRepository()
+	defer memoryRepository.Close()
+
+	price := func(day int, ticks int64, scale int) models.DailyStockPrice {
+		return models.DailyStockPrice{PriceDate: date(day), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(ticks, scale)}}
+	}
+	// 版の記録日時（ミリ秒単位）が前後の書き込みと重ならないように待ってから現在日時を返す
+	checkpoint := func() time.Time {
+		time.Sleep(5 * time.Millisecond)
+		now := time.Now()
+		time.Sleep(5 * time.Millisecond)
+		return now
+	}
+
+	// Act
+	type snapshot struct {
+		AsOf     []string
+		Versions []string
+	}
+	run := func(repository models.StockPriceRepository) snapshot {
+		ctx := context.Background()
+		checkpoints := []time.Time{checkpoint()}
+		if _, err := repository.InitializeDailyStockPriceTableFromSeq(ctx, models.NoImportRun, models.DailyStockPriceSeq([]models.DailyStockPrice{price(3, 2800, 0), price(4, 2873, 0)}), 10); err != nil {
+			t.Fatalf("Failed to initialize: %v", err)
+		}
+		// 同じ内容での置き換えは版を増やさない
+		if _, err := repository.InitializeDailyStockPriceTableFromSeq(ctx, models.NoImportRun, models.DailyStockPriceSeq([]models.DailyStockPrice{price(3, 2800, 0), price(4, 2873, 0)}), 10); err != nil {
+			t.Fatalf("Failed to replace: %v", err)
+		}
+		checkpoints = append(checkpoints, checkpoint())
+		runID, err := repository.StartImportRun(ctx, models.ImportRun{SourcePath: "prices.tsv"})
+		if err != nil {
+			t.Fatalf("Failed to start import run: %v", err)
+		}
+		upserts := []models.DailyStockPrice{price(4, 2880, 0), price(5, 28905, 1)}
+		if _, err := repository.UpsertDailyStockPricesFromSeq(ctx, runID, models.DailyStockPriceSeq(upserts), 10, models.ConflictPolicyOverwrite); err != nil {
+			t.Fatalf("Failed to upsert prices: %v", err)
+		}
+		if err := repository.FinishImportRun(ctx, models.ImportRun{ID: runID, Status: models.ImportRunCompleted}); err != nil {
+			t.Fatalf("Failed to finish import run: %v", err)
+		}
+		checkpoints = append(checkpoints, checkpoint())
+		if _, err := repository.UndoImportRun(ctx, runID); err != nil {
+			t.Fatalf("Failed to undo import run: %v", err)
+		}
+
+		var got snapshot
+		for _, asOf := range append(checkpoints, time.Time{}) {
+			dailyPrices, err := repository.GetDailyStockPricesByDateRange(ctx, "7203", date(1), date(28), asOf)
+			if err != nil {
+				t.Fatalf("Failed to get prices as of %v: %v", asOf, err)
+			}
+			got.AsOf = append(got.AsOf, fmt.Sprint(dailyPrices))
+		}
+		for day := 3; day <= 5; day++ {
+			versions, err := repository.GetDailyStockPriceHistory(ctx, "7203", date(day))
+			if err != nil {
+				t.Fatalf("Failed to get history: %v", err)
+			}
+			for _, version := range versions {
+				got.Versions = append(got.Versions, fmt.Sprintf("%s %s current=%t", version.PriceDate.Format(time.DateOnly), version.StockPrice.Price, version.IsCurrent()))
+			}
+		}
+		return got
+	}
+	expected := run(sqliteRepository)
+	got := run(memoryRepository)
+
+	// Assert
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("In-memory history differs from SQLite.\nSQLite: %+v\nMemory: %+v", expected, got)
+	}
+	if len(expected.Versions) != 5 {
+		t.Errorf("Expected 5 versions (undo restores a new version), but got %q", expected.Versions)
+	}
+}
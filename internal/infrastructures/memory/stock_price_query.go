@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+)
+
+// QueryDailyStockPrices は検索条件に一致する日次株価情報を取得します。
+// SQLiteStockPriceRepository と同じ条件・並び順で、同じ件数を返します。
+//
+// 引数:
+//   - ctx: キャンセルや期限を伝えるコンテキスト
+//   - query: 検索条件
+//
+// 戻り値:
+//   - 検索結果
+//   - エラー（検索条件が不正な場合やリポジトリが閉じられている場合）
+func (r *InMemoryStockPriceRepository) QueryDailyStockPrices(ctx context.Context, query models.DailyStockPriceQuery) (models.DailyStockPriceQueryResult, error) {
+	if err := query.Validate(); err != nil {
+		return models.DailyStockPriceQueryResult{}, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if err := r.checkOpen(ctx); err != nil {
+		return models.DailyStockPriceQueryResult{}, err
+	}
+
+	// 条件に一致する行を (銘柄コード, 日付) の順に集める
+	rows := r.rows
+	if !query.AsOf.IsZero() {
+		rows = r.rowsAsOf(query.AsOf)
+	}
+	var dailyPrices []models.DailyStockPrice
+	for _, row := range rows {
+		dailyPrice := row.dailyStockPrice()
+		if query.Matches(dailyPrice) {
+			dailyPrices = append(dailyPrices, dailyPrice)
+		}
+	}
+
+	var result models.DailyStockPriceQueryResult
+	if query.DistinctStocks {
+		// 行は銘柄コード順に並んでいるため、連続する同じ銘柄コードを1つにまとめる
+		for _, dailyPrice := range dailyPrices {
+			if len(result.StockIDs) == 0 || result.StockIDs[len(result.StockIDs)-1] != dailyPrice.StockPrice.StockID {
+				result.StockIDs = append(result.StockIDs, dailyPrice.StockPrice.StockID)
+			}
+		}
+		if query.Descending {
+			slices.Reverse(result.StockIDs)
+		}
+		result.StockIDs = queryWindow(result.StockIDs, query.Limit, query.Offset)
+		return result, nil
+	}
+
+	slices.SortFunc(dailyPrices, query.Compare)
+	result.Prices = queryWindow(dailyPrices, query.Limit, query.Offset)
+	return result, nil
+}
+
+// queryWindow は並べた結果から offset 件を読み飛ばし、最大 limit 件を返します。
+//
+// 引数:
+//   - values: 並べた結果
+//   - limit: 最大件数（0の場合は制限しない）
+//   - offset: 先頭から読み飛ばす件数
+//
+// 戻り値:
+//   - 範囲内の結果（範囲内に1件もない場合は nil）
+func queryWindow[T any](values []T, limit int, offset int) []T {
+	if offset >= len(values) {
+		return nil
+	}
+	values = values[offset:]
+	if limit > 0 && limit < len(values) {
+		values = values[:limit]
+	}
+	return values
+}
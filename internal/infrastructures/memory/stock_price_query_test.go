@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+func TestQueryDailyStockPrices_MatchesSQLite(t *testing.T) {
+	// Arrange - 株価の桁数が異なる銘柄を含む同じ日次株価情報をメモリ上のリポジトリとSQLiteのリポジトリに登録する
+	dbPath := "./test_memory_query_matches_sqlite.db"
+	sqliteRepository := testutil.NewSQLiteRepository(t, dbPath)
+	memoryRepository := NewInMemoryStockPriceRepository()
+	defer memoryRepository.Close()
+
+	price := func(stockID string, day int, ticks int64, scale int) models.DailyStockPrice {
+		return models.DailyStockPrice{PriceDate: date(day), StockPrice: models.StockPrice{StockID: stockID, Price: models.NewPrice(ticks, scale)}}
+	}
+	initialPrices := []models.DailyStockPrice{
+		price("9984", 6, 8100, 0), price("7203", 3, 28005, 1), price("7203", 4, 2873, 0),
+		price("1301", 4, 350025, 2), price("9984", 4, 2873, 0), price("7203", 6, 29035, 1),
+	}
+	minPrice := models.NewPrice(2873, 0)
+	maxPrice := models.NewPrice(35003, 1)
+	queries := []models.DailyStockPriceQuery{
+		{},
+		{StockIDs: []string{"9984", "7203"}, From: date(4)},
+		{To: date(4), MinPrice: &minPrice},
+		{MaxPrice: &maxPrice, SortBy: models.SortByPrice},
+		{SortBy: models.SortByPrice, Descending: true, Limit: 4, Offset: 1},
+		{SortBy: models.SortByDate, Limit: 3},
+		{SortBy: models.SortByDate, Descending: true, Offset: 2},
+		{Offset: 10},
+		{DistinctStocks: true},
+		{DistinctStocks: true, MinPrice: &maxPrice, Descending: true},
+		{DistinctStocks: true, From: date(4), To: date(4), Limit: 2, Offset: 1},
+		{DistinctStocks: true, StockIDs: []string{"0000"}},
+	}
+
+	// Act
+	run := func(repository models.StockPriceRepository) []models.DailyStockPriceQueryResult {
+		ctx := context.Background()
+		if _, err := repository.InitializeDailyStockPriceTableFromSeq(ctx, models.NoImportRun, models.DailyStockPriceSeq(initialPrices), 10); err != nil {
+			t.Fatalf("Failed to initialize: %v", err)
+		}
+		// 上書き前の版を検索する条件
+		time.Sleep(5 * time.Millisecond)
+		beforeUpsert := time.Now()
+		time.Sleep(5 * time.Millisecond)
+		if _, err := repository.UpsertDailyStockPricesFromSeq(ctx, models.NoImportRun, models.DailyStockPriceSeq([]models.DailyStockPrice{price("7203", 4, 2950, 0)}), 10, models.ConflictPolicyOverwrite); err != nil {
+			t.Fatalf("Failed to upsert prices: %v", err)
+		}
+
+		var results []models.DailyStockPriceQueryResult
+		for _, query := range append(queries, models.DailyStockPriceQuery{AsOf: beforeUpsert, SortBy: models.SortByPrice}) {
+			result, err := repository.QueryDailyStockPrices(ctx, query)
+			if err != nil {
+				t.Fatalf("Failed to query %+v: %v", query, err)
+			}
+			results = append(results, result)
+		}
+		return results
+	}
+	expected := run(sqliteRepository)
+	got := run(memoryRepository)
+
+	// Assert
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("In-memory query results differ from SQLite.\nSQLite: %+v\nMemory: %+v", expected, got)
+	}
+}
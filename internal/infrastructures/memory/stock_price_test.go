@@ -0,0 +1,301 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/toriwasa/sqlite-playground/internal/domain/models"
+	"github.com/toriwasa/sqlite-playground/internal/testutil"
+)
+
+// newTestRepository はテスト用に日次株価情報を登録したリポジトリを作成します。
+func newTestRepository(t *testing.T, dailyPrices []models.DailyStockPrice) *InMemoryStockPriceRepository {
+	t.Helper()
+	repository := NewInMemoryStockPriceRepository()
+	t.Cleanup(func() { repository.Close() })
+	if _, err := repository.InitializeDailyStockPriceTableFromSeq(context.Background(), models.NoImportRun, models.DailyStockPriceSeq(dailyPrices), 100); err != nil {
+		t.Fatalf("Failed to initialize repository: %v", err)
+	}
+	return repository
+}
+
+// date はテスト用に2025年2月の日付を返します。
+func date(day int) time.Time {
+	return time.Date(2025, 2, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestGetDailyStockPricesByDateRange(t *testing.T) {
+	// Arrange - 日付の順序をばらばらに登録し、時刻付きの日付も含める
+	repository := newTestRepository(t, []models.DailyStockPrice{
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903, 0)}},
+		{PriceDate: date(3).Add(15 * time.Hour), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2800, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8000, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+		{PriceDate: date(6), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2950, 0)}},
+	})
+	expected := []models.DailyStockPrice{
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2800, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903, 0)}},
+	}
+
+	// Act - 両端の日付を含む
+	dailyPrices, err := repository.GetDailyStockPricesByDateRange(context.Background(), "7203", date(3), date(5), time.Time{})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !reflect.DeepEqual(dailyPrices, expected) {
+		t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", expected, dailyPrices)
+	}
+}
+
+func TestGetDailyStockPricesPage(t *testing.T) {
+	// Arrange
+	repository := newTestRepository(t, []models.DailyStockPrice{
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8000, 0)}},
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+	})
+
+	// Act
+	firstPage, err := repository.GetDailyStockPricesPage(context.Background(), models.DailyStockPriceCursor{}, 2)
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	secondPage, err := repository.GetDailyStockPricesPage(context.Background(), firstPage.Next, 2)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	expectedNext := models.DailyStockPriceCursor{StockID: "7203", PriceDate: date(5)}
+	if len(firstPage.Prices) != 2 || firstPage.Next != expectedNext {
+		t.Errorf("Unexpected first page: %+v", firstPage)
+	}
+	if len(secondPage.Prices) != 1 || secondPage.Prices[0].StockPrice.StockID != "9984" || secondPage.HasNext() {
+		t.Errorf("Unexpected second page: %+v", secondPage)
+	}
+}
+
+func TestUpsertDailyStockPricesFromSeq(t *testing.T) {
+	// Arrange
+	existingPrices := []models.DailyStockPrice{
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2800, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+	}
+	newPrices := []models.DailyStockPrice{
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2800, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2880, 0)}},
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903, 0)}},
+	}
+	testCases := []struct {
+		policy         models.ConflictPolicy
+		expectedResult models.UpsertResult
+		expectedPrice  models.Price
+	}{
+		{policy: models.ConflictPolicyOverwrite, expectedResult: models.UpsertResult{Inserted: 1, Updated: 1, Unchanged: 1}, expectedPrice: models.NewPrice(2880, 0)},
+		{policy: models.ConflictPolicyKeep, expectedResult: models.UpsertResult{Inserted: 1, Updated: 0, Unchanged: 2}, expectedPrice: models.NewPrice(2873, 0)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			repository := newTestRepository(t, existingPrices)
+
+			// Act
+			result, err := repository.UpsertDailyStockPricesFromSeq(context.Background(), models.NoImportRun, models.DailyStockPriceSeq(newPrices), 2, tc.policy)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if result != tc.expectedResult {
+				t.Errorf("Expected %+v, but got %+v", tc.expectedResult, result)
+			}
+			dailyPrices, err := repository.GetDailyStockPricesByDateRange(context.Background(), "7203", date(4), date(4), time.Time{})
+			if err != nil {
+				t.Fatalf("Failed to get prices: %v", err)
+			}
+			if len(dailyPrices) != 1 || dailyPrices[0].StockPrice.Price != tc.expectedPrice {
+				t.Errorf("Expected price %v on 2025-02-04, but got %+v", tc.expectedPrice, dailyPrices)
+			}
+		})
+	}
+}
+
+func TestUpsertDailyStockPricesFromSeq_FailRollsBackChunk(t *testing.T) {
+	// Arrange
+	repository := newTestRepository(t, []models.DailyStockPrice{
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903, 0)}},
+	})
+	newPrices := []models.DailyStockPrice{
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2800, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8000, 0)}},
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2950, 0)}},
+	}
+
+	// Act - 2件目のチャンクで衝突する
+	result, err := repository.UpsertDailyStockPricesFromSeq(context.Background(), models.NoImportRun, models.DailyStockPriceSeq(newPrices), 2, models.ConflictPolicyFail)
+
+	// Assert
+	var conflictErr *models.DailyStockPriceConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Expected DailyStockPriceConflictError, but got: %v", err)
+	}
+	if result != (models.UpsertResult{Inserted: 2}) {
+		t.Errorf("Expected only the first chunk to be committed, but got %+v", result)
+	}
+	count, err := repository.CountDailyStockPrices(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to count prices: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 prices, but got %d", count)
+	}
+	if _, found, _ := repository.GetStock(context.Background(), "9984"); found {
+		t.Error("Expected the stock registered in the rolled back chunk to be discarded")
+	}
+}
+
+func TestInitializeDailyStockPriceTableFromSeq_Duplicate(t *testing.T) {
+	// Arrange
+	repository := newTestRepository(t, []models.DailyStockPrice{
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903, 0)}},
+	})
+	newPrices := []models.DailyStockPrice{
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2800, 0)}},
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2801, 0)}},
+	}
+
+	// Act - 1件ごとのチャンクでも、置き換えは全件を読み込んでからまとめてコミットされる
+	insertedCount, err := repository.InitializeDailyStockPriceTableFromSeq(context.Background(), models.NoImportRun, models.DailyStockPriceSeq(newPrices), 1)
+
+	// Assert
+	if err == nil {
+		t.Fatal("Expected an error for a duplicate row, but got nil")
+	}
+	if insertedCount != 0 {
+		t.Errorf("Expected no inserted rows, but got %d", insertedCount)
+	}
+	dailyPrices, err := repository.GetDailyStockPrices(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get prices: %v", err)
+	}
+	if len(dailyPrices) != 1 || dailyPrices[0].StockPrice.Price != models.NewPrice(2903, 0) {
+		t.Errorf("Expected the existing rows to be kept, but got %+v", dailyPrices)
+	}
+}
+
+func TestGetDailyStockBarsByDateRange(t *testing.T) {
+	// Arrange
+	repository := NewInMemoryStockPriceRepository()
+	defer repository.Close()
+	dailyBars := []models.DailyStockBar{
+		{PriceDate: date(4), StockID: "7203", Open: models.NewPrice(2850, 0), High: models.NewPrice(2900, 0), Low: models.NewPrice(2840, 0), Close: models.NewPrice(2873, 0), Volume: 1000},
+	}
+	if err := repository.InitializeDailyStockBarTable(context.Background(), models.NoImportRun, dailyBars); err != nil {
+		t.Fatalf("Failed to initialize bars: %v", err)
+	}
+	closeOnly := []models.DailyStockPrice{{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2903, 0)}}}
+	if _, err := repository.UpsertDailyStockPricesFromSeq(context.Background(), models.NoImportRun, models.DailyStockPriceSeq(closeOnly), 10, models.ConflictPolicyFail); err != nil {
+		t.Fatalf("Failed to upsert prices: %v", err)
+	}
+	expected := []models.DailyStockBar{
+		dailyBars[0],
+		{PriceDate: date(5), StockID: "7203", Open: models.NewPrice(2903, 0), High: models.NewPrice(2903, 0), Low: models.NewPrice(2903, 0), Close: models.NewPrice(2903, 0)},
+	}
+
+	// Act
+	bars, err := repository.GetDailyStockBarsByDateRange(context.Background(), "7203", date(1), date(28))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if !reflect.DeepEqual(bars, expected) {
+		t.Errorf("Result mismatch.\nExpected: %+v\nGot: %+v", expected, bars)
+	}
+}
+
+func TestInMemoryStockPriceRepository_Close(t *testing.T) {
+	// Arrange
+	repository := NewInMemoryStockPriceRepository()
+
+	// Act
+	closeErr := repository.Close()
+	_, err := repository.GetDailyStockPrices(context.Background())
+
+	// Assert
+	if closeErr != nil {
+		t.Fatalf("Expected no error on close, but got: %v", closeErr)
+	}
+	if !errors.Is(err, errRepositoryClosed) {
+		t.Errorf("Expected errRepositoryClosed after close, but got: %v", err)
+	}
+}
+
+func TestInMemoryStockPriceRepository_MatchesSQLite(t *testing.T) {
+	// Arrange - 同じ操作をメモリ上のリポジトリとSQLiteのリポジトリに適用する
+	dbPath := "./test_memory_matches_sqlite.db"
+	sqliteRepository := testutil.NewSQLiteRepository(t, dbPath)
+	memoryRepository := NewInMemoryStockPriceRepository()
+	defer memoryRepository.Close()
+
+	initialPrices := []models.DailyStockPrice{
+		{PriceDate: date(6), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8100, 0)}},
+		{PriceDate: date(3), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2800, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2873, 0)}},
+	}
+	newPrices := []models.DailyStockPrice{
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "7203", Price: models.NewPrice(2880, 0)}},
+		{PriceDate: date(5), StockPrice: models.StockPrice{StockID: "1301", Price: models.NewPrice(3500, 0)}},
+		{PriceDate: date(4), StockPrice: models.StockPrice{StockID: "9984", Price: models.NewPrice(8000, 0)}},
+	}
+	stocks := []models.Stock{{StockID: "7203", Name: "トヨタ自動車", Market: "TSE Prime"}}
+
+	// Act
+	type snapshot struct {
+		Result     models.UpsertResult
+		Prices     []models.DailyStockPrice
+		RangeItems []models.DailyStockPrice
+		Stocks     []models.Stock
+	}
+	run := func(repository models.StockPriceRepository) snapshot {
+		ctx := context.Background()
+		if _, err := repository.InitializeDailyStockPriceTableFromSeq(ctx, models.NoImportRun, models.DailyStockPriceSeq(initialPrices), 2); err != nil {
+			t.Fatalf("Failed to initialize: %v", err)
+		}
+		if _, err := repository.UpsertStocks(ctx, stocks); err != nil {
+			t.Fatalf("Failed to upsert stocks: %v", err)
+		}
+		result, err := repository.UpsertDailyStockPricesFromSeq(ctx, models.NoImportRun, models.DailyStockPriceSeq(newPrices), 2, models.ConflictPolicyOverwrite)
+		if err != nil {
+			t.Fatalf("Failed to upsert prices: %v", err)
+		}
+		prices, err := repository.GetDailyStockPrices(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get prices: %v", err)
+		}
+		rangeItems, err := repository.GetDailyStockPricesByDateRange(ctx, "7203", date(4), date(6), time.Time{})
+		if err != nil {
+			t.Fatalf("Failed to get prices by date range: %v", err)
+		}
+		allStocks, err := repository.GetStocks(ctx)
+		if err != nil {
+			t.Fatalf("Failed to get stocks: %v", err)
+		}
+		return snapshot{Result: result, Prices: prices, RangeItems: rangeItems, Stocks: allStocks}
+	}
+	expected := run(sqliteRepository)
+	got := run(memoryRepository)
+
+	// Assert
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("In-memory repository differs from SQLite.\nSQLite: %+v\nMemory: %+v", expected, got)
+	}
+}
@@ -0,0 +1,68 @@
+// Package testutil は複数のパッケージのテストで共有するヘルパーを提供します。
+// テスト以外のコードからは使用しないでください。
+package testutil
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/toriwasa/sqlite-playground/internal/infrastructures/db"
+)
+
+// WriteFile はテスト用のファイルを作成します（親ディレクトリがなければ作成する）。
+//
+// 引数:
+//   - t: テストの状態
+//   - path: 作成するファイルのパス
+//   - content: ファイルの内容
+func WriteFile(t testing.TB, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+}
+
+// WriteTempFile はテスト用のファイルを一時ディレクトリに作成し、そのパスを返します。
+//
+// 引数:
+//   - t: テストの状態
+//   - name: ファイル名
+//   - content: ファイルの内容
+//
+// 戻り値:
+//   - 作成したファイルのパス
+func WriteTempFile(t testing.TB, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	WriteFile(t, path, content)
+	return path
+}
+
+// NewSQLiteRepository はデータベースファイルを作成してマイグレーションを適用し、テスト用のリポジトリを作成します。
+// テスト終了時にリポジトリを閉じてデータベースファイルを削除します。
+//
+// 引数:
+//   - t: テストの状態
+//   - dbPath: SQLiteデータベースファイルのパス
+//
+// 戻り値:
+//   - リポジトリ
+func NewSQLiteRepository(t testing.TB, dbPath string) *db.SQLiteStockPriceRepository {
+	t.Helper()
+	options := db.DefaultSQLiteOptions()
+	options.Migrate = true
+	repository, err := db.NewSQLiteStockPriceRepositoryWithOptions(context.Background(), dbPath, options)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	t.Cleanup(func() {
+		repository.Close()
+		os.Remove(dbPath)
+	})
+	return repository
+}
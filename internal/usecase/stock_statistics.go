@@ -14,6 +14,9 @@ const ErrEmptyStockPricesMessage = "stock prices cannot be empty"
 // 異なる銘柄コードが含まれる場合のエラーメッセージ
 const ErrDifferentStockIDsMessage = "all stock prices must have the same stock ID"
 
+// 未知の四本値項目が指定された場合のエラーメッセージ
+const ErrUnknownStockBarFieldMessage = "unknown stock bar field"
+
 // CalculateStockPriceStatistics は、n日分の株価情報から統計情報を計算します。
 // 入力された株価情報が空の場合はエラーを返します。
 // 入力された株価情報の銘柄コードが一致しない場合はエラーを返します。
@@ -53,7 +56,7 @@ func CalculateStockPriceStatistics(dailyPrices []models.DailyStockPrice) (models
 	startDate := sortedPrices[0].PriceDate
 	endDate := sortedPrices[len(sortedPrices)-1].PriceDate
 
-	// 統計情報の計算
+	// 統計情報の計算（株価は固定小数点のため最大値と最小値は丸めずに比較する）
 	sum := 0.0
 	max := sortedPrices[0].StockPrice.Price
 	min := sortedPrices[0].StockPrice.Price
@@ -61,13 +64,13 @@ func CalculateStockPriceStatistics(dailyPrices []models.DailyStockPrice) (models
 	// 合計、最大値、最小値を計算
 	for _, price := range sortedPrices {
 		currentPrice := price.StockPrice.Price
-		sum += currentPrice
+		sum += currentPrice.Float64()
 
-		if currentPrice > max {
+		if currentPrice.Cmp(max) > 0 {
 			max = currentPrice
 		}
 
-		if currentPrice < min {
+		if currentPrice.Cmp(min) < 0 {
 			min = currentPrice
 		}
 	}
@@ -79,7 +82,7 @@ func CalculateStockPriceStatistics(dailyPrices []models.DailyStockPrice) (models
 	// 標準偏差を計算
 	sumSquaredDiff := 0.0
 	for _, price := range sortedPrices {
-		diff := price.StockPrice.Price - average
+		diff := price.StockPrice.Price.Float64() - average
 		sumSquaredDiff += diff * diff
 	}
 	standardDeviation := math.Sqrt(sumSquaredDiff / count)
@@ -91,9 +94,68 @@ func CalculateStockPriceStatistics(dailyPrices []models.DailyStockPrice) (models
 		StockPriceStatistics: models.StockPriceStatistics{
 			StockID:           firstStockID,
 			Average:           average,
-			Max:               max,
-			Min:               min,
+			Max:               max.Float64(),
+			Min:               min.Float64(),
 			StandardDeviation: standardDeviation,
 		},
 	}, nil
 }
+
+// CalculateStockBarStatistics は、n日分の日次四本値のうち指定された項目の統計情報を計算します。
+// 入力された日次四本値が空の場合はエラーを返します。
+// 入力された日次四本値の銘柄コードが一致しない場合はエラーを返します。
+// 未知の項目が指定された場合はエラーを返します。
+//
+// 引数:
+//   - dailyBars: n日分の日次四本値
+//   - field: 統計の対象とする項目（始値・高値・安値・終値・出来高）
+//
+// 戻り値:
+//   - 指定された項目の統計情報
+//   - エラー（処理中に問題が発生した場合）
+func CalculateStockBarStatistics(dailyBars []models.DailyStockBar, field models.StockBarField) (models.DailyStockPriceStatistics, error) {
+	// 日次四本値から指定された項目の値を取り出して日次株価情報に変換
+	dailyValues := make([]models.DailyStockPrice, 0, len(dailyBars))
+	for _, bar := range dailyBars {
+		value, err := stockBarFieldValue(bar, field)
+		if err != nil {
+			return models.DailyStockPriceStatistics{}, err
+		}
+		dailyValues = append(dailyValues, models.DailyStockPrice{
+			PriceDate: bar.PriceDate,
+			StockPrice: models.StockPrice{
+				StockID: bar.StockID,
+				Price:   value,
+			},
+		})
+	}
+
+	// 日次株価情報と同じ方法で統計情報を計算
+	return CalculateStockPriceStatistics(dailyValues)
+}
+
+// stockBarFieldValue は日次四本値から指定された項目の値を取り出します。
+//
+// 引数:
+//   - bar: 日次四本値
+//   - field: 取り出す項目
+//
+// 戻り値:
+//   - 項目の値
+//   - エラー（未知の項目が指定された場合）
+func stockBarFieldValue(bar models.DailyStockBar, field models.StockBarField) (models.Price, error) {
+	switch field {
+	case models.StockBarFieldOpen:
+		return bar.Open, nil
+	case models.StockBarFieldHigh:
+		return bar.High, nil
+	case models.StockBarFieldLow:
+		return bar.Low, nil
+	case models.StockBarFieldClose:
+		return bar.Close, nil
+	case models.StockBarFieldVolume:
+		return models.NewPrice(bar.Volume, 0), nil
+	default:
+		return models.Price{}, errors.New(ErrUnknownStockBarFieldMessage + ": " + string(field))
+	}
+}
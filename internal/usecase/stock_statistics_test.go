@@ -16,21 +16,21 @@ func TestCalculateStockPriceStatistics_ValidPrices(t *testing.T) {
 			PriceDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   100.0,
+				Price:   models.NewPrice(100, 0),
 			},
 		},
 		{
 			PriceDate: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   110.0,
+				Price:   models.NewPrice(110, 0),
 			},
 		},
 		{
 			PriceDate: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   90.0,
+				Price:   models.NewPrice(90, 0),
 			},
 		},
 	}
@@ -105,14 +105,14 @@ func TestCalculateStockPriceStatistics_DifferentStockIDs(t *testing.T) {
 			PriceDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: "1234",
-				Price:   100.0,
+				Price:   models.NewPrice(100, 0),
 			},
 		},
 		{
 			PriceDate: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
 			StockPrice: models.StockPrice{
 				StockID: "5678", // 異なる銘柄コード
-				Price:   110.0,
+				Price:   models.NewPrice(110, 0),
 			},
 		},
 	}
@@ -142,7 +142,7 @@ func TestCalculateStockPriceStatistics_SingleDay(t *testing.T) {
 			PriceDate: priceDate,
 			StockPrice: models.StockPrice{
 				StockID: stockID,
-				Price:   price,
+				Price:   models.NewPrice(100, 0),
 			},
 		},
 	}
@@ -183,3 +183,86 @@ func TestCalculateStockPriceStatistics_SingleDay(t *testing.T) {
 		t.Errorf("Expected StandardDeviation 0.0, but got %f", result.StandardDeviation)
 	}
 }
+
+// TestCalculateStockBarStatistics_SelectedField は、日次四本値の指定された項目について統計情報が計算されることをテストします。
+func TestCalculateStockBarStatistics_SelectedField(t *testing.T) {
+	// Arrange
+	stockID := "1234"
+	dailyBars := []models.DailyStockBar{
+		{
+			PriceDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			StockID:   stockID,
+			Open:      models.NewPrice(100, 0),
+			High:      models.NewPrice(120, 0),
+			Low:       models.NewPrice(95, 0),
+			Close:     models.NewPrice(110, 0),
+			Volume:    1000,
+		},
+		{
+			PriceDate: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+			StockID:   stockID,
+			Open:      models.NewPrice(110, 0),
+			High:      models.NewPrice(130, 0),
+			Low:       models.NewPrice(105, 0),
+			Close:     models.NewPrice(125, 0),
+			Volume:    3000,
+		},
+	}
+
+	testCases := []struct {
+		field           models.StockBarField
+		expectedAverage float64
+		expectedMax     float64
+		expectedMin     float64
+	}{
+		{field: models.StockBarFieldOpen, expectedAverage: 105.0, expectedMax: 110.0, expectedMin: 100.0},
+		{field: models.StockBarFieldHigh, expectedAverage: 125.0, expectedMax: 130.0, expectedMin: 120.0},
+		{field: models.StockBarFieldLow, expectedAverage: 100.0, expectedMax: 105.0, expectedMin: 95.0},
+		{field: models.StockBarFieldClose, expectedAverage: 117.5, expectedMax: 125.0, expectedMin: 110.0},
+		{field: models.StockBarFieldVolume, expectedAverage: 2000.0, expectedMax: 3000.0, expectedMin: 1000.0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.field), func(t *testing.T) {
+			// Act
+			result, err := CalculateStockBarStatistics(dailyBars, tc.field)
+
+			// Assert
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if result.StockID != stockID {
+				t.Errorf("Expected StockID %s, but got %s", stockID, result.StockID)
+			}
+			if result.Average != tc.expectedAverage {
+				t.Errorf("Expected Average %f, but got %f", tc.expectedAverage, result.Average)
+			}
+			if result.Max != tc.expectedMax {
+				t.Errorf("Expected Max %f, but got %f", tc.expectedMax, result.Max)
+			}
+			if result.Min != tc.expectedMin {
+				t.Errorf("Expected Min %f, but got %f", tc.expectedMin, result.Min)
+			}
+		})
+	}
+}
+
+// TestCalculateStockBarStatistics_UnknownField は、未知の項目が指定された場合にエラーが返されることをテストします。
+func TestCalculateStockBarStatistics_UnknownField(t *testing.T) {
+	// Arrange
+	dailyBars := []models.DailyStockBar{
+		{
+			PriceDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			StockID:   "1234",
+			Close:     models.NewPrice(100, 0),
+		},
+	}
+
+	// Act
+	_, err := CalculateStockBarStatistics(dailyBars, models.StockBarField("vwap"))
+
+	// Assert
+	if err == nil {
+		t.Error("Expected an error for unknown field, but got nil")
+	}
+}